@@ -2,7 +2,7 @@ package api
 
 import "time"
 
-//go:generate mockgen -package mock -destination ../mock/mock_api.go github.com/andig/evcc/api Charger,Meter,MeterEnergy,Vehicle,ChargeRater
+//go:generate mockgen -package mock -destination ../mock/mock_api.go github.com/andig/evcc/api Charger,Meter,MeterEnergy,MeterCurrent,Vehicle,ChargeRater,Battery,VehicleClimater,VehicleChargeController,VehicleCurrentController,VehicleRange,VehicleStatus,VehicleChargeLimit,VehicleWakeUp,CurrentLimiter,CurrentGetter,BatteryTemperature,ChargePhases
 
 // ChargeMode are charge modes modeled after OpenWB
 type ChargeMode string
@@ -12,6 +12,7 @@ const (
 	ModeNow   ChargeMode = "now"
 	ModeMinPV ChargeMode = "minpv"
 	ModePV    ChargeMode = "pv"
+	ModePrice ChargeMode = "price"
 )
 
 // String implements Stringer
@@ -60,6 +61,31 @@ type Charger interface {
 	MaxCurrent(current int64) error
 }
 
+// CurrentLimiter provides the max current in A supported by the charger's installation and cable
+type CurrentLimiter interface {
+	CurrentLimit() (int64, error)
+}
+
+// CurrentGetter provides the charge current setpoint in A applied by the charger
+type CurrentGetter interface {
+	GetMaxCurrent() (int64, error)
+}
+
+// ChargePhases provides the number of phases energized by the charger, 0 if unknown
+type ChargePhases interface {
+	ActivePhases() (int64, error)
+}
+
+// ChargePhaseSwitcher switches the charger between 1 and 3 phases
+type ChargePhaseSwitcher interface {
+	Phases1p3p(phases int) error
+}
+
+// ChargerDisplay shows text messages on the charger's display
+type ChargerDisplay interface {
+	Display(text string) error
+}
+
 // Diagnosis is a helper interface that allows to dump diagnostic data to console
 type Diagnosis interface {
 	Diagnosis()
@@ -75,9 +101,60 @@ type ChargeRater interface {
 	ChargedEnergy() (float64, error)
 }
 
+// Battery provides the vehicle's state of charge, e.g. from ISO 15118 charger communication
+type Battery interface {
+	SoC() (float64, error)
+}
+
+// BatteryTemperature provides the vehicle's battery temperature in °C
+type BatteryTemperature interface {
+	BatteryTemperature() (float64, error)
+}
+
+// BatteryCapacity provides the vehicle's battery capacity in kWh
+type BatteryCapacity interface {
+	Capacity() int64
+}
+
+// VehicleClimater provides the vehicle's climate (preconditioning) status
+type VehicleClimater interface {
+	Climater() (active bool, outsideTemp float64, targetTemp float64, err error)
+}
+
+// VehicleChargeController allows to start/stop the charging session on the vehicle side
+type VehicleChargeController interface {
+	StartCharge() error
+	StopCharge() error
+}
+
+// VehicleCurrentController allows to set the vehicle-side charge current
+type VehicleCurrentController interface {
+	MaxCurrent(current int64) error
+}
+
+// VehicleWakeUp allows to wake up a sleeping vehicle
+type VehicleWakeUp interface {
+	WakeUp() error
+}
+
+// VehicleStatus provides the vehicle's plug and charging status
+type VehicleStatus interface {
+	Status() (ChargeStatus, error)
+}
+
+// VehicleChargeLimit provides the vehicle's charge limit (%) set in the vehicle or its app
+type VehicleChargeLimit interface {
+	ChargeLimit() (int64, error)
+}
+
+// VehicleRange provides the vehicle's remaining electric range in km
+type VehicleRange interface {
+	Range() (int64, error)
+}
+
 // Vehicle represents the EV and it's battery
 type Vehicle interface {
 	Title() string
-	Capacity() int64
+	BatteryCapacity
 	ChargeState() (float64, error)
 }
@@ -0,0 +1,45 @@
+package api
+
+// capabilities maps the device interfaces to their names. Vehicle interfaces
+// overlap with charger methods and are only reported for vehicles.
+var capabilities = []struct {
+	name    string
+	vehicle bool
+	is      func(interface{}) bool
+}{
+	{"Meter", false, func(d interface{}) bool { _, ok := d.(Meter); return ok }},
+	{"MeterEnergy", false, func(d interface{}) bool { _, ok := d.(MeterEnergy); return ok }},
+	{"MeterCurrent", false, func(d interface{}) bool { _, ok := d.(MeterCurrent); return ok }},
+	{"Charger", false, func(d interface{}) bool { _, ok := d.(Charger); return ok }},
+	{"ChargeTimer", false, func(d interface{}) bool { _, ok := d.(ChargeTimer); return ok }},
+	{"CurrentLimiter", false, func(d interface{}) bool { _, ok := d.(CurrentLimiter); return ok }},
+	{"CurrentGetter", false, func(d interface{}) bool { _, ok := d.(CurrentGetter); return ok }},
+	{"ChargePhases", false, func(d interface{}) bool { _, ok := d.(ChargePhases); return ok }},
+	{"ChargeRater", false, func(d interface{}) bool { _, ok := d.(ChargeRater); return ok }},
+	{"ChargePhaseSwitcher", false, func(d interface{}) bool { _, ok := d.(ChargePhaseSwitcher); return ok }},
+	{"ChargerDisplay", false, func(d interface{}) bool { _, ok := d.(ChargerDisplay); return ok }},
+	{"Diagnosis", false, func(d interface{}) bool { _, ok := d.(Diagnosis); return ok }},
+	{"Battery", false, func(d interface{}) bool { _, ok := d.(Battery); return ok }},
+	{"Vehicle", false, func(d interface{}) bool { _, ok := d.(Vehicle); return ok }},
+	{"VehicleClimater", true, func(d interface{}) bool { _, ok := d.(VehicleClimater); return ok }},
+	{"VehicleChargeController", true, func(d interface{}) bool { _, ok := d.(VehicleChargeController); return ok }},
+	{"VehicleCurrentController", true, func(d interface{}) bool { _, ok := d.(VehicleCurrentController); return ok }},
+	{"VehicleWakeUp", true, func(d interface{}) bool { _, ok := d.(VehicleWakeUp); return ok }},
+	{"VehicleStatus", true, func(d interface{}) bool { _, ok := d.(VehicleStatus); return ok }},
+	{"VehicleChargeLimit", true, func(d interface{}) bool { _, ok := d.(VehicleChargeLimit); return ok }},
+	{"VehicleRange", true, func(d interface{}) bool { _, ok := d.(VehicleRange); return ok }},
+	{"BatteryTemperature", true, func(d interface{}) bool { _, ok := d.(BatteryTemperature); return ok }},
+}
+
+// Capabilities returns the names of the interfaces implemented by the device
+func Capabilities(device interface{}) []string {
+	_, vehicle := device.(Vehicle)
+
+	res := make([]string, 0)
+	for _, c := range capabilities {
+		if (vehicle || !c.vehicle) && c.is(device) {
+			res = append(res, c.name)
+		}
+	}
+	return res
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+type capabilityCharger struct{}
+
+func (c *capabilityCharger) Status() (ChargeStatus, error)                { return StatusA, nil }
+func (c *capabilityCharger) Enabled() (bool, error)                       { return false, nil }
+func (c *capabilityCharger) Enable(enable bool) error                     { return nil }
+func (c *capabilityCharger) MaxCurrent(current int64) error               { return nil }
+func (c *capabilityCharger) CurrentPower() (float64, error)               { return 0, nil }
+func (c *capabilityCharger) ChargedEnergy() (float64, error)              { return 0, nil }
+func (c *capabilityCharger) Currents() (float64, float64, float64, error) { return 0, 0, 0, nil }
+
+type capabilityVehicle struct{}
+
+func (v *capabilityVehicle) Title() string                  { return "car" }
+func (v *capabilityVehicle) Capacity() int64                { return 50 }
+func (v *capabilityVehicle) ChargeState() (float64, error)  { return 0, nil }
+func (v *capabilityVehicle) Status() (ChargeStatus, error)  { return StatusA, nil }
+func (v *capabilityVehicle) MaxCurrent(current int64) error { return nil }
+
+func TestCapabilities(t *testing.T) {
+	tc := []struct {
+		device interface{}
+		caps   []string
+	}{
+		{nil, nil},
+		{struct{}{}, nil},
+		{&capabilityCharger{}, []string{"Meter", "MeterCurrent", "Charger", "ChargeRater"}},
+		{&capabilityVehicle{}, []string{"Vehicle", "VehicleCurrentController", "VehicleStatus"}},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		if caps := Capabilities(tc.device); strings.Join(caps, ",") != strings.Join(tc.caps, ",") {
+			t.Errorf("expected %v, got %v", tc.caps, caps)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package api
+
+import "errors"
+
+// ErrNotSupported is returned by devices that lack the requested feature
+var ErrNotSupported = errors.New("not supported")
+
+// ErrAsleep is returned by vehicles that are asleep and have been requested to wake up.
+// The request should be retried once the vehicle is online.
+var ErrAsleep = errors.New("vehicle asleep")
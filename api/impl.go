@@ -11,6 +11,8 @@ func ChargeModeString(mode string) ChargeMode {
 		return ModeMinPV
 	case string(ModePV):
 		return ModePV
+	case string(ModePrice):
+		return ModePrice
 	default:
 		return ModeOff
 	}
@@ -327,7 +327,7 @@ Vue.component("vehicle", {
 
 Vue.component("mode", {
   template: "#mode-template",
-  props: ["mode", "pv", "caption"],
+  props: ["mode", "pv", "price", "caption"],
   methods: {
     targetMode: function (mode) {
       this.$emit("updated", mode)
@@ -18,7 +18,10 @@ type Charger struct {
 
 // NewConfigurableFromConfig creates a new configurable charger
 func NewConfigurableFromConfig(other map[string]interface{}) (api.Charger, error) {
-	cc := struct{ Status, Enable, Enabled, MaxCurrent provider.Config }{}
+	cc := struct {
+		Status, Enable, Enabled, MaxCurrent provider.Config
+		SoC                                 *provider.Config // optional
+	}{}
 	if err := util.DecodeOther(other, &cc); err != nil {
 		return nil, err
 	}
@@ -55,7 +58,40 @@ func NewConfigurableFromConfig(other map[string]interface{}) (api.Charger, error
 		return nil, err
 	}
 
-	return NewConfigurable(status, enabled, enable, maxcurrent)
+	c, err := NewConfigurable(status, enabled, enable, maxcurrent)
+	if err != nil {
+		return nil, err
+	}
+
+	// decorate Charger with Battery
+	if cc.SoC != nil {
+		soc, err := provider.NewFloatGetterFromConfig(*cc.SoC)
+		if err != nil {
+			return nil, err
+		}
+
+		type BatteryDecorator struct {
+			api.Charger
+			api.Battery
+		}
+
+		c = &BatteryDecorator{
+			Charger: c,
+			Battery: &battery{soc},
+		}
+	}
+
+	return c, nil
+}
+
+// battery implements the api.Battery interface
+type battery struct {
+	socG func() (float64, error)
+}
+
+// SoC implements the Battery.SoC interface
+func (b *battery) SoC() (float64, error) {
+	return b.socG()
 }
 
 // NewConfigurable creates a new charger
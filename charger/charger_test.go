@@ -0,0 +1,44 @@
+package charger
+
+import (
+	"testing"
+
+	"github.com/andig/evcc/api"
+)
+
+func TestConfigurableTransform(t *testing.T) {
+	other := map[string]interface{}{
+		"status": map[string]interface{}{
+			"type": "script",
+			"cmd":  "echo 2",
+			"map":  map[string]interface{}{"0": "A", "1": "B", "2": "C"},
+		},
+		"enabled":    map[string]interface{}{"type": "script", "cmd": "echo true"},
+		"enable":     map[string]interface{}{"type": "script", "cmd": "true"},
+		"maxcurrent": map[string]interface{}{"type": "script", "cmd": "true"},
+		"soc": map[string]interface{}{
+			"type":   "script",
+			"cmd":    "echo 1300",
+			"scale":  0.1,
+			"offset": -50,
+		},
+	}
+
+	c, err := NewConfigurableFromConfig(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status, err := c.Status(); err != nil || status != api.StatusC {
+		t.Errorf("expected status C, got %v %v", status, err)
+	}
+
+	b, ok := c.(api.Battery)
+	if !ok {
+		t.Fatal("missing battery")
+	}
+
+	if soc, err := b.SoC(); err != nil || soc != 80 {
+		t.Errorf("expected soc 80, got %v %v", soc, err)
+	}
+}
@@ -17,10 +17,14 @@ func NewFromConfig(typ string, other map[string]interface{}) (charger api.Charge
 		charger, err = NewConfigurableFromConfig(other)
 	case "wallbe":
 		charger, err = NewWallbeFromConfig(other)
+	case "phoenix":
+		charger, err = NewPhoenixFromConfig(other)
 	case "phoenix-emcp":
 		charger, err = NewPhoenixEMCPFromConfig(other)
 	case "phoenix-evcc":
 		charger, err = NewPhoenixEVCCFromConfig(other)
+	case "nrgkick":
+		charger, err = NewNRGKickFromConfig(other)
 	case "nrgkick-bluetooth", "nrgkick-bt", "nrgble":
 		charger, err = NewNRGKickBLEFromConfig(other)
 	case "nrgkick-connect", "nrgconnect":
@@ -35,6 +39,8 @@ func NewFromConfig(typ string, other map[string]interface{}) (charger api.Charge
 		charger, err = NewMobileConnectFromConfig(other)
 	case "keba", "bmw":
 		charger, err = NewKebaFromConfig(other)
+	case "eebus":
+		charger, err = NewEEBusFromConfig(other)
 	default:
 		return nil, fmt.Errorf("invalid charger type: %s", typ)
 	}
@@ -24,3 +24,36 @@ func TestChargers(t *testing.T) {
 		}
 	}
 }
+
+// TestFreeChargers guards against community chargers accidentally requiring a token
+func TestFreeChargers(t *testing.T) {
+	tc := []struct {
+		typ   string
+		other map[string]interface{}
+	}{
+		{"keba", map[string]interface{}{"uri": "127.0.0.1"}},
+		{"wallbe", map[string]interface{}{"uri": "127.0.0.1:502"}},
+		{"phoenix", map[string]interface{}{"variant": "em-cp", "uri": "127.0.0.1:502"}},
+		{"phoenix-emcp", map[string]interface{}{"uri": "127.0.0.1:502"}},
+		{"phoenix-evcc", map[string]interface{}{"uri": "127.0.0.1:502"}},
+		{"simpleevse", map[string]interface{}{"uri": "127.0.0.1:502"}},
+		{"evsewifi", map[string]interface{}{"uri": "http://127.0.0.1"}},
+		{"go-e", map[string]interface{}{"uri": "http://127.0.0.1"}},
+		{"nrgkick-connect", map[string]interface{}{"uri": "http://127.0.0.1", "mac": "00:00:00:00:00:00"}},
+		{"mcc", map[string]interface{}{"uri": "http://127.0.0.1", "password": "secret"}},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		c, err := NewFromConfig(tc.typ, tc.other)
+		if err != nil {
+			t.Errorf("%s: %v", tc.typ, err)
+			continue
+		}
+
+		if c == nil {
+			t.Errorf("%s: missing charger", tc.typ)
+		}
+	}
+}
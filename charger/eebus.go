@@ -0,0 +1,303 @@
+package charger
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/andig/evcc/api"
+	"github.com/andig/evcc/charger/eebus"
+	"github.com/andig/evcc/util"
+)
+
+// EEBus is an api.Charger implementation for EEBUS/SPINE capable chargers.
+// It implements the overload protection by EV current limitation use case.
+type EEBus struct {
+	mux   sync.Mutex
+	log   *util.Logger
+	uri   string
+	ski   string
+	trust *eebus.TrustStore
+	dial  func() (*eebus.Connection, error)
+
+	conn    *eebus.Connection
+	enabled bool
+	current int64
+}
+
+// NewEEBusFromConfig creates an EEBus charger from generic config
+func NewEEBusFromConfig(other map[string]interface{}) (api.Charger, error) {
+	cc := struct {
+		URI         string
+		SKI         string
+		Certificate string
+		Key         string
+		TrustStore  string
+	}{
+		Certificate: "eebus.crt",
+		Key:         "eebus.key",
+		TrustStore:  "eebus-trust.json",
+	}
+
+	if err := util.DecodeOther(other, &cc); err != nil {
+		return nil, err
+	}
+
+	if cc.URI == "" {
+		return nil, errors.New("eebus: missing uri")
+	}
+
+	return NewEEBus(cc.URI, cc.SKI, cc.Certificate, cc.Key, cc.TrustStore)
+}
+
+// NewEEBus creates EEBus charger
+func NewEEBus(uri, ski, certFile, keyFile, trustFile string) (*EEBus, error) {
+	log := util.NewLogger("eebus")
+
+	cert, err := eebus.LoadOrCreateCertificate(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("eebus: certificate: %v", err)
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("eebus: certificate: %v", err)
+	}
+
+	localSKI, err := eebus.SKI(leaf)
+	if err != nil {
+		return nil, fmt.Errorf("eebus: certificate: %v", err)
+	}
+	log.INFO.Printf("local ski: %s", localSKI)
+
+	trust, err := eebus.NewTrustStore(trustFile)
+	if err != nil {
+		return nil, fmt.Errorf("eebus: trust store: %v", err)
+	}
+
+	c := &EEBus{
+		log:   log,
+		uri:   uri,
+		ski:   eebus.NormalizeSKI(ski),
+		trust: trust,
+	}
+
+	c.dial = func() (*eebus.Connection, error) {
+		return eebus.Dial(log, uri, cert, c.verify)
+	}
+
+	return c, nil
+}
+
+// verify pairs the remote device if its SKI matches the configured SKI
+func (c *EEBus) verify(ski string) error {
+	if c.trust.Trusted(ski) {
+		return nil
+	}
+
+	if ski != c.ski {
+		return fmt.Errorf("untrusted remote ski %s, configure ski to pair", ski)
+	}
+
+	c.log.INFO.Printf("pairing remote ski: %s", ski)
+	return c.trust.Trust(ski, c.uri)
+}
+
+// connection returns an established connection, reconnects if necessary
+func (c *EEBus) connection() (*eebus.Connection, error) {
+	if c.conn != nil && c.conn.Err() == nil {
+		return c.conn, nil
+	}
+
+	conn, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+
+	c.conn = conn
+	return conn, nil
+}
+
+// discover returns the remote device's discovery data
+func (c *EEBus) discover(conn *eebus.Connection) (*eebus.NodeManagementDetailedDiscoveryData, error) {
+	res, err := conn.Read(eebus.FeatureAddress{Entity: []int{0}, Feature: 0}, eebus.Cmd{
+		NodeManagementDetailedDiscoveryData: &eebus.NodeManagementDetailedDiscoveryData{},
+	})
+
+	if err == nil && res.NodeManagementDetailedDiscoveryData == nil {
+		err = errors.New("invalid discovery reply")
+	}
+
+	return res.NodeManagementDetailedDiscoveryData, err
+}
+
+// evFeature returns the connection and EV feature address or false if no EV connected
+func (c *EEBus) evFeature(featureType string) (*eebus.Connection, eebus.FeatureAddress, bool, error) {
+	conn, err := c.connection()
+	if err != nil {
+		return nil, eebus.FeatureAddress{}, false, err
+	}
+
+	discovery, err := c.discover(conn)
+	if err != nil {
+		return nil, eebus.FeatureAddress{}, false, err
+	}
+
+	addr, ok := discovery.Feature(eebus.EntityTypeEV, featureType, eebus.RoleServer)
+	return conn, addr, ok, nil
+}
+
+// currents reads the EV's phase currents
+func (c *EEBus) currents() ([]float64, bool, error) {
+	conn, addr, ok, err := c.evFeature(eebus.FeatureTypeMeasurement)
+	if err != nil || !ok {
+		return nil, ok, err
+	}
+
+	desc, err := conn.Read(addr, eebus.Cmd{MeasurementDescriptionListData: &eebus.MeasurementDescriptionListData{}})
+	if err != nil {
+		return nil, ok, err
+	}
+
+	data, err := conn.Read(addr, eebus.Cmd{MeasurementListData: &eebus.MeasurementListData{}})
+	if err != nil {
+		return nil, ok, err
+	}
+
+	if desc.MeasurementDescriptionListData == nil || data.MeasurementListData == nil {
+		return nil, ok, errors.New("invalid measurement reply")
+	}
+
+	values := make(map[int]float64)
+	for _, m := range data.MeasurementListData.MeasurementData {
+		values[m.MeasurementID] = m.Value.Value()
+	}
+
+	var res []float64
+	for _, d := range desc.MeasurementDescriptionListData.MeasurementDescriptionData {
+		if d.ScopeType == eebus.ScopeTypeACCurrent {
+			res = append(res, values[d.MeasurementID])
+		}
+	}
+
+	return res, ok, nil
+}
+
+// Status implements the Charger.Status interface
+func (c *EEBus) Status() (api.ChargeStatus, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	currents, connected, err := c.currents()
+	if err != nil {
+		return api.StatusNone, err
+	}
+
+	if !connected {
+		return api.StatusA, nil
+	}
+
+	for _, i := range currents {
+		if i >= 1 {
+			return api.StatusC, nil
+		}
+	}
+
+	return api.StatusB, nil
+}
+
+// Enabled implements the Charger.Enabled interface
+func (c *EEBus) Enabled() (bool, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	return c.enabled, nil
+}
+
+// writeLimit writes the current limit for all phases
+func (c *EEBus) writeLimit(current int64) error {
+	conn, addr, ok, err := c.evFeature(eebus.FeatureTypeLoadControl)
+	if err != nil {
+		return err
+	}
+
+	// limits can only be applied to connected vehicles
+	if !ok {
+		return nil
+	}
+
+	desc, err := conn.Read(addr, eebus.Cmd{LoadControlLimitDescriptionListData: &eebus.LoadControlLimitDescriptionListData{}})
+	if err != nil {
+		return err
+	}
+
+	if desc.LoadControlLimitDescriptionListData == nil {
+		return errors.New("invalid limit description reply")
+	}
+
+	var limits eebus.LoadControlLimitListData
+	for _, d := range desc.LoadControlLimitDescriptionListData.LoadControlLimitDescriptionData {
+		if d.LimitCategory == eebus.LimitCategoryObligation && d.ScopeType == eebus.ScopeTypeOverloadProtection {
+			limits.LoadControlLimitData = append(limits.LoadControlLimitData, eebus.LoadControlLimitData{
+				LimitID:       d.LimitID,
+				IsLimitActive: true,
+				Value:         eebus.ScaledNumber{Number: current},
+			})
+		}
+	}
+
+	if len(limits.LoadControlLimitData) == 0 {
+		return errors.New("current limit not supported")
+	}
+
+	return conn.Write(addr, eebus.Cmd{LoadControlLimitListData: &limits})
+}
+
+// Enable implements the Charger.Enable interface
+func (c *EEBus) Enable(enable bool) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	var current int64
+	if enable {
+		current = c.current
+	}
+
+	err := c.writeLimit(current)
+	if err == nil {
+		c.enabled = enable
+	}
+
+	return err
+}
+
+// MaxCurrent implements the Charger.MaxCurrent interface
+func (c *EEBus) MaxCurrent(current int64) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.current = current
+	if !c.enabled {
+		return nil
+	}
+
+	return c.writeLimit(current)
+}
+
+// Currents implements the MeterCurrent interface
+func (c *EEBus) Currents() (float64, float64, float64, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	currents, _, err := c.currents()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	for len(currents) < 3 {
+		currents = append(currents, 0)
+	}
+
+	return currents[0], currents[1], currents[2], nil
+}
@@ -0,0 +1,109 @@
+package eebus
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+// LoadOrCreateCertificate loads the local SHIP certificate from the given files.
+// If files do not exist, a new self-signed certificate is created and persisted.
+func LoadOrCreateCertificate(certFile, keyFile string) (tls.Certificate, error) {
+	if !isNotExist(certFile, keyFile) {
+		return tls.LoadX509KeyPair(certFile, keyFile)
+	}
+
+	certPEM, keyPEM, err := createCertificate("evcc")
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	if err := ioutil.WriteFile(certFile, certPEM, 0644); err != nil {
+		return tls.Certificate{}, err
+	}
+
+	if err := ioutil.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		return tls.Certificate{}, err
+	}
+
+	return tls.X509KeyPair(certPEM, keyPEM)
+}
+
+func isNotExist(files ...string) bool {
+	for _, file := range files {
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			return true
+		}
+	}
+	return false
+}
+
+// createCertificate creates a self-signed SHIP certificate with subject key identifier
+func createCertificate(cn string) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pub, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// SHIP requires the SKI to be the SHA-1 of the public key
+	ski := sha1.Sum(pub)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		SubjectKeyId:          ski[:],
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	priv, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: priv})
+
+	return certPEM, keyPEM, nil
+}
+
+// SKI returns the normalized subject key identifier of the certificate
+func SKI(cert *x509.Certificate) (string, error) {
+	if len(cert.SubjectKeyId) == 0 {
+		return "", errors.New("missing subject key identifier")
+	}
+
+	return hex.EncodeToString(cert.SubjectKeyId), nil
+}
+
+// NormalizeSKI removes separators and converts SKI to lower case
+func NormalizeSKI(ski string) string {
+	ski = strings.ToLower(ski)
+	return strings.NewReplacer(" ", "", ":", "", "-", "").Replace(ski)
+}
@@ -0,0 +1,339 @@
+package eebus
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/andig/evcc/charger/eebus/ship"
+	"github.com/andig/evcc/util"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	handshakeTimeout = 10 * time.Second
+	requestTimeout   = 10 * time.Second
+
+	// LocalDevice is the local SPINE device address
+	LocalDevice = "d:_i:evcc"
+)
+
+// Connection is a SHIP connection to a remote EEBUS device
+type Connection struct {
+	mux     sync.Mutex
+	log     *util.Logger
+	ws      *websocket.Conn
+	counter int
+	pending map[int]chan Datagram
+	closed  chan struct{}
+	err     error
+}
+
+// Dial connects to the remote device and performs the SHIP handshake.
+// The verify function is called with the remote's SKI during TLS handshake.
+func Dial(log *util.Logger, uri string, cert tls.Certificate, verify func(ski string) error) (*Connection, error) {
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		// SHIP devices use self-signed certificates, trust is established by SKI
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return errors.New("missing remote certificate")
+			}
+
+			remote, err := x509.ParseCertificate(rawCerts[0])
+			if err != nil {
+				return err
+			}
+
+			ski, err := SKI(remote)
+			if err != nil {
+				return err
+			}
+
+			return verify(ski)
+		},
+	}
+
+	dialer := &websocket.Dialer{
+		TLSClientConfig:  tlsConfig,
+		Subprotocols:     []string{ship.SubProtocol},
+		HandshakeTimeout: handshakeTimeout,
+	}
+
+	ws, _, err := dialer.Dial(uri, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Connection{
+		log:     log,
+		ws:      ws,
+		pending: make(map[int]chan Datagram),
+		closed:  make(chan struct{}),
+	}
+
+	if err := c.handshake(); err != nil {
+		_ = ws.Close()
+		return nil, err
+	}
+
+	go c.run()
+
+	return c, nil
+}
+
+func (c *Connection) write(b []byte) error {
+	c.log.TRACE.Printf("send: %s", b)
+	return c.ws.WriteMessage(websocket.BinaryMessage, b)
+}
+
+// handshake executes the SHIP handshake synchronously
+func (c *Connection) handshake() error {
+	hs := ship.NewHandshake("evcc")
+
+	out, err := hs.Start()
+	for !hs.Complete() {
+		if err != nil {
+			return err
+		}
+
+		for _, b := range out {
+			if err := c.write(b); err != nil {
+				return err
+			}
+		}
+
+		_ = c.ws.SetReadDeadline(time.Now().Add(handshakeTimeout))
+
+		var b []byte
+		if _, b, err = c.ws.ReadMessage(); err != nil {
+			return err
+		}
+
+		c.log.TRACE.Printf("recv: %s", b)
+		out, err = hs.Handle(b)
+	}
+
+	_ = c.ws.SetReadDeadline(time.Time{})
+
+	return err
+}
+
+// run receives SPINE messages and dispatches replies
+func (c *Connection) run() {
+	for {
+		_, b, err := c.ws.ReadMessage()
+		if err != nil {
+			c.close(err)
+			return
+		}
+
+		c.log.TRACE.Printf("recv: %s", b)
+
+		if len(b) > 0 && b[0] == ship.MsgTypeControl {
+			if msg, err := ship.DecodeControl(b); err == nil && msg.ConnectionClose != nil {
+				c.close(errors.New("connection closed by remote"))
+				return
+			}
+			continue
+		}
+
+		var msg Message
+		if err := ship.DecodeData(b, &msg); err != nil {
+			c.log.ERROR.Printf("invalid message: %v", err)
+			continue
+		}
+
+		c.dispatch(msg.Datagram)
+	}
+}
+
+func (c *Connection) dispatch(d Datagram) {
+	switch d.Header.CmdClassifier {
+	case CmdReply, CmdResult:
+		c.mux.Lock()
+		res, ok := c.pending[d.Header.MsgCounterReference]
+		delete(c.pending, d.Header.MsgCounterReference)
+		c.mux.Unlock()
+
+		if ok {
+			res <- d
+		}
+
+	case CmdRead:
+		// answer remote discovery of our device
+		for _, cmd := range d.Payload.Cmd {
+			if cmd.NodeManagementDetailedDiscoveryData != nil {
+				if err := c.reply(d.Header, Cmd{NodeManagementDetailedDiscoveryData: localDiscovery()}); err != nil {
+					c.log.ERROR.Println(err)
+				}
+			}
+		}
+	}
+}
+
+func (c *Connection) close(err error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if c.err == nil {
+		c.err = err
+		close(c.closed)
+		_ = c.ws.Close()
+	}
+}
+
+// Err returns the error if the connection has been closed
+func (c *Connection) Err() error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.err
+}
+
+// Close closes the connection
+func (c *Connection) Close() error {
+	c.close(errors.New("connection closed"))
+	return nil
+}
+
+func (c *Connection) send(h Header, cmd Cmd) (chan Datagram, error) {
+	c.mux.Lock()
+	if c.err != nil {
+		c.mux.Unlock()
+		return nil, c.err
+	}
+
+	c.counter++
+	h.MsgCounter = c.counter
+	h.SpecificationVersion = SpecificationVersion
+
+	var res chan Datagram
+	if h.CmdClassifier == CmdRead || h.AckRequest {
+		res = make(chan Datagram, 1)
+		c.pending[h.MsgCounter] = res
+	}
+	c.mux.Unlock()
+
+	var msg Message
+	msg.Datagram.Header = h
+	msg.Datagram.Payload.Cmd = []Cmd{cmd}
+
+	b, err := ship.EncodeData(msg)
+	if err == nil {
+		c.mux.Lock()
+		err = c.write(b)
+		c.mux.Unlock()
+	}
+
+	return res, err
+}
+
+func (c *Connection) wait(res chan Datagram) (Datagram, error) {
+	select {
+	case d := <-res:
+		return d, nil
+	case <-c.closed:
+		return Datagram{}, c.Err()
+	case <-time.After(requestTimeout):
+		return Datagram{}, errors.New("timeout")
+	}
+}
+
+func (c *Connection) reply(req Header, cmd Cmd) error {
+	_, err := c.send(Header{
+		AddressSource:       req.AddressDestination,
+		AddressDestination:  req.AddressSource,
+		MsgCounterReference: req.MsgCounter,
+		CmdClassifier:       CmdReply,
+	}, cmd)
+
+	return err
+}
+
+// Read reads the given command from the remote feature
+func (c *Connection) Read(dst FeatureAddress, cmd Cmd) (Cmd, error) {
+	res, err := c.send(Header{
+		AddressSource:      FeatureAddress{Device: LocalDevice, Entity: []int{1}, Feature: 1},
+		AddressDestination: dst,
+		CmdClassifier:      CmdRead,
+	}, cmd)
+	if err != nil {
+		return Cmd{}, err
+	}
+
+	d, err := c.wait(res)
+	if err != nil {
+		return Cmd{}, err
+	}
+
+	if len(d.Payload.Cmd) != 1 {
+		return Cmd{}, fmt.Errorf("invalid reply: %d commands", len(d.Payload.Cmd))
+	}
+
+	return d.Payload.Cmd[0], nil
+}
+
+// Write writes the given command to the remote feature and waits for acknowledgement
+func (c *Connection) Write(dst FeatureAddress, cmd Cmd) error {
+	res, err := c.send(Header{
+		AddressSource:      FeatureAddress{Device: LocalDevice, Entity: []int{1}, Feature: 1},
+		AddressDestination: dst,
+		CmdClassifier:      CmdWrite,
+		AckRequest:         true,
+	}, cmd)
+	if err != nil {
+		return err
+	}
+
+	d, err := c.wait(res)
+	if err != nil {
+		return err
+	}
+
+	for _, cmd := range d.Payload.Cmd {
+		if r := cmd.ResultData; r != nil && r.ErrorNumber != 0 {
+			return fmt.Errorf("write failed: %d %s", r.ErrorNumber, r.Description)
+		}
+	}
+
+	return nil
+}
+
+// localDiscovery describes the local device as energy manager with load control and measurement clients
+func localDiscovery() *NodeManagementDetailedDiscoveryData {
+	res := &NodeManagementDetailedDiscoveryData{}
+
+	res.DeviceInformation = &struct {
+		Description DeviceDescription `json:"description"`
+	}{}
+	res.DeviceInformation.Description.DeviceAddress.Device = LocalDevice
+	res.DeviceInformation.Description.DeviceType = "EnergyManagementSystem"
+
+	var ei EntityInformation
+	ei.Description.EntityAddress = EntityAddress{Device: LocalDevice, Entity: []int{1}}
+	ei.Description.EntityType = EntityTypeCEM
+	res.EntityInformation = append(res.EntityInformation, ei)
+
+	for _, f := range []struct {
+		entity  []int
+		feature int
+		typ     string
+		role    string
+	}{
+		{[]int{0}, 0, FeatureTypeNodeManagement, RoleSpecial},
+		{[]int{1}, 1, FeatureTypeLoadControl, RoleClient},
+		{[]int{1}, 2, FeatureTypeMeasurement, RoleClient},
+	} {
+		var fi FeatureInformation
+		fi.Description.FeatureAddress = FeatureAddress{Device: LocalDevice, Entity: f.entity, Feature: f.feature}
+		fi.Description.FeatureType = f.typ
+		fi.Description.Role = f.role
+		res.FeatureInformation = append(res.FeatureInformation, fi)
+	}
+
+	return res
+}
@@ -0,0 +1,208 @@
+package ship
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// State is the SHIP handshake state
+type State int
+
+// Handshake states as seen from the client side
+const (
+	StateInit State = iota
+	StateCmiWait
+	StateHelloWait
+	StateProtocolWait
+	StatePinWait
+	StateAccessWait
+	StateComplete
+	StateError
+)
+
+func (s State) String() string {
+	switch s {
+	case StateInit:
+		return "init"
+	case StateCmiWait:
+		return "cmi"
+	case StateHelloWait:
+		return "hello"
+	case StateProtocolWait:
+		return "protocol"
+	case StatePinWait:
+		return "pin"
+	case StateAccessWait:
+		return "access"
+	case StateComplete:
+		return "complete"
+	default:
+		return "error"
+	}
+}
+
+// Handshake implements the client side SHIP handshake state machine.
+// It is transport-agnostic: received frames are passed to Handle which
+// returns the frames to be sent in response.
+type Handshake struct {
+	id    string
+	state State
+}
+
+// NewHandshake creates a SHIP handshake for the given local SHIP id
+func NewHandshake(id string) *Handshake {
+	return &Handshake{id: id}
+}
+
+// State returns the current handshake state
+func (h *Handshake) State() State {
+	return h.state
+}
+
+// Complete returns true if the handshake has completed successfully
+func (h *Handshake) Complete() bool {
+	return h.state == StateComplete
+}
+
+// Start returns the initial frames sent by the client
+func (h *Handshake) Start() ([][]byte, error) {
+	if h.state != StateInit {
+		return nil, h.fail(fmt.Errorf("invalid state: %s", h.state))
+	}
+
+	h.state = StateCmiWait
+	return [][]byte{CmiInit}, nil
+}
+
+// Handle processes a received frame and returns the response frames
+func (h *Handshake) Handle(b []byte) ([][]byte, error) {
+	switch h.state {
+	case StateCmiWait:
+		if !bytes.Equal(b, CmiInit) {
+			return nil, h.fail(errors.New("invalid cmi init"))
+		}
+
+		h.state = StateHelloWait
+		return h.encode(CmiControl{
+			ConnectionHello: &ConnectionHello{Phase: PhaseReady, Waiting: helloWaiting},
+		})
+
+	case StateHelloWait, StateProtocolWait, StatePinWait, StateAccessWait:
+		msg, err := DecodeControl(b)
+		if err != nil {
+			return nil, h.fail(err)
+		}
+
+		if msg.ConnectionClose != nil {
+			return nil, h.fail(fmt.Errorf("connection closed: %s", msg.ConnectionClose.Reason))
+		}
+
+		return h.handleControl(msg)
+
+	default:
+		return nil, h.fail(fmt.Errorf("invalid state: %s", h.state))
+	}
+}
+
+func (h *Handshake) handleControl(msg CmiControl) ([][]byte, error) {
+	switch h.state {
+	case StateHelloWait:
+		if msg.ConnectionHello == nil {
+			return nil, h.fail(errors.New("expected connection hello"))
+		}
+
+		switch msg.ConnectionHello.Phase {
+		case PhasePending:
+			return nil, nil
+		case PhaseReady:
+			h.state = StateProtocolWait
+			return h.encode(CmiControl{
+				MessageProtocolHandshake: &MessageProtocolHandshake{
+					HandshakeType: HandshakeAnnounceMax,
+					Version:       Version{Major: 1, Minor: 0},
+					Formats:       Formats{Format: []string{FormatJSON}},
+				},
+			})
+		default:
+			return nil, h.fail(fmt.Errorf("connection hello: %s", msg.ConnectionHello.Phase))
+		}
+
+	case StateProtocolWait:
+		if msg.MessageProtocolHandshakeError != nil {
+			return nil, h.fail(fmt.Errorf("protocol handshake error: %d", msg.MessageProtocolHandshakeError.Error))
+		}
+
+		ph := msg.MessageProtocolHandshake
+		if ph == nil || ph.HandshakeType != HandshakeSelect {
+			return nil, h.fail(errors.New("expected protocol handshake selection"))
+		}
+
+		if ph.Version.Major != 1 {
+			return nil, h.fail(fmt.Errorf("unsupported version: %d.%d", ph.Version.Major, ph.Version.Minor))
+		}
+
+		if len(ph.Formats.Format) != 1 || ph.Formats.Format[0] != FormatJSON {
+			return nil, h.fail(fmt.Errorf("unsupported format: %v", ph.Formats.Format))
+		}
+
+		h.state = StatePinWait
+		return h.encode(
+			CmiControl{MessageProtocolHandshake: ph},
+			CmiControl{ConnectionPinState: &ConnectionPinState{PinState: PinStateNone}},
+		)
+
+	case StatePinWait:
+		// remote may have completed pin verification already
+		if msg.AccessMethodsRequest != nil {
+			return h.encode(CmiControl{AccessMethods: &AccessMethods{ID: h.id}})
+		}
+
+		if msg.ConnectionPinState == nil {
+			return nil, h.fail(errors.New("expected pin state"))
+		}
+
+		switch msg.ConnectionPinState.PinState {
+		case PinStateNone, PinStateOptional, PinStatePinOk:
+			h.state = StateAccessWait
+			return h.encode(CmiControl{AccessMethodsRequest: &AccessMethodsRequest{}})
+		default:
+			return nil, h.fail(fmt.Errorf("pin verification not supported: %s", msg.ConnectionPinState.PinState))
+		}
+
+	case StateAccessWait:
+		// answer remote access methods request
+		if msg.AccessMethodsRequest != nil {
+			return h.encode(CmiControl{AccessMethods: &AccessMethods{ID: h.id}})
+		}
+
+		if msg.AccessMethods != nil {
+			h.state = StateComplete
+			return nil, nil
+		}
+
+		return nil, h.fail(errors.New("expected access methods"))
+	}
+
+	return nil, h.fail(fmt.Errorf("invalid state: %s", h.state))
+}
+
+func (h *Handshake) encode(msgs ...CmiControl) ([][]byte, error) {
+	var res [][]byte
+
+	for _, msg := range msgs {
+		b, err := Encode(MsgTypeControl, msg)
+		if err != nil {
+			return nil, h.fail(err)
+		}
+
+		res = append(res, b)
+	}
+
+	return res, nil
+}
+
+func (h *Handshake) fail(err error) error {
+	h.state = StateError
+	return fmt.Errorf("ship handshake: %v", err)
+}
@@ -0,0 +1,133 @@
+package ship
+
+import (
+	"testing"
+)
+
+func control(t *testing.T, msg CmiControl) []byte {
+	b, err := Encode(MsgTypeControl, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestHandshake(t *testing.T) {
+	h := NewHandshake("evcc")
+
+	out, err := h.Start()
+	if err != nil || len(out) != 1 || string(out[0]) != string(CmiInit) {
+		t.Fatalf("start: %v %v", out, err)
+	}
+
+	selection := &MessageProtocolHandshake{
+		HandshakeType: HandshakeSelect,
+		Version:       Version{Major: 1, Minor: 0},
+		Formats:       Formats{Format: []string{FormatJSON}},
+	}
+
+	tc := []struct {
+		in    []byte
+		state State
+		out   []CmiControl
+	}{
+		{CmiInit, StateHelloWait, []CmiControl{
+			{ConnectionHello: &ConnectionHello{Phase: PhaseReady, Waiting: helloWaiting}},
+		}},
+		{control(t, CmiControl{ConnectionHello: &ConnectionHello{Phase: PhasePending}}), StateHelloWait, nil},
+		{control(t, CmiControl{ConnectionHello: &ConnectionHello{Phase: PhaseReady}}), StateProtocolWait, []CmiControl{
+			{MessageProtocolHandshake: &MessageProtocolHandshake{
+				HandshakeType: HandshakeAnnounceMax,
+				Version:       Version{Major: 1, Minor: 0},
+				Formats:       Formats{Format: []string{FormatJSON}},
+			}},
+		}},
+		{control(t, CmiControl{MessageProtocolHandshake: selection}), StatePinWait, []CmiControl{
+			{MessageProtocolHandshake: selection},
+			{ConnectionPinState: &ConnectionPinState{PinState: PinStateNone}},
+		}},
+		{control(t, CmiControl{ConnectionPinState: &ConnectionPinState{PinState: PinStateNone}}), StateAccessWait, []CmiControl{
+			{AccessMethodsRequest: &AccessMethodsRequest{}},
+		}},
+		{control(t, CmiControl{AccessMethodsRequest: &AccessMethodsRequest{}}), StateAccessWait, []CmiControl{
+			{AccessMethods: &AccessMethods{ID: "evcc"}},
+		}},
+		{control(t, CmiControl{AccessMethods: &AccessMethods{ID: "wallbox"}}), StateComplete, nil},
+	}
+
+	for step, tc := range tc {
+		out, err := h.Handle(tc.in)
+		if err != nil {
+			t.Fatalf("step %d: %v", step, err)
+		}
+
+		if h.State() != tc.state {
+			t.Errorf("step %d: wanted state %s, got %s", step, tc.state, h.State())
+		}
+
+		if len(out) != len(tc.out) {
+			t.Fatalf("step %d: wanted %d messages, got %d", step, len(tc.out), len(out))
+		}
+
+		for i, msg := range tc.out {
+			if expected := control(t, msg); string(out[i]) != string(expected) {
+				t.Errorf("step %d: wanted %s, got %s", step, expected[1:], out[i][1:])
+			}
+		}
+	}
+
+	if !h.Complete() {
+		t.Error("handshake not complete")
+	}
+}
+
+func TestHandshakeErrors(t *testing.T) {
+	tc := []struct {
+		state State
+		in    CmiControl
+	}{
+		{StateHelloWait, CmiControl{ConnectionHello: &ConnectionHello{Phase: PhaseAborted}}},
+		{StateHelloWait, CmiControl{ConnectionPinState: &ConnectionPinState{PinState: PinStateNone}}},
+		{StateProtocolWait, CmiControl{MessageProtocolHandshakeError: &MessageProtocolHandshakeError{Error: 2}}},
+		{StateProtocolWait, CmiControl{MessageProtocolHandshake: &MessageProtocolHandshake{
+			HandshakeType: HandshakeSelect,
+			Version:       Version{Major: 2},
+			Formats:       Formats{Format: []string{FormatJSON}},
+		}}},
+		{StateProtocolWait, CmiControl{MessageProtocolHandshake: &MessageProtocolHandshake{
+			HandshakeType: HandshakeSelect,
+			Version:       Version{Major: 1},
+			Formats:       Formats{Format: []string{"JSON-UTF16"}},
+		}}},
+		{StatePinWait, CmiControl{ConnectionPinState: &ConnectionPinState{PinState: PinStateRequired}}},
+		{StateAccessWait, CmiControl{ConnectionClose: &ConnectionClose{Phase: "announce"}}},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		h := &Handshake{id: "evcc", state: tc.state}
+		if _, err := h.Handle(control(t, tc.in)); err == nil {
+			t.Error("expected error")
+		}
+
+		if h.State() != StateError {
+			t.Errorf("wanted state %s, got %s", StateError, h.State())
+		}
+	}
+}
+
+func TestHandshakeInvalidCmi(t *testing.T) {
+	h := NewHandshake("evcc")
+	if _, err := h.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := h.Handle([]byte{MsgTypeControl, 0x00}); err == nil {
+		t.Error("expected error")
+	}
+
+	if _, err := h.Start(); err == nil {
+		t.Error("expected error on restart")
+	}
+}
@@ -0,0 +1,127 @@
+package ship
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// EEBUS uses a non-standard JSON representation where objects are encoded
+// as arrays of single-key objects, e.g. {"a":1,"b":2} becomes [{"a":1},{"b":2}].
+// Marshal and Unmarshal transparently convert between both representations.
+
+// Marshal encodes v into EEBUS JSON representation
+func Marshal(v interface{}) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var i interface{}
+	if err := json.Unmarshal(b, &i); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(toEEBUS(i))
+}
+
+// Unmarshal decodes EEBUS JSON representation into v
+func Unmarshal(b []byte, v interface{}) error {
+	var i interface{}
+	if err := json.Unmarshal(b, &i); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(fromEEBUS(i))
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+// toEEBUS converts objects to arrays of single-key objects
+func toEEBUS(i interface{}) interface{} {
+	switch v := i.(type) {
+	case map[string]interface{}:
+		res := make([]interface{}, 0, len(v))
+		for _, key := range sortedKeys(v) {
+			res = append(res, map[string]interface{}{key: toEEBUS(v[key])})
+		}
+		return res
+
+	case []interface{}:
+		res := make([]interface{}, 0, len(v))
+		for _, el := range v {
+			res = append(res, toEEBUS(el))
+		}
+		return res
+
+	default:
+		return v
+	}
+}
+
+// fromEEBUS converts arrays of single-key objects back to objects
+func fromEEBUS(i interface{}) interface{} {
+	switch v := i.(type) {
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for key, el := range v {
+			res[key] = fromEEBUS(el)
+		}
+		return res
+
+	case []interface{}:
+		// empty arrays may be either empty objects or empty lists
+		if len(v) == 0 {
+			return nil
+		}
+
+		if obj, ok := mergeObjects(v); ok {
+			return obj
+		}
+
+		res := make([]interface{}, 0, len(v))
+		for _, el := range v {
+			res = append(res, fromEEBUS(el))
+		}
+		return res
+
+	default:
+		return v
+	}
+}
+
+// mergeObjects merges an array of single-key objects into a single object
+func mergeObjects(v []interface{}) (map[string]interface{}, bool) {
+	if len(v) == 0 {
+		return nil, false
+	}
+
+	res := make(map[string]interface{}, len(v))
+
+	for _, el := range v {
+		obj, ok := el.(map[string]interface{})
+		if !ok || len(obj) != 1 {
+			return nil, false
+		}
+
+		for key, val := range obj {
+			if _, exists := res[key]; exists {
+				return nil, false
+			}
+			res[key] = fromEEBUS(val)
+		}
+	}
+
+	return res, true
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,71 @@
+package ship
+
+import (
+	"testing"
+)
+
+func TestMarshal(t *testing.T) {
+	tc := []struct {
+		in       interface{}
+		expected string
+	}{
+		{CmiControl{ConnectionHello: &ConnectionHello{Phase: PhaseReady}},
+			`[{"connectionHello":[{"phase":"ready"}]}]`},
+		{CmiControl{AccessMethodsRequest: &AccessMethodsRequest{}},
+			`[{"accessMethodsRequest":[]}]`},
+		{Version{Major: 1, Minor: 0},
+			`[{"major":1},{"minor":0}]`},
+		{Formats{Format: []string{FormatJSON}},
+			`[{"format":["JSON-UTF8"]}]`},
+		{[]Version{{Major: 1}, {Major: 2}},
+			`[[{"major":1},{"minor":0}],[{"major":2},{"minor":0}]]`},
+	}
+
+	for _, tc := range tc {
+		b, err := Marshal(tc.in)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if string(b) != tc.expected {
+			t.Errorf("wanted %s, got %s", tc.expected, b)
+		}
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	var ph MessageProtocolHandshake
+	in := `[{"handshakeType":"select"},{"version":[{"major":1},{"minor":0}]},{"formats":[{"format":["JSON-UTF8"]}]}]`
+
+	if err := Unmarshal([]byte(in), &ph); err != nil {
+		t.Fatal(err)
+	}
+
+	if ph.HandshakeType != HandshakeSelect || ph.Version.Major != 1 || len(ph.Formats.Format) != 1 || ph.Formats.Format[0] != FormatJSON {
+		t.Errorf("unexpected result: %+v", ph)
+	}
+
+	var versions []Version
+	if err := Unmarshal([]byte(`[[{"major":1},{"minor":0}],[{"major":2},{"minor":1}]]`), &versions); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(versions) != 2 || versions[1].Major != 2 || versions[1].Minor != 1 {
+		t.Errorf("unexpected result: %+v", versions)
+	}
+}
+
+func TestDecodeControl(t *testing.T) {
+	msg, err := DecodeControl([]byte("\x01[{\"accessMethodsRequest\":[]}]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if msg.AccessMethodsRequest == nil {
+		t.Error("missing access methods request")
+	}
+
+	if _, err := DecodeControl([]byte("\x02[]")); err == nil {
+		t.Error("expected error for data message")
+	}
+}
@@ -0,0 +1,192 @@
+package ship
+
+import (
+	"errors"
+	"fmt"
+)
+
+// SHIP - Smart Home IP, the EEBUS transport protocol
+
+// SubProtocol is the websocket sub protocol used by SHIP
+const SubProtocol = "ship"
+
+// Message types
+const (
+	MsgTypeInit    byte = 0
+	MsgTypeControl byte = 1
+	MsgTypeData    byte = 2
+	MsgTypeEnd     byte = 3
+)
+
+// CmiInit is the connection mode initialisation message
+var CmiInit = []byte{MsgTypeInit, 0x00}
+
+// Connection hello phases
+const (
+	PhasePending = "pending"
+	PhaseReady   = "ready"
+	PhaseAborted = "aborted"
+)
+
+// Protocol handshake types
+const (
+	HandshakeAnnounceMax = "announceMax"
+	HandshakeSelect      = "select"
+)
+
+// Pin states
+const (
+	PinStateRequired = "required"
+	PinStateOptional = "optional"
+	PinStatePinOk    = "pinOk"
+	PinStateNone     = "none"
+)
+
+const (
+	// ProtocolIDSpine is the SPINE data protocol id
+	ProtocolIDSpine = "ee1.0"
+
+	// FormatJSON is the only supported message format
+	FormatJSON = "JSON-UTF8"
+
+	// helloWaiting is the announced waiting time in ms
+	helloWaiting = 60000
+)
+
+// ConnectionHello is the connection hello message
+type ConnectionHello struct {
+	Phase   string `json:"phase"`
+	Waiting int    `json:"waiting,omitempty"`
+}
+
+// Version is the SHIP version
+type Version struct {
+	Major int `json:"major"`
+	Minor int `json:"minor"`
+}
+
+// Formats is the list of supported message formats
+type Formats struct {
+	Format []string `json:"format"`
+}
+
+// MessageProtocolHandshake is the protocol handshake message
+type MessageProtocolHandshake struct {
+	HandshakeType string  `json:"handshakeType"`
+	Version       Version `json:"version"`
+	Formats       Formats `json:"formats"`
+}
+
+// MessageProtocolHandshakeError is the protocol handshake error message
+type MessageProtocolHandshakeError struct {
+	Error int `json:"error"`
+}
+
+// ConnectionPinState is the pin state message
+type ConnectionPinState struct {
+	PinState string `json:"pinState"`
+}
+
+// AccessMethodsRequest is the access methods request message
+type AccessMethodsRequest struct{}
+
+// AccessMethods is the access methods message
+type AccessMethods struct {
+	ID string `json:"id"`
+}
+
+// ConnectionClose is the connection close message
+type ConnectionClose struct {
+	Phase  string `json:"phase"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// CmiControl is the union of all SHIP control messages
+type CmiControl struct {
+	ConnectionHello               *ConnectionHello               `json:"connectionHello,omitempty"`
+	MessageProtocolHandshake      *MessageProtocolHandshake      `json:"messageProtocolHandshake,omitempty"`
+	MessageProtocolHandshakeError *MessageProtocolHandshakeError `json:"messageProtocolHandshakeError,omitempty"`
+	ConnectionPinState            *ConnectionPinState            `json:"connectionPinState,omitempty"`
+	AccessMethodsRequest          *AccessMethodsRequest          `json:"accessMethodsRequest,omitempty"`
+	AccessMethods                 *AccessMethods                 `json:"accessMethods,omitempty"`
+	ConnectionClose               *ConnectionClose               `json:"connectionClose,omitempty"`
+}
+
+// DataHeader is the SHIP data header
+type DataHeader struct {
+	ProtocolID string `json:"protocolId"`
+}
+
+// Data is the SHIP data message transporting SPINE payloads
+type Data struct {
+	Header  DataHeader  `json:"header"`
+	Payload interface{} `json:"payload"`
+}
+
+// Encode creates a SHIP frame of given type
+func Encode(typ byte, msg interface{}) ([]byte, error) {
+	b, err := Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{typ}, b...), nil
+}
+
+// DecodeControl decodes a SHIP control frame
+func DecodeControl(b []byte) (CmiControl, error) {
+	var res CmiControl
+
+	if len(b) < 2 || b[0] != MsgTypeControl {
+		return res, errors.New("invalid control message")
+	}
+
+	if err := Unmarshal(b[1:], &res); err != nil {
+		return res, err
+	}
+
+	// empty objects are decoded as null, detect presence explicitly
+	var keys map[string]interface{}
+	if err := Unmarshal(b[1:], &keys); err == nil {
+		if _, ok := keys["accessMethodsRequest"]; ok && res.AccessMethodsRequest == nil {
+			res.AccessMethodsRequest = &AccessMethodsRequest{}
+		}
+	}
+
+	return res, nil
+}
+
+// EncodeData creates a SHIP data frame for given SPINE payload
+func EncodeData(payload interface{}) ([]byte, error) {
+	return Encode(MsgTypeData, struct {
+		Data Data `json:"data"`
+	}{
+		Data: Data{
+			Header:  DataHeader{ProtocolID: ProtocolIDSpine},
+			Payload: payload,
+		},
+	})
+}
+
+// DecodeData decodes a SHIP data frame into given SPINE payload
+func DecodeData(b []byte, payload interface{}) error {
+	if len(b) < 2 || b[0] != MsgTypeData {
+		return errors.New("invalid data message")
+	}
+
+	res := struct {
+		Data Data `json:"data"`
+	}{
+		Data: Data{Payload: payload},
+	}
+
+	if err := Unmarshal(b[1:], &res); err != nil {
+		return err
+	}
+
+	if res.Data.Header.ProtocolID != ProtocolIDSpine {
+		return fmt.Errorf("invalid protocol: %s", res.Data.Header.ProtocolID)
+	}
+
+	return nil
+}
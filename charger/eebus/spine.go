@@ -0,0 +1,232 @@
+package eebus
+
+import (
+	"math"
+)
+
+// SPINE - Smart Premises Interoperable Neutral-message Exchange, the EEBUS data protocol.
+// Only the subset of the data model required for the EVSE current limit use case
+// (overload protection by EV current limitation) is implemented.
+
+// SpecificationVersion is the SPINE version implemented
+const SpecificationVersion = "1.2.0"
+
+// Command classifiers
+const (
+	CmdRead   = "read"
+	CmdReply  = "reply"
+	CmdNotify = "notify"
+	CmdWrite  = "write"
+	CmdResult = "result"
+)
+
+// Entity, feature and role types
+const (
+	EntityTypeCEM  = "CEM"
+	EntityTypeEVSE = "EVSE"
+	EntityTypeEV   = "EV"
+
+	FeatureTypeNodeManagement = "NodeManagement"
+	FeatureTypeLoadControl    = "LoadControl"
+	FeatureTypeMeasurement    = "Measurement"
+
+	RoleClient  = "client"
+	RoleServer  = "server"
+	RoleSpecial = "special"
+
+	ScopeTypeACCurrent          = "acCurrent"
+	ScopeTypeOverloadProtection = "overloadProtection"
+	LimitCategoryObligation     = "obligation"
+)
+
+// FeatureAddress addresses a device feature
+type FeatureAddress struct {
+	Device  string `json:"device,omitempty"`
+	Entity  []int  `json:"entity"`
+	Feature int    `json:"feature"`
+}
+
+// EntityAddress addresses a device entity
+type EntityAddress struct {
+	Device string `json:"device,omitempty"`
+	Entity []int  `json:"entity"`
+}
+
+// Header is the SPINE datagram header
+type Header struct {
+	SpecificationVersion string         `json:"specificationVersion"`
+	AddressSource        FeatureAddress `json:"addressSource"`
+	AddressDestination   FeatureAddress `json:"addressDestination"`
+	MsgCounter           int            `json:"msgCounter"`
+	MsgCounterReference  int            `json:"msgCounterReference,omitempty"`
+	CmdClassifier        string         `json:"cmdClassifier"`
+	AckRequest           bool           `json:"ackRequest,omitempty"`
+}
+
+// ScaledNumber is the SPINE number representation
+type ScaledNumber struct {
+	Number int64 `json:"number"`
+	Scale  int   `json:"scale,omitempty"`
+}
+
+// Value returns the scaled number's value
+func (n ScaledNumber) Value() float64 {
+	return float64(n.Number) * math.Pow10(n.Scale)
+}
+
+// DeviceDescription describes a device
+type DeviceDescription struct {
+	DeviceAddress struct {
+		Device string `json:"device"`
+	} `json:"deviceAddress"`
+	DeviceType string `json:"deviceType,omitempty"`
+}
+
+// EntityInformation describes an entity
+type EntityInformation struct {
+	Description struct {
+		EntityAddress EntityAddress `json:"entityAddress"`
+		EntityType    string        `json:"entityType"`
+	} `json:"description"`
+}
+
+// FeatureInformation describes a feature
+type FeatureInformation struct {
+	Description struct {
+		FeatureAddress FeatureAddress `json:"featureAddress"`
+		FeatureType    string         `json:"featureType"`
+		Role           string         `json:"role"`
+	} `json:"description"`
+}
+
+// NodeManagementDetailedDiscoveryData is the discovery data of a device
+type NodeManagementDetailedDiscoveryData struct {
+	DeviceInformation *struct {
+		Description DeviceDescription `json:"description"`
+	} `json:"deviceInformation,omitempty"`
+	EntityInformation  []EntityInformation  `json:"entityInformation,omitempty"`
+	FeatureInformation []FeatureInformation `json:"featureInformation,omitempty"`
+}
+
+// MeasurementDescriptionData describes a measurement
+type MeasurementDescriptionData struct {
+	MeasurementID   int    `json:"measurementId"`
+	MeasurementType string `json:"measurementType,omitempty"`
+	Unit            string `json:"unit,omitempty"`
+	ScopeType       string `json:"scopeType,omitempty"`
+}
+
+// MeasurementDescriptionListData is the list of measurement descriptions
+type MeasurementDescriptionListData struct {
+	MeasurementDescriptionData []MeasurementDescriptionData `json:"measurementDescriptionData,omitempty"`
+}
+
+// MeasurementData is a measurement value
+type MeasurementData struct {
+	MeasurementID int          `json:"measurementId"`
+	Value         ScaledNumber `json:"value"`
+}
+
+// MeasurementListData is the list of measurement values
+type MeasurementListData struct {
+	MeasurementData []MeasurementData `json:"measurementData,omitempty"`
+}
+
+// LoadControlLimitDescriptionData describes a load control limit
+type LoadControlLimitDescriptionData struct {
+	LimitID       int    `json:"limitId"`
+	LimitCategory string `json:"limitCategory,omitempty"`
+	MeasurementID int    `json:"measurementId,omitempty"`
+	Unit          string `json:"unit,omitempty"`
+	ScopeType     string `json:"scopeType,omitempty"`
+}
+
+// LoadControlLimitDescriptionListData is the list of load control limit descriptions
+type LoadControlLimitDescriptionListData struct {
+	LoadControlLimitDescriptionData []LoadControlLimitDescriptionData `json:"loadControlLimitDescriptionData,omitempty"`
+}
+
+// LoadControlLimitData is a load control limit value
+type LoadControlLimitData struct {
+	LimitID       int          `json:"limitId"`
+	IsLimitActive bool         `json:"isLimitActive"`
+	Value         ScaledNumber `json:"value"`
+}
+
+// LoadControlLimitListData is the list of load control limit values
+type LoadControlLimitListData struct {
+	LoadControlLimitData []LoadControlLimitData `json:"loadControlLimitData,omitempty"`
+}
+
+// ResultData is the result of a write operation
+type ResultData struct {
+	ErrorNumber int    `json:"errorNumber"`
+	Description string `json:"description,omitempty"`
+}
+
+// Cmd is the union of all supported SPINE commands
+type Cmd struct {
+	NodeManagementDetailedDiscoveryData *NodeManagementDetailedDiscoveryData `json:"nodeManagementDetailedDiscoveryData,omitempty"`
+	MeasurementDescriptionListData      *MeasurementDescriptionListData      `json:"measurementDescriptionListData,omitempty"`
+	MeasurementListData                 *MeasurementListData                 `json:"measurementListData,omitempty"`
+	LoadControlLimitDescriptionListData *LoadControlLimitDescriptionListData `json:"loadControlLimitDescriptionListData,omitempty"`
+	LoadControlLimitListData            *LoadControlLimitListData            `json:"loadControlLimitListData,omitempty"`
+	ResultData                          *ResultData                          `json:"resultData,omitempty"`
+}
+
+// Datagram is the SPINE datagram
+type Datagram struct {
+	Header  Header `json:"header"`
+	Payload struct {
+		Cmd []Cmd `json:"cmd"`
+	} `json:"payload"`
+}
+
+// Message is the SPINE message container
+type Message struct {
+	Datagram Datagram `json:"datagram"`
+}
+
+// Feature returns the address of the first feature of given type and role on an entity of given type
+func (d *NodeManagementDetailedDiscoveryData) Feature(entityType, featureType, role string) (FeatureAddress, bool) {
+	for _, ei := range d.EntityInformation {
+		if ei.Description.EntityType != entityType {
+			continue
+		}
+
+		for _, fi := range d.FeatureInformation {
+			addr := fi.Description.FeatureAddress
+			if fi.Description.FeatureType == featureType && fi.Description.Role == role &&
+				equalEntity(addr.Entity, ei.Description.EntityAddress.Entity) {
+				return addr, true
+			}
+		}
+	}
+
+	return FeatureAddress{}, false
+}
+
+// HasEntity returns true if an entity of given type is present
+func (d *NodeManagementDetailedDiscoveryData) HasEntity(entityType string) bool {
+	for _, ei := range d.EntityInformation {
+		if ei.Description.EntityType == entityType {
+			return true
+		}
+	}
+
+	return false
+}
+
+func equalEntity(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,76 @@
+package eebus
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/andig/evcc/charger/eebus/ship"
+)
+
+func TestDiscoveryRoundtrip(t *testing.T) {
+	var msg Message
+	msg.Datagram.Header.CmdClassifier = CmdReply
+	msg.Datagram.Payload.Cmd = []Cmd{{NodeManagementDetailedDiscoveryData: localDiscovery()}}
+
+	b, err := ship.EncodeData(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var res Message
+	if err := ship.DecodeData(b, &res); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Datagram.Payload.Cmd) != 1 || res.Datagram.Payload.Cmd[0].NodeManagementDetailedDiscoveryData == nil {
+		t.Fatalf("invalid payload: %+v", res.Datagram.Payload)
+	}
+
+	discovery := res.Datagram.Payload.Cmd[0].NodeManagementDetailedDiscoveryData
+	if !discovery.HasEntity(EntityTypeCEM) {
+		t.Error("missing CEM entity")
+	}
+
+	addr, ok := discovery.Feature(EntityTypeCEM, FeatureTypeLoadControl, RoleClient)
+	if !ok || addr.Feature != 1 {
+		t.Errorf("invalid load control feature: %v %v", addr, ok)
+	}
+
+	if _, ok := discovery.Feature(EntityTypeEV, FeatureTypeLoadControl, RoleServer); ok {
+		t.Error("unexpected EV feature")
+	}
+}
+
+func TestTrustStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eebus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "trust.json")
+
+	ts, err := NewTrustStore(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ts.Trusted("ab:cd") {
+		t.Error("unexpected trust")
+	}
+
+	if err := ts.Trust("AB:CD", "wallbox"); err != nil {
+		t.Fatal(err)
+	}
+
+	// reload persisted store
+	if ts, err = NewTrustStore(file); err != nil {
+		t.Fatal(err)
+	}
+
+	if !ts.Trusted("abcd") {
+		t.Error("missing persisted trust")
+	}
+}
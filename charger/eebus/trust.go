@@ -0,0 +1,63 @@
+package eebus
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// TrustStore persists the SKIs of paired remote devices
+type TrustStore struct {
+	mux  sync.Mutex
+	file string
+	skis map[string]string
+}
+
+// NewTrustStore creates a trust store backed by the given file
+func NewTrustStore(file string) (*TrustStore, error) {
+	ts := &TrustStore{
+		file: file,
+		skis: make(map[string]string),
+	}
+
+	b, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return ts, nil
+	}
+
+	if err == nil {
+		err = json.Unmarshal(b, &ts.skis)
+	}
+
+	return ts, err
+}
+
+// Trusted returns true if the SKI has been paired before
+func (ts *TrustStore) Trusted(ski string) bool {
+	ts.mux.Lock()
+	defer ts.mux.Unlock()
+
+	_, ok := ts.skis[NormalizeSKI(ski)]
+	return ok
+}
+
+// Trust adds the SKI to the trust store and persists it
+func (ts *TrustStore) Trust(ski, name string) error {
+	ts.mux.Lock()
+	defer ts.mux.Unlock()
+
+	ski = NormalizeSKI(ski)
+	if current, ok := ts.skis[ski]; ok && current == name {
+		return nil
+	}
+
+	ts.skis[ski] = name
+
+	b, err := json.MarshalIndent(ts.skis, "", "  ")
+	if err == nil {
+		err = ioutil.WriteFile(ts.file, b, 0600)
+	}
+
+	return err
+}
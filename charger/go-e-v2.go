@@ -0,0 +1,183 @@
+package charger
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/andig/evcc/api"
+	"github.com/andig/evcc/util"
+)
+
+// https://github.com/goecharger/go-eCharger-API-v2
+
+// goeV2StatusResponse is the local V2 API status response
+type goeV2StatusResponse struct {
+	Fwv string    `json:"fwv"` // firmware version
+	Car int       `json:"car"` // car status
+	Alw bool      `json:"alw"` // charging allowed
+	Amp int       `json:"amp"` // current [A]
+	Frc int       `json:"frc"` // force state
+	Wh  float64   `json:"wh"`  // energy since car connected [Wh]
+	Nrg []float64 `json:"nrg"` // voltage [V], current [A], power [W]
+	Pha []bool    `json:"pha"` // phases before (L1-L3) and after (L1-L3) contactor
+}
+
+// force states
+const (
+	goeV2ForceOff = 1
+	goeV2ForceOn  = 2
+)
+
+// phase switch modes
+const (
+	goeV2PhasesAuto = 0
+	goeV2Phases1p   = 1
+	goeV2Phases3p   = 2
+)
+
+// GoEV2 charger implementation for the local V2 API of Gemini and V3 firmware
+type GoEV2 struct {
+	*util.HTTPHelper
+	uri string
+}
+
+// NewGoEV2 creates GoE charger using the V2 api
+func NewGoEV2(uri string) (*GoEV2, error) {
+	c := &GoEV2{
+		HTTPHelper: util.NewHTTPHelper(util.NewLogger("go-e")),
+		uri:        strings.TrimRight(uri, "/"),
+	}
+
+	return c, nil
+}
+
+func (c *GoEV2) apiStatus() (goeV2StatusResponse, error) {
+	var status goeV2StatusResponse
+	_, err := c.GetJSON(fmt.Sprintf("%s/api/status?filter=fwv,car,alw,amp,frc,wh,nrg,pha", c.uri), &status)
+	return status, err
+}
+
+// apiUpdate sets the key to value. The set api confirms each key with true or returns an error message.
+func (c *GoEV2) apiUpdate(key string, value int64) error {
+	var res map[string]interface{}
+	if _, err := c.GetJSON(fmt.Sprintf("%s/api/set?%s=%d", c.uri, key, value), &res); err != nil {
+		return err
+	}
+
+	if ok, _ := res[key].(bool); !ok {
+		return fmt.Errorf("%s update failed: %v", key, res[key])
+	}
+
+	return nil
+}
+
+// initPhases sets the phase switch mode to a fixed number of phases.
+// Firmware without phase switching does not report psm and is skipped.
+func (c *GoEV2) initPhases(phases int) error {
+	var res map[string]interface{}
+	if _, err := c.GetJSON(fmt.Sprintf("%s/api/status?filter=psm", c.uri), &res); err != nil {
+		return err
+	}
+
+	if _, ok := res["psm"]; !ok {
+		c.Log.WARN.Println("phase switching not supported by firmware, ignoring phases")
+		return nil
+	}
+
+	return c.Phases1p3p(phases)
+}
+
+// Status implements the Charger.Status interface
+func (c *GoEV2) Status() (api.ChargeStatus, error) {
+	status, err := c.apiStatus()
+	if err != nil {
+		return api.StatusNone, err
+	}
+
+	switch status.Car {
+	case 1:
+		return api.StatusA, nil
+	case 2:
+		return api.StatusC, nil
+	case 3, 4:
+		return api.StatusB, nil
+	default:
+		return api.StatusNone, fmt.Errorf("car unknown result: %d", status.Car)
+	}
+}
+
+// Enabled implements the Charger.Enabled interface
+func (c *GoEV2) Enabled() (bool, error) {
+	status, err := c.apiStatus()
+	return status.Alw, err
+}
+
+// Enable implements the Charger.Enable interface
+func (c *GoEV2) Enable(enable bool) error {
+	frc := int64(goeV2ForceOff)
+	if enable {
+		frc = goeV2ForceOn
+	}
+
+	return c.apiUpdate("frc", frc)
+}
+
+// MaxCurrent implements the Charger.MaxCurrent interface
+func (c *GoEV2) MaxCurrent(current int64) error {
+	return c.apiUpdate("amp", current)
+}
+
+// GetMaxCurrent implements the CurrentGetter interface
+func (c *GoEV2) GetMaxCurrent() (int64, error) {
+	status, err := c.apiStatus()
+	return int64(status.Amp), err
+}
+
+// Phases1p3p implements the ChargePhaseSwitcher interface
+func (c *GoEV2) Phases1p3p(phases int) error {
+	psm := int64(goeV2Phases1p)
+	if phases == 3 {
+		psm = goeV2Phases3p
+	}
+
+	return c.apiUpdate("psm", psm)
+}
+
+// CurrentPower implements the Meter interface.
+func (c *GoEV2) CurrentPower() (float64, error) {
+	status, err := c.apiStatus()
+	var power float64
+	if len(status.Nrg) == 16 {
+		power = status.Nrg[11]
+	}
+	return power, err
+}
+
+// ChargedEnergy implements the ChargeRater interface
+func (c *GoEV2) ChargedEnergy() (float64, error) {
+	status, err := c.apiStatus()
+	return status.Wh / 1e3, err
+}
+
+// ActivePhases implements the ChargePhases interface
+func (c *GoEV2) ActivePhases() (int64, error) {
+	status, err := c.apiStatus()
+
+	var pha int
+	for i, b := range status.Pha {
+		if b {
+			pha |= 1 << i
+		}
+	}
+
+	return goePhases(pha), err
+}
+
+// Currents implements the MeterCurrent interface
+func (c *GoEV2) Currents() (float64, float64, float64, error) {
+	status, err := c.apiStatus()
+	if len(status.Nrg) == 16 {
+		return status.Nrg[4], status.Nrg[5], status.Nrg[6], nil
+	}
+	return 0, 0, 0, err
+}
@@ -33,6 +33,26 @@ type goeStatusResponse struct {
 	Tmp int    `json:"tmp,string"` // temperature [°C]
 	Dws int    `json:"dws,string"` // energy [Ws]
 	Nrg []int  `json:"nrg"`        // voltage, current, power
+	Pha int    `json:"pha,string"` // phases before (bits 0-2) and after (bits 3-5) contactor
+}
+
+// goePhases decodes the number of phases from the pha bitmask. Phases after the contactor
+// are energized for the vehicle, phases before the contactor are provided by the installation.
+func goePhases(pha int) int64 {
+	count := func(bits int) (res int64) {
+		for i := 0; i < 3; i++ {
+			if bits&(1<<i) != 0 {
+				res++
+			}
+		}
+		return res
+	}
+
+	if after := count(pha >> 3); after > 0 {
+		return after
+	}
+
+	return count(pha)
 }
 
 // GoE charger implementation
@@ -47,10 +67,14 @@ type GoE struct {
 // NewGoEFromConfig creates a go-e charger from generic config
 func NewGoEFromConfig(other map[string]interface{}) (api.Charger, error) {
 	cc := struct {
-		Token string
-		URI   string
-		Cache time.Duration
-	}{}
+		Token  string
+		URI    string
+		API    string // local api version, v1 or v2 (Gemini and V3 firmware)
+		Cache  time.Duration
+		Phases int // initial phase switch mode, 1 or 3 phases (api v2 only)
+	}{
+		API: "v1",
+	}
 	if err := util.DecodeOther(other, &cc); err != nil {
 		return nil, err
 	}
@@ -62,6 +86,30 @@ func NewGoEFromConfig(other map[string]interface{}) (api.Charger, error) {
 		return nil, errors.New("go-e config: must have one of uri/token")
 	}
 
+	if cc.Phases != 0 && cc.Phases != 1 && cc.Phases != 3 {
+		return nil, fmt.Errorf("go-e config: invalid phases: %d", cc.Phases)
+	}
+
+	switch strings.ToLower(cc.API) {
+	case "v1":
+		if cc.Phases != 0 {
+			return nil, errors.New("go-e config: phases requires api v2")
+		}
+	case "v2":
+		if cc.Token != "" {
+			return nil, errors.New("go-e config: api v2 requires uri")
+		}
+
+		c, err := NewGoEV2(cc.URI)
+		if err == nil && cc.Phases != 0 {
+			err = c.initPhases(cc.Phases)
+		}
+
+		return c, err
+	default:
+		return nil, fmt.Errorf("go-e config: invalid api: %s", cc.API)
+	}
+
 	return NewGoE(cc.URI, cc.Token, cc.Cache)
 }
 
@@ -204,6 +252,16 @@ func (c *GoE) MaxCurrent(current int64) error {
 	return err
 }
 
+// GetMaxCurrent implements the CurrentGetter interface.
+// Cached cloud update responses don't contain the current.
+func (c *GoE) GetMaxCurrent() (int64, error) {
+	status, err := c.apiStatus()
+	if err == nil && !isValid(status) {
+		return 0, api.ErrNotSupported
+	}
+	return int64(status.Amp), err
+}
+
 // CurrentPower implements the Meter interface.
 func (c *GoE) CurrentPower() (float64, error) {
 	status, err := c.apiStatus()
@@ -221,6 +279,12 @@ func (c *GoE) ChargedEnergy() (float64, error) {
 	return energy, err
 }
 
+// ActivePhases implements the ChargePhases interface
+func (c *GoE) ActivePhases() (int64, error) {
+	status, err := c.apiStatus()
+	return goePhases(status.Pha), err
+}
+
 // Currents implements the MeterCurrent interface
 func (c *GoE) Currents() (float64, float64, float64, error) {
 	status, err := c.apiStatus()
@@ -1,6 +1,9 @@
 package charger
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/andig/evcc/api"
@@ -21,4 +24,248 @@ func TestGoE(t *testing.T) {
 	if _, ok := wb.(api.ChargeRater); !ok {
 		t.Error("missing ChargeRater interface")
 	}
+
+	wb, err = NewGoEV2("foo")
+	if err != nil {
+		t.Error(err)
+	}
+
+	if _, ok := wb.(api.MeterCurrent); !ok {
+		t.Error("missing MeterCurrent interface")
+	}
+
+	if _, ok := wb.(api.ChargeRater); !ok {
+		t.Error("missing ChargeRater interface")
+	}
+}
+
+// goeMeter is the common meter interface of both api versions
+type goeMeter interface {
+	api.Charger
+	api.Meter
+	api.MeterCurrent
+	api.ChargeRater
+}
+
+func testGoEMeter(t *testing.T, wb goeMeter) {
+	t.Helper()
+
+	if status, err := wb.Status(); err != nil || status != api.StatusC {
+		t.Errorf("status: expected %s, got %s %v", api.StatusC, status, err)
+	}
+
+	if enabled, err := wb.Enabled(); err != nil || !enabled {
+		t.Errorf("enabled: expected true, got %v %v", enabled, err)
+	}
+
+	if power, err := wb.CurrentPower(); err != nil || power != 11040 {
+		t.Errorf("power: expected 11040, got %v %v", power, err)
+	}
+
+	if l1, l2, l3, err := wb.Currents(); err != nil || l1 != 16 || l2 != 16 || l3 != 16 {
+		t.Errorf("currents: expected 16A, got %v %v %v %v", l1, l2, l3, err)
+	}
+
+	if energy, err := wb.ChargedEnergy(); err != nil || energy != 1.5 {
+		t.Errorf("energy: expected 1.5kWh, got %v %v", energy, err)
+	}
+}
+
+func TestGoEV1Local(t *testing.T) {
+	var payloads []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		amp := "16"
+		switch r.URL.Path {
+		case "/status":
+		case "/mqtt":
+			payload := r.URL.Query().Get("payload")
+			payloads = append(payloads, payload)
+			_, _ = fmt.Sscanf(payload, "amp=%s", &amp)
+		default:
+			t.Errorf("unexpected request: %s", r.URL.Path)
+		}
+
+		fmt.Fprintf(w, `{"fwv":"040.0","car":"2","alw":"1","amp":"%s","dws":"540000",
+			"nrg":[230,230,230,0,160,160,160,368,368,368,0,1104,100,100,100,100]}`, amp)
+	}))
+	defer srv.Close()
+
+	wb, err := NewGoE(srv.URL, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testGoEMeter(t, wb)
+
+	if err := wb.MaxCurrent(10); err != nil {
+		t.Error(err)
+	}
+
+	if len(payloads) != 1 || payloads[0] != "amp=10" {
+		t.Errorf("unexpected payloads: %v", payloads)
+	}
+}
+
+func TestGoEV2(t *testing.T) {
+	var updates []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/status":
+			fmt.Fprint(w, `{"fwv":"051.3","car":2,"alw":true,"amp":16,"frc":0,"wh":1500.0,
+				"nrg":[230,230,230,0,16,16,16,3680,3680,3680,0,11040,100,100,100,100]}`)
+		case "/api/set":
+			updates = append(updates, r.URL.RawQuery)
+			if r.URL.Query().Get("amp") == "33" {
+				fmt.Fprint(w, `{"amp":"value out of range"}`)
+				return
+			}
+			for k := range r.URL.Query() {
+				fmt.Fprintf(w, `{"%s":true}`, k)
+			}
+		default:
+			t.Errorf("unexpected request: %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	wb, err := NewGoEV2(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testGoEMeter(t, wb)
+
+	if err := wb.MaxCurrent(10); err != nil {
+		t.Error(err)
+	}
+
+	if err := wb.MaxCurrent(33); err == nil {
+		t.Error("expected update error")
+	}
+
+	if err := wb.Enable(false); err != nil {
+		t.Error(err)
+	}
+
+	if err := wb.Enable(true); err != nil {
+		t.Error(err)
+	}
+
+	expected := []string{"amp=10", "amp=33", "frc=1", "frc=2"}
+	if fmt.Sprint(updates) != fmt.Sprint(expected) {
+		t.Errorf("expected updates %v, got %v", expected, updates)
+	}
+}
+
+func TestGoEV2Phases(t *testing.T) {
+	tc := []struct {
+		psm     bool // firmware supports phase switching
+		phases  int
+		updates []string
+	}{
+		{true, 1, []string{"psm=1"}},
+		{true, 3, []string{"psm=2"}},
+		{false, 3, nil},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		var updates []string
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/status":
+				if tc.psm {
+					fmt.Fprint(w, `{"psm":0}`)
+				} else {
+					fmt.Fprint(w, `{}`)
+				}
+			case "/api/set":
+				updates = append(updates, r.URL.RawQuery)
+				fmt.Fprint(w, `{"psm":true}`)
+			default:
+				t.Errorf("unexpected request: %s", r.URL.Path)
+			}
+		}))
+
+		_, err := NewGoEFromConfig(map[string]interface{}{
+			"uri":    srv.URL,
+			"api":    "v2",
+			"phases": tc.phases,
+		})
+		if err != nil {
+			t.Error(err)
+		}
+
+		if fmt.Sprint(updates) != fmt.Sprint(tc.updates) {
+			t.Errorf("expected updates %v, got %v", tc.updates, updates)
+		}
+
+		srv.Close()
+	}
+}
+
+func TestGoEPhasesConfig(t *testing.T) {
+	tc := []map[string]interface{}{
+		{"uri": "foo", "api": "v2", "phases": 2},
+		{"uri": "foo", "api": "v1", "phases": 3},
+	}
+
+	for _, tc := range tc {
+		if _, err := NewGoEFromConfig(tc); err == nil {
+			t.Errorf("%v: expected config error", tc)
+		}
+	}
+}
+
+func TestGoEPhases(t *testing.T) {
+	tc := []struct {
+		pha    int
+		phases int64
+	}{
+		{0, 0},
+		{0b000001, 1}, // L1 installation, contactor open
+		{0b000111, 3}, // 3p installation, contactor open
+		{0b001111, 1}, // 3p installation, L1 energized
+		{0b111111, 3}, // 3p installation, all phases energized
+		{0b011011, 2}, // L1 and L2
+		{0b100100, 1}, // L3 only
+	}
+
+	for _, tc := range tc {
+		if phases := goePhases(tc.pha); phases != tc.phases {
+			t.Errorf("pha %06b: expected %dp, got %dp", tc.pha, tc.phases, phases)
+		}
+	}
+}
+
+func TestGoEActivePhases(t *testing.T) {
+	v1 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"fwv":"040.0","car":"2","pha":"15"}`)
+	}))
+	defer v1.Close()
+
+	v2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"fwv":"051.3","car":2,"pha":[true,true,true,true,false,false]}`)
+	}))
+	defer v2.Close()
+
+	goe, err := NewGoE(v1.URL, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	goeV2, err := NewGoEV2(v2.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, wb := range []api.ChargePhases{goe, goeV2} {
+		if phases, err := wb.ActivePhases(); err != nil || phases != 1 {
+			t.Errorf("expected 1p, got %dp %v", phases, err)
+		}
+	}
 }
@@ -20,6 +20,10 @@ import (
 const (
 	udpTimeout = time.Second
 	kebaPort   = "7090"
+
+	broadcastMaxAge = time.Minute // poll status despite broadcasts
+
+	kebaDisplayLength = 23 // max characters of display text
 )
 
 // RFID contains access credentials
@@ -29,38 +33,52 @@ type RFID struct {
 
 // Keba is an api.Charger implementation with configurable getters and setters.
 type Keba struct {
-	log     *util.Logger
-	conn    string
-	rfid    RFID
-	timeout time.Duration
-	recv    chan keba.UDPMsg
+	log       *util.Logger
+	conn      string
+	rfid      RFID
+	timeout   time.Duration
+	recv      chan keba.UDPMsg
+	info      *keba.Report1
+	model     keba.Model
+	broadcast *keba.Broadcast
 }
 
 // NewKebaFromConfig creates a new configurable charger
 func NewKebaFromConfig(other map[string]interface{}) (api.Charger, error) {
 	cc := struct {
-		URI     string
-		Timeout time.Duration
-		RFID    RFID
+		URI       string
+		Model     string
+		Timeout   time.Duration
+		RFID      RFID
+		Broadcast bool
 	}{}
 	if err := util.DecodeOther(other, &cc); err != nil {
 		return nil, err
 	}
 
-	return NewKeba(cc.URI, cc.RFID, cc.Timeout)
+	return NewKeba(cc.URI, cc.Model, cc.RFID, cc.Timeout, cc.Broadcast)
 }
 
-// NewKeba creates a new charger
-func NewKeba(conn string, rfid RFID, timeout time.Duration) (api.Charger, error) {
+// NewKeba creates a new charger. If model is empty, it is detected from report 1.
+// If broadcast is enabled, status changes broadcast by the charger are applied without polling.
+func NewKeba(conn, model string, rfid RFID, timeout time.Duration, broadcast bool) (api.Charger, error) {
 	log := util.NewLogger("keba")
 
+	var profile keba.Model
+	if model != "" {
+		var ok bool
+		if profile, ok = keba.ModelByName(model); !ok {
+			return nil, fmt.Errorf("invalid model: %s", model)
+		}
+	}
+
 	if keba.Instance == nil {
 		keba.Instance = keba.New(log, fmt.Sprintf(":%s", kebaPort))
 	}
 
-	// add default port
-	if _, _, err := net.SplitHostPort(conn); err != nil {
-		conn = fmt.Sprintf("%s:%s", conn, kebaPort)
+	conn, err := kebaAddr(conn)
+	if err != nil {
+		log.WARN.Printf("resolve %s: %v", conn, err)
 	}
 
 	if timeout == 0 {
@@ -73,13 +91,137 @@ func NewKeba(conn string, rfid RFID, timeout time.Duration) (api.Charger, error)
 		rfid:    rfid,
 		timeout: timeout,
 		recv:    make(chan keba.UDPMsg),
+		model:   profile,
+	}
+
+	if broadcast {
+		c.broadcast = keba.NewBroadcast(broadcastMaxAge)
+
+		in := make(chan keba.UDPMsg)
+		keba.Instance.Subscribe(conn, in)
+		go c.dispatch(in)
+	} else {
+		keba.Instance.Subscribe(conn, c.recv)
+	}
+
+	kr, err := c.report1()
+	if err == nil {
+		log.INFO.Printf("detected %s model %s (serial %s, firmware %s)", kr.Product, kr.Model(), kr.Serial, kr.FirmwareVersion())
+	} else {
+		log.WARN.Printf("report 1: %v", err)
 	}
 
-	keba.Instance.Subscribe(conn, c.recv)
+	if model == "" {
+		c.model = keba.DefaultModel
+		if err == nil {
+			c.model = keba.DetectModel(kr)
+		}
+	}
+	log.DEBUG.Printf("model profile: %+v", c.model)
 
 	return c, nil
 }
 
+// kebaAddr adds the default port if missing and resolves the charger's address. Listener messages are
+// matched by sender address, hence hostnames and IPv6 literals are converted to their canonical form.
+// If the address can't be resolved, it is returned unresolved including the port.
+func kebaAddr(conn string) (string, error) {
+	if _, _, err := net.SplitHostPort(conn); err != nil {
+		conn = net.JoinHostPort(strings.Trim(conn, "[]"), kebaPort)
+	}
+
+	addr, err := net.ResolveUDPAddr("udp", conn)
+	if err != nil {
+		return conn, err
+	}
+
+	return addr.String(), nil
+}
+
+// report1 returns the cached device information
+func (c *Keba) report1() (keba.Report1, error) {
+	if c.info != nil {
+		return *c.info, nil
+	}
+
+	var kr keba.Report1
+	err := c.roundtrip("report 1", 1, &kr)
+	if err == nil {
+		c.info = &kr
+	}
+
+	return kr, err
+}
+
+// dispatch applies broadcasts and forwards all other messages to the receiver
+func (c *Keba) dispatch(in <-chan keba.UDPMsg) {
+	for msg := range in {
+		if !keba.IsBroadcast(msg) {
+			select {
+			case c.recv <- msg:
+			default:
+				c.log.TRACE.Println("recv blocked")
+			}
+			continue
+		}
+
+		c.log.TRACE.Printf("broadcast: %s", msg.Message)
+		if err := c.broadcast.Apply(msg.Message); err != nil {
+			c.log.WARN.Printf("broadcast: %v", err)
+		}
+	}
+}
+
+// report2 returns the status report. If broadcasts are received, the report is only polled
+// after broadcastMaxAge.
+func (c *Keba) report2() (keba.Report2, error) {
+	if c.broadcast != nil {
+		if kr, ok := c.broadcast.Report(); ok {
+			return kr, nil
+		}
+	}
+
+	return c.pollReport2()
+}
+
+// pollReport2 requests report 2 from the charger, bypassing the broadcast cache
+func (c *Keba) pollReport2() (keba.Report2, error) {
+	var kr keba.Report2
+	err := c.roundtrip("report 2", 2, &kr)
+	if err == nil && c.broadcast != nil {
+		c.broadcast.Update(kr)
+	}
+
+	return kr, err
+}
+
+// phaseSwitching returns true if the model supports phase switching
+func (c *Keba) phaseSwitching() bool {
+	return c.model.PhaseSwitching
+}
+
+// report3 returns the meter report if supported by the model
+func (c *Keba) report3() (keba.Report3, error) {
+	var kr keba.Report3
+	if !c.model.Meter {
+		return kr, api.ErrNotSupported
+	}
+
+	err := c.roundtrip("report 3", 3, &kr)
+	return kr, err
+}
+
+// report100 returns the last session report if supported by the model
+func (c *Keba) report100() (keba.Report100, error) {
+	var kr keba.Report100
+	if !c.model.Sessions {
+		return kr, api.ErrNotSupported
+	}
+
+	err := c.roundtrip("report 100", 100, &kr)
+	return kr, err
+}
+
 func (c *Keba) send(msg string) error {
 	raddr, err := net.ResolveUDPAddr("udp", c.conn)
 	if err != nil {
@@ -156,8 +298,7 @@ func (c *Keba) roundtrip(msg string, report int, res interface{}) error {
 
 // Status implements the Charger.Status interface
 func (c *Keba) Status() (api.ChargeStatus, error) {
-	var kr keba.Report2
-	err := c.roundtrip("report 2", 2, &kr)
+	kr, err := c.report2()
 	if err != nil {
 		return api.StatusA, err
 	}
@@ -181,8 +322,7 @@ func (c *Keba) Status() (api.ChargeStatus, error) {
 
 // Enabled implements the Charger.Enabled interface
 func (c *Keba) Enabled() (bool, error) {
-	var kr keba.Report2
-	err := c.roundtrip("report 2", 2, &kr)
+	kr, err := c.report2()
 	if err != nil {
 		return false, err
 	}
@@ -254,46 +394,141 @@ func (c *Keba) MaxCurrent(current int64) error {
 	return fmt.Errorf("curr unexpected response: %s", resp)
 }
 
+// CurrentLimit implements the CurrentLimiter interface
+func (c *Keba) CurrentLimit() (int64, error) {
+	kr, err := c.report2()
+
+	// hardware limit including dip switch settings, cable coding and temperature derating
+	// 1mA to A
+	return int64(kr.CurrHW) / keba.CurrentUnit, err
+}
+
+// GetMaxCurrent implements the CurrentGetter interface. The setpoint is not broadcast,
+// hence report 2 is polled to avoid comparing against a stale cached value.
+func (c *Keba) GetMaxCurrent() (int64, error) {
+	kr, err := c.pollReport2()
+
+	// current setpoint written by curr command
+	// 1mA to A
+	return int64(kr.Curruser) / keba.CurrentUnit, err
+}
+
+// Phases1p3p implements the ChargePhaseSwitcher interface. Requires the x2 phase switch source
+// to be configured to UDP control on the charger.
+func (c *Keba) Phases1p3p(phases int) error {
+	if !c.phaseSwitching() {
+		return api.ErrNotSupported
+	}
+
+	var x2 int
+	if phases == 3 {
+		x2 = 1
+	}
+
+	var resp string
+	err := c.roundtrip(fmt.Sprintf("x2 %d", x2), 0, &resp)
+	if err != nil {
+		return err
+	}
+
+	if resp == keba.OK {
+		return nil
+	}
+
+	return fmt.Errorf("x2 unexpected response: %s", resp)
+}
+
+// kebaDisplay formats the display command. Spaces are encoded as $ and the text is truncated to the display length.
+func kebaDisplay(text string) string {
+	text = strings.ReplaceAll(strings.TrimSpace(text), " ", "$")
+	if len(text) > kebaDisplayLength {
+		text = text[:kebaDisplayLength]
+	}
+
+	return fmt.Sprintf("display 0 0 0 0 %s", text)
+}
+
+// Display implements the ChargerDisplay interface
+func (c *Keba) Display(text string) error {
+	if !c.model.Display {
+		return api.ErrNotSupported
+	}
+
+	var resp string
+	err := c.roundtrip(kebaDisplay(text), 0, &resp)
+	if err != nil {
+		return err
+	}
+
+	if resp == keba.OK {
+		return nil
+	}
+
+	return fmt.Errorf("display unexpected response: %s", resp)
+}
+
+// implausible logs implausible report values, e.g. from garbled datagrams. They are returned as error
+// to avoid corrupting session statistics.
+func (c *Keba) implausible(err error) error {
+	var ie keba.ImplausibleError
+	if errors.As(err, &ie) {
+		c.log.WARN.Println(err)
+	}
+	return err
+}
+
 // CurrentPower implements the Meter interface
 func (c *Keba) CurrentPower() (float64, error) {
-	var kr keba.Report3
-	err := c.roundtrip("report 3", 3, &kr)
+	kr, err := c.report3()
+	if err != nil {
+		return 0, err
+	}
 
-	// mW to W
-	return float64(kr.P) / 1e3, err
+	power, err := kr.Power()
+	return power, c.implausible(err)
 }
 
 // TotalEnergy implements the MeterEnergy interface
 func (c *Keba) TotalEnergy() (float64, error) {
-	var kr keba.Report3
-	err := c.roundtrip("report 3", 3, &kr)
+	kr, err := c.report3()
+	if err != nil {
+		return 0, err
+	}
 
-	// mW to W
-	return float64(kr.ETotal) / 1e4, err
+	energy, err := kr.TotalEnergy()
+	return energy, c.implausible(err)
 }
 
 // ChargedEnergy implements the ChargeRater interface
 func (c *Keba) ChargedEnergy() (float64, error) {
-	var kr keba.Report3
-	err := c.roundtrip("report 3", 3, &kr)
+	kr, err := c.report3()
+	if err != nil {
+		return 0, err
+	}
 
-	// 0,1Wh to kWh
-	return float64(kr.EPres) / 1e4, err
+	energy, err := kr.SessionEnergy()
+	return energy, c.implausible(err)
 }
 
 // Currents implements the MeterCurrents interface
 func (c *Keba) Currents() (float64, float64, float64, error) {
-	var kr keba.Report3
-	err := c.roundtrip("report 3", 3, &kr)
+	kr, err := c.report3()
+	if err != nil {
+		return 0, 0, 0, err
+	}
 
-	// 1mA to A
-	return float64(kr.I1) / 1e3, float64(kr.I2) / 1e3, float64(kr.I3) / 1e3, err
+	i1, i2, i3, err := kr.Currents()
+	return i1, i2, i3, c.implausible(err)
 }
 
 // Diagnosis implements the Diagnosis interface
 func (c *Keba) Diagnosis() {
-	var kr keba.Report100
-	if err := c.roundtrip("report 100", 100, &kr); err == nil {
+	if kr, err := c.report1(); err == nil {
+		fmt.Printf("%+v\n", kr)
+		fmt.Printf("model: %s (%s) firmware: %s phase switching: %v\n", kr.Model(), c.model.Name, kr.FirmwareVersion(), c.phaseSwitching())
+	}
+
+	if kr, err := c.report100(); err == nil {
 		fmt.Printf("%+v\n", kr)
 	}
 }
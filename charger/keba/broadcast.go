@@ -0,0 +1,77 @@
+package keba
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+)
+
+// IsBroadcast returns true if the message is an unsolicited broadcast. Broadcasts contain
+// single report 2 fields like {"State": 3} and no report id.
+func IsBroadcast(msg UDPMsg) bool {
+	return msg.Report != nil && msg.Report.ID == 0
+}
+
+// Broadcast maintains the status report from unsolicited broadcasts. The polled report
+// is only replaced by the broadcast report once a broadcast has been received since
+// broadcasts may not be enabled on the charger.
+type Broadcast struct {
+	mu       sync.Mutex
+	clock    clock.Clock
+	maxAge   time.Duration
+	report   *Report2
+	updated  time.Time
+	received bool
+}
+
+// NewBroadcast creates broadcast state. The report is polled again after maxAge.
+func NewBroadcast(maxAge time.Duration) *Broadcast {
+	return &Broadcast{
+		clock:  clock.New(),
+		maxAge: maxAge,
+	}
+}
+
+// Update sets the polled status report
+func (b *Broadcast) Update(kr Report2) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.report = &kr
+	b.updated = b.clock.Now()
+}
+
+// Apply updates the status report from a broadcast message
+func (b *Broadcast) Apply(msg []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.report == nil {
+		b.received = true
+		return nil
+	}
+
+	kr := *b.report
+	if err := json.Unmarshal(msg, &kr); err != nil {
+		return err
+	}
+
+	b.report = &kr
+	b.received = true
+
+	return nil
+}
+
+// Report returns the status report if broadcasts have been received and the report has not expired
+func (b *Broadcast) Report() (Report2, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.received || b.report == nil || b.clock.Since(b.updated) >= b.maxAge {
+		return Report2{}, false
+	}
+
+	return *b.report, true
+}
@@ -0,0 +1,70 @@
+package keba
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+)
+
+func TestBroadcast(t *testing.T) {
+	clck := clock.NewMock()
+	b := NewBroadcast(time.Minute)
+	b.clock = clck
+
+	if _, ok := b.Report(); ok {
+		t.Error("unexpected report before poll")
+	}
+
+	b.Update(Report2{State: 2, Plug: 7, EnableSys: 1})
+
+	// broadcasts not enabled on the charger
+	if _, ok := b.Report(); ok {
+		t.Error("unexpected report before broadcast")
+	}
+
+	// datagram as received by the listener
+	var msg UDPMsg
+	msg.Message = []byte(`{"State": 3}`)
+	if err := json.Unmarshal(msg.Message, &msg.Report); err != nil {
+		t.Fatal(err)
+	}
+
+	if !IsBroadcast(msg) {
+		t.Fatal("expected broadcast")
+	}
+
+	if err := b.Apply(msg.Message); err != nil {
+		t.Fatal(err)
+	}
+
+	kr, ok := b.Report()
+	if !ok {
+		t.Fatal("missing report")
+	}
+
+	if kr.State != 3 || kr.Plug != 7 || kr.EnableSys != 1 {
+		t.Errorf("unexpected report: %+v", kr)
+	}
+
+	// poll again after max age
+	clck.Add(time.Minute)
+	if _, ok := b.Report(); ok {
+		t.Error("unexpected expired report")
+	}
+
+	if err := b.Apply([]byte(`{"State": `)); err == nil {
+		t.Error("expected invalid broadcast error")
+	}
+}
+
+func TestIsBroadcast(t *testing.T) {
+	if IsBroadcast(UDPMsg{Message: []byte(OK)}) {
+		t.Error("command response is no broadcast")
+	}
+
+	if IsBroadcast(UDPMsg{Report: &Report{ID: 2}}) {
+		t.Error("report 2 is no broadcast")
+	}
+}
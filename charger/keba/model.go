@@ -0,0 +1,57 @@
+package keba
+
+import (
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+// Model describes the capabilities of a KEBA charger model
+type Model struct {
+	Name           string
+	Meter          bool // report 3 energy and power readings
+	Sessions       bool // report 100 session history
+	Display        bool // display text command
+	PhaseSwitching bool // phase switching
+}
+
+// Models are the known KEBA model profiles
+var Models = map[string]Model{
+	"p20":  {Name: "p20", Meter: true},
+	"p30c": {Name: "p30c", Meter: true, Sessions: true, Display: true},
+	"p30x": {Name: "p30x", Meter: true, Sessions: true, Display: true, PhaseSwitching: true},
+	"bmw":  {Name: "bmw", Meter: true, Sessions: true},
+}
+
+// DefaultModel is used if the model cannot be detected
+var DefaultModel = Models["p30c"]
+
+// phaseSwitchingFirmware is the minimum P30 firmware supporting phase switching
+var phaseSwitchingFirmware = version.Must(version.NewVersion("3.10"))
+
+// ModelByName returns the model profile by name
+func ModelByName(name string) (Model, bool) {
+	m, ok := Models[strings.ToLower(name)]
+	return m, ok
+}
+
+// DetectModel derives the model profile from report 1 device information
+func DetectModel(r Report1) Model {
+	if strings.Contains(strings.ToUpper(r.Product), "BMW") {
+		return Models["bmw"]
+	}
+
+	switch r.Model() {
+	case "P20":
+		return Models["p20"]
+	case "P30":
+		// x-series with communication module supports phase switching with recent firmware
+		fw, err := version.NewVersion(r.FirmwareVersion())
+		if r.COMModule == 1 && err == nil && fw.Compare(phaseSwitchingFirmware) >= 0 {
+			return Models["p30x"]
+		}
+		return Models["p30c"]
+	}
+
+	return DefaultModel
+}
@@ -0,0 +1,50 @@
+package keba
+
+import (
+	"testing"
+)
+
+func TestDetectModel(t *testing.T) {
+	tc := []struct {
+		report Report1
+		model  string
+	}{
+		{Report1{Product: "KC-P20-ES240030-000-ST", Firmware: "P20 v 2.5a3 (140604-143740)"}, "p20"},
+		{Report1{Product: "KC-P30-ES240022-E0R", Firmware: "P30 v 3.07.23 (160428-041442)", COMModule: 1}, "p30c"},
+		{Report1{Product: "KC-P30-EC2404B2-M0R", Firmware: "P30 v 3.10.16 (200121-093019)", COMModule: 1}, "p30x"},
+		{Report1{Product: "BMW-10-EC2405B2-E1R", Firmware: "P30 v 3.10.16 (200121-093019)", COMModule: 1}, "bmw"},
+		{Report1{Product: "unknown"}, DefaultModel.Name},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		if m := DetectModel(tc.report); m.Name != tc.model {
+			t.Errorf("wanted %s, got %s", tc.model, m.Name)
+		}
+	}
+}
+
+func TestModelCapabilities(t *testing.T) {
+	p20, ok := ModelByName("P20")
+	if !ok {
+		t.Fatal("missing p20 model")
+	}
+
+	p30x, ok := ModelByName("p30x")
+	if !ok {
+		t.Fatal("missing p30x model")
+	}
+
+	if p20.Sessions || p20.Display || p20.PhaseSwitching {
+		t.Errorf("unexpected p20 capabilities: %+v", p20)
+	}
+
+	if !p30x.Sessions || !p30x.Display || !p30x.PhaseSwitching {
+		t.Errorf("missing p30x capabilities: %+v", p30x)
+	}
+
+	if _, ok := ModelByName("foo"); ok {
+		t.Error("unexpected model")
+	}
+}
@@ -1,5 +1,10 @@
 package keba
 
+import (
+	"regexp"
+	"strings"
+)
+
 // Report contains report id and device serial
 type Report struct {
 	ID     int    `json:"ID,string"`
@@ -77,3 +82,24 @@ type Report100 struct {
 	RFIDClass string `json:"RFID class"`
 	Sec       int64  `json:"Sec"`
 }
+
+// Model returns the charger model from the product code, e.g. P30
+func (r Report1) Model() string {
+	segments := strings.Split(r.Product, "-")
+	if len(segments) < 2 {
+		return ""
+	}
+
+	return segments[1]
+}
+
+var firmwareRE = regexp.MustCompile(`v\s*(\d+\.\d+(\.\d+)?)`)
+
+// FirmwareVersion returns the firmware version, e.g. 3.07.23
+func (r Report1) FirmwareVersion() string {
+	if match := firmwareRE.FindStringSubmatch(r.Firmware); len(match) > 1 {
+		return match[1]
+	}
+
+	return ""
+}
@@ -0,0 +1,103 @@
+package keba
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestReport1(t *testing.T) {
+	// sample from KeContact P20/P30 UDP programmers guide
+	msg := `{
+		"ID": "1",
+		"Product": "KC-P30-ES240022-E0R",
+		"Serial": "16614242",
+		"Firmware": "P30 v 3.07.23 (160428-041442)",
+		"COM-module": 1,
+		"Backend": 0,
+		"timeQ": 0,
+		"DIP-Sw1": "0x22",
+		"DIP-Sw2": "0x00",
+		"Sec": 287742
+	}`
+
+	var r Report1
+	if err := json.Unmarshal([]byte(msg), &r); err != nil {
+		t.Fatal(err)
+	}
+
+	if r.ID != 1 {
+		t.Errorf("ID: %d", r.ID)
+	}
+	if r.Serial != "16614242" {
+		t.Errorf("Serial: %s", r.Serial)
+	}
+	if r.COMModule != 1 {
+		t.Errorf("COMModule: %d", r.COMModule)
+	}
+	if model := r.Model(); model != "P30" {
+		t.Errorf("Model: %s", model)
+	}
+	if fw := r.FirmwareVersion(); fw != "3.07.23" {
+		t.Errorf("FirmwareVersion: %s", fw)
+	}
+}
+
+func TestReport3(t *testing.T) {
+	// 3p charging at 16A
+	msg := `{
+		"ID": "3",
+		"U1": 230, "U2": 229, "U3": 231,
+		"I1": 16012, "I2": 15998, "I3": 16000,
+		"P": 11028000,
+		"PF": 999,
+		"E pres": 123456,
+		"E total": 77980745,
+		"Serial": "16614242",
+		"Sec": 287742
+	}`
+
+	var r Report3
+	if err := json.Unmarshal([]byte(msg), &r); err != nil {
+		t.Fatal(err)
+	}
+
+	if energy, err := r.SessionEnergy(); err != nil || energy != 12.3456 {
+		t.Errorf("session energy: %v %v", energy, err)
+	}
+	if energy, err := r.TotalEnergy(); err != nil || energy != 7798.0745 {
+		t.Errorf("total energy: %v %v", energy, err)
+	}
+	if power, err := r.Power(); err != nil || power != 11028 {
+		t.Errorf("power: %v %v", power, err)
+	}
+	if i1, i2, i3, err := r.Currents(); err != nil || i1 != 16.012 || i2 != 15.998 || i3 != 16 {
+		t.Errorf("currents: %v %v %v %v", i1, i2, i3, err)
+	}
+}
+
+func TestReport3Implausible(t *testing.T) {
+	tc := []struct {
+		name   string
+		report Report3
+		read   func(Report3) error
+	}{
+		{"session energy", Report3{EPres: 500e4 + 1}, func(r Report3) error { _, err := r.SessionEnergy(); return err }},
+		{"negative session energy", Report3{EPres: -1}, func(r Report3) error { _, err := r.SessionEnergy(); return err }},
+		{"total energy", Report3{ETotal: 1e10 + 1}, func(r Report3) error { _, err := r.TotalEnergy(); return err }},
+		{"power", Report3{P: 11028000 * 10}, func(r Report3) error { _, err := r.Power(); return err }},
+		{"current", Report3{I2: 160120}, func(r Report3) error { _, _, _, err := r.Currents(); return err }},
+	}
+
+	for _, tc := range tc {
+		var ie ImplausibleError
+		if err := tc.read(tc.report); !errors.As(err, &ie) {
+			t.Errorf("%s: expected implausible error, got %v", tc.name, err)
+		}
+	}
+
+	// upper bounds are plausible
+	if energy, err := (Report3{EPres: 500e4}).SessionEnergy(); err != nil || energy != MaxSessionEnergy {
+		t.Errorf("session energy: %v %v", energy, err)
+	}
+}
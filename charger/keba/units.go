@@ -0,0 +1,66 @@
+package keba
+
+import "fmt"
+
+// report 3 units
+const (
+	EnergyUnit  = 1e4 // 0.1Wh per kWh
+	PowerUnit   = 1e3 // mW per W
+	CurrentUnit = 1e3 // mA per A
+)
+
+// plausibility limits, values beyond indicate a garbled datagram or unit error
+const (
+	MaxSessionEnergy = 500  // kWh
+	MaxTotalEnergy   = 1e6  // kWh
+	MaxPower         = 50e3 // W
+	MaxCurrent       = 80   // A
+)
+
+// ImplausibleError is returned if a reported value exceeds its plausibility limit
+type ImplausibleError struct {
+	Name  string
+	Value float64
+	Max   float64
+}
+
+func (e ImplausibleError) Error() string {
+	return fmt.Sprintf("implausible %s: %.4g (max %.4g)", e.Name, e.Value, e.Max)
+}
+
+// convert scales the reported value to unit and checks it against the plausibility limit
+func convert(name string, value int64, unit, max float64) (float64, error) {
+	res := float64(value) / unit
+	if res < 0 || res > max {
+		return 0, ImplausibleError{Name: name, Value: res, Max: max}
+	}
+	return res, nil
+}
+
+// SessionEnergy returns the session energy in kWh
+func (r Report3) SessionEnergy() (float64, error) {
+	return convert("session energy", r.EPres, EnergyUnit, MaxSessionEnergy)
+}
+
+// TotalEnergy returns the total energy in kWh
+func (r Report3) TotalEnergy() (float64, error) {
+	return convert("total energy", r.ETotal, EnergyUnit, MaxTotalEnergy)
+}
+
+// Power returns the power in W
+func (r Report3) Power() (float64, error) {
+	return convert("power", r.P, PowerUnit, MaxPower)
+}
+
+// Currents returns the phase currents in A
+func (r Report3) Currents() (float64, float64, float64, error) {
+	var res [3]float64
+	for i, v := range []int64{r.I1, r.I2, r.I3} {
+		var err error
+		if res[i], err = convert(fmt.Sprintf("L%d current", i+1), v, CurrentUnit, MaxCurrent); err != nil {
+			return 0, 0, 0, err
+		}
+	}
+
+	return res[0], res[1], res[2], nil
+}
@@ -2,13 +2,16 @@ package charger
 
 import (
 	"testing"
+	"time"
 
 	"github.com/andig/evcc/api"
+	"github.com/andig/evcc/charger/keba"
+	"github.com/andig/evcc/util"
 )
 
 func TestKeba(t *testing.T) {
 	var wb api.Charger
-	wb, err := NewKeba("foo", RFID{}, 0)
+	wb, err := NewKeba("foo", "", RFID{}, 0, false)
 	if err != nil {
 		t.Error(err)
 	}
@@ -25,3 +28,176 @@ func TestKeba(t *testing.T) {
 		t.Error("missing ChargeRater interface")
 	}
 }
+
+func TestKebaModelCapabilities(t *testing.T) {
+	p20 := &Keba{model: keba.Models["p20"]}
+	if _, err := p20.report100(); err != api.ErrNotSupported {
+		t.Errorf("p20 sessions: expected %v, got %v", api.ErrNotSupported, err)
+	}
+	if p20.phaseSwitching() {
+		t.Error("p20 phase switching")
+	}
+
+	p30x := &Keba{model: keba.Models["p30x"]}
+	if !p30x.phaseSwitching() {
+		t.Error("p30x missing phase switching")
+	}
+
+	meterless := &Keba{model: keba.Model{Name: "meterless"}}
+	if _, err := meterless.CurrentPower(); err != api.ErrNotSupported {
+		t.Errorf("meter: expected %v, got %v", api.ErrNotSupported, err)
+	}
+
+	if _, err := NewKeba("foo", "invalid", RFID{}, 0, false); err == nil {
+		t.Error("expected invalid model error")
+	}
+}
+
+func TestKebaBroadcast(t *testing.T) {
+	c := &Keba{
+		log:       util.NewLogger("foo"),
+		recv:      make(chan keba.UDPMsg),
+		broadcast: keba.NewBroadcast(time.Minute),
+	}
+
+	// polled status
+	c.broadcast.Update(keba.Report2{ID: 2, State: 2, Plug: 7})
+
+	// simulated charging state broadcast
+	in := make(chan keba.UDPMsg, 1)
+	in <- keba.UDPMsg{Report: &keba.Report{}, Message: []byte(`{"State": 3}`)}
+	close(in)
+
+	c.dispatch(in)
+
+	if status, err := c.Status(); err != nil || status != api.StatusC {
+		t.Errorf("expected %s, got %s (%v)", api.StatusC, status, err)
+	}
+}
+
+func TestKebaWithoutToken(t *testing.T) {
+	wb, err := NewKebaFromConfig(map[string]interface{}{"uri": "127.0.0.1", "timeout": "10ms"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// all features available without token
+	for _, capability := range []string{"Charger", "Meter", "MeterEnergy", "MeterCurrent", "ChargeRater", "Diagnosis"} {
+		var found bool
+		for _, c := range api.Capabilities(wb) {
+			found = found || c == capability
+		}
+
+		if !found {
+			t.Errorf("missing %s interface", capability)
+		}
+	}
+}
+
+func TestKebaAddr(t *testing.T) {
+	tc := []struct {
+		conn, addr string
+	}{
+		{"127.0.0.1", "127.0.0.1:7090"},
+		{"127.0.0.1:7091", "127.0.0.1:7091"},
+		{"::1", "[::1]:7090"},
+		{"[::1]", "[::1]:7090"},
+		{"[0:0::1]:7091", "[::1]:7091"},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		addr, err := kebaAddr(tc.conn)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if addr != tc.addr {
+			t.Errorf("expected %s, got %s", tc.addr, addr)
+		}
+	}
+}
+
+func TestKebaIPv6(t *testing.T) {
+	wb, err := NewKeba("[::1]", "p30c", RFID{}, time.Millisecond, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if conn := wb.(*Keba).conn; conn != "[::1]:7090" {
+		t.Errorf("expected [::1]:7090, got %s", conn)
+	}
+
+	// send to ipv6 literal
+	if err := wb.(*Keba).send("report 1"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestKebaCurrentLimit(t *testing.T) {
+	c := &Keba{
+		log:       util.NewLogger("foo"),
+		broadcast: keba.NewBroadcast(time.Minute),
+	}
+
+	// 16A cable on 32A installation
+	c.broadcast.Update(keba.Report2{ID: 2, Plug: 7, CurrHW: 16000})
+	if err := c.broadcast.Apply([]byte(`{"Plug": 7}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	if limit, err := c.CurrentLimit(); err != nil || limit != 16 {
+		t.Errorf("expected 16A, got %dA (%v)", limit, err)
+	}
+}
+
+func TestKebaGetMaxCurrent(t *testing.T) {
+	c := &Keba{
+		log:       util.NewLogger("foo"),
+		conn:      "127.0.0.1:7090",
+		timeout:   time.Second,
+		recv:      make(chan keba.UDPMsg),
+		broadcast: keba.NewBroadcast(time.Minute),
+	}
+
+	// cached report without current setpoint
+	c.broadcast.Update(keba.Report2{ID: 2, Plug: 7, CurrHW: 32000})
+	if err := c.broadcast.Apply([]byte(`{"Plug": 7}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	// polled report
+	go func() {
+		c.recv <- keba.UDPMsg{
+			Report:  &keba.Report{ID: 2},
+			Message: []byte(`{"ID": "2", "Plug": 7, "Curr HW": 32000, "Curr user": 10000}`),
+		}
+	}()
+
+	if current, err := c.GetMaxCurrent(); err != nil || current != 10 {
+		t.Errorf("expected 10A, got %dA (%v)", current, err)
+	}
+}
+
+func TestKebaDisplay(t *testing.T) {
+	tc := []struct {
+		text, msg string
+	}{
+		{"PV charging 3.2kW", "display 0 0 0 0 PV$charging$3.2kW"},
+		{"Full", "display 0 0 0 0 Full"},
+		{" Waiting for PV ", "display 0 0 0 0 Waiting$for$PV"},
+		{"Charging with a very long text", "display 0 0 0 0 Charging$with$a$very$lo"},
+	}
+
+	for _, tc := range tc {
+		if msg := kebaDisplay(tc.text); msg != tc.msg {
+			t.Errorf("expected %q, got %q", tc.msg, msg)
+		}
+	}
+
+	p20 := &Keba{model: keba.Models["p20"]}
+	if err := p20.Display("Full"); err != api.ErrNotSupported {
+		t.Errorf("p20 display: expected %v, got %v", api.ErrNotSupported, err)
+	}
+}
@@ -0,0 +1,120 @@
+package charger
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/andig/evcc/api"
+)
+
+// modbusServer is a minimal Modbus TCP server answering register reads
+// with the given value and recording the requested unit ids
+func modbusServer(t *testing.T, value uint16) (string, chan byte) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	units := make(chan byte, 10)
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for {
+			// mbap header + function code + address + quantity
+			req := make([]byte, 12)
+			if _, err := io.ReadFull(conn, req); err != nil {
+				return
+			}
+
+			units <- req[6]
+
+			quantity := binary.BigEndian.Uint16(req[10:])
+			data := make([]byte, 2*quantity)
+			binary.BigEndian.PutUint16(data, value)
+
+			pdu := append([]byte{req[7], byte(len(data))}, data...)
+
+			res := make([]byte, 7, 7+len(pdu))
+			copy(res, req[:4])
+			binary.BigEndian.PutUint16(res[4:], uint16(1+len(pdu)))
+			res[6] = req[6]
+
+			if _, err := conn.Write(append(res, pdu...)); err != nil {
+				return
+			}
+		}
+	}()
+
+	return l.Addr().String(), units
+}
+
+func TestModbusUnitID(t *testing.T) {
+	tc := []struct {
+		name   string
+		value  uint16
+		id     uint8
+		new    func(uri string, id uint8) (api.Charger, error)
+		status api.ChargeStatus
+	}{
+		{"wallbe", 'C', 7, func(uri string, id uint8) (api.Charger, error) {
+			return NewWallbe(uri, id)
+		}, api.StatusC},
+		{"phoenix-emcp", 'B', 8, NewPhoenixEMCP, api.StatusB},
+		{"simpleevse", 2 << 8, 9, func(uri string, id uint8) (api.Charger, error) {
+			return NewSimpleEVSE(uri, "", id)
+		}, api.StatusB},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc.name)
+
+		uri, units := modbusServer(t, tc.value)
+
+		c, err := tc.new(uri, tc.id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		status, err := c.Status()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if status != tc.status {
+			t.Errorf("expected status %s, got %s", tc.status, status)
+		}
+
+		if id := <-units; id != tc.id {
+			t.Errorf("expected unit id %d, got %d", tc.id, id)
+		}
+	}
+}
+
+func TestModbusBatch(t *testing.T) {
+	uri, requests := modbusServer(t, 'C')
+
+	wb, err := NewWallbe(uri, wbSlaveID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := wb.Status(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := wb.ChargingTime(); err != nil {
+		t.Fatal(err)
+	}
+
+	// status and charge time are read in a single transaction
+	if len(requests) != 1 {
+		t.Errorf("expected single request, got %d", len(requests))
+	}
+}
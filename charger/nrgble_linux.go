@@ -125,6 +125,27 @@ func (nrg *NRGKickBLE) connect() (*device.Device1, error) {
 	return dev, nil
 }
 
+// connection returns the connected device. The device is re-discovered and connected
+// if it hasn't been connected yet or the connection was lost.
+func (nrg *NRGKickBLE) connection() (*device.Device1, error) {
+	if nrg.dev != nil {
+		if connected, err := nrg.dev.GetConnected(); err != nil || !connected {
+			nrg.log.DEBUG.Println("connection lost, reconnecting")
+			nrg.close()
+		}
+	}
+
+	if nrg.dev == nil {
+		dev, err := nrg.connect()
+		if err != nil {
+			return nil, err
+		}
+		nrg.dev = dev
+	}
+
+	return nrg.dev, nil
+}
+
 func (nrg *NRGKickBLE) close() {
 	if nrg.dev != nil {
 		nrg.dev.Close()
@@ -145,15 +166,12 @@ func (nrg *NRGKickBLE) read(service string, res interface{}) error {
 	nrg.waitTimer()
 	defer nrg.setTimer()
 
-	if nrg.dev == nil {
-		dev, err := nrg.connect()
-		if err != nil {
-			return err
-		}
-		nrg.dev = dev
+	dev, err := nrg.connection()
+	if err != nil {
+		return err
 	}
 
-	char, err := nrg.dev.GetCharByUUID(service)
+	char, err := dev.GetCharByUUID(service)
 	if err != nil {
 		nrg.close()
 		return err
@@ -179,15 +197,12 @@ func (nrg *NRGKickBLE) write(service string, val interface{}) error {
 	nrg.waitTimer()
 	defer nrg.setTimer()
 
-	if nrg.dev == nil {
-		dev, err := nrg.connect()
-		if err != nil {
-			return err
-		}
-		nrg.dev = dev
+	dev, err := nrg.connection()
+	if err != nil {
+		return err
 	}
 
-	char, err := nrg.dev.GetCharByUUID(service)
+	char, err := dev.GetCharByUUID(service)
 	if err != nil {
 		nrg.close()
 		return err
@@ -223,16 +238,7 @@ func (nrg *NRGKickBLE) Status() (api.ChargeStatus, error) {
 
 	nrg.log.TRACE.Printf("read power: %+v", res)
 
-	switch res.CPSignal {
-	case 3:
-		return api.StatusB, nil
-	case 2:
-		return api.StatusC, nil
-	case 4:
-		return api.StatusA, nil
-	}
-
-	return api.StatusA, fmt.Errorf("unexpected cp signal: %d", res.CPSignal)
+	return nrgbleStatus(res)
 }
 
 // Enabled implements the Charger.Enabled interface
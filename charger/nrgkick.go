@@ -0,0 +1,48 @@
+package charger
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/andig/evcc/api"
+	"github.com/andig/evcc/charger/nrgble"
+	"github.com/andig/evcc/util"
+)
+
+// NewNRGKickFromConfig creates a NRGKick charger using the Connect module if an uri is configured
+// or the Bluetooth connection if a mac address is configured
+func NewNRGKickFromConfig(other map[string]interface{}) (api.Charger, error) {
+	cc := struct {
+		URI, MacAddress string
+		Other           map[string]interface{} `mapstructure:",remain"`
+	}{}
+
+	if err := util.DecodeOther(other, &cc); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case cc.URI != "" && cc.MacAddress != "":
+		return nil, errors.New("nrgkick: either uri or macaddress must be configured, not both")
+	case cc.URI != "":
+		return NewNRGKickConnectFromConfig(other)
+	case cc.MacAddress != "":
+		return NewNRGKickBLEFromConfig(other)
+	default:
+		return nil, errors.New("nrgkick: missing uri (connect) or macaddress (bluetooth)")
+	}
+}
+
+// nrgbleStatus decodes the charge status from the control pilot signal of the power characteristic
+func nrgbleStatus(res nrgble.Power) (api.ChargeStatus, error) {
+	switch res.CPSignal {
+	case 3:
+		return api.StatusB, nil
+	case 2:
+		return api.StatusC, nil
+	case 4:
+		return api.StatusA, nil
+	}
+
+	return api.StatusA, fmt.Errorf("unexpected cp signal: %d", res.CPSignal)
+}
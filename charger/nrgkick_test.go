@@ -0,0 +1,147 @@
+package charger
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/andig/evcc/api"
+	"github.com/andig/evcc/charger/nrgble"
+	"github.com/lunixbochs/struc"
+)
+
+func TestNRGKickConfig(t *testing.T) {
+	tc := []struct {
+		config map[string]interface{}
+		err    bool
+	}{
+		{map[string]interface{}{}, true},
+		{map[string]interface{}{"uri": "http://192.168.1.4", "macaddress": "00:99:22:33:44:55"}, true},
+		{map[string]interface{}{"uri": "http://192.168.1.4", "foo": "bar"}, true},
+	}
+
+	for _, tc := range tc {
+		if _, err := NewNRGKickFromConfig(tc.config); (err != nil) != tc.err {
+			t.Errorf("%v: unexpected error %v", tc.config, err)
+		}
+	}
+}
+
+func TestNRGKickBLEPower(t *testing.T) {
+	b := []byte{
+		0x01, 0x2c, // total power 300 -> 3kW
+		0x00, 0x64, // L1
+		0x00, 0x64, // L2
+		0x00, 0x64, // L3
+		0x01, 0x90, // peak power
+		0x13, 0x88, // frequency
+		0xff, 0xf6, // temperature -10
+		0x00, 0x00, // remaining distance
+		0x00, 0x00, // costs
+		0x02, // cp signal
+	}
+
+	var res nrgble.Power
+	if err := struc.Unpack(bytes.NewReader(b), &res); err != nil {
+		t.Fatal(err)
+	}
+
+	if res.TotalPower != 300 || res.L1 != 100 || res.Frequency != 5000 || res.Temperature != -10 {
+		t.Errorf("unexpected power: %+v", res)
+	}
+
+	if status, err := nrgbleStatus(res); err != nil || status != api.StatusC {
+		t.Errorf("unexpected status: %s (%v)", status, err)
+	}
+}
+
+func TestNRGKickBLEStatus(t *testing.T) {
+	tc := []struct {
+		cp     int
+		status api.ChargeStatus
+		err    bool
+	}{
+		{4, api.StatusA, false},
+		{3, api.StatusB, false},
+		{2, api.StatusC, false},
+		{1, api.StatusA, true},
+	}
+
+	for _, tc := range tc {
+		status, err := nrgbleStatus(nrgble.Power{CPSignal: tc.cp})
+		if (err != nil) != tc.err || status != tc.status {
+			t.Errorf("cp %d: expected %s, got %s (%v)", tc.cp, tc.status, status, err)
+		}
+	}
+}
+
+func TestNRGKickBLEEnergy(t *testing.T) {
+	b := []byte{
+		0x00, 0x01, 0xe2, 0x40, // total energy 123456 -> 123.456kWh
+		0x00, 0x00, 0x27, 0x10, // last charge 10000
+		0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00,
+		0x4e, 0x1d, // charging energy limit 19997
+		0x00, // pad
+	}
+
+	var res nrgble.Energy
+	if err := struc.Unpack(bytes.NewReader(b), &res); err != nil {
+		t.Fatal(err)
+	}
+
+	if res.TotalEnergy != 123456 || res.EnergyLastCharge != 10000 || res.ChargingEnergyLimit != 19997 {
+		t.Errorf("unexpected energy: %+v", res)
+	}
+}
+
+func TestNRGKickBLEVoltageCurrent(t *testing.T) {
+	b := []byte{
+		0x08, 0xfc, // 230.0V
+		0x08, 0xfc,
+		0x08, 0xfc,
+		0x06, 0x40, // 16.00A
+		0x06, 0x40,
+		0x00, 0x00,
+		0x00, 0x00, // pad
+	}
+
+	var res nrgble.VoltageCurrent
+	if err := struc.Unpack(bytes.NewReader(b), &res); err != nil {
+		t.Fatal(err)
+	}
+
+	if res.VoltageL1 != 2300 || res.CurrentL1 != 1600 || res.CurrentL2 != 1600 || res.CurrentL3 != 0 {
+		t.Errorf("unexpected voltage/current: %+v", res)
+	}
+}
+
+func TestNRGKickBLESettings(t *testing.T) {
+	settings := nrgble.Settings{
+		PIN:                 1234,
+		Current:             16,
+		ChargingEnergyLimit: 19997,
+		PauseCharging:       true,
+	}
+
+	var out bytes.Buffer
+	if err := struc.Pack(&out, &settings); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []byte{
+		0x04, 0xd2, // pin
+		0x10,       // current
+		0x4e, 0x1d, // charging energy limit
+		0x00, 0x00, // kwh per 100
+		0x00,       // amount per kwh
+		0x00, 0x00, // pad
+		0x00,                         // efficiency
+		0x01,                         // pause charging
+		0x00,                         // ble transmission power
+		0x00, 0x00, 0x00, 0x00, 0x00, // pad
+	}
+
+	if !bytes.Equal(out.Bytes(), expected) {
+		t.Errorf("expected % x, got % x", expected, out.Bytes())
+	}
+}
@@ -7,7 +7,9 @@ import (
 
 	"github.com/andig/evcc/api"
 	"github.com/andig/evcc/util"
-	"github.com/grid-x/modbus"
+	"github.com/andig/evcc/util/modbus"
+	gridx "github.com/grid-x/modbus"
+	"github.com/volkszaehler/mbmd/meters"
 )
 
 const (
@@ -17,12 +19,15 @@ const (
 	phEMCPRegEnable     = 400 // Coil
 )
 
+// phEMCPStatusBlock covers status and charge time input registers
+var phEMCPStatusBlock = modbus.Block{Input: true, Start: phEMCPRegStatus, Quantity: phEMCPRegChargeTime + 2 - phEMCPRegStatus}
+
 // PhoenixEMCP is an api.ChargeController implementation for Phoenix EM-CP-PP-ETH wallboxes.
 // It uses Modbus TCP to communicate with the wallbox at modbus client id 180.
 type PhoenixEMCP struct {
 	log     *util.Logger
-	client  modbus.Client
-	handler *modbus.TCPClientHandler
+	client  gridx.Client
+	handler meters.Connection // alias for close method
 }
 
 // NewPhoenixEMCPFromConfig creates a Phoenix charger from generic config
@@ -49,17 +54,18 @@ func NewPhoenixEMCPFromConfig(other map[string]interface{}) (api.Charger, error)
 func NewPhoenixEMCP(uri string, id uint8) (api.Charger, error) {
 	log := util.NewLogger("emcp")
 
-	handler := modbus.NewTCPClientHandler(uri)
-	client := modbus.NewClient(handler)
+	conn, err := modbus.NewConnection(uri, "", "", 0, false)
+	if err != nil {
+		return nil, err
+	}
 
-	handler.SlaveID = id
-	handler.Timeout = timeout
-	handler.ProtocolRecoveryTimeout = protocolTimeout
+	conn.Slave(id)
+	conn.Timeout(timeout)
 
 	wb := &PhoenixEMCP{
 		log:     log,
-		client:  client,
-		handler: handler,
+		client:  modbus.NewBatchClient(conn.ModbusClient(), batchMaxAge, phEMCPStatusBlock),
+		handler: conn,
 	}
 
 	return wb, nil
@@ -74,7 +80,7 @@ func (wb *PhoenixEMCP) Status() (api.ChargeStatus, error) {
 		return api.StatusNone, err
 	}
 
-	return api.ChargeStatus(string(b[1])), nil
+	return phoenixStatus(b)
 }
 
 // Enabled implements the Charger.Enabled interface
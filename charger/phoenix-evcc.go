@@ -63,7 +63,7 @@ func (wb *PhoenixEVCC) Status() (api.ChargeStatus, error) {
 		return api.StatusNone, err
 	}
 
-	return api.ChargeStatus(string(b[1])), nil
+	return phoenixStatus(b)
 }
 
 // Enabled implements the Charger.Enabled interface
@@ -0,0 +1,72 @@
+package charger
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/andig/evcc/api"
+	"github.com/andig/evcc/util"
+	"github.com/andig/evcc/util/modbus"
+)
+
+const (
+	phoenixEMCP = "em-cp" // EM-CP-PP-ETH
+	phoenixEVCC = "ev-cc" // EV-CC-AC1-M
+)
+
+// NewPhoenixFromConfig creates a Phoenix charger using the register map of the configured controller variant
+func NewPhoenixFromConfig(other map[string]interface{}) (api.Charger, error) {
+	cc := struct {
+		Variant           string
+		modbus.Connection `mapstructure:",squash"`
+	}{}
+
+	if err := util.DecodeOther(other, &cc); err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(cc.Variant) {
+	case phoenixEMCP, "emcp", "em-cp-pp-eth":
+		if cc.Device != "" {
+			return nil, errors.New("serial connection not supported by em-cp variant")
+		}
+		if _, _, err := net.SplitHostPort(cc.URI); err != nil {
+			return nil, fmt.Errorf("missing or invalid phoenix uri: %s", cc.URI)
+		}
+		if cc.ID == 0 {
+			cc.ID = 180
+		}
+
+		return NewPhoenixEMCP(cc.URI, cc.ID)
+
+	case phoenixEVCC, "evcc", "ev-cc-ac1-m":
+		if cc.ID == 0 {
+			cc.ID = 255
+		}
+
+		return NewPhoenixEVCC(cc.URI, cc.Device, cc.Comset, cc.Baudrate, cc.ID)
+
+	case "":
+		return nil, fmt.Errorf("missing phoenix variant: %s or %s", phoenixEMCP, phoenixEVCC)
+
+	default:
+		return nil, fmt.Errorf("invalid phoenix variant: %s (valid variants: %s, %s)", cc.Variant, phoenixEMCP, phoenixEVCC)
+	}
+}
+
+// phoenixStatus decodes the status register shared by all controller variants.
+// The low byte contains the IEC 61851 status as ASCII character.
+func phoenixStatus(b []byte) (api.ChargeStatus, error) {
+	if len(b) < 2 {
+		return api.StatusNone, fmt.Errorf("invalid status length: %d", len(b))
+	}
+
+	status := api.ChargeStatus(string(b[1]))
+	if status < api.StatusA || status > api.StatusF {
+		return api.StatusNone, fmt.Errorf("invalid status: %0 X", b)
+	}
+
+	return status, nil
+}
@@ -0,0 +1,101 @@
+package charger
+
+import (
+	"testing"
+
+	"github.com/andig/evcc/api"
+	"github.com/andig/evcc/util"
+	gridx "github.com/grid-x/modbus"
+	"github.com/volkszaehler/mbmd/meters"
+)
+
+// statusClient answers input register reads of the given status register
+type statusClient struct {
+	gridx.Client
+	meters.Connection
+	address uint16
+	value   []byte
+}
+
+func (c *statusClient) ReadInputRegisters(address, quantity uint16) ([]byte, error) {
+	if address != c.address {
+		return nil, api.ErrNotSupported
+	}
+	return c.value, nil
+}
+
+func (c *statusClient) Close() {}
+
+func TestPhoenixStatus(t *testing.T) {
+	tc := []struct {
+		value  []byte
+		status api.ChargeStatus
+		err    bool
+	}{
+		{[]byte{0, 'A'}, api.StatusA, false},
+		{[]byte{0, 'B'}, api.StatusB, false},
+		{[]byte{0, 'C'}, api.StatusC, false},
+		{[]byte{0, 'F'}, api.StatusF, false},
+		{[]byte{0, 0}, api.StatusNone, true},
+		{[]byte{0, 'G'}, api.StatusNone, true},
+		{[]byte{'C'}, api.StatusNone, true},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		// status register of each controller variant
+		for _, wb := range []api.Charger{
+			&PhoenixEMCP{
+				log:     util.NewLogger("foo"),
+				client:  &statusClient{address: phEMCPRegStatus, value: tc.value},
+				handler: &statusClient{},
+			},
+			&PhoenixEVCC{
+				log:     util.NewLogger("foo"),
+				client:  &statusClient{address: phEVCCRegStatus, value: tc.value},
+				handler: &statusClient{},
+			},
+		} {
+			status, err := wb.Status()
+			if status != tc.status || (err != nil) != tc.err {
+				t.Errorf("%T: expected %s (error %v), got %s (%v)", wb, tc.status, tc.err, status, err)
+			}
+		}
+	}
+}
+
+func TestPhoenixVariant(t *testing.T) {
+	tc := []struct {
+		config map[string]interface{}
+		typ    interface{}
+	}{
+		{map[string]interface{}{"variant": "em-cp", "uri": "127.0.0.1:502"}, &PhoenixEMCP{}},
+		{map[string]interface{}{"variant": "EV-CC", "uri": "127.0.0.1:502"}, &PhoenixEVCC{}},
+		{map[string]interface{}{"variant": "em-cp", "device": "/dev/ttyUSB0"}, nil},
+		{map[string]interface{}{"variant": "em-cp"}, nil},
+		{map[string]interface{}{"uri": "127.0.0.1:502"}, nil},
+		{map[string]interface{}{"variant": "foo", "uri": "127.0.0.1:502"}, nil},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		wb, err := NewPhoenixFromConfig(tc.config)
+
+		switch tc.typ.(type) {
+		case *PhoenixEMCP:
+			if _, ok := wb.(*PhoenixEMCP); !ok || err != nil {
+				t.Errorf("expected em-cp charger, got %T (%v)", wb, err)
+			}
+		case *PhoenixEVCC:
+			if _, ok := wb.(*PhoenixEVCC); !ok || err != nil {
+				t.Errorf("expected ev-cc charger, got %T (%v)", wb, err)
+			}
+		default:
+			if err == nil {
+				t.Error("expected error")
+			}
+		}
+	}
+}
@@ -6,14 +6,16 @@ import (
 
 	"github.com/andig/evcc/api"
 	"github.com/andig/evcc/util"
-	"github.com/grid-x/modbus"
+	"github.com/andig/evcc/util/modbus"
+	gridx "github.com/grid-x/modbus"
+	"github.com/volkszaehler/mbmd/meters"
 )
 
 // SimpleEVSE charger implementation
 type SimpleEVSE struct {
 	log     *util.Logger
-	client  modbus.Client
-	handler modbus.ClientHandler
+	client  gridx.Client
+	handler meters.Connection // alias for close method
 }
 
 const (
@@ -24,43 +26,39 @@ const (
 
 // NewSimpleEVSEFromConfig creates a SimpleEVSE charger from generic config
 func NewSimpleEVSEFromConfig(other map[string]interface{}) (api.Charger, error) {
-	cc := struct{ URI, Device string }{}
+	cc := struct {
+		URI, Device string
+		ID          uint8
+	}{
+		ID: 1, // default
+	}
+
 	if err := util.DecodeOther(other, &cc); err != nil {
 		return nil, err
 	}
 
-	return NewSimpleEVSE(cc.URI, cc.Device)
+	return NewSimpleEVSE(cc.URI, cc.Device, cc.ID)
 }
 
 // NewSimpleEVSE creates SimpleEVSE charger
-func NewSimpleEVSE(conn, device string) (api.Charger, error) {
+func NewSimpleEVSE(conn, device string, id uint8) (api.Charger, error) {
 	log := util.NewLogger("evse")
 
-	var handler modbus.ClientHandler
 	if conn != "" && device != "" {
 		return nil, errors.New("cannot define uri and device both")
 	}
-	if conn != "" {
-		handler = modbus.NewTCPClientHandler(conn)
-		handler.(*modbus.TCPClientHandler).Timeout = time.Second
-		handler.(*modbus.TCPClientHandler).SlaveID = 1
-	}
-	if device != "" {
-		handler = modbus.NewRTUClientHandler(device)
-		handler.(*modbus.RTUClientHandler).BaudRate = 9600
-		handler.(*modbus.RTUClientHandler).DataBits = 8
-		handler.(*modbus.RTUClientHandler).StopBits = 1
-		handler.(*modbus.RTUClientHandler).Parity = "N"
-		handler.(*modbus.RTUClientHandler).Timeout = time.Second
-		handler.(*modbus.RTUClientHandler).SlaveID = 1
-	}
-	if handler == nil {
+
+	handler, err := modbus.NewConnection(conn, device, "8N1", 9600, false)
+	if err != nil {
 		return nil, errors.New("must define either uri or device")
 	}
 
+	handler.Slave(id)
+	handler.Timeout(time.Second)
+
 	evse := &SimpleEVSE{
 		log:     log,
-		client:  modbus.NewClient(handler),
+		client:  handler.ModbusClient(),
 		handler: handler,
 	}
 
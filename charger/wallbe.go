@@ -6,7 +6,9 @@ import (
 
 	"github.com/andig/evcc/api"
 	"github.com/andig/evcc/util"
-	"github.com/grid-x/modbus"
+	"github.com/andig/evcc/util/modbus"
+	gridx "github.com/grid-x/modbus"
+	"github.com/volkszaehler/mbmd/meters"
 )
 
 const (
@@ -18,18 +20,23 @@ const (
 	wbRegEnable        = 400 // Coil
 	wbRegMaxCurrent    = 528 // Holding
 
-	timeout         = 1 * time.Second
-	protocolTimeout = 2 * time.Second
+	timeout = 1 * time.Second
+
+	// batchMaxAge is the max age of batched register reads, status and charge time are read within one cycle
+	batchMaxAge = time.Second
 )
 
+// wbStatusBlock covers status and charge time input registers
+var wbStatusBlock = modbus.Block{Input: true, Start: wbRegStatus, Quantity: wbRegChargeTime + 2 - wbRegStatus}
+
 // Wallbe is an api.ChargeController implementation for Wallbe wallboxes.
 // It supports both wallbe controllers (post 2019 models) and older ones using the
 // Phoenix EV-CC-AC1-M3-CBC-RCM-ETH controller.
-// It uses Modbus TCP to communicate with the wallbox at modbus client id 255.
+// It uses Modbus TCP to communicate with the wallbox at modbus client id 255 by default.
 type Wallbe struct {
 	log     *util.Logger
-	client  modbus.Client
-	handler *modbus.TCPClientHandler
+	client  gridx.Client
+	handler meters.Connection // alias for close method
 	factor  int64
 }
 
@@ -37,16 +44,21 @@ type Wallbe struct {
 func NewWallbeFromConfig(other map[string]interface{}) (*Wallbe, error) {
 	cc := struct {
 		URI    string
+		ID     uint8
 		Legacy bool
 	}{
 		URI: "192.168.0.8:502",
+		ID:  wbSlaveID,
 	}
 
 	if err := util.DecodeOther(other, &cc); err != nil {
 		return nil, err
 	}
 
-	wb := NewWallbe(cc.URI)
+	wb, err := NewWallbe(cc.URI, cc.ID)
+	if err != nil {
+		return nil, err
+	}
 
 	if cc.Legacy {
 		wb.factor = 1
@@ -56,22 +68,23 @@ func NewWallbeFromConfig(other map[string]interface{}) (*Wallbe, error) {
 }
 
 // NewWallbe creates a Wallbe charger
-func NewWallbe(conn string) *Wallbe {
-	handler := modbus.NewTCPClientHandler(conn)
-	client := modbus.NewClient(handler)
+func NewWallbe(uri string, id uint8) (*Wallbe, error) {
+	conn, err := modbus.NewConnection(uri, "", "", 0, false)
+	if err != nil {
+		return nil, err
+	}
 
-	handler.SlaveID = wbSlaveID
-	handler.Timeout = timeout
-	handler.ProtocolRecoveryTimeout = protocolTimeout
+	conn.Slave(id)
+	conn.Timeout(timeout)
 
 	wb := &Wallbe{
 		log:     util.NewLogger("wallbe"),
-		client:  client,
-		handler: handler,
+		client:  modbus.NewBatchClient(conn.ModbusClient(), batchMaxAge, wbStatusBlock),
+		handler: conn,
 		factor:  10,
 	}
 
-	return wb
+	return wb, nil
 }
 
 // Status implements the Charger.Status interface
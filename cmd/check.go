@@ -0,0 +1,309 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Configuration tools",
+}
+
+// configCheckCmd represents the config check command
+var configCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Validate configuration file without starting evcc",
+	Run:   runConfigCheck,
+}
+
+// configSchemaCmd represents the config schema command
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Dump known device types and their configuration keys as JSON",
+	Run:   runConfigSchema,
+}
+
+func init() {
+	configCmd.AddCommand(configCheckCmd)
+	configCmd.AddCommand(configSchemaCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+// topLevelKeys returns the keys accepted at the root of the configuration file
+func topLevelKeys() map[string]bool {
+	res := make(map[string]bool)
+
+	typ := reflect.TypeOf(config{})
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+
+		key := strings.Split(f.Tag.Get("mapstructure"), ",")[0]
+		if key == "" {
+			key = f.Name
+		}
+
+		res[strings.ToLower(key)] = true
+	}
+
+	return res
+}
+
+// configError is a configuration problem at a given line
+type configError struct {
+	Line int
+	Msg  string
+}
+
+func (e configError) Error() string {
+	if e.Line == 0 {
+		return e.Msg
+	}
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+type configChecker struct {
+	errs  []configError
+	names map[string]map[string]bool
+}
+
+func (c *configChecker) errorf(node *yaml.Node, format string, args ...interface{}) {
+	c.errs = append(c.errs, configError{Line: node.Line, Msg: fmt.Sprintf(format, args...)})
+}
+
+// mapping returns the keys and value nodes of a mapping node, keys are lower-cased
+func mapping(node *yaml.Node) ([]*yaml.Node, map[string]*yaml.Node) {
+	var keys []*yaml.Node
+	values := make(map[string]*yaml.Node)
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keys = append(keys, node.Content[i])
+		values[strings.ToLower(node.Content[i].Value)] = node.Content[i+1]
+	}
+
+	return keys, values
+}
+
+// checkConfig validates the configuration file contents against the known device types.
+// All problems found are returned including their line context.
+func checkConfig(b []byte) []configError {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return []configError{{Msg: err.Error()}}
+	}
+
+	if len(doc.Content) == 0 {
+		return []configError{{Msg: "empty configuration"}}
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return []configError{{Line: root.Line, Msg: "configuration must be a mapping"}}
+	}
+
+	c := &configChecker{names: make(map[string]map[string]bool)}
+
+	accepted := topLevelKeys()
+
+	keys, values := mapping(root)
+	for _, key := range keys {
+		if !accepted[strings.ToLower(key.Value)] {
+			c.errorf(key, "invalid key: %s", key.Value)
+		}
+	}
+
+	for _, class := range []string{"meters", "chargers", "vehicles"} {
+		c.devices(class, values[class])
+	}
+
+	c.site(values["site"])
+	c.loadpoints(values["loadpoints"])
+
+	return c.errs
+}
+
+// devices validates a list of devices of given class
+func (c *configChecker) devices(class string, node *yaml.Node) {
+	c.names[class] = make(map[string]bool)
+	if node == nil {
+		return
+	}
+
+	if node.Kind != yaml.SequenceNode {
+		c.errorf(node, "%s: must be a list", class)
+		return
+	}
+
+	for i, dev := range node.Content {
+		path := fmt.Sprintf("%s[%d]", class, i)
+
+		if dev.Kind != yaml.MappingNode {
+			c.errorf(dev, "%s: must be a mapping", path)
+			continue
+		}
+
+		keys, values := mapping(dev)
+
+		if name, ok := values["name"]; !ok || name.Value == "" {
+			c.errorf(dev, "%s: missing name", path)
+		} else {
+			if c.names[class][name.Value] {
+				c.errorf(name, "%s: duplicate name: %s", path, name.Value)
+			}
+			c.names[class][name.Value] = true
+			path = fmt.Sprintf("%s (%s)", path, name.Value)
+		}
+
+		typ, ok := values["type"]
+		if !ok || typ.Value == "" {
+			c.errorf(dev, "%s: missing type", path)
+			continue
+		}
+
+		schema, ok := lookupSchema(class, typ.Value)
+		if !ok {
+			c.errorf(typ, "%s: invalid type: %s (valid types: %s)", path, typ.Value, strings.Join(schemaTypes(class), ", "))
+			continue
+		}
+
+		accepted := schema.keys()
+		for _, key := range keys {
+			if !accepted[strings.ToLower(key.Value)] {
+				c.errorf(key, "%s: invalid key: %s", path, key.Value)
+			}
+		}
+
+		for _, req := range schema.Required {
+			var found bool
+			for _, alt := range strings.Split(req, "|") {
+				if _, ok := values[alt]; ok {
+					found = true
+				}
+			}
+
+			if !found {
+				c.errorf(dev, "%s: missing %s", path, strings.Join(strings.Split(req, "|"), " or "))
+			}
+		}
+	}
+}
+
+// refs returns the reference nodes of a scalar or list value
+func refs(node *yaml.Node) []*yaml.Node {
+	if node.Kind == yaml.SequenceNode {
+		return node.Content
+	}
+	return []*yaml.Node{node}
+}
+
+// reference validates that the referenced device exists
+func (c *configChecker) reference(class, path string, node *yaml.Node) {
+	if node == nil {
+		return
+	}
+
+	for _, ref := range refs(node) {
+		if ref.Value != "" && !c.names[class][ref.Value] {
+			c.errorf(ref, "%s: undefined %s reference: %s", path, strings.TrimSuffix(class, "s"), ref.Value)
+		}
+	}
+}
+
+// site validates the site's meter references
+func (c *configChecker) site(node *yaml.Node) {
+	if node == nil {
+		return
+	}
+
+	_, values := mapping(node)
+
+	meters, ok := values["meters"]
+	if !ok {
+		c.errorf(node, "site: missing meters")
+		return
+	}
+
+	_, usages := mapping(meters)
+	if _, ok := usages["grid"]; !ok {
+		c.errorf(meters, "site: missing grid meter")
+	}
+
+	for _, usage := range []string{"grid", "pv", "battery"} {
+		c.reference("meters", "site", usages[usage])
+	}
+}
+
+// loadpoints validates the loadpoints' device references
+func (c *configChecker) loadpoints(node *yaml.Node) {
+	if node == nil {
+		return
+	}
+
+	if node.Kind != yaml.SequenceNode {
+		c.errorf(node, "loadpoints: must be a list")
+		return
+	}
+
+	for i, lp := range node.Content {
+		path := fmt.Sprintf("loadpoints[%d]", i)
+		_, values := mapping(lp)
+
+		_, charger := values["charger"]
+		_, chargers := values["chargers"]
+		if !charger && !chargers {
+			c.errorf(lp, "%s: missing charger", path)
+		}
+
+		c.reference("chargers", path, values["charger"])
+		c.reference("chargers", path, values["chargers"])
+		c.reference("vehicles", path, values["vehicle"])
+		c.reference("vehicles", path, values["vehicles"])
+
+		if meters, ok := values["meters"]; ok {
+			_, usages := mapping(meters)
+			c.reference("meters", path, usages["charge"])
+		}
+	}
+}
+
+func runConfigCheck(cmd *cobra.Command, args []string) {
+	if cfgFile == "" {
+		fmt.Println("config file not found")
+		os.Exit(1)
+	}
+
+	b, err := ioutil.ReadFile(cfgFile)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	errs := checkConfig(b)
+	for _, err := range errs {
+		fmt.Printf("%s: %v\n", cfgFile, err)
+	}
+
+	if len(errs) > 0 {
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s: config ok\n", cfgFile)
+}
+
+func runConfigSchema(cmd *cobra.Command, args []string) {
+	b, err := json.MarshalIndent(configSchema, "", "  ")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	fmt.Println(string(b))
+}
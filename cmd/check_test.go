@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckConfig(t *testing.T) {
+	tc := []struct {
+		config string
+		errs   []string
+	}{
+		{`
+meters:
+- name: grid
+  type: modbus
+  model: sdm
+  uri: 192.168.0.1:502
+- name: pv
+  type: sungrow
+  uri: 192.168.0.2:502
+  usage: pv
+- name: home
+  type: plenticore
+  uri: 192.168.0.3:1502
+  usage: home
+- name: battery
+  type: victron
+  portalid: c0619ab12345
+  usage: battery
+chargers:
+- name: wallbe
+  type: wallbe
+vehicles:
+- name: car
+  type: Tesla
+  email: foo@bar.com
+  password: secret
+  clientID: id
+  clientSecret: secret
+- name: i3
+  type: bmw
+  user: foo
+  password: bar
+site:
+  meters:
+    grid: grid
+loadpoints:
+- charger: wallbe
+  vehicle: car
+`, nil},
+		{`
+foo: bar
+meters:
+- name: grid
+  type: sdm
+`, []string{
+			"line 2: invalid key: foo",
+			"line 5: meters[0] (grid): invalid type: sdm (valid types: default, kostal, modbus, sma, sungrow, tesla, victron)",
+		}},
+		{`
+chargers:
+- type: keba
+  uri: 192.168.0.1
+  host: 192.168.0.1
+- name: goe
+  type: go-e
+- name: nrgkick
+  type: nrgkick
+  macaddress: 00:11:22:33:44:55
+  pin: 1234
+`, []string{
+			"line 3: chargers[0]: missing name",
+			"line 5: chargers[0]: invalid key: host",
+			"line 6: chargers[1] (goe): missing uri or token",
+		}},
+		{`
+vehicles:
+- name: car
+  type: bmw
+  user: foo
+  password: bar
+  vin: WBY
+- name: car
+`, []string{
+			"line 8: vehicles[1]: duplicate name: car",
+			"line 8: vehicles[1] (car): missing type",
+		}},
+		{`
+meters:
+- name: grid
+  type: sma
+  uri: 192.168.0.1
+site:
+  meters:
+    pv: pv
+loadpoints:
+- vehicle: car
+  meters:
+    charge: grid
+`, []string{
+			"line 8: site: missing grid meter",
+			"line 8: site: undefined meter reference: pv",
+			"line 10: loadpoints[0]: missing charger",
+			"line 10: loadpoints[0]: undefined vehicle reference: car",
+		}},
+		{`
+chargers: wallbe
+`, []string{
+			"line 2: chargers: must be a list",
+		}},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		var errs []string
+		for _, err := range checkConfig([]byte(tc.config)) {
+			errs = append(errs, err.Error())
+		}
+
+		if strings.Join(errs, "\n") != strings.Join(tc.errs, "\n") {
+			t.Errorf("expected\n%s\ngot\n%s", strings.Join(tc.errs, "\n"), strings.Join(errs, "\n"))
+		}
+	}
+}
+
+func TestCheckConfigSyntax(t *testing.T) {
+	errs := checkConfig([]byte("meters: [\n"))
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "line") {
+		t.Errorf("expected syntax error with line, got %v", errs)
+	}
+}
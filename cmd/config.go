@@ -9,6 +9,7 @@ import (
 	"github.com/andig/evcc/provider"
 	"github.com/andig/evcc/push"
 	"github.com/andig/evcc/server"
+	"github.com/andig/evcc/util"
 	"github.com/andig/evcc/vehicle"
 )
 
@@ -17,9 +18,15 @@ type config struct {
 	Log        string
 	Levels     map[string]string
 	Interval   time.Duration
+	DryRun     bool
+	Tokens     string
+	State      string
 	Mqtt       provider.MqttConfig
 	Influx     server.InfluxConfig
 	Menu       []server.MenuConfig
+	Auth       server.AuthConfig
+	Cors       server.CorsConfig
+	Units      util.Units
 	Messaging  messagingConfig
 	Meters     []qualifiedConfig
 	Chargers   []qualifiedConfig
@@ -77,6 +84,27 @@ func (cp *ConfigProvider) Vehicle(name string) api.Vehicle {
 	return nil
 }
 
+// capabilities returns the implemented interfaces of all configured devices by device class
+func (cp *ConfigProvider) capabilities() map[string]map[string][]string {
+	res := map[string]map[string][]string{
+		"meters":   make(map[string][]string),
+		"chargers": make(map[string][]string),
+		"vehicles": make(map[string][]string),
+	}
+
+	for name, m := range cp.meters {
+		res["meters"][name] = api.Capabilities(m)
+	}
+	for name, c := range cp.chargers {
+		res["chargers"][name] = api.Capabilities(c)
+	}
+	for name, v := range cp.vehicles {
+		res["vehicles"][name] = api.Capabilities(v)
+	}
+
+	return res
+}
+
 func (cp *ConfigProvider) configure(conf config) {
 	cp.configureMeters(conf)
 	cp.configureChargers(conf)
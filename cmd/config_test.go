@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/andig/evcc/api"
+	"github.com/andig/evcc/mock"
+	"github.com/golang/mock/gomock"
+)
+
+func TestCapabilities(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	charger := &struct {
+		*mock.MockCharger
+		*mock.MockMeterEnergy
+		*mock.MockChargeRater
+	}{
+		mock.NewMockCharger(ctrl),
+		mock.NewMockMeterEnergy(ctrl),
+		mock.NewMockChargeRater(ctrl),
+	}
+
+	vehicle := &struct {
+		*mock.MockVehicle
+		*mock.MockVehicleRange
+	}{
+		mock.NewMockVehicle(ctrl),
+		mock.NewMockVehicleRange(ctrl),
+	}
+
+	cp := &ConfigProvider{
+		meters:   map[string]api.Meter{"grid": mock.NewMockMeter(ctrl)},
+		chargers: map[string]api.Charger{"wallbe": charger},
+		vehicles: map[string]api.Vehicle{"car": vehicle},
+	}
+
+	expected := map[string]map[string][]string{
+		"meters":   {"grid": {"Meter"}},
+		"chargers": {"wallbe": {"MeterEnergy", "Charger", "ChargeRater"}},
+		"vehicles": {"car": {"Vehicle", "VehicleRange"}},
+	}
+
+	if caps := cp.capabilities(); !reflect.DeepEqual(caps, expected) {
+		t.Errorf("expected %v, got %v", expected, caps)
+	}
+}
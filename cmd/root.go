@@ -5,10 +5,12 @@ import (
 	"os"
 	"time"
 
+	"github.com/andig/evcc/core"
 	"github.com/andig/evcc/server"
 	"github.com/andig/evcc/server/updater"
 	"github.com/andig/evcc/util"
 	"github.com/andig/evcc/util/pipe"
+	"github.com/andig/evcc/vehicle"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -71,6 +73,15 @@ func init() {
 		"Update interval",
 	)
 	bind(rootCmd, "interval")
+
+	rootCmd.PersistentFlags().Bool(
+		"dry-run",
+		false,
+		"Read all devices and log control decisions without writing to chargers",
+	)
+	if err := viper.BindPFlag("dryrun", rootCmd.PersistentFlags().Lookup("dry-run")); err != nil {
+		panic(err)
+	}
 }
 
 // initConfig reads in config file and ENV variables if set
@@ -128,7 +139,7 @@ func run(cmd *cobra.Command, args []string) {
 
 	// setup mqtt client listener
 	if conf.Mqtt.Broker != "" {
-		configureMQTT(conf.Mqtt)
+		configureMQTT(conf.Mqtt, true)
 	}
 
 	// start broadcasting values
@@ -138,23 +149,39 @@ func run(cmd *cobra.Command, args []string) {
 	cache := util.NewCache()
 	go cache.Run(pipe.NewDropper(ignoreParams...).Pipe(tee.Attach()))
 
+	// persist vehicle api tokens across restarts
+	vehicle.TokenFile = conf.Tokens
+
+	// persist loadpoint state like energy and cost counters across restarts
+	core.StateFile = conf.State
+
 	// setup loadpoints
-	site := loadConfig(conf)
+	if conf.DryRun {
+		log.WARN.Println("dry-run: chargers will not be controlled")
+		core.DryRun = true
+	}
+	site, cp := loadConfig(conf)
+
+	// published units of power and energy values
+	if err := conf.Units.Validate(); err != nil {
+		log.FATAL.Fatal(err)
+	}
+	units := pipe.NewUnitConverter(conf.Units)
 
 	// setup database
 	if conf.Influx.URL != "" {
-		configureDatabase(conf.Influx, site.LoadPoints(), tee.Attach())
+		configureDatabase(conf.Influx, site.LoadPoints(), units.Pipe(tee.Attach()))
 	}
 
 	// setup mqtt publisher
 	if conf.Mqtt.Broker != "" && conf.Mqtt.Topic != "" {
 		publisher := server.NewMQTT(conf.Mqtt.Topic)
-		go publisher.Run(site, tee.Attach())
+		go publisher.Run(site, units.Pipe(tee.Attach()))
 	}
 
 	// create webserver
 	socketHub := server.NewSocketHub()
-	httpd := server.NewHTTPd(uri, conf.Menu, site, socketHub, cache)
+	httpd := server.NewHTTPd(uri, conf.Menu, conf.Auth, conf.Cors, conf.Units, site, cp.capabilities(), socketHub, cache)
 
 	// publish to UI
 	go socketHub.Run(tee.Attach(), cache)
@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"sort"
+	"strings"
+)
+
+// deviceSchema describes the configuration keys of a device type.
+// Required entries may list alternatives separated by |, one of which must be present.
+type deviceSchema struct {
+	Aliases  []string `json:"aliases,omitempty"`
+	Required []string `json:"required,omitempty"`
+	Optional []string `json:"optional,omitempty"`
+}
+
+// keys returns all keys accepted by the schema
+func (s deviceSchema) keys() map[string]bool {
+	res := map[string]bool{"name": true, "type": true}
+	for _, k := range append(s.Optional, s.Required...) {
+		for _, alt := range strings.Split(k, "|") {
+			res[alt] = true
+		}
+	}
+	return res
+}
+
+var (
+	modbusKeys  = []string{"id", "subdevice", "uri", "device", "comset", "baudrate", "rtu"}
+	vehicleKeys = []string{"title", "capacity", "cache", "timeout"}
+)
+
+// configSchema contains the known device types by device class.
+// It must be kept in sync with the {charger,meter,vehicle}.NewFromConfig factories.
+var configSchema = map[string]map[string]deviceSchema{
+	"meters": {
+		"default": {
+			Aliases:  []string{"configurable"},
+			Required: []string{"power"},
+			Optional: []string{"energy", "currents"},
+		},
+		"modbus": {
+			Required: []string{"model", "uri|device"},
+			Optional: append([]string{"power", "energy"}, modbusKeys...),
+		},
+		"kostal": {
+			Aliases:  []string{"plenticore"},
+			Required: []string{"uri", "usage"},
+			Optional: []string{"id"},
+		},
+		"sma": {
+			Required: []string{"uri|serial"},
+			Optional: []string{"power", "energy"},
+		},
+		"sungrow": {
+			Required: []string{"uri", "usage"},
+			Optional: []string{"id"},
+		},
+		"victron": {
+			Aliases:  []string{"venus"},
+			Required: []string{"uri|portalid", "usage"},
+			Optional: []string{"gridinstance"},
+		},
+		"tesla": {
+			Aliases:  []string{"powerwall"},
+			Required: []string{"uri", "usage"},
+		},
+	},
+	"chargers": {
+		"default": {
+			Aliases:  []string{"configurable"},
+			Required: []string{"status", "enable", "enabled", "maxcurrent"},
+			Optional: []string{"soc"},
+		},
+		"wallbe": {
+			Optional: []string{"uri", "id", "legacy"},
+		},
+		"phoenix": {
+			Required: []string{"variant", "uri|device"},
+			Optional: modbusKeys,
+		},
+		"phoenix-emcp": {
+			Required: []string{"uri"},
+			Optional: []string{"id"},
+		},
+		"phoenix-evcc": {
+			Required: []string{"uri|device"},
+			Optional: modbusKeys,
+		},
+		"nrgkick": {
+			Required: []string{"uri|macaddress"},
+			Optional: []string{"mac", "password", "device", "pin"},
+		},
+		"nrgkick-bluetooth": {
+			Aliases:  []string{"nrgkick-bt", "nrgble"},
+			Required: []string{"macaddress"},
+			Optional: []string{"device", "pin"},
+		},
+		"nrgkick-connect": {
+			Aliases:  []string{"nrgconnect"},
+			Required: []string{"uri", "mac"},
+			Optional: []string{"password"},
+		},
+		"go-e": {
+			Aliases:  []string{"goe"},
+			Required: []string{"uri|token"},
+			Optional: []string{"api", "cache", "phases"},
+		},
+		"evsewifi": {
+			Required: []string{"uri"},
+		},
+		"simpleevse": {
+			Aliases:  []string{"evse"},
+			Required: []string{"uri|device"},
+			Optional: []string{"id"},
+		},
+		"mcc": {
+			Aliases:  []string{"porsche", "audi", "bentley"},
+			Required: []string{"uri", "password"},
+		},
+		"keba": {
+			Aliases:  []string{"bmw"},
+			Required: []string{"uri"},
+			Optional: []string{"model", "timeout", "rfid", "broadcast"},
+		},
+		"eebus": {
+			Required: []string{"uri"},
+			Optional: []string{"ski", "certificate", "key", "truststore"},
+		},
+	},
+	"vehicles": {
+		"default": {
+			Aliases:  []string{"configurable"},
+			Required: []string{"charge"},
+			Optional: vehicleKeys,
+		},
+		"audi": {
+			Aliases:  []string{"etron"},
+			Required: []string{"user", "password", "vin"},
+			Optional: vehicleKeys,
+		},
+		"bmw": {
+			Aliases:  []string{"i3"},
+			Required: []string{"user", "password"},
+			Optional: append([]string{"vin"}, vehicleKeys...),
+		},
+		"tesla": {
+			Aliases:  []string{"model3", "model 3", "models", "model s"},
+			Required: []string{"clientid", "email|refreshtoken"},
+			Optional: append([]string{"clientsecret", "password", "vin", "api", "region"}, vehicleKeys...),
+		},
+		"nissan": {
+			Aliases:  []string{"leaf"},
+			Required: []string{"user", "password"},
+			Optional: append([]string{"region"}, vehicleKeys...),
+		},
+		"renault": {
+			Aliases:  []string{"zoe"},
+			Required: []string{"user", "password"},
+			Optional: append([]string{"region", "vin", "brand"}, vehicleKeys...),
+		},
+		"dacia": {
+			Aliases:  []string{"spring"},
+			Required: []string{"user", "password"},
+			Optional: append([]string{"region", "vin", "brand"}, vehicleKeys...),
+		},
+		"porsche": {
+			Aliases:  []string{"taycan"},
+			Required: []string{"user", "password", "vin"},
+			Optional: vehicleKeys,
+		},
+		"script": {
+			Aliases:  []string{"exec"},
+			Required: []string{"cmd"},
+			Optional: vehicleKeys,
+		},
+		"teslamate": {
+			Optional: append([]string{"topic", "carid"}, vehicleKeys...),
+		},
+		"http": {
+			Required: []string{"uri", "soc"},
+			Optional: append([]string{"range", "scale", "headers", "auth", "insecure"}, vehicleKeys...),
+		},
+		"modbus": {
+			Required: []string{"soc", "uri|device"},
+			Optional: append(append([]string{"range"}, modbusKeys...), vehicleKeys...),
+		},
+	},
+}
+
+// lookupSchema returns the schema for the given device class and type or alias
+func lookupSchema(class, typ string) (deviceSchema, bool) {
+	typ = strings.ToLower(typ)
+	for name, s := range configSchema[class] {
+		if name == typ {
+			return s, true
+		}
+		for _, alias := range s.Aliases {
+			if alias == typ {
+				return s, true
+			}
+		}
+	}
+	return deviceSchema{}, false
+}
+
+// schemaTypes returns the sorted type names of a device class
+func schemaTypes(class string) []string {
+	var res []string
+	for name := range configSchema[class] {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
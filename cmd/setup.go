@@ -46,9 +46,15 @@ func mqttClientID() string {
 	return fmt.Sprintf("evcc-%d", pid)
 }
 
-// setup mqtt
-func configureMQTT(conf provider.MqttConfig) {
-	provider.MQTT = provider.NewMqttClient(conf.Broker, conf.User, conf.Password, mqttClientID(), 1)
+// setup mqtt. Availability is only published by the main process since short-lived
+// commands would trigger the offline last will on exit.
+func configureMQTT(conf provider.MqttConfig, availability bool) {
+	var topic string
+	if availability && conf.Topic != "" {
+		topic = conf.Topic + "/status"
+	}
+
+	provider.MQTT = provider.NewMqttClient(conf.Broker, conf.User, conf.Password, mqttClientID(), 1, topic)
 }
 
 func configureMessengers(conf messagingConfig, cache *util.Cache) chan push.Event {
@@ -68,14 +74,19 @@ func configureMessengers(conf messagingConfig, cache *util.Cache) chan push.Even
 	return notificationChan
 }
 
-func loadConfig(conf config) *core.Site {
+func loadConfig(conf config) (*core.Site, *ConfigProvider) {
 	cp := &ConfigProvider{}
 	cp.configure(conf)
 
+	// report unreachable devices early
+	if err := cp.validate(); err != nil {
+		log.ERROR.Println(err)
+	}
+
 	loadPoints := configureLoadPoints(conf, cp)
 	site := configureSite(conf.Site, cp, loadPoints)
 
-	return site
+	return site, cp
 }
 
 func configureSite(conf map[string]interface{}, cp *ConfigProvider, loadPoints []*core.LoadPoint) *core.Site {
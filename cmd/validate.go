@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// sortedKeys returns the names of the configured devices in stable order
+func sortedKeys(m map[string]func() error) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// validate attempts a single read from each configured meter, charger and vehicle.
+// All failures are aggregated into a single error.
+func (cp *ConfigProvider) validate() error {
+	checks := make(map[string]func() error)
+
+	for name, m := range cp.meters {
+		m := m
+		checks["meter "+name] = func() error {
+			_, err := m.CurrentPower()
+			return err
+		}
+	}
+
+	for name, c := range cp.chargers {
+		c := c
+		checks["charger "+name] = func() error {
+			_, err := c.Status()
+			return err
+		}
+	}
+
+	for name, v := range cp.vehicles {
+		v := v
+		checks["vehicle "+name] = func() error {
+			_, err := v.ChargeState()
+			return err
+		}
+	}
+
+	var failed []string
+	for _, device := range sortedKeys(checks) {
+		if err := checks[device](); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", device, err))
+			continue
+		}
+
+		log.INFO.Printf("%s: reachable", device)
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("unreachable devices:\n  %s", strings.Join(failed, "\n  "))
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/andig/evcc/api"
+	"github.com/andig/evcc/mock"
+	"github.com/golang/mock/gomock"
+)
+
+func TestValidate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	grid := mock.NewMockMeter(ctrl)
+	grid.EXPECT().CurrentPower().Return(100.0, nil)
+
+	pv := mock.NewMockMeter(ctrl)
+	pv.EXPECT().CurrentPower().Return(0.0, errors.New("timeout"))
+
+	keba := mock.NewMockCharger(ctrl)
+	keba.EXPECT().Status().Return(api.StatusNone, errors.New("no route to host"))
+
+	wallbe := mock.NewMockCharger(ctrl)
+	wallbe.EXPECT().Status().Return(api.StatusA, nil)
+
+	car := mock.NewMockVehicle(ctrl)
+	car.EXPECT().ChargeState().Return(50.0, nil)
+
+	cp := &ConfigProvider{
+		meters:   map[string]api.Meter{"grid": grid, "pv": pv},
+		chargers: map[string]api.Charger{"keba": keba, "wallbe": wallbe},
+		vehicles: map[string]api.Vehicle{"car": car},
+	}
+
+	err := cp.validate()
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	// all failures are reported
+	for _, s := range []string{"meter pv: timeout", "charger keba: no route to host"} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("missing %q in %v", s, err)
+		}
+	}
+
+	// reachable devices are not reported
+	for _, s := range []string{"grid", "wallbe", "car"} {
+		if strings.Contains(err.Error(), s) {
+			t.Errorf("unexpected %q in %v", s, err)
+		}
+	}
+
+	ctrl.Finish()
+}
+
+func TestValidateReachable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	grid := mock.NewMockMeter(ctrl)
+	grid.EXPECT().CurrentPower().Return(100.0, nil)
+
+	wallbe := mock.NewMockCharger(ctrl)
+	wallbe.EXPECT().Status().Return(api.StatusA, nil)
+
+	cp := &ConfigProvider{
+		meters:   map[string]api.Meter{"grid": grid},
+		chargers: map[string]api.Charger{"wallbe": wallbe},
+	}
+
+	if err := cp.validate(); err != nil {
+		t.Error(err)
+	}
+
+	ctrl.Finish()
+}
@@ -30,7 +30,7 @@ func runVehicle(cmd *cobra.Command, args []string) {
 
 	// setup mqtt
 	if conf.Mqtt.Broker != "" {
-		configureMQTT(conf.Mqtt)
+		configureMQTT(conf.Mqtt, false)
 	}
 
 	cp := &ConfigProvider{}
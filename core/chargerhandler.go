@@ -1,7 +1,9 @@
 package core
 
 import (
+	"errors"
 	"fmt"
+	"math/rand"
 	"time"
 
 	"github.com/andig/evcc/api"
@@ -21,14 +23,20 @@ type Handler interface {
 	Status() (api.ChargeStatus, error)
 	TargetCurrent() int64
 	Ramp(int64, ...bool) error
+	WakeUp() error
 }
 
 // HandlerConfig contains the public configuration for the ChargerHandler
 type HandlerConfig struct {
 	Sensitivity   int64         // Step size of current change
+	RampRate      int64         // Max current increase per cycle, decreases are applied immediately
 	MinCurrent    int64         // PV mode: start current	Min+PV mode: min current
 	MaxCurrent    int64         // Max allowed current. Physically ensured by the charge controller
 	GuardDuration time.Duration // charger enable/disable minimum holding time
+	Relay         bool          // Charger is only switched on/off and charges at fixed max current
+	StartJitter   time.Duration // Max random delay before enabling the charger to spread grid load, 0 to disable
+	CurrentStep   int64         // Charge current is rounded down to multiples of this step, 0 to disable
+	MinChange     int64         // Min current change written to the charger unless forced, 0 to disable
 }
 
 // ChargerHandler handles steering of the charger state and allowed current
@@ -37,15 +45,23 @@ type ChargerHandler struct {
 	bus   evbus.Bus   // event bus
 	log   *util.Logger
 
-	charger api.Charger // Charger
+	charger api.Charger                 // Charger
+	vehicle api.VehicleChargeController // Optional vehicle-side charge control
 
 	HandlerConfig // public configuration
 
+	dryRun bool // log charger settings instead of writing them
+
 	enabled       bool  // Charger enabled state
 	targetCurrent int64 // Charger target current
+	currentLimit  int64 // Charger-reported hardware current limit, 0 if unknown
 
 	// contactor switch guard
 	guardUpdated time.Time // charger enabled/disabled timestamp
+
+	// randomized start delay
+	startAt time.Time         // earliest charger enable timestamp, zero if no start pending
+	jitter  func(int64) int64 // random source, defaults to math/rand
 }
 
 // Status returns charger status
@@ -90,6 +106,11 @@ func (lp *ChargerHandler) Prepare() {
 func (lp *ChargerHandler) SyncEnabled() {
 	enabled, err := lp.charger.Enabled()
 	if err == nil && enabled != lp.enabled {
+		// charger state is expected to differ in dry-run mode
+		if lp.dryRun {
+			return
+		}
+
 		lp.log.DEBUG.Printf("sync enabled state to %s", status[lp.enabled])
 		err = lp.charger.Enable(lp.enabled)
 	}
@@ -97,6 +118,31 @@ func (lp *ChargerHandler) SyncEnabled() {
 	if err != nil {
 		lp.log.ERROR.Printf("charge controller error: %v", err)
 	}
+
+	lp.syncCurrent()
+}
+
+// syncCurrent re-writes the target current if the charger reports a different current setpoint,
+// e.g. after the charger has been restarted or rejected the previous write
+func (lp *ChargerHandler) syncCurrent() {
+	cg, ok := lp.charger.(api.CurrentGetter)
+	if !ok || !lp.enabled || lp.targetCurrent == 0 || lp.Relay || lp.dryRun {
+		return
+	}
+
+	current, err := cg.GetMaxCurrent()
+	if errors.Is(err, api.ErrNotSupported) {
+		return
+	}
+
+	if err == nil && current != lp.targetCurrent {
+		lp.log.WARN.Printf("charger current %dA does not match target current %dA, re-writing", current, lp.targetCurrent)
+		err = lp.writeCurrent(lp.targetCurrent)
+	}
+
+	if err != nil {
+		lp.log.ERROR.Printf("charge controller error: %v", err)
+	}
 }
 
 // chargerEnable switches charging on or off. Minimum cycle duration is guaranteed.
@@ -110,9 +156,13 @@ func (lp *ChargerHandler) chargerEnable(enable bool) error {
 		return nil
 	}
 
+	if lp.enabled != enable && lp.startDelayed(enable) {
+		return nil
+	}
+
 	if lp.enabled != enable {
-		if err := lp.charger.Enable(enable); err != nil {
-			return fmt.Errorf("charge controller error: %v", err)
+		if err := lp.writeEnable(enable); err != nil {
+			return err
 		}
 
 		lp.enabled = enable // cache
@@ -128,18 +178,125 @@ func (lp *ChargerHandler) chargerEnable(enable bool) error {
 	return nil
 }
 
+// startDelayed returns true while the randomized start delay has not elapsed.
+// The delay is chosen once per start request within the configured jitter window.
+func (lp *ChargerHandler) startDelayed(enable bool) bool {
+	if !enable || lp.StartJitter <= 0 {
+		lp.startAt = time.Time{}
+		return false
+	}
+
+	if lp.startAt.IsZero() {
+		jitter := lp.jitter
+		if jitter == nil {
+			jitter = rand.Int63n
+		}
+
+		delay := time.Duration(jitter(int64(lp.StartJitter)))
+		lp.startAt = lp.clock.Now().Add(delay)
+		lp.log.DEBUG.Printf("charger start delay: %v", delay.Truncate(time.Second))
+	}
+
+	if remaining := lp.startAt.Sub(lp.clock.Now()); remaining > 0 {
+		lp.log.DEBUG.Printf("charger %s - start delay %v", status[enable], remaining.Truncate(time.Second))
+		return true
+	}
+
+	lp.startAt = time.Time{}
+	return false
+}
+
+// WakeUp briefly disables and re-enables the charger to wake up a sleeping vehicle.
+// The contactor guard is not applied.
+func (lp *ChargerHandler) WakeUp() error {
+	if !lp.enabled {
+		return nil
+	}
+
+	lp.log.DEBUG.Println("charger toggle enable")
+	if err := lp.writeEnable(false); err != nil {
+		return err
+	}
+
+	return lp.writeEnable(true)
+}
+
+// writeEnable writes the enabled state to charger and vehicle. In dry-run mode the change is only logged.
+func (lp *ChargerHandler) writeEnable(enable bool) error {
+	if lp.dryRun {
+		lp.log.INFO.Printf("dry-run: charger %s", status[enable])
+		return nil
+	}
+
+	if err := lp.charger.Enable(enable); err != nil {
+		return fmt.Errorf("charge controller error: %v", err)
+	}
+
+	if err := lp.vehicleEnable(enable); err != nil {
+		return fmt.Errorf("vehicle charge controller error: %v", err)
+	}
+
+	return nil
+}
+
+// vehicleEnable starts or stops charging on the vehicle side if configured
+func (lp *ChargerHandler) vehicleEnable(enable bool) error {
+	if lp.vehicle == nil {
+		return nil
+	}
+
+	if enable {
+		return lp.vehicle.StartCharge()
+	}
+
+	return lp.vehicle.StopCharge()
+}
+
+// updateCurrentLimit reads the hardware current limit if provided by the charger, e.g. from cable coding.
+// Limits below min current are ignored as they are reported while no cable is connected.
+func (lp *ChargerHandler) updateCurrentLimit() {
+	cl, ok := lp.charger.(api.CurrentLimiter)
+	if !ok {
+		return
+	}
+
+	limit, err := cl.CurrentLimit()
+	if err != nil {
+		lp.log.ERROR.Printf("charger current limit error: %v", err)
+		return
+	}
+
+	if limit < lp.MinCurrent {
+		limit = 0
+	}
+
+	if limit != lp.currentLimit && limit > 0 && limit < lp.MaxCurrent {
+		lp.log.DEBUG.Printf("charger current limit: %dA", limit)
+	}
+
+	lp.currentLimit = limit
+}
+
+// maxCurrent returns the max current limited by the charger's hardware current limit
+func (lp *ChargerHandler) maxCurrent() int64 {
+	if lp.currentLimit > 0 && lp.currentLimit < lp.MaxCurrent {
+		return lp.currentLimit
+	}
+	return lp.MaxCurrent
+}
+
 // setTargetCurrent guards setting current against changing to identical value
-// and violating MaxCurrent
+// and violating MaxCurrent or the charger's hardware current limit
 func (lp *ChargerHandler) setTargetCurrent(targetCurrentIn int64) error {
-	targetCurrent := clamp(targetCurrentIn, lp.MinCurrent, lp.MaxCurrent)
+	targetCurrent := clamp(targetCurrentIn, lp.MinCurrent, lp.maxCurrent())
 	if targetCurrent != targetCurrentIn {
 		lp.log.WARN.Printf("hard limit charge current: %dA", targetCurrent)
 	}
 
 	if lp.targetCurrent != targetCurrent {
 		lp.log.DEBUG.Printf("set charge current: %dA", targetCurrent)
-		if err := lp.charger.MaxCurrent(targetCurrent); err != nil {
-			return fmt.Errorf("charge controller error: %v", err)
+		if err := lp.writeCurrent(targetCurrent); err != nil {
+			return err
 		}
 
 		lp.targetCurrent = targetCurrent // cache
@@ -151,7 +308,68 @@ func (lp *ChargerHandler) setTargetCurrent(targetCurrentIn int64) error {
 	return nil
 }
 
+// writeCurrent writes the current to charger and vehicle. In dry-run mode the change is only logged.
+func (lp *ChargerHandler) writeCurrent(current int64) error {
+	// relay-switched chargers have no current control
+	if lp.Relay {
+		return nil
+	}
+
+	if lp.dryRun {
+		lp.log.INFO.Printf("dry-run: set charge current: %dA", current)
+		return nil
+	}
+
+	if err := lp.charger.MaxCurrent(current); err != nil {
+		return fmt.Errorf("charge controller error: %v", err)
+	}
+
+	if vc, ok := lp.vehicle.(api.VehicleCurrentController); ok {
+		if err := vc.MaxCurrent(current); err != nil {
+			return fmt.Errorf("vehicle charge controller error: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// Phases1p3p implements the api.ChargePhaseSwitcher interface. In dry-run mode the change is only logged.
+func (lp *ChargerHandler) Phases1p3p(phases int) error {
+	ps, ok := lp.charger.(api.ChargePhaseSwitcher)
+	if !ok {
+		return api.ErrNotSupported
+	}
+
+	if lp.dryRun {
+		lp.log.INFO.Printf("dry-run: switch phases: %dp", phases)
+		return nil
+	}
+
+	if err := ps.Phases1p3p(phases); err != nil {
+		return fmt.Errorf("charge controller error: %v", err)
+	}
+
+	return nil
+}
+
+// Display implements the api.ChargerDisplay interface. In dry-run mode the text is only logged.
+func (lp *ChargerHandler) Display(text string) error {
+	d, ok := lp.charger.(api.ChargerDisplay)
+	if !ok {
+		return api.ErrNotSupported
+	}
+
+	if lp.dryRun {
+		lp.log.INFO.Printf("dry-run: charger display: %s", text)
+		return nil
+	}
+
+	return d.Display(text)
+}
+
 // rampUpDown moves stepwise towards target current.
+// If ramp rate is configured, current increases are limited by ramp rate
+// while decreases are applied immediately.
 // It does not enable or disable the charger.
 func (lp *ChargerHandler) rampUpDown(target int64) error {
 	current := lp.targetCurrent
@@ -159,18 +377,56 @@ func (lp *ChargerHandler) rampUpDown(target int64) error {
 		return nil
 	}
 
+	// intermediate steps are kept on the current step grid
 	var step int64
 	if current < target {
 		step = min(current+lp.Sensitivity, target)
+		if lp.RampRate > 0 {
+			step = min(current+lp.RampRate, target)
+		}
+
+		// progress by at least one grid step if rounding down would stall
+		if step = lp.quantize(step); step <= current {
+			step = min(lp.quantizeUp(current+1), target)
+		}
 	} else if current > target {
 		step = max(current-lp.Sensitivity, target)
+		if lp.RampRate > 0 {
+			step = target
+		}
+
+		step = max(lp.quantize(step), target)
 	}
 
-	step = clamp(step, lp.MinCurrent, lp.MaxCurrent)
+	step = clamp(step, lp.MinCurrent, lp.maxCurrent())
 
 	return lp.setTargetCurrent(step)
 }
 
+// quantize rounds the target current down to the configured current step
+func (lp *ChargerHandler) quantize(target int64) int64 {
+	target = min(target, lp.maxCurrent())
+	if lp.CurrentStep <= 1 {
+		return target
+	}
+
+	return max(target/lp.CurrentStep*lp.CurrentStep, lp.MinCurrent)
+}
+
+// quantizeUp rounds the current up to the configured current step
+func (lp *ChargerHandler) quantizeUp(current int64) int64 {
+	if lp.CurrentStep <= 1 {
+		return current
+	}
+
+	return (current + lp.CurrentStep - 1) / lp.CurrentStep * lp.CurrentStep
+}
+
+// belowMinChange returns true if the target current differs from the current less than the min change
+func (lp *ChargerHandler) belowMinChange(target int64) bool {
+	return lp.MinChange > 0 && target != lp.targetCurrent && abs(target-lp.targetCurrent) < lp.MinChange
+}
+
 // rampOff disables charger after setting minCurrent.
 // Setting current and disabling are two steps. If already disabled, this is a nop.
 func (lp *ChargerHandler) rampOff() error {
@@ -182,6 +438,9 @@ func (lp *ChargerHandler) rampOff() error {
 		return lp.chargerEnable(false)
 	}
 
+	// withdraw pending start
+	lp.startAt = time.Time{}
+
 	return nil
 }
 
@@ -207,15 +466,26 @@ func (lp *ChargerHandler) Ramp(targetCurrent int64, force ...bool) error {
 		lp.guardUpdated = time.Time{}
 	}
 
+	lp.updateCurrentLimit()
+
 	// if targetCurrent == 0 ramp down to disabled state
 	if targetCurrent == 0 {
 		return lp.rampOff()
 	}
 
+	targetCurrent = lp.quantize(targetCurrent)
+
 	// targetCurrent != 0 and not enabled ramp to enabled state
 	if !lp.enabled {
 		return lp.rampOn(targetCurrent)
 	}
 
+	// ignore small changes to avoid frequent writes
+	if !(len(force) == 1 && force[0]) && lp.belowMinChange(targetCurrent) {
+		lp.log.DEBUG.Printf("charge current change below %dA: %dA", lp.MinChange, targetCurrent)
+		lp.bus.Publish(evChargeCurrent, lp.targetCurrent)
+		return nil
+	}
+
 	return lp.rampUpDown(targetCurrent)
 }
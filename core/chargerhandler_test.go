@@ -1,6 +1,8 @@
 package core
 
 import (
+	"errors"
+	"math/rand"
 	"testing"
 	"time"
 
@@ -346,3 +348,593 @@ func TestRampUpDown(t *testing.T) {
 		ctrl.Finish()
 	}
 }
+
+func TestRampRate(t *testing.T) {
+	const rampRate = 2
+
+	tc := []struct {
+		targetCurrentI, targetCurrent int64
+		expect                        func(*mock.MockCharger)
+	}{
+		// at min: set max is rate-limited
+		{minA, maxA, func(mc *mock.MockCharger) {
+			mc.EXPECT().MaxCurrent(minA + rampRate).Return(nil)
+		}},
+		// near max: set max
+		{maxA - 1, maxA, func(mc *mock.MockCharger) {
+			mc.EXPECT().MaxCurrent(maxA).Return(nil)
+		}},
+		// at max: set min is instant
+		{maxA, minA, func(mc *mock.MockCharger) {
+			mc.EXPECT().MaxCurrent(minA).Return(nil)
+		}},
+		// at max: set <min is instant and clamped
+		{maxA, minA - 100, func(mc *mock.MockCharger) {
+			mc.EXPECT().MaxCurrent(minA).Return(nil)
+		}},
+	}
+
+	for _, tc := range tc {
+		ctrl := gomock.NewController(t)
+		mc := mock.NewMockCharger(ctrl)
+
+		t.Log(tc)
+
+		clock := clock.NewMock()
+		h := newChargerHandler(clock, mc)
+		h.RampRate = rampRate
+		h.enabled = true
+		h.targetCurrent = tc.targetCurrentI
+
+		tc.expect(mc)
+
+		if err := h.rampUpDown(tc.targetCurrent); err != nil {
+			t.Error(err)
+		}
+
+		ctrl.Finish()
+	}
+}
+
+func TestVehicleControl(t *testing.T) {
+	tc := []struct {
+		enabled bool
+		enable  bool
+		expect  func(*mock.MockCharger, *mock.MockVehicleChargeController)
+	}{
+		{false, true, func(mc *mock.MockCharger, vc *mock.MockVehicleChargeController) {
+			mc.EXPECT().Enable(true).Return(nil)
+			vc.EXPECT().StartCharge().Return(nil)
+		}},
+		{true, false, func(mc *mock.MockCharger, vc *mock.MockVehicleChargeController) {
+			mc.EXPECT().Enable(false).Return(nil)
+			vc.EXPECT().StopCharge().Return(nil)
+		}},
+		// no change
+		{true, true, func(mc *mock.MockCharger, vc *mock.MockVehicleChargeController) {
+			// nop
+		}},
+	}
+
+	for _, tc := range tc {
+		ctrl := gomock.NewController(t)
+		mc := mock.NewMockCharger(ctrl)
+		vc := mock.NewMockVehicleChargeController(ctrl)
+
+		t.Log(tc)
+
+		clock := clock.NewMock()
+		r := newChargerHandler(clock, mc)
+		r.vehicle = vc
+		r.enabled = tc.enabled
+		r.targetCurrent = minA
+
+		tc.expect(mc, vc)
+		clock.Add(dt)
+
+		if err := r.chargerEnable(tc.enable); err != nil {
+			t.Error(err)
+		}
+
+		ctrl.Finish()
+	}
+}
+
+func TestVehicleCurrentControl(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mc := mock.NewMockCharger(ctrl)
+
+	vc := struct {
+		*mock.MockVehicleChargeController
+		*mock.MockVehicleCurrentController
+	}{
+		mock.NewMockVehicleChargeController(ctrl),
+		mock.NewMockVehicleCurrentController(ctrl),
+	}
+
+	r := newChargerHandler(clock.NewMock(), mc)
+	r.vehicle = vc
+	r.targetCurrent = minA
+
+	mc.EXPECT().MaxCurrent(int64(maxA)).Return(nil)
+	vc.MockVehicleCurrentController.EXPECT().MaxCurrent(int64(maxA)).Return(nil)
+
+	if err := r.setTargetCurrent(maxA); err != nil {
+		t.Error(err)
+	}
+
+	ctrl.Finish()
+}
+
+func TestDryRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mc := mock.NewMockCharger(ctrl)
+	vc := mock.NewMockVehicleChargeController(ctrl)
+	clock := clock.NewMock()
+
+	r := &ChargerHandler{
+		log:     util.NewLogger("foo"),
+		clock:   clock,
+		bus:     evbus.New(),
+		charger: mc,
+		vehicle: vc,
+		HandlerConfig: HandlerConfig{
+			MinCurrent:    minA,
+			MaxCurrent:    maxA,
+			Sensitivity:   sensitivity,
+			GuardDuration: guardDuration,
+		},
+		dryRun: true,
+	}
+
+	// getters are still executed, setters are never called
+	mc.EXPECT().Enabled().Return(false, nil).Times(2)
+
+	r.Prepare()
+	clock.Add(dt)
+
+	// enable
+	if err := r.Ramp(maxA); err != nil {
+		t.Error(err)
+	}
+
+	// charger state differs from expected state
+	r.SyncEnabled()
+
+	// ramp current
+	for i := minA; i < maxA; i++ {
+		if err := r.Ramp(maxA); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if !r.Enabled() || r.TargetCurrent() != maxA {
+		t.Errorf("expected enabled at %dA, got %v at %dA", maxA, r.Enabled(), r.TargetCurrent())
+	}
+
+	clock.Add(dt)
+
+	// disable
+	for i := 0; i < 2; i++ {
+		if err := r.Ramp(0); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if r.Enabled() {
+		t.Error("expected disabled")
+	}
+
+	// phase switching
+	ps := &testPhaseSwitcher{}
+	r.charger = &struct {
+		api.Charger
+		api.ChargePhaseSwitcher
+	}{mc, ps}
+
+	if err := r.Phases1p3p(1); err != nil || len(ps.switches) != 0 {
+		t.Errorf("expected phases not switched, got %v (%v)", ps.switches, err)
+	}
+
+	// display
+	display := &testDisplay{}
+	r.charger = &struct {
+		api.Charger
+		api.ChargePhaseSwitcher
+		api.ChargerDisplay
+	}{mc, ps, display}
+
+	if err := r.Display("foo"); err != nil || len(display.texts) != 0 {
+		t.Errorf("expected display not written, got %v (%v)", display.texts, err)
+	}
+
+	r.dryRun = false
+	if err := r.Phases1p3p(1); err != nil || len(ps.switches) != 1 {
+		t.Errorf("expected phases switched, got %v (%v)", ps.switches, err)
+	}
+
+	if err := r.Display("foo"); err != nil || len(display.texts) != 1 {
+		t.Errorf("expected display written, got %v (%v)", display.texts, err)
+	}
+
+	ctrl.Finish()
+}
+
+func TestRelay(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mc := mock.NewMockCharger(ctrl)
+	clock := clock.NewMock()
+
+	r := &ChargerHandler{
+		log:     util.NewLogger("foo"),
+		clock:   clock,
+		bus:     evbus.New(),
+		charger: mc,
+		HandlerConfig: HandlerConfig{
+			MinCurrent:    maxA,
+			MaxCurrent:    maxA,
+			Sensitivity:   sensitivity,
+			GuardDuration: guardDuration,
+			Relay:         true,
+		},
+	}
+
+	// current is never written
+	mc.EXPECT().Enabled().Return(false, nil)
+	r.Prepare()
+	clock.Add(dt)
+
+	mc.EXPECT().Enable(true).Return(nil)
+	if err := r.Ramp(maxA); err != nil {
+		t.Error(err)
+	}
+
+	clock.Add(dt)
+
+	mc.EXPECT().Enable(false).Return(nil)
+	if err := r.Ramp(0); err != nil {
+		t.Error(err)
+	}
+
+	ctrl.Finish()
+}
+
+func TestStartJitter(t *testing.T) {
+	const (
+		window = 10 * time.Minute
+		step   = 10 * time.Second
+	)
+
+	random := rand.New(rand.NewSource(1))
+
+	var first, last time.Duration = window, 0
+	for i := 0; i < 20; i++ {
+		ctrl := gomock.NewController(t)
+		mc := mock.NewMockCharger(ctrl)
+		clock := clock.NewMock()
+
+		r := &ChargerHandler{
+			log:     util.NewLogger("foo"),
+			clock:   clock,
+			bus:     evbus.New(),
+			charger: mc,
+			HandlerConfig: HandlerConfig{
+				MinCurrent:  minA,
+				MaxCurrent:  maxA,
+				Sensitivity: sensitivity,
+				StartJitter: window,
+			},
+			jitter: random.Int63n,
+		}
+
+		mc.EXPECT().Enabled().Return(false, nil)
+		mc.EXPECT().MaxCurrent(minA).Return(nil)
+		r.Prepare()
+
+		start := clock.Now()
+		mc.EXPECT().Enable(true).Return(nil)
+
+		for !r.Enabled() {
+			if err := r.Ramp(maxA); err != nil {
+				t.Fatal(err)
+			}
+
+			if elapsed := clock.Since(start); elapsed > window {
+				t.Fatalf("start delay %v exceeds jitter window", elapsed)
+			}
+
+			if !r.Enabled() {
+				clock.Add(step)
+			}
+		}
+
+		delay := clock.Since(start)
+		if delay < first {
+			first = delay
+		}
+		if delay > last {
+			last = delay
+		}
+
+		ctrl.Finish()
+	}
+
+	// starts must spread across the window
+	if first > window/4 || last < 3*window/4 {
+		t.Errorf("start delays not distributed: %v - %v", first, last)
+	}
+}
+
+func TestStartJitterWithdrawn(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mc := mock.NewMockCharger(ctrl)
+	clock := clock.NewMock()
+
+	r := &ChargerHandler{
+		log:     util.NewLogger("foo"),
+		clock:   clock,
+		bus:     evbus.New(),
+		charger: mc,
+		HandlerConfig: HandlerConfig{
+			MinCurrent:  minA,
+			MaxCurrent:  maxA,
+			Sensitivity: sensitivity,
+			StartJitter: time.Minute,
+		},
+		jitter: func(n int64) int64 { return n - 1 },
+	}
+
+	mc.EXPECT().Enabled().Return(false, nil)
+	mc.EXPECT().MaxCurrent(minA).Return(nil)
+	r.Prepare()
+
+	// start delayed
+	if err := r.Ramp(maxA); err != nil || r.Enabled() {
+		t.Fatalf("expected delayed start: %v", err)
+	}
+
+	// start withdrawn, next start request chooses a new delay
+	if err := r.Ramp(0); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Add(time.Minute)
+	r.jitter = func(int64) int64 { return 0 }
+
+	mc.EXPECT().Enable(true).Return(nil)
+	if err := r.Ramp(maxA); err != nil || !r.Enabled() {
+		t.Errorf("expected immediate start: %v", err)
+	}
+
+	ctrl.Finish()
+}
+
+func TestWakeUpToggle(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mc := mock.NewMockCharger(ctrl)
+	clock := clock.NewMock()
+	r := newChargerHandler(clock, mc)
+
+	// toggle ignores contactor guard
+	gomock.InOrder(
+		mc.EXPECT().Enable(false).Return(nil),
+		mc.EXPECT().Enable(true).Return(nil),
+	)
+
+	if err := r.WakeUp(); err != nil || !r.Enabled() {
+		t.Errorf("expected enabled charger: %v", err)
+	}
+
+	// disabled charger is not toggled
+	r.enabled = false
+	if err := r.WakeUp(); err != nil {
+		t.Error(err)
+	}
+
+	ctrl.Finish()
+}
+
+func TestCurrentLimit(t *testing.T) {
+	tc := []struct {
+		limit, target, current int64
+	}{
+		{0, maxA, maxA},    // unknown limit
+		{32, maxA, maxA},   // limit above max current
+		{13, maxA, 13},     // cable coding 13A
+		{13, 10, 10},       // below limit
+		{2, maxA, maxA},    // limit below min current ignored
+		{10, maxA - 1, 10}, // limit reduced while charging
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		ctrl := gomock.NewController(t)
+		mc := mock.NewMockCharger(ctrl)
+		cl := mock.NewMockCurrentLimiter(ctrl)
+
+		r := newChargerHandler(clock.NewMock(), mc)
+		r.charger = &struct {
+			*mock.MockCharger
+			*mock.MockCurrentLimiter
+		}{mc, cl}
+		r.Sensitivity = maxA // single step
+
+		cl.EXPECT().CurrentLimit().Return(tc.limit, nil)
+		mc.EXPECT().MaxCurrent(tc.current).Return(nil)
+
+		if err := r.Ramp(tc.target); err != nil {
+			t.Error(err)
+		}
+
+		if r.TargetCurrent() != tc.current {
+			t.Errorf("expected %dA, got %dA", tc.current, r.TargetCurrent())
+		}
+
+		ctrl.Finish()
+	}
+}
+
+func TestCurrentStep(t *testing.T) {
+	tc := []struct {
+		step, target, current int64
+	}{
+		{0, 11, 11},     // disabled
+		{1, 11, 11},     // 1A steps
+		{2, 11, 10},     // rounded down
+		{4, 7, minA},    // not below min current
+		{5, 20, 15},     // max current applied first
+		{2, maxA, maxA}, // multiple of step
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		ctrl := gomock.NewController(t)
+		mc := mock.NewMockCharger(ctrl)
+
+		r := newChargerHandler(clock.NewMock(), mc)
+		r.Sensitivity = maxA // single step
+		r.CurrentStep = tc.step
+
+		if tc.current != minA {
+			mc.EXPECT().MaxCurrent(tc.current).Return(nil)
+		}
+
+		if err := r.Ramp(tc.target); err != nil {
+			t.Error(err)
+		}
+
+		if r.TargetCurrent() != tc.current {
+			t.Errorf("expected %dA, got %dA", tc.current, r.TargetCurrent())
+		}
+
+		ctrl.Finish()
+	}
+}
+
+func TestCurrentStepRamp(t *testing.T) {
+	tc := []struct {
+		step, rampRate, sensitivity int64
+		from, target                int64
+		steps                       []int64
+	}{
+		// ramp rate not a multiple of current step
+		{4, 3, sensitivity, minA, maxA, []int64{8, 8 + 4, maxA}},
+		{5, 3, sensitivity, minA, maxA, []int64{minA + 4, 10 + 5}},
+		// sensitivity not a multiple of current step
+		{4, 0, 3, minA, maxA, []int64{8, 8 + 4, maxA}},
+		{4, 0, 3, maxA, minA, []int64{12, 8, minA}},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		ctrl := gomock.NewController(t)
+		mc := mock.NewMockCharger(ctrl)
+
+		r := newChargerHandler(clock.NewMock(), mc)
+		r.CurrentStep = tc.step
+		r.RampRate = tc.rampRate
+		r.Sensitivity = tc.sensitivity
+		r.enabled = true
+		r.targetCurrent = tc.from
+
+		// multiples of current step or min current
+		for _, step := range tc.steps {
+			mc.EXPECT().MaxCurrent(step).Return(nil)
+		}
+
+		for range tc.steps {
+			if err := r.Ramp(tc.target); err != nil {
+				t.Error(err)
+			}
+		}
+
+		ctrl.Finish()
+	}
+}
+
+func TestMinChange(t *testing.T) {
+	tc := []struct {
+		target, current int64
+		force           bool
+	}{
+		{minA + 1, minA, false}, // ignored
+		{minA + 2, minA + 2, false},
+		{minA + 1, minA + 2, false}, // ignored
+		{minA + 1, minA + 1, true},  // forced
+		{maxA, maxA, false},
+	}
+
+	ctrl := gomock.NewController(t)
+	mc := mock.NewMockCharger(ctrl)
+
+	r := newChargerHandler(clock.NewMock(), mc)
+	r.Sensitivity = maxA // single step
+	r.MinChange = 2
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		if tc.current != r.TargetCurrent() {
+			mc.EXPECT().MaxCurrent(tc.current).Return(nil)
+		}
+
+		if err := r.Ramp(tc.target, tc.force); err != nil {
+			t.Error(err)
+		}
+
+		if r.TargetCurrent() != tc.current {
+			t.Errorf("expected %dA, got %dA", tc.current, r.TargetCurrent())
+		}
+	}
+
+	ctrl.Finish()
+}
+
+func TestSyncCurrent(t *testing.T) {
+	tc := []struct {
+		enabled  bool
+		readback int64
+		err      error
+		rewrite  bool
+	}{
+		{true, 10, nil, false},                  // setpoint applied
+		{true, 16, nil, true},                   // charger lost setpoint
+		{true, 0, errors.New("timeout"), false}, // read error
+		{true, 0, api.ErrNotSupported, false},   // read-back not available
+		{false, 0, nil, false},                  // disabled
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		ctrl := gomock.NewController(t)
+		mc := mock.NewMockCharger(ctrl)
+		cg := mock.NewMockCurrentGetter(ctrl)
+
+		r := newChargerHandler(clock.NewMock(), mc)
+		r.charger = &struct {
+			*mock.MockCharger
+			*mock.MockCurrentGetter
+		}{mc, cg}
+
+		r.enabled = tc.enabled
+		r.targetCurrent = 10
+
+		mc.EXPECT().Enabled().Return(tc.enabled, nil)
+
+		if tc.enabled {
+			cg.EXPECT().GetMaxCurrent().Return(tc.readback, tc.err)
+		}
+
+		if tc.rewrite {
+			mc.EXPECT().MaxCurrent(int64(10)).Return(nil)
+		}
+
+		r.SyncEnabled()
+
+		ctrl.Finish()
+	}
+}
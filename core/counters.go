@@ -0,0 +1,78 @@
+package core
+
+import "time"
+
+// Counters are the loadpoint's charged energy and cost totals across sessions
+type Counters struct {
+	LifetimeEnergy float64   `json:"lifetimeEnergy"` // Charged energy (kWh), never reset
+	LifetimeCost   float64   `json:"lifetimeCost"`   // Charging cost, never reset
+	Energy         float64   `json:"energy"`         // Charged energy (kWh) since reset
+	Cost           float64   `json:"cost"`           // Charging cost since reset
+	Reset          time.Time `json:"reset"`          // Time of last reset, zero if never reset
+}
+
+// add accounts the charged energy (Wh) at the given price per kWh
+func (c *Counters) add(energy, price float64) {
+	kWh := energy / 1e3
+	c.LifetimeEnergy += kWh
+	c.LifetimeCost += kWh * price
+	c.Energy += kWh
+	c.Cost += kWh * price
+}
+
+// reset clears the resettable counters
+func (c *Counters) reset(now time.Time) {
+	c.Energy = 0
+	c.Cost = 0
+	c.Reset = now
+}
+
+// GetCounters returns the loadpoint's energy and cost counters
+func (lp *LoadPoint) GetCounters() Counters {
+	lp.Lock()
+	defer lp.Unlock()
+	return lp.counters
+}
+
+// ResetCounters clears the resettable energy and cost counters. Lifetime counters are kept.
+func (lp *LoadPoint) ResetCounters() {
+	lp.Lock()
+	defer lp.Unlock()
+
+	lp.log.INFO.Println("reset counters")
+
+	lp.counters.reset(lp.clock.Now())
+	lp.publishCounters(lp.counters)
+	lp.saveCounters(lp.counters)
+}
+
+// addCounters accounts the charged energy (Wh) to the counters
+func (lp *LoadPoint) addCounters(energy float64) {
+	lp.Lock()
+	defer lp.Unlock()
+
+	lp.counters.add(energy, lp.Price)
+	lp.publishCounters(lp.counters)
+}
+
+// persistCounters saves the current counters, e.g. when charging stops
+func (lp *LoadPoint) persistCounters() {
+	lp.Lock()
+	defer lp.Unlock()
+	lp.saveCounters(lp.counters)
+}
+
+// saveCounters persists the counters. Errors are only logged.
+func (lp *LoadPoint) saveCounters(c Counters) {
+	if err := lp.store.SaveCounters(lp.ChargerRef, c); err != nil {
+		lp.log.ERROR.Printf("cannot persist counters: %v", err)
+	}
+}
+
+// publishCounters publishes the counters, energy in Wh like the session energy
+func (lp *LoadPoint) publishCounters(c Counters) {
+	lp.publish("lifetimeEnergy", 1e3*c.LifetimeEnergy)
+	lp.publish("lifetimeCost", c.LifetimeCost)
+	lp.publish("counterEnergy", 1e3*c.Energy)
+	lp.publish("counterCost", c.Cost)
+}
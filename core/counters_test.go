@@ -0,0 +1,71 @@
+package core
+
+import (
+	"math"
+	"testing"
+
+	"github.com/andig/evcc/util"
+	"github.com/benbjohnson/clock"
+)
+
+func TestCounters(t *testing.T) {
+	clck := clock.NewMock()
+
+	uiChan := make(chan util.Param)
+	go func() {
+		for range uiChan {
+		}
+	}()
+	defer close(uiChan)
+
+	lp := &LoadPoint{
+		log:    util.NewLogger("foo"),
+		clock:  clck,
+		uiChan: uiChan,
+		Price:  0.3,
+	}
+
+	// charge rater restarts at charge start and reports the charged energy
+	charge := func(energy float64) {
+		lp.chargedEnergy = 0
+		lp.updateSession(0)
+		lp.chargedEnergy = energy
+		lp.updateSession(0)
+	}
+
+	expect := func(lifetimeEnergy, energy float64) {
+		t.Helper()
+
+		c := lp.GetCounters()
+		if math.Abs(c.LifetimeEnergy-lifetimeEnergy) > 1e-9 || math.Abs(c.LifetimeCost-0.3*lifetimeEnergy) > 1e-9 {
+			t.Errorf("lifetime: expected %.1fkWh, got %.1fkWh %.2f", lifetimeEnergy, c.LifetimeEnergy, c.LifetimeCost)
+		}
+		if math.Abs(c.Energy-energy) > 1e-9 || math.Abs(c.Cost-0.3*energy) > 1e-9 {
+			t.Errorf("counter: expected %.1fkWh, got %.1fkWh %.2f", energy, c.Energy, c.Cost)
+		}
+	}
+
+	// first session
+	charge(11000)
+	expect(11, 11)
+
+	// counters survive the session reset on disconnect
+	lp.resetSession()
+	charge(1850)
+	expect(12.85, 12.85)
+
+	// reset keeps lifetime counters
+	lp.ResetCounters()
+	expect(12.85, 0)
+
+	if c := lp.GetCounters(); !c.Reset.Equal(clck.Now()) {
+		t.Errorf("expected reset time %v, got %v", clck.Now(), c.Reset)
+	}
+
+	// no energy is accounted while paused
+	lp.updateSession(0)
+	expect(12.85, 0)
+
+	charge(7400)
+	expect(20.25, 7.4)
+}
@@ -0,0 +1,41 @@
+package core
+
+import (
+	"math"
+	"time"
+
+	"github.com/benbjohnson/clock"
+)
+
+// ema is a time-based exponential moving average low-pass filter.
+// After one time constant tau, 63% of a step change have propagated to the output, 95% after 3 tau.
+type ema struct {
+	clock   clock.Clock
+	tau     time.Duration
+	value   float64
+	updated time.Time
+}
+
+// newEMA creates a filter with time constant tau
+func newEMA(clock clock.Clock, tau time.Duration) *ema {
+	return &ema{
+		clock: clock,
+		tau:   tau,
+	}
+}
+
+// Add adds a raw value and returns the filtered value
+func (f *ema) Add(value float64) float64 {
+	now := f.clock.Now()
+
+	if f.tau <= 0 || f.updated.IsZero() {
+		f.value = value
+	} else {
+		alpha := 1 - math.Exp(-float64(now.Sub(f.updated))/float64(f.tau))
+		f.value += alpha * (value - f.value)
+	}
+
+	f.updated = now
+
+	return f.value
+}
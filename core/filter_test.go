@@ -0,0 +1,65 @@
+package core
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+)
+
+func TestEMANoise(t *testing.T) {
+	clck := clock.NewMock()
+	f := newEMA(clck, 30*time.Second)
+
+	// noisy series jittering +/-500W around -2000W
+	series := []float64{-2000, -1500, -2500, -1500, -2500, -1500, -2500, -1500, -2500, -1500, -2500}
+
+	var res float64
+	for i, v := range series {
+		res = f.Add(v)
+		clck.Add(10 * time.Second)
+
+		if i > 0 && math.Abs(res+2000) >= 500 {
+			t.Errorf("%d: expected smoothed value, got %.0f", i, res)
+		}
+	}
+
+	if math.Abs(res+2000) > 200 {
+		t.Errorf("expected value close to -2000, got %.0f", res)
+	}
+}
+
+func TestEMAStep(t *testing.T) {
+	clck := clock.NewMock()
+	tau := 20 * time.Second
+	f := newEMA(clck, tau)
+
+	// first value is not filtered
+	if res := f.Add(-3000); res != -3000 {
+		t.Errorf("expected -3000, got %.0f", res)
+	}
+
+	// cloud: surplus drops to zero
+	clck.Add(tau)
+	if res := f.Add(0); math.Abs(res+3000*math.Exp(-1)) > 1 {
+		t.Errorf("expected 63%% step after tau, got %.0f", res)
+	}
+
+	clck.Add(2 * tau)
+	if res := f.Add(0); res < -150 {
+		t.Errorf("expected 95%% step after 3 tau, got %.0f", res)
+	}
+}
+
+func TestEMADisabled(t *testing.T) {
+	clck := clock.NewMock()
+	f := newEMA(clck, 0)
+
+	for _, v := range []float64{100, -500, 2000} {
+		clck.Add(time.Second)
+		if res := f.Add(v); res != v {
+			t.Errorf("expected %.0f, got %.0f", v, res)
+		}
+	}
+}
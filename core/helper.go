@@ -15,11 +15,32 @@ var (
 
 	// Voltage global value
 	Voltage float64
+
+	// DryRun global value. Decisions are logged but not written to chargers.
+	DryRun bool
 )
 
 // powerToCurrent is a helper function to convert power to per-phase current
-func powerToCurrent(power float64, phases int64) int64 {
-	return int64(math.Floor(power / (float64(phases) * Voltage)))
+func powerToCurrent(power, voltage float64, phases int64) int64 {
+	return int64(math.Floor(power / (float64(phases) * voltage)))
+}
+
+// requiredEnergy returns the energy in kWh required for charging from soc to target soc
+func requiredEnergy(soc, targetSoC float64, capacity int64) float64 {
+	if soc >= targetSoC {
+		return 0
+	}
+
+	return (targetSoC - soc) / 100 * float64(capacity)
+}
+
+// targetRange extrapolates the range at current soc to the target soc
+func targetRange(rng int64, soc, targetSoC float64) int64 {
+	if soc >= targetSoC {
+		return rng
+	}
+
+	return int64(math.Round(float64(rng) * targetSoC / soc))
 }
 
 // consumedPower estimates how much power the charger might have consumed given it was the only load
@@ -0,0 +1,66 @@
+package core
+
+import (
+	"errors"
+	"math"
+
+	"github.com/andig/evcc/api"
+)
+
+// homePower calculates the household consumption without charging loadpoints.
+// Grid import, pv generation and battery discharge are supplied to the home,
+// missing meters report zero. Negative values due to unsynchronized readings are zero.
+func homePower(grid, pv, battery float64, charge ...float64) float64 {
+	res := grid + pv + battery
+	for _, p := range charge {
+		res -= p
+	}
+
+	return math.Max(res, 0)
+}
+
+// homeMeter is a virtual meter providing the site's home consumption
+type homeMeter struct {
+	site *Site
+}
+
+// CurrentPower implements the Meter.CurrentPower interface
+func (m *homeMeter) CurrentPower() (float64, error) {
+	m.site.mux.Lock()
+	defer m.site.mux.Unlock()
+
+	if !m.site.homeValid {
+		return 0, errors.New("home power not available")
+	}
+
+	return m.site.homePower, nil
+}
+
+// HomeMeter returns a virtual meter providing the home consumption as
+// grid + pv + battery - charge power of all loadpoints
+func (site *Site) HomeMeter() api.Meter {
+	return &homeMeter{site: site}
+}
+
+// updateHomePower calculates and publishes the home consumption from the last meter readings
+func (site *Site) updateHomePower(valid bool) {
+	var power float64
+	if valid {
+		charge := make([]float64, 0, len(site.loadpoints))
+		for _, lp := range site.loadpoints {
+			charge = append(charge, lp.chargePower)
+		}
+
+		power = homePower(site.gridPower, site.pvPower, site.batteryPower, charge...)
+	}
+
+	site.mux.Lock()
+	site.homePower = power
+	site.homeValid = valid
+	site.mux.Unlock()
+
+	if valid {
+		site.log.DEBUG.Printf("home power: %.0fW", power)
+		site.publish("homePower", power)
+	}
+}
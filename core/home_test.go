@@ -0,0 +1,62 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/andig/evcc/util"
+)
+
+func TestHomePower(t *testing.T) {
+	tc := []struct {
+		grid, pv, battery float64
+		charge            []float64
+		home              float64
+	}{
+		{500, 0, 0, nil, 500},                          // grid only, no pv meter
+		{-2000, 3000, 0, nil, 1000},                    // pv export
+		{-2000, 3000, 0, []float64{1000}, 0},           // pv export while charging
+		{1000, 5000, 0, []float64{5500}, 500},          // charging with grid import
+		{0, 2000, -1500, nil, 500},                     // battery charging
+		{0, 0, 800, nil, 800},                          // battery discharging at night
+		{200, 4000, -1000, []float64{1400, 1600}, 200}, // multiple loadpoints
+		{-500, 0, 0, []float64{100}, 0},                // unsynchronized readings
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		if home := homePower(tc.grid, tc.pv, tc.battery, tc.charge...); home != tc.home {
+			t.Errorf("expected %.0fW, got %.0fW", tc.home, home)
+		}
+	}
+}
+
+func TestHomeMeter(t *testing.T) {
+	site := &Site{
+		log:          util.NewLogger("foo"),
+		gridPower:    1000,
+		pvPower:      4000,
+		batteryPower: -500,
+		loadpoints: []*LoadPoint{
+			{chargePower: 3000},
+		},
+	}
+
+	m := site.HomeMeter()
+
+	// not yet updated
+	if _, err := m.CurrentPower(); err == nil {
+		t.Error("expected error")
+	}
+
+	site.updateHomePower(true)
+	if p, err := m.CurrentPower(); err != nil || p != 1500 {
+		t.Errorf("expected 1500W, got %.0fW %v", p, err)
+	}
+
+	// site power unavailable
+	site.updateHomePower(false)
+	if _, err := m.CurrentPower(); err == nil {
+		t.Error("expected error")
+	}
+}
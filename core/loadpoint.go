@@ -1,12 +1,16 @@
 package core
 
 import (
+	"fmt"
+	"math"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/andig/evcc/api"
 	"github.com/andig/evcc/core/wrapper"
+	"github.com/andig/evcc/provider"
 	"github.com/andig/evcc/push"
 	"github.com/andig/evcc/util"
 	"github.com/pkg/errors"
@@ -17,14 +21,81 @@ import (
 )
 
 const (
-	evChargeStart       = "start"      // update chargeTimer
-	evChargeStop        = "stop"       // update chargeTimer
-	evChargeCurrent     = "current"    // update fakeChargeMeter
-	evChargePower       = "power"      // update chargeRater
-	evVehicleConnect    = "connect"    // vehicle connected
-	evVehicleDisconnect = "disconnect" // vehicle disconnected
-
-	minActiveCurrent = 1.0 // minimum current at which a phase is treated as active
+	evChargeStart       = "start"       // update chargeTimer
+	evChargeStop        = "stop"        // update chargeTimer
+	evChargeCurrent     = "current"     // update fakeChargeMeter
+	evChargePower       = "power"       // update chargeRater
+	evVehicleConnect    = "connect"     // vehicle connected
+	evVehicleDisconnect = "disconnect"  // vehicle disconnected
+	evChargeComplete    = "complete"    // target soc reached
+	evChargerError      = "error"       // charger communication failed
+	evVentilation       = "ventilation" // charging with ventilation (status D) rejected
+	evChargerDegraded   = "degraded"    // charger failed repeatedly, charging disabled
+
+	minActiveCurrent    = 1.0              // minimum current at which a phase is treated as active
+	phaseDetectionDelay = 30 * time.Second // currents are still ramping up after charge start
+	singlePhaseWarning  = 5 * time.Minute  // persistent single phase charging on 3p loadpoint before warning
+
+	defaultPhaseSwitchCooldown = 5 * time.Minute // min time between phase switches to protect the charger's contactors
+	phaseSwitchMargin          = 1               // A, current above min current required for switching back to 3p
+
+	defaultClimatePower = 1000 // W, climate power assumed if boost is enabled without configured power
+
+	defaultDimmingPower = 4200 // W, minimum charge power guaranteed by grid operator while dimmed (§14a EnWG)
+
+	defaultErrorThreshold = 5 // consecutive charger errors before loadpoint is degraded
+
+	defaultErrorCooldown = time.Minute // time after recovering from degraded state before charging is re-enabled
+	maxErrorCooldown     = time.Hour   // max cool-down after repeated degradation
+
+	completeStop   = "stop"   // disable charger when target soc is reached
+	completeHold   = "hold"   // keep charger enabled at min current when target soc is reached
+	completeNotify = "notify" // disable charger and send notification when target soc is reached
+
+	offDisable = "disable" // disable charger in off mode
+	offHold    = "hold"    // keep charger enabled at min current in off mode
+
+	belowMinStop = "stop" // disable charger after disable delay if pv surplus is below min current
+	belowMinHold = "hold" // keep charging at min current if pv surplus is below min current
+
+	strategyFailover = "failover" // control next charger if the active charger fails
+
+	socErrorContinue = "continue" // ignore soc and continue pv/grid logic if vehicle soc is unavailable
+	socErrorPause    = "pause"    // disable charger if vehicle soc is unavailable
+
+	socErrorGracePeriod = 5 * time.Minute // time the vehicle soc must be unavailable before charging is paused
+
+	wakeupToggle  = "toggle"  // toggle charger enable to wake up vehicle
+	wakeupVehicle = "vehicle" // wake up vehicle using vehicle api
+
+	defaultWakeupTimeout = time.Minute // time without charging after enable before waking up the vehicle
+	defaultWakeupRetries = 3           // wakeup attempts per charging session
+
+	stateDisconnected = "disconnected" // vehicle not connected
+	stateRejected     = "rejected"     // charging with ventilation rejected
+	stateError        = "error"        // charger communication failed
+	stateLimited      = "limited"      // session energy limit reached
+	stateComplete     = "complete"     // target soc or energy reached
+	stateOff          = "off"          // off mode
+	stateIdle         = "idle"         // pv mode without sufficient surplus
+	stateEnabling     = "enabling"     // pv enable timer running
+	stateEnabled      = "enabled"      // charging enabled
+	stateDisabling    = "disabling"    // pv disable timer running
+	stateCooldown     = "cooldown"     // charger recovered from repeated errors, waiting before re-enabling
+	statePaused       = "paused"       // vehicle soc unavailable
+	stateSwitching    = "switching"    // charging paused for phase switching
+
+	maxTransitions = 20 // state transitions kept for the api
+
+	displayInterval = time.Minute // min time between charger display updates to avoid flicker
+
+	defaultFallbackHold = 5 * time.Minute // time to hold the last current while site power is unavailable
+	defaultBoost        = time.Hour       // boost duration if not requested otherwise
+
+	defaultCapacity int64 = 50 // kWh, used if vehicle capacity is not configured
+
+	iecMinCurrent int64 = 6  // A, min current signalled according to IEC 61851
+	iecMaxCurrent int64 = 80 // A, max current signalled according to IEC 61851
 )
 
 // ThresholdConfig defines enable/disable hysteresis parameters
@@ -48,22 +119,82 @@ type LoadPoint struct {
 	Mode       api.ChargeMode `mapstructure:"mode"`      // Charge mode, guarded by mutex
 	TargetSoC  int            `mapstructure:"targetSoC"` // Target SoC, guarded by mutex
 
-	Title      string `mapstructure:"title"`   // UI title
-	Phases     int64  `mapstructure:"phases"`  // Phases- required for converting power and current
-	ChargerRef string `mapstructure:"charger"` // Charger reference
-	VehicleRef string `mapstructure:"vehicle"` // Vehicle reference
-	Meters     struct {
+	PersistMode bool `mapstructure:"persistMode"` // Restore the last runtime mode at startup instead of the configured mode
+
+	Title       string        `mapstructure:"title"`    // UI title
+	Interval    time.Duration `mapstructure:"interval"` // Update interval, defaults to site interval
+	Phases      int64         `mapstructure:"phases"`   // Phases- required for converting power and current
+	ChargerRef  string        `mapstructure:"charger"`  // Charger reference
+	ChargerRefs []string      `mapstructure:"chargers"` // Additional chargers, see ChargerStrategy
+	VehicleRef  string        `mapstructure:"vehicle"`  // Vehicle reference
+	VehicleRefs []string      `mapstructure:"vehicles"` // Additional vehicles selectable at runtime
+	Meters      struct {
 		ChargeMeterRef string `mapstructure:"charge"` // Charge meter reference
 	}
 	SoC struct {
 		AlwaysUpdate bool  `mapstructure:"alwaysUpdate"`
 		Levels       []int `mapstructure:"levels"`
+		Capacity     int64 `mapstructure:"capacity"` // Battery capacity (kWh) for offline soc estimation without vehicle
+		Start        int   `mapstructure:"start"`    // Assumed soc when plugging in for offline soc estimation
+		Min          int   `mapstructure:"min"`      // Minimum soc, always charged from grid
 	}
 	OnDisconnect struct {
 		Mode      api.ChargeMode `mapstructure:"mode"`      // Charge mode to apply when car disconnected
 		TargetSoC int            `mapstructure:"targetSoC"` // Target SoC to apply when car disconnected
 	}
-	Enable, Disable ThresholdConfig
+	Climate struct {
+		Boost bool    `mapstructure:"boost"` // Raise pv allowance while vehicle is preconditioning
+		Power float64 `mapstructure:"power"` // Climate power (W) added to available pv power
+	}
+	Wakeup struct {
+		Strategy string        `mapstructure:"strategy"` // Wakeup strategy for vehicles sleeping on the connector
+		Timeout  time.Duration `mapstructure:"timeout"`  // Time without charging after enable before waking up
+		Retries  int           `mapstructure:"retries"`  // Max wakeup attempts
+	}
+	Dimming struct {
+		Signal provider.Config `mapstructure:"signal"` // Grid operator dimming signal (§14a EnWG)
+		Power  float64         `mapstructure:"power"`  // Max charge power (W) while dimmed
+	}
+	Tariff struct {
+		Price     provider.Config `mapstructure:"price"`     // Current energy price per kWh, e.g. spot market price
+		Threshold float64         `mapstructure:"threshold"` // Price mode charges while the price is below this threshold
+	}
+	Fallback struct {
+		Hold    time.Duration `mapstructure:"hold"`    // Time to hold the last current while site power is unavailable
+		Current int64         `mapstructure:"current"` // Safe current (A) after hold period, 0 to disable charger
+	}
+	MaxPower         float64 `mapstructure:"maxPower"`         // Max charge power (kW) converted to current using the active phases, 0 to disable
+	MaxSessionEnergy float64 `mapstructure:"maxSessionEnergy"` // Max charged energy (kWh) per session, 0 to disable
+	TargetEnergy     float64 `mapstructure:"targetEnergy"`     // Default charged energy (kWh) after which charging completes, 0 to disable
+	OnComplete       string  `mapstructure:"onComplete"`       // Action when target soc is reached
+	OnOff            string  `mapstructure:"onOff"`            // Charger behavior in off mode
+	ChargerStrategy  string  `mapstructure:"chargerStrategy"`  // Selection strategy for multiple chargers
+	Ventilation      bool    `mapstructure:"ventilation"`      // Allow charging with ventilation (status D)
+	VehicleControl   bool    `mapstructure:"vehicleControl"`   // Start/stop charging using vehicle api
+	ErrorThreshold   int     `mapstructure:"errorThreshold"`   // Consecutive charger errors before loadpoint is degraded, 0 to disable
+	Enable, Disable  ThresholdConfig
+
+	ErrorCooldown time.Duration `mapstructure:"errorCooldown"` // Initial cool-down after degradation before re-enabling, doubled on repeated degradation, 0 to disable
+
+	BoostDuration time.Duration   `mapstructure:"boostDuration"` // Default duration of charging at max current on request
+	Webhooks      []WebhookConfig `mapstructure:"webhooks"`      // Webhooks notified on charger status transitions
+
+	Voltage float64 `mapstructure:"voltage"` // Nominal voltage for power estimation from charger currents, defaults to site voltage
+
+	ForceSinglePhase bool `mapstructure:"forceSinglePhase"` // Treat the vehicle as single phase regardless of charger phases
+
+	PhaseSwitching      bool          `mapstructure:"phaseSwitching"`      // Switch between 1 and 3 phases in pv modes depending on surplus
+	PhaseSwitchCooldown time.Duration `mapstructure:"phaseSwitchCooldown"` // Min time between phase switches, independent of pv enable/disable delays
+
+	OnBelowMin string `mapstructure:"onBelowMin"` // PV mode behavior when surplus drops below min current while charging
+
+	Debounce time.Duration `mapstructure:"debounce"` // Time a connect/disconnect status change must be stable before it is applied, 0 to disable
+
+	Price float64 `mapstructure:"price"` // Energy price per kWh for the charging cost counters
+
+	OnSoCError string `mapstructure:"onSoCError"` // Behavior if the vehicle soc can't be read
+
+	Display bool `mapstructure:"display"` // Show the charging state on the charger's display if supported
 
 	handler       Handler
 	HandlerConfig `mapstructure:",squash"` // handle charger state and current
@@ -71,19 +202,86 @@ type LoadPoint struct {
 	chargeTimer api.ChargeTimer
 	chargeRater api.ChargeRater
 
+	dimmingG func() (bool, error) // Grid operator dimming signal
+	dimmed   bool                 // Dimming signal active
+
+	priceG        func() (float64, error) // Tariff price for price mode
+	priceCharging bool                    // Last price mode decision
+
 	chargeMeter api.Meter   // Charger usage meter
-	vehicle     api.Vehicle // Vehicle
+	vehicle     api.Vehicle // Vehicle, written under mutex
+
+	vehicles        map[string]api.Vehicle  // Vehicles selectable at runtime
+	vehicleName     string                  // Name of the active vehicle
+	selectedVehicle string                  // Vehicle selected at runtime, guarded by mutex
+	battery         api.Battery             // Charger-reported vehicle soc (ISO 15118)
+	chargerPhases   api.ChargePhases        // Charger-reported energized phases
+	phaseSwitcher   api.ChargePhaseSwitcher // Charger 1p/3p switching
+
+	socEstimator *SoCEstimator // Vehicle soc interpolation
+
+	webhooks []*webhook // Charger status webhooks
+
+	display        api.ChargerDisplay // Charger display
+	displayText    string             // Last text shown on the charger display
+	displayUpdated time.Time          // Time of last charger display update
 
 	// cached state
-	status        api.ChargeStatus // Charger status
-	charging      bool             // Charging cycle
-	chargePower   float64          // Charging power
-	connectedTime time.Time        // Time when vehicle was connected
-	pvTimer       time.Time        // PV enabled/disable timer
+	status         api.ChargeStatus // Charger status
+	pendingStatus  api.ChargeStatus // Connect/disconnect status change waiting for debounce
+	pendingSince   time.Time        // Time since pending status is reported
+	charging       bool             // Charging cycle
+	activePhases   int64            // Detected phases used by the vehicle, 0 if unknown
+	singlePhase    time.Time        // Time since single phase charging is detected on 3p loadpoint
+	switchedPhases int64            // Phases selected by phase switching, 0 if not switched
+	phaseSwitched  time.Time        // Time of the last phase switch
+	phaseWarned    bool             // Single phase warning has been logged
+	chargeStarted  time.Time        // Time when charging cycle started
+	chargePower    float64          // Charging power
+	connectedTime  time.Time        // Time when vehicle was connected
+	updated        time.Time        // Time of last scheduled update
+	pvTimer        time.Time        // PV enabled/disable timer
+	completed      bool             // Target soc reached
+	chargerError   bool             // Charger communication failed
+	chargerErrors  int              // Consecutive charger errors
+	degraded       bool             // Charging disabled due to repeated charger errors
+	degradations   int              // Consecutive degradations without stable operation in between
+	cooldownUntil  time.Time        // End of cool-down after recovering from degraded state
+	ventRejected   bool             // Charging with ventilation rejected
+	wakeupTimer    time.Time        // Time since charger enabled without charging
+	wakeups        int              // Wakeup attempts since charging
+	wakeupDone     chan struct{}    // Closed when the pending vehicle wakeup request has returned
+	siteFailure    time.Time        // Time since site power is unavailable
+	state          string           // Charging decision of the current cycle
+	stateReason    string           // Reason of the charging decision
+	prevState      string           // Charging decision of the previous cycle
+	stateSince     time.Time        // Time of the last state transition, guarded by mutex
+	transitions    []Transition     // Recent state transitions, guarded by mutex
 
 	socCharge      float64       // Vehicle SoC
+	socError       error         // Vehicle soc error of the current cycle, nil if soc is valid
+	socFailure     time.Time     // Time since vehicle soc is unavailable
+	chargeLimit    int64         // Vehicle-side charge limit, 0 if unknown
+	startSoC       int           // Offline estimation start soc, guarded by mutex
+	targetEnergy   float64       // Session target energy (kWh), guarded by mutex
+	targetTime     time.Time     // Time at which target soc should be reached, zero if not planned, guarded by mutex
 	chargedEnergy  float64       // Charged energy while connected
 	chargeDuration time.Duration // Charge duration
+
+	boostUntil time.Time      // End of boost, zero if not boosting, guarded by mutex
+	boostMode  api.ChargeMode // Mode restored after boost, guarded by mutex
+
+	// session counters, accumulated across charging pauses until disconnect
+	sessionEnergy   float64       // Charged energy of current session (Wh)
+	sessionSolar    float64       // Charged energy of current session not imported from grid (Wh)
+	sessionDuration time.Duration // Charge duration of current session
+	sessionRated    float64       // Charged energy at last session update (Wh)
+	sessionTimed    time.Duration // Charge duration at last session update
+	sessionSynced   bool          // Charged energy and duration of last session update are valid
+	sessionLimited  bool          // Session energy cap reached
+
+	counters Counters    // Energy and cost counters across sessions, guarded by mutex
+	store    *stateStore // Persistent loadpoint state
 }
 
 // NewLoadPointFromConfig creates a new loadpoint
@@ -94,6 +292,9 @@ func NewLoadPointFromConfig(log *util.Logger, cp configProvider, other map[strin
 	}
 
 	// set sane defaults
+	if mode := api.ChargeModeString(string(lp.Mode)); lp.Mode != "" && string(mode) != strings.ToLower(string(lp.Mode)) {
+		lp.log.FATAL.Fatalf("invalid mode: %s", lp.Mode)
+	}
 	lp.Mode = api.ChargeModeString(string(lp.Mode))
 	lp.OnDisconnect.Mode = api.ChargeModeString(string(lp.OnDisconnect.Mode))
 
@@ -111,41 +312,293 @@ func NewLoadPointFromConfig(log *util.Logger, cp configProvider, other map[strin
 	}
 	if lp.VehicleRef != "" {
 		lp.vehicle = cp.Vehicle(lp.VehicleRef)
+
+		if lp.vehicle.Capacity() <= 0 {
+			lp.log.WARN.Printf("vehicle capacity not configured, assuming %dkWh", defaultCapacity)
+		}
+
+		lp.socEstimator = NewSoCEstimator(lp.log, lp.capacity())
 	}
 
-	if lp.ChargerRef == "" {
-		lp.log.FATAL.Fatal("missing charger")
+	// configured vehicle is the default of the selectable vehicles
+	lp.vehicleName = lp.VehicleRef
+	if len(lp.VehicleRefs) > 0 {
+		lp.vehicles = make(map[string]api.Vehicle)
+		if lp.vehicle != nil {
+			lp.vehicles[lp.VehicleRef] = lp.vehicle
+		}
+		for _, ref := range lp.VehicleRefs {
+			lp.vehicles[ref] = cp.Vehicle(ref)
+		}
+	}
+
+	lp.targetEnergy = lp.TargetEnergy
+
+	if lp.Price < 0 {
+		lp.log.FATAL.Fatalf("invalid price: %.2f", lp.Price)
+	}
+
+	store, err := sharedStateStore()
+	if err != nil {
+		lp.log.ERROR.Printf("cannot load state: %v", err)
+	}
+	lp.store = store
+	lp.counters = store.LoadCounters(lp.ChargerRef)
+
+	if lp.PersistMode {
+		if store == nil {
+			lp.log.WARN.Println("persisting mode requires state file")
+		} else if mode := store.LoadMode(lp.ChargerRef); mode != "" {
+			lp.log.INFO.Printf("restored mode: %s", mode)
+			lp.Mode = api.ChargeModeString(string(mode))
+		}
+	}
+
+	if lp.offline() {
+		lp.log.INFO.Printf("offline soc estimation: %dkWh, start soc %d%%", lp.SoC.Capacity, lp.SoC.Start)
+		lp.startSoC = lp.SoC.Start
+	}
+
+	if lp.Climate.Boost {
+		if _, ok := lp.vehicle.(api.VehicleClimater); !ok {
+			lp.log.WARN.Println("climate boost requires vehicle with climate status")
+		}
+
+		if lp.Climate.Power == 0 {
+			lp.Climate.Power = defaultClimatePower
+		}
 	}
-	charger := cp.Charger(lp.ChargerRef)
+
+	charger := lp.configureChargers(cp)
 	lp.configureChargerType(charger)
 
+	if _, ok := charger.(api.ChargerDisplay); lp.Display && !ok {
+		lp.log.WARN.Println("display requires charger with display support")
+		lp.Display = false
+	}
+
+	switch lp.Wakeup.Strategy {
+	case "":
+	case wakeupToggle, wakeupVehicle:
+		if _, ok := lp.vehicle.(api.VehicleWakeUp); !ok && lp.Wakeup.Strategy == wakeupVehicle {
+			lp.log.FATAL.Fatal("vehicle wakeup requires vehicle with wakeup support")
+		}
+
+		if lp.Wakeup.Timeout == 0 {
+			lp.Wakeup.Timeout = defaultWakeupTimeout
+		}
+		if lp.Wakeup.Retries == 0 {
+			lp.Wakeup.Retries = defaultWakeupRetries
+		}
+	default:
+		lp.log.FATAL.Fatalf("invalid wakeup strategy: %s", lp.Wakeup.Strategy)
+	}
+
+	if lp.Dimming.Signal.Type != "" {
+		var err error
+		if lp.dimmingG, err = provider.NewBoolGetterFromConfig(lp.Dimming.Signal); err != nil {
+			lp.log.FATAL.Fatalf("invalid dimming signal: %v", err)
+		}
+
+		if lp.Dimming.Power == 0 {
+			lp.Dimming.Power = defaultDimmingPower
+		}
+	}
+
+	if lp.Tariff.Price.Type != "" {
+		var err error
+		if lp.priceG, err = provider.NewFloatGetterFromConfig(lp.Tariff.Price); err != nil {
+			lp.log.FATAL.Fatalf("invalid tariff price: %v", err)
+		}
+	}
+
+	if lp.Mode == api.ModePrice && lp.priceG == nil {
+		lp.log.FATAL.Fatal("price mode requires tariff price")
+	}
+
+	if lp.Fallback.Hold == 0 {
+		lp.Fallback.Hold = defaultFallbackHold
+	}
+	if lp.Fallback.Current != 0 && (lp.Fallback.Current < lp.MinCurrent || lp.Fallback.Current > lp.MaxCurrent) {
+		lp.log.FATAL.Fatalf("fallback current %dA must be within min/max current", lp.Fallback.Current)
+	}
+
+	if lp.MaxPower < 0 {
+		lp.log.FATAL.Fatalf("invalid max power: %.1fkW", lp.MaxPower)
+	}
+
+	if lp.Voltage < 0 {
+		lp.log.FATAL.Fatalf("invalid voltage: %.0fV", lp.Voltage)
+	}
+
+	if lp.BoostDuration <= 0 {
+		lp.BoostDuration = defaultBoost
+	}
+
+	for _, cc := range lp.Webhooks {
+		h, err := newWebhook(lp.log, cc)
+		if err != nil {
+			lp.log.FATAL.Fatal(err)
+		}
+		lp.webhooks = append(lp.webhooks, h)
+	}
+
+	if lp.SoC.Min > 0 && !lp.hasSoC() {
+		lp.log.WARN.Println("minimum soc requires vehicle or charger soc")
+	}
+
+	switch lp.OnComplete {
+	case "", completeStop, completeHold, completeNotify:
+	default:
+		lp.log.FATAL.Fatalf("invalid onComplete action: %s", lp.OnComplete)
+	}
+
+	switch lp.OnOff {
+	case "", offDisable, offHold:
+	default:
+		lp.log.FATAL.Fatalf("invalid onOff behavior: %s", lp.OnOff)
+	}
+
+	switch lp.OnSoCError {
+	case "", socErrorContinue, socErrorPause:
+	default:
+		lp.log.FATAL.Fatalf("invalid onSoCError behavior: %s", lp.OnSoCError)
+	}
+
+	switch lp.OnBelowMin {
+	case "", belowMinStop, belowMinHold:
+	default:
+		lp.log.FATAL.Fatalf("invalid onBelowMin behavior: %s", lp.OnBelowMin)
+	}
+
 	if lp.Enable.Threshold > lp.Disable.Threshold {
 		log.WARN.Printf("PV mode enable threshold (%.0fW) is larger than disable threshold (%.0fW)", lp.Enable.Threshold, lp.Disable.Threshold)
 	}
 
-	lp.handler = &ChargerHandler{
+	warnings, err := checkCurrents(lp.HandlerConfig, charger)
+	for _, w := range warnings {
+		lp.log.WARN.Println(w)
+	}
+	if err != nil {
+		lp.log.FATAL.Fatal(err)
+	}
+
+	if lp.PhaseSwitching {
+		if _, ok := charger.(api.ChargePhaseSwitcher); !ok || lp.Phases != 3 || lp.ForceSinglePhase {
+			lp.log.FATAL.Fatal("phase switching requires 3p charger with phase switching support")
+		}
+
+		if lp.PhaseSwitchCooldown == 0 {
+			lp.PhaseSwitchCooldown = defaultPhaseSwitchCooldown
+		}
+	}
+
+	if lp.ForceSinglePhase && lp.Phases != 1 {
+		lp.log.INFO.Printf("single phase vehicle: ignoring %dp charger", lp.Phases)
+	}
+
+	// relay-switched chargers charge at fixed current, pv mode requires surplus to exceed the fixed draw
+	if lp.Relay {
+		lp.log.INFO.Printf("relay charger: fixed charge current %dA", lp.MaxCurrent)
+		lp.MinCurrent = lp.MaxCurrent
+	}
+
+	handler := &ChargerHandler{
 		log:           lp.log,
 		clock:         lp.clock,
 		bus:           lp.bus,
 		charger:       charger,
 		HandlerConfig: lp.HandlerConfig,
+		dryRun:        DryRun,
+	}
+
+	if lp.VehicleControl {
+		vc, ok := lp.vehicle.(api.VehicleChargeController)
+		if !ok {
+			lp.log.FATAL.Fatal("vehicle control requires vehicle with charge start/stop support")
+		}
+		handler.vehicle = vc
+
+		if len(lp.VehicleRefs) > 0 {
+			lp.log.WARN.Println("vehicle control only applies to the configured vehicle")
+		}
 	}
 
+	// phases and display are written by the handler to honor dry-run mode
+	if lp.PhaseSwitching {
+		lp.phaseSwitcher = handler
+	}
+	if lp.Display {
+		lp.display = handler
+	}
+
+	lp.handler = handler
+
 	return lp
 }
 
+// checkCurrents validates min and max current against IEC 61851 and the charger-reported current limit.
+// Invalid configurations are returned as error, questionable ones as warnings.
+func checkCurrents(cfg HandlerConfig, charger api.Charger) ([]string, error) {
+	if cfg.MinCurrent <= 0 || cfg.MaxCurrent <= 0 {
+		return nil, fmt.Errorf("min current %dA and max current %dA must be positive", cfg.MinCurrent, cfg.MaxCurrent)
+	}
+
+	if cfg.MinCurrent > cfg.MaxCurrent {
+		return nil, fmt.Errorf("min current %dA exceeds max current %dA", cfg.MinCurrent, cfg.MaxCurrent)
+	}
+
+	var warnings []string
+
+	// relay-switched chargers don't signal the current
+	if !cfg.Relay {
+		if cfg.MinCurrent < iecMinCurrent {
+			warnings = append(warnings, fmt.Sprintf("min current %dA is below %dA, vehicles may not charge", cfg.MinCurrent, iecMinCurrent))
+		}
+
+		if cfg.MaxCurrent > iecMaxCurrent {
+			warnings = append(warnings, fmt.Sprintf("max current %dA exceeds %dA", cfg.MaxCurrent, iecMaxCurrent))
+		}
+	}
+
+	cl, ok := charger.(api.CurrentLimiter)
+	if !ok {
+		return warnings, nil
+	}
+
+	limit, err := cl.CurrentLimit()
+	if err != nil {
+		return append(warnings, fmt.Sprintf("cannot read charger current limit: %v", err)), nil
+	}
+
+	// limits below min current are reported while no cable is connected
+	if limit < iecMinCurrent {
+		return warnings, nil
+	}
+
+	if cfg.MinCurrent > limit {
+		warnings = append(warnings, fmt.Sprintf("min current %dA exceeds charger current limit %dA", cfg.MinCurrent, limit))
+	} else if cfg.MaxCurrent > limit {
+		warnings = append(warnings, fmt.Sprintf("max current %dA exceeds charger current limit %dA", cfg.MaxCurrent, limit))
+	}
+
+	return warnings, nil
+}
+
 // NewLoadPoint creates a LoadPoint with sane defaults
 func NewLoadPoint(log *util.Logger) *LoadPoint {
 	clock := clock.New()
 	bus := evbus.New()
 
 	lp := &LoadPoint{
-		log:    log,   // logger
-		clock:  clock, // mockable time
-		bus:    bus,   // event bus
-		Mode:   api.ModeOff,
-		Phases: 1,
-		status: api.StatusNone,
+		log:            log,   // logger
+		clock:          clock, // mockable time
+		bus:            bus,   // event bus
+		Mode:           api.ModeOff,
+		Phases:         1,
+		status:         api.StatusNone,
+		ErrorThreshold: defaultErrorThreshold,
+		ErrorCooldown:  defaultErrorCooldown,
 		HandlerConfig: HandlerConfig{
 			MinCurrent:    6,  // A
 			MaxCurrent:    16, // A
@@ -171,14 +624,102 @@ func (lp *LoadPoint) SetMode(mode api.ChargeMode) {
 
 	lp.log.INFO.Printf("set charge mode: %s", string(mode))
 
+	// explicit mode change ends boost
+	if !lp.boostUntil.IsZero() {
+		lp.boostUntil = time.Time{}
+		lp.publish("boostUntil", lp.boostUntil)
+	}
+
 	// apply immediately
 	if lp.Mode != mode {
 		lp.Mode = mode
 		lp.publish("mode", mode)
+		lp.saveMode(mode)
 		lp.requestUpdate()
 	}
 }
 
+// saveMode persists the runtime mode if enabled. Errors are only logged.
+func (lp *LoadPoint) saveMode(mode api.ChargeMode) {
+	if !lp.PersistMode {
+		return
+	}
+
+	if err := lp.store.SaveMode(lp.ChargerRef, mode); err != nil {
+		lp.log.ERROR.Printf("cannot persist mode: %v", err)
+	}
+}
+
+// GetBoost returns the end of boost, zero if not boosting
+func (lp *LoadPoint) GetBoost() time.Time {
+	lp.Lock()
+	defer lp.Unlock()
+	return lp.boostUntil
+}
+
+// Boost charges at max current for the given duration, 0 for the configured boost duration.
+// The previous mode is restored once the boost has elapsed. Boosting again extends the boost.
+func (lp *LoadPoint) Boost(duration time.Duration) {
+	lp.Lock()
+	defer lp.Unlock()
+
+	if duration <= 0 {
+		duration = lp.BoostDuration
+	}
+
+	// keep mode to restore when extending
+	if lp.boostUntil.IsZero() {
+		lp.boostMode = lp.Mode
+	}
+
+	lp.boostUntil = lp.clock.Now().Add(duration)
+	lp.log.INFO.Printf("boost for %v, then restore %s mode", duration, lp.boostMode)
+	lp.publish("boostUntil", lp.boostUntil)
+
+	if lp.Mode != api.ModeNow {
+		lp.Mode = api.ModeNow
+		lp.publish("mode", lp.Mode)
+	}
+
+	lp.requestUpdate()
+}
+
+// CancelBoost ends the boost and restores the previous mode
+func (lp *LoadPoint) CancelBoost() {
+	lp.Lock()
+	defer lp.Unlock()
+
+	if !lp.boostUntil.IsZero() {
+		lp.log.INFO.Println("boost cancelled")
+		lp.endBoost()
+		lp.requestUpdate()
+	}
+}
+
+// updateBoost restores the previous mode once the boost has elapsed
+func (lp *LoadPoint) updateBoost() {
+	lp.Lock()
+	defer lp.Unlock()
+
+	if !lp.boostUntil.IsZero() && !lp.clock.Now().Before(lp.boostUntil) {
+		lp.log.INFO.Println("boost elapsed")
+		lp.endBoost()
+	}
+}
+
+// endBoost restores the mode from before the boost. Must be called with mutex held.
+func (lp *LoadPoint) endBoost() {
+	lp.boostUntil = time.Time{}
+	lp.publish("boostUntil", lp.boostUntil)
+
+	if lp.Mode != lp.boostMode {
+		lp.Mode = lp.boostMode
+		lp.log.INFO.Printf("set charge mode: %s", string(lp.Mode))
+		lp.publish("mode", lp.Mode)
+		lp.saveMode(lp.Mode)
+	}
+}
+
 // GetTargetSoC returns loadpoint charge targetSoC
 func (lp *LoadPoint) GetTargetSoC() int {
 	lp.Lock()
@@ -201,64 +742,270 @@ func (lp *LoadPoint) SetTargetSoC(targetSoC int) {
 	}
 }
 
-// requestUpdate requests site to update this loadpoint
-func (lp *LoadPoint) requestUpdate() {
-	select {
-	case lp.lpChan <- lp: // request loadpoint update
-	default:
-	}
+// GetStartSoC gets the start soc for offline soc estimation
+func (lp *LoadPoint) GetStartSoC() int {
+	lp.Lock()
+	defer lp.Unlock()
+	return lp.startSoC
 }
 
-// configureChargerType ensures that chargeMeter, Rate and Timer can use charger capabilities
-func (lp *LoadPoint) configureChargerType(charger api.Charger) {
-	// ensure charge meter exists
-	if lp.chargeMeter == nil {
-		if mt, ok := charger.(api.Meter); ok {
-			lp.chargeMeter = mt
-		} else {
-			mt := &wrapper.ChargeMeter{}
-			_ = lp.bus.Subscribe(evChargeCurrent, lp.evChargeCurrentHandler)
-			_ = lp.bus.Subscribe(evChargeStop, func() {
-				mt.SetPower(0)
-			})
-			lp.chargeMeter = mt
-		}
-	}
+// SetStartSoC sets the start soc for offline soc estimation, e.g. when plugging in
+func (lp *LoadPoint) SetStartSoC(startSoC int) {
+	lp.Lock()
+	defer lp.Unlock()
 
-	// ensure charge rater exists
-	if rt, ok := charger.(api.ChargeRater); ok {
-		lp.chargeRater = rt
-	} else {
-		rt := wrapper.NewChargeRater(lp.log, lp.chargeMeter)
-		_ = lp.bus.Subscribe(evChargePower, rt.SetChargePower)
-		_ = lp.bus.Subscribe(evChargeStart, rt.StartCharge)
-		_ = lp.bus.Subscribe(evChargeStop, rt.StopCharge)
-		lp.chargeRater = rt
-	}
+	lp.log.INFO.Println("set start soc:", startSoC)
 
-	// ensure charge timer exists
-	if ct, ok := charger.(api.ChargeTimer); ok {
-		lp.chargeTimer = ct
-	} else {
-		ct := wrapper.NewChargeTimer()
-		_ = lp.bus.Subscribe(evChargeStart, ct.StartCharge)
-		_ = lp.bus.Subscribe(evChargeStop, ct.StopCharge)
-		lp.chargeTimer = ct
+	// apply immediately
+	if lp.startSoC != startSoC {
+		lp.startSoC = startSoC
+		lp.publish("startSoC", startSoC)
+		lp.requestUpdate()
 	}
 }
 
-// notify sends push messages to clients
-func (lp *LoadPoint) notify(event string) {
-	lp.pushChan <- push.Event{Event: event}
+// GetTargetEnergy returns the session target energy in kWh
+func (lp *LoadPoint) GetTargetEnergy() float64 {
+	lp.Lock()
+	defer lp.Unlock()
+	return lp.targetEnergy
 }
 
-// publish sends values to UI and databases
-func (lp *LoadPoint) publish(key string, val interface{}) {
-	lp.uiChan <- util.Param{Key: key, Val: val}
-}
+// SetTargetEnergy sets the session target energy in kWh, 0 to disable.
+// The configured target energy is restored when the vehicle disconnects.
+func (lp *LoadPoint) SetTargetEnergy(targetEnergy float64) {
+	lp.Lock()
+	defer lp.Unlock()
 
-// evChargeStartHandler sends external start event
-func (lp *LoadPoint) evChargeStartHandler() {
+	lp.log.INFO.Printf("set target energy: %.1fkWh", targetEnergy)
+
+	// apply immediately
+	if lp.targetEnergy != targetEnergy {
+		lp.targetEnergy = targetEnergy
+		lp.publish("targetEnergy", 1e3*targetEnergy)
+		lp.requestUpdate()
+	}
+}
+
+// GetTargetTime returns the time at which the target soc should be reached, zero if not planned
+func (lp *LoadPoint) GetTargetTime() time.Time {
+	lp.Lock()
+	defer lp.Unlock()
+	return lp.targetTime
+}
+
+// SetTargetTime sets the time at which the target soc should be reached, zero to cancel the plan.
+// The plan is cancelled when the vehicle disconnects.
+func (lp *LoadPoint) SetTargetTime(targetTime time.Time) {
+	lp.Lock()
+	defer lp.Unlock()
+
+	if targetTime.IsZero() {
+		lp.log.INFO.Println("cancel target time")
+	} else {
+		lp.log.INFO.Printf("set target time: %s", targetTime.Round(time.Second).Local())
+	}
+
+	// apply immediately
+	if !lp.targetTime.Equal(targetTime) {
+		lp.targetTime = targetTime
+		lp.publish("targetTime", targetTime)
+		lp.requestUpdate()
+	}
+}
+
+// GetVehicle returns the name of the active vehicle
+func (lp *LoadPoint) GetVehicle() string {
+	lp.Lock()
+	defer lp.Unlock()
+	return lp.activeVehicle()
+}
+
+// activeVehicle returns the selected vehicle or the configured vehicle if none is selected
+func (lp *LoadPoint) activeVehicle() string {
+	if lp.selectedVehicle != "" {
+		return lp.selectedVehicle
+	}
+	return lp.VehicleRef
+}
+
+// SetVehicle selects the active vehicle by name. Empty name restores the configured vehicle.
+func (lp *LoadPoint) SetVehicle(name string) error {
+	if _, ok := lp.vehicles[name]; !ok && name != "" {
+		return errors.Errorf("invalid vehicle: %s", name)
+	}
+
+	lp.Lock()
+	defer lp.Unlock()
+
+	// apply immediately
+	if lp.selectedVehicle != name {
+		lp.log.INFO.Println("set vehicle:", name)
+		lp.selectedVehicle = name
+		lp.publish("vehicle", lp.activeVehicle())
+		lp.requestUpdate()
+	}
+
+	return nil
+}
+
+// syncVehicle applies the vehicle selected at runtime and resets vehicle-related state on change
+func (lp *LoadPoint) syncVehicle() {
+	lp.Lock()
+	defer lp.Unlock()
+
+	name := lp.activeVehicle()
+	if name == lp.vehicleName {
+		return
+	}
+
+	lp.vehicleName = name
+	lp.vehicle = lp.vehicles[name]
+	lp.socCharge = 0
+	lp.chargeLimit = 0
+	lp.socEstimator = nil
+
+	if lp.vehicle != nil {
+		lp.log.INFO.Printf("vehicle: %s", lp.vehicle.Title())
+		lp.socEstimator = NewSoCEstimator(lp.log, lp.capacity())
+	}
+}
+
+// requestUpdate requests site to update this loadpoint
+func (lp *LoadPoint) requestUpdate() {
+	select {
+	case lp.lpChan <- lp: // request loadpoint update
+	default:
+	}
+}
+
+// updateDue returns true if the scheduled update interval has elapsed and restarts the interval
+func (lp *LoadPoint) updateDue() bool {
+	now := lp.clock.Now()
+	if lp.Interval > 0 && !lp.updated.IsZero() && now.Sub(lp.updated) < lp.Interval {
+		return false
+	}
+
+	lp.updated = now
+	return true
+}
+
+// configureChargers returns the configured charger or combines multiple chargers according to the charger strategy
+func (lp *LoadPoint) configureChargers(cp configProvider) api.Charger {
+	var names []string
+	if lp.ChargerRef != "" {
+		names = append(names, lp.ChargerRef)
+	}
+	names = append(names, lp.ChargerRefs...)
+
+	if len(names) == 0 {
+		lp.log.FATAL.Fatal("missing charger")
+	}
+
+	if len(names) == 1 {
+		return cp.Charger(names[0])
+	}
+
+	switch lp.ChargerStrategy {
+	case "", strategyFailover:
+	default:
+		lp.log.FATAL.Fatalf("invalid charger strategy: %s", lp.ChargerStrategy)
+	}
+
+	chargers := make([]api.Charger, 0, len(names))
+	for _, name := range names {
+		chargers = append(chargers, cp.Charger(name))
+	}
+
+	lp.log.INFO.Printf("charger failover: %s", strings.Join(names, ", "))
+
+	return wrapper.NewFailoverCharger(lp.log, names, chargers)
+}
+
+// voltage returns the nominal voltage for estimating power from currents
+func (lp *LoadPoint) voltage() float64 {
+	if lp.Voltage > 0 {
+		return lp.Voltage
+	}
+	return Voltage
+}
+
+// configureChargerType ensures that chargeMeter, Rate and Timer can use charger capabilities
+func (lp *LoadPoint) configureChargerType(charger api.Charger) {
+	// ensure charge meter exists
+	if lp.chargeMeter == nil {
+		if mt, ok := charger.(api.Meter); ok {
+			lp.chargeMeter = mt
+		} else if mc, ok := charger.(api.MeterCurrent); ok {
+			lp.log.DEBUG.Println("charge meter: using charger currents")
+			lp.chargeMeter = wrapper.NewCurrentMeter(mc, lp.voltage)
+		} else {
+			mt := &wrapper.ChargeMeter{}
+			_ = lp.bus.Subscribe(evChargeCurrent, lp.evChargeCurrentHandler)
+			_ = lp.bus.Subscribe(evChargeStop, func() {
+				mt.SetPower(0)
+			})
+			lp.chargeMeter = mt
+		}
+	}
+
+	// use charger-reported soc if available
+	if b, ok := charger.(api.Battery); ok {
+		lp.battery = b
+	}
+
+	// limit phase detection to charger-reported phases if available
+	if cp, ok := charger.(api.ChargePhases); ok {
+		lp.chargerPhases = cp
+	}
+
+	// ensure charge rater exists
+	if rt, ok := charger.(api.ChargeRater); ok {
+		lp.chargeRater = rt
+	} else {
+		meter := lp.chargeMeter
+
+		// use charger's lifetime energy if charge meter doesn't provide energy
+		if _, ok := meter.(api.MeterEnergy); !ok {
+			if em, ok := charger.(api.MeterEnergy); ok {
+				lp.log.DEBUG.Println("charge rater: using charger energy")
+				meter = &struct {
+					api.Meter
+					api.MeterEnergy
+				}{meter, em}
+			}
+		}
+
+		rt := wrapper.NewChargeRater(lp.log, meter)
+		_ = lp.bus.Subscribe(evChargePower, rt.SetChargePower)
+		_ = lp.bus.Subscribe(evChargeStart, rt.StartCharge)
+		_ = lp.bus.Subscribe(evChargeStop, rt.StopCharge)
+		lp.chargeRater = rt
+	}
+
+	// ensure charge timer exists
+	if ct, ok := charger.(api.ChargeTimer); ok {
+		lp.chargeTimer = ct
+	} else {
+		ct := wrapper.NewChargeTimer()
+		_ = lp.bus.Subscribe(evChargeStart, ct.StartCharge)
+		_ = lp.bus.Subscribe(evChargeStop, ct.StopCharge)
+		lp.chargeTimer = ct
+	}
+}
+
+// notify sends push messages to clients
+func (lp *LoadPoint) notify(event string) {
+	lp.pushChan <- push.Event{Event: event}
+}
+
+// publish sends values to UI and databases
+func (lp *LoadPoint) publish(key string, val interface{}) {
+	lp.uiChan <- util.Param{Key: key, Val: val}
+}
+
+// evChargeStartHandler sends external start event
+func (lp *LoadPoint) evChargeStartHandler() {
 	lp.log.INFO.Println("start charging ->")
 	lp.notify(evChargeStart)
 }
@@ -267,6 +1014,7 @@ func (lp *LoadPoint) evChargeStartHandler() {
 func (lp *LoadPoint) evChargeStopHandler() {
 	lp.log.INFO.Println("stop charging <-")
 	lp.notify(evChargeStop)
+	lp.persistCounters()
 }
 
 // evVehicleConnectHandler sends external start event
@@ -294,6 +1042,9 @@ func (lp *LoadPoint) evVehicleDisconnectHandler() {
 
 	lp.notify(evVehicleDisconnect)
 
+	// reset session state, a charging pause (status B) does not reset the session
+	lp.resetSession()
+
 	// set default mode on disconnect
 	if lp.OnDisconnect.Mode != "" {
 		lp.SetMode(lp.OnDisconnect.Mode)
@@ -301,15 +1052,53 @@ func (lp *LoadPoint) evVehicleDisconnectHandler() {
 	if lp.OnDisconnect.TargetSoC != 0 {
 		lp.SetTargetSoC(lp.OnDisconnect.TargetSoC)
 	}
+
+	// clear vehicle selection
+	_ = lp.SetVehicle("")
+}
+
+// resetSession discards vehicle-related state when the vehicle is unplugged
+func (lp *LoadPoint) resetSession() {
+	lp.socCharge = 0
+	lp.socError = nil
+	lp.socFailure = time.Time{}
+	lp.chargeLimit = 0
+	lp.completed = false
+
+	lp.sessionEnergy = 0
+	lp.sessionSolar = 0
+	lp.sessionDuration = 0
+	lp.sessionSynced = false
+	lp.sessionLimited = false
+
+	// next vehicle may use different phases
+	lp.activePhases = 0
+	lp.singlePhase = time.Time{}
+
+	lp.Lock()
+	lp.startSoC = lp.SoC.Start
+	if !lp.targetTime.IsZero() {
+		lp.targetTime = time.Time{}
+		lp.publish("targetTime", lp.targetTime)
+	}
+	if lp.targetEnergy != lp.TargetEnergy {
+		lp.targetEnergy = lp.TargetEnergy
+		lp.publish("targetEnergy", 1e3*lp.targetEnergy)
+	}
+	lp.Unlock()
+
+	if lp.socEstimator != nil {
+		lp.socEstimator.Reset()
+	}
 }
 
 // evChargeCurrentHandler updates the dummy charge meter's charge power. This simplifies the main flow
 // where the charge meter can always be treated as present. It assumes that the charge meter cannot consume
 // more than total household consumption. If physical charge meter is present this handler is not used.
 func (lp *LoadPoint) evChargeCurrentHandler(current int64) {
-	power := float64(current*lp.Phases) * Voltage
+	power := float64(current*lp.phases()) * lp.voltage()
 
-	if !lp.handler.Enabled() || lp.status != api.StatusC {
+	if !lp.handler.Enabled() || !lp.chargingStatus(lp.status) {
 		// if disabled we cannot be charging
 		power = 0
 	}
@@ -350,6 +1139,15 @@ func (lp *LoadPoint) Prepare(uiChan chan<- util.Param, pushChan chan<- push.Even
 	lp.Lock()
 	lp.publish("mode", lp.Mode)
 	lp.publish("targetSoC", lp.TargetSoC)
+	lp.publish("targetEnergy", 1e3*lp.targetEnergy)
+	lp.publish("targetTime", lp.targetTime)
+	if lp.offline() {
+		lp.publish("startSoC", lp.startSoC)
+	}
+	if len(lp.vehicles) > 0 {
+		lp.publish("vehicle", lp.activeVehicle())
+	}
+	lp.publishCounters(lp.counters)
 	lp.Unlock()
 
 	// prepare charger status
@@ -358,7 +1156,24 @@ func (lp *LoadPoint) Prepare(uiChan chan<- util.Param, pushChan chan<- push.Even
 
 // connected returns the EVs connection state
 func (lp *LoadPoint) connected() bool {
-	return lp.status == api.StatusB || lp.status == api.StatusC
+	return lp.status == api.StatusB || lp.chargingStatus(lp.status)
+}
+
+// chargingStatus returns true if the status is an allowed charging status.
+// Charging with ventilation (status D) is only allowed if configured.
+func (lp *LoadPoint) chargingStatus(status api.ChargeStatus) bool {
+	return status == api.StatusC || status == api.StatusD && lp.Ventilation
+}
+
+// rejectVentilation disables the charger if charging with ventilation is requested but not allowed
+func (lp *LoadPoint) rejectVentilation() error {
+	if !lp.ventRejected {
+		lp.ventRejected = true
+		lp.log.WARN.Println("charging with ventilation (status D) requested but not allowed")
+		lp.notify(evVentilation)
+	}
+
+	return lp.handler.Ramp(0, true)
 }
 
 // targetSocReached checks if targetSoC configured and reached
@@ -367,6 +1182,40 @@ func (lp *LoadPoint) targetSocReached(socCharge, targetSoC float64) bool {
 	return targetSoC > 0 && targetSoC < 100 && socCharge >= targetSoC
 }
 
+// targetEnergyReached checks if the session target energy is set and reached
+func (lp *LoadPoint) targetEnergyReached() bool {
+	targetEnergy := lp.GetTargetEnergy()
+	return targetEnergy > 0 && lp.chargedEnergy >= 1e3*targetEnergy
+}
+
+// complete executes the configured action when target soc or energy is reached
+func (lp *LoadPoint) complete() error {
+	if !lp.completed {
+		lp.completed = true
+		lp.log.INFO.Printf("charge target reached, on complete: %s", lp.OnComplete)
+		lp.fireWebhooks(hookComplete, lp.status, lp.status)
+
+		if lp.OnComplete == completeNotify {
+			lp.notify(evChargeComplete)
+		}
+	}
+
+	if lp.OnComplete == completeHold {
+		return lp.handler.Ramp(lp.MinCurrent)
+	}
+
+	return lp.handler.Ramp(0)
+}
+
+// off executes the configured off mode behavior
+func (lp *LoadPoint) off() error {
+	if lp.OnOff == offHold {
+		return lp.handler.Ramp(lp.MinCurrent, true)
+	}
+
+	return lp.handler.Ramp(0, true)
+}
+
 // updateChargerStatus updates car status and detects car connected/disconnected events
 func (lp *LoadPoint) updateChargerStatus() error {
 	status, err := lp.handler.Status()
@@ -376,23 +1225,33 @@ func (lp *LoadPoint) updateChargerStatus() error {
 
 	lp.log.DEBUG.Printf("charger status: %s", status)
 
+	if !lp.debounced(status) {
+		return nil
+	}
+
 	if prevStatus := lp.status; status != prevStatus {
 		lp.status = status
 
 		// changed from A - connected
 		if prevStatus == api.StatusA {
 			lp.bus.Publish(evVehicleConnect)
+			lp.fireWebhooks(hookConnect, prevStatus, status)
 		}
 
 		// changed to C - start/stop charging cycle - handle before disconnect to update energy
-		if lp.charging = status == api.StatusC; lp.charging {
+		wasCharging := lp.chargingStatus(prevStatus)
+		if lp.charging = lp.chargingStatus(status); lp.charging && !wasCharging {
+			lp.chargeStarted = lp.clock.Now()
 			lp.bus.Publish(evChargeStart)
-		} else if prevStatus == api.StatusC {
+			lp.fireWebhooks(hookStart, prevStatus, status)
+		} else if !lp.charging && wasCharging {
 			lp.bus.Publish(evChargeStop)
+			lp.fireWebhooks(hookStop, prevStatus, status)
 		}
 
-		// changed to A - disconnected
+		// changed to A - disconnected, send session before reset
 		if status == api.StatusA {
+			lp.fireWebhooks(hookDisconnect, prevStatus, status)
 			lp.bus.Publish(evVehicleDisconnect)
 		}
 
@@ -403,10 +1262,47 @@ func (lp *LoadPoint) updateChargerStatus() error {
 	return nil
 }
 
-// detectPhases uses MeterCurrent interface to count phases with current >=1A
+// debounced returns true if the status can be applied. Status changes from or to A
+// are only applied once the charger reported the new status for the debounce period.
+// The initial status at startup is applied immediately.
+func (lp *LoadPoint) debounced(status api.ChargeStatus) bool {
+	if lp.Debounce == 0 || status == lp.status || lp.status == api.StatusNone ||
+		(status != api.StatusA && lp.status != api.StatusA) {
+		lp.pendingStatus = api.StatusNone
+		return true
+	}
+
+	if status != lp.pendingStatus {
+		lp.pendingStatus = status
+		lp.pendingSince = lp.clock.Now()
+	}
+
+	if elapsed := lp.clock.Since(lp.pendingSince); elapsed < lp.Debounce {
+		lp.log.DEBUG.Printf("charger status %s pending for %v", status, elapsed.Round(time.Second))
+		return false
+	}
+
+	lp.pendingStatus = api.StatusNone
+	return true
+}
+
+// countPhases returns the number of phases with current >= minActiveCurrent
+func countPhases(currents ...float64) int64 {
+	var phases int64
+	for _, i := range currents {
+		if i >= minActiveCurrent {
+			phases++
+		}
+	}
+	return phases
+}
+
+// detectPhases uses MeterCurrent interface to count phases with current >=1A.
+// Detection is skipped while currents are ramping up after charge start.
 func (lp *LoadPoint) detectPhases() {
 	phaseMeter, ok := lp.chargeMeter.(api.MeterCurrent)
 	if !ok {
+		lp.detectPhasesFromPower()
 		return
 	}
 
@@ -419,100 +1315,381 @@ func (lp *LoadPoint) detectPhases() {
 	lp.log.TRACE.Printf("charge currents: %vA", []float64{i1, i2, i3})
 	lp.publish("chargeCurrents", []float64{i1, i2, i3})
 
-	if lp.charging {
-		var phases int64
-		for _, i := range []float64{i1, i2, i3} {
-			if i >= minActiveCurrent {
-				phases++
-			}
-		}
+	if lp.ForceSinglePhase || !lp.charging || lp.clock.Since(lp.chargeStarted) < phaseDetectionDelay {
+		return
+	}
 
-		if phases > 0 {
-			lp.Phases = min(phases, lp.Phases)
-			lp.log.DEBUG.Printf("detected phases: %d (%v)", lp.Phases, []float64{i1, i2, i3})
+	if phases := countPhases(i1, i2, i3); phases > 0 {
+		lp.activePhases = min(phases, lp.availablePhases())
+		lp.log.DEBUG.Printf("detected phases: %d (%v)", lp.activePhases, []float64{i1, i2, i3})
 
-			lp.publish("activePhases", lp.Phases)
-		}
+		lp.publish("activePhases", lp.activePhases)
+
+		lp.checkSinglePhase(phases)
 	}
 }
 
-// maxCurrent calculates the maximum target current for PV mode
-func (lp *LoadPoint) maxCurrent(mode api.ChargeMode, sitePower float64) int64 {
-	// calculate target charge current from delta power and actual current
-	effectiveCurrent := lp.handler.TargetCurrent()
-	if lp.status != api.StatusC {
-		effectiveCurrent = 0
+// checkSinglePhase warns once if a 3p loadpoint persistently charges on a single phase
+func (lp *LoadPoint) checkSinglePhase(phases int64) {
+	if lp.Phases != 3 || phases != 1 || lp.switchedPhases == 1 {
+		lp.singlePhase = time.Time{}
+		return
 	}
-	deltaCurrent := powerToCurrent(-sitePower, lp.Phases)
-	targetCurrent := clamp(effectiveCurrent+deltaCurrent, 0, lp.MaxCurrent)
 
-	lp.log.DEBUG.Printf("max charge current: %dA = %dA + %dA (%.0fW @ %dp)", targetCurrent, effectiveCurrent, deltaCurrent, sitePower, lp.Phases)
+	if lp.singlePhase.IsZero() {
+		lp.singlePhase = lp.clock.Now()
+	}
 
-	// in MinPV mode return at least minCurrent
-	if mode == api.ModeMinPV && targetCurrent < lp.MinCurrent {
-		return lp.MinCurrent
+	if !lp.phaseWarned && lp.clock.Since(lp.singlePhase) >= singlePhaseWarning {
+		lp.phaseWarned = true
+		lp.log.WARN.Printf("charging on single phase for %v although configured for 3 phases: check charger wiring or vehicle", singlePhaseWarning)
 	}
+}
 
-	// in PV mode disable charger if car not charging and minCurrent not possible
-	if mode == api.ModePV && lp.status != api.StatusC {
-		lp.pvTimer = time.Time{}
+// detectPhasesFromPower estimates the active phases from measured charge power and target current
+// if the charge meter does not provide phase currents
+func (lp *LoadPoint) detectPhasesFromPower() {
+	if lp.ForceSinglePhase || !lp.charging || lp.chargePower <= 0 || !lp.hasChargeMeter() ||
+		lp.clock.Since(lp.chargeStarted) < phaseDetectionDelay {
+		return
+	}
 
-		if targetCurrent < lp.MinCurrent {
-			return 0
-		}
+	current := lp.handler.TargetCurrent()
+	if current <= 0 {
+		return
+	}
 
-		return lp.MinCurrent
+	phases := int64(math.Round(lp.chargePower / (float64(current) * lp.voltage())))
+	if phases > 0 {
+		lp.activePhases = min(phases, lp.availablePhases())
+		lp.log.DEBUG.Printf("detected phases: %d (%.0fW @ %dA)", lp.activePhases, lp.chargePower, current)
+
+		lp.publish("activePhases", lp.activePhases)
 	}
+}
 
-	// read only once to simplify testing
-	enabled := lp.handler.Enabled()
+// availablePhases returns the phases energized by the charger limited by the configured phases.
+// The configured phases are used if the charger doesn't report phases.
+func (lp *LoadPoint) availablePhases() int64 {
+	if lp.chargerPhases == nil {
+		return lp.Phases
+	}
 
-	if mode == api.ModePV && enabled && targetCurrent < lp.MinCurrent {
-		// kick off disable sequence
-		if sitePower >= lp.Disable.Threshold {
-			lp.log.DEBUG.Printf("site power %.0fW >= disable threshold %.0fW", sitePower, lp.Disable.Threshold)
+	phases, err := lp.chargerPhases.ActivePhases()
+	if err != nil {
+		lp.log.ERROR.Printf("charger phases: %v", err)
+		return lp.Phases
+	}
 
-			if lp.pvTimer.IsZero() {
-				lp.log.DEBUG.Printf("start pv disable timer: %v", lp.Disable.Delay)
-				lp.pvTimer = lp.clock.Now()
-			}
+	if phases > 0 {
+		return min(phases, lp.Phases)
+	}
 
-			elapsed := lp.clock.Since(lp.pvTimer)
-			if elapsed >= lp.Disable.Delay {
-				lp.log.DEBUG.Println("pv disable timer elapsed")
-				return 0
-			}
+	return lp.Phases
+}
 
-			lp.log.DEBUG.Printf("pv disable timer remaining: %v", (lp.Disable.Delay - elapsed).Round(time.Second))
-		} else {
-			// reset timer
-			lp.pvTimer = lp.clock.Now()
-		}
+// scalePhases switches to 1 phase if the surplus is insufficient for min current on 3 phases
+// and back to 3 phases once it is sufficient for min current plus the switching margin.
+// Phases are not switched again within the cool-down or if the vehicle has been detected charging
+// on a single phase only. Returns true while charging must be paused before switching.
+func (lp *LoadPoint) scalePhases(sitePower float64) bool {
+	if lp.phaseSwitcher == nil {
+		return false
+	}
 
-		return lp.MinCurrent
+	current := lp.switchedPhases
+	if current == 0 {
+		current = lp.Phases
 	}
 
-	if mode == api.ModePV && !enabled {
-		// kick off enable sequence
-		if targetCurrent >= lp.MinCurrent ||
-			(lp.Enable.Threshold != 0 && sitePower <= lp.Enable.Threshold) {
-			lp.log.DEBUG.Printf("site power %.0fW < enable threshold %.0fW", sitePower, lp.Enable.Threshold)
+	// single phase vehicle doesn't benefit from switching
+	if current == 3 && lp.activePhases == 1 {
+		return false
+	}
 
-			if lp.pvTimer.IsZero() {
-				lp.log.DEBUG.Printf("start pv enable timer: %v", lp.Enable.Delay)
-				lp.pvTimer = lp.clock.Now()
-			}
+	// surplus including the current charge power
+	power := -sitePower
+	if lp.charging {
+		power += lp.chargePower
+	}
 
-			elapsed := lp.clock.Since(lp.pvTimer)
+	var phases int64
+	switch {
+	case current != 1 && power < float64(lp.MinCurrent*3)*lp.voltage():
+		phases = 1
+	case current == 1 && power >= float64((lp.MinCurrent+phaseSwitchMargin)*3)*lp.voltage():
+		phases = 3
+	default:
+		return false
+	}
+
+	if elapsed := lp.clock.Since(lp.phaseSwitched); !lp.phaseSwitched.IsZero() && elapsed < lp.PhaseSwitchCooldown {
+		lp.log.DEBUG.Printf("phase switch to %dp delayed by cool-down: %v", phases, (lp.PhaseSwitchCooldown - elapsed).Round(time.Second))
+		return false
+	}
+
+	// switch phases only while not charging
+	if lp.handler.Enabled() {
+		lp.log.DEBUG.Printf("pausing charger to switch phases: %dp", phases)
+		return true
+	}
+
+	lp.log.INFO.Printf("switch phases: %dp (surplus %.1fkW)", phases, power/1e3)
+
+	if err := lp.phaseSwitcher.Phases1p3p(int(phases)); err != nil {
+		lp.log.ERROR.Printf("phase switch: %v", err)
+		return false
+	}
+
+	lp.phaseSwitched = lp.clock.Now()
+	lp.switchedPhases = phases
+	lp.activePhases = phases
+	lp.publish("activePhases", lp.activePhases)
+
+	return false
+}
+
+// phases returns the number of phases used for converting between power and current.
+// Detected phases take precedence over switched and configured phases unless single phase is forced.
+func (lp *LoadPoint) phases() int64 {
+	if lp.ForceSinglePhase {
+		return 1
+	}
+	if lp.activePhases > 0 {
+		return lp.activePhases
+	}
+	if lp.switchedPhases > 0 {
+		return lp.switchedPhases
+	}
+	return lp.Phases
+}
+
+// Transition is a change of the loadpoint's charging decision including its reason
+type Transition struct {
+	Time   time.Time `json:"time"`
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	Reason string    `json:"reason"`
+}
+
+// String implements the Stringer interface
+func (t Transition) String() string {
+	if t.From == "" {
+		return fmt.Sprintf("%s: %s", t.To, t.Reason)
+	}
+	return fmt.Sprintf("%s→%s: %s", t.From, t.To, t.Reason)
+}
+
+// setState records the charging decision of the current cycle
+func (lp *LoadPoint) setState(state, format string, args ...interface{}) {
+	lp.state = state
+	lp.stateReason = fmt.Sprintf(format, args...)
+}
+
+// publishTransition logs and publishes the charging decision if it has changed since the previous cycle.
+// The duration since the last transition is published on every cycle.
+func (lp *LoadPoint) publishTransition() {
+	defer func() {
+		lp.publish("stateDuration", lp.StateDuration().Round(time.Second))
+	}()
+
+	if lp.state == lp.prevState {
+		return
+	}
+
+	t := Transition{
+		Time:   lp.clock.Now(),
+		From:   lp.prevState,
+		To:     lp.state,
+		Reason: lp.stateReason,
+	}
+	lp.prevState = lp.state
+
+	lp.log.INFO.Printf("state %v", t)
+
+	lp.Lock()
+	lp.stateSince = t.Time
+	lp.transitions = append(lp.transitions, t)
+	if len(lp.transitions) > maxTransitions {
+		lp.transitions = lp.transitions[len(lp.transitions)-maxTransitions:]
+	}
+	lp.Unlock()
+
+	lp.publish("transition", t)
+}
+
+// chargerDisplayText returns the text describing the charging state on the charger display, empty if nothing is to be shown
+func (lp *LoadPoint) chargerDisplayText(mode api.ChargeMode) string {
+	switch lp.state {
+	case stateEnabled, stateDisabling:
+		if !lp.charging {
+			return ""
+		}
+
+		power := fmt.Sprintf("%.1fkW", lp.chargePower/1e3)
+		if mode == api.ModePV || mode == api.ModeMinPV {
+			return "PV charging " + power
+		}
+		return "Charging " + power
+
+	case stateIdle, stateEnabling:
+		if mode == api.ModePrice {
+			return "Waiting for price"
+		}
+		return "Waiting for PV"
+
+	case stateComplete:
+		return "Full"
+
+	case stateOff:
+		return "Off"
+	}
+
+	return ""
+}
+
+// updateDisplay shows the charging state on the charger display. Updates are rate-limited to displayInterval.
+func (lp *LoadPoint) updateDisplay(mode api.ChargeMode) {
+	if lp.display == nil {
+		return
+	}
+
+	text := lp.chargerDisplayText(mode)
+	if text == "" || text == lp.displayText || lp.clock.Since(lp.displayUpdated) < displayInterval {
+		return
+	}
+
+	lp.log.DEBUG.Printf("charger display: %s", text)
+
+	err := lp.display.Display(text)
+	if errors.Is(err, api.ErrNotSupported) {
+		lp.log.WARN.Println("charger display not supported")
+		lp.display = nil
+		return
+	}
+
+	if err != nil {
+		lp.log.ERROR.Printf("charger display: %v", err)
+		return
+	}
+
+	lp.displayText = text
+	lp.displayUpdated = lp.clock.Now()
+}
+
+// StateDuration returns the time since the last change of the charging decision
+func (lp *LoadPoint) StateDuration() time.Duration {
+	lp.Lock()
+	defer lp.Unlock()
+
+	if lp.stateSince.IsZero() {
+		return 0
+	}
+	return lp.clock.Since(lp.stateSince)
+}
+
+// Transitions returns the recent changes of the charging decision, oldest first
+func (lp *LoadPoint) Transitions() []Transition {
+	lp.Lock()
+	defer lp.Unlock()
+	return append([]Transition{}, lp.transitions...)
+}
+
+// maxCurrent calculates the maximum target current for PV mode
+func (lp *LoadPoint) maxCurrent(mode api.ChargeMode, sitePower float64) int64 {
+	// calculate target charge current from delta power and actual current
+	effectiveCurrent := lp.handler.TargetCurrent()
+	if !lp.chargingStatus(lp.status) {
+		effectiveCurrent = 0
+	}
+	deltaCurrent := powerToCurrent(-sitePower, lp.voltage(), lp.phases())
+	targetCurrent := clamp(effectiveCurrent+deltaCurrent, 0, lp.maxChargeCurrent())
+
+	lp.log.DEBUG.Printf("max charge current: %dA = %dA + %dA (%.0fW @ %dp)", targetCurrent, effectiveCurrent, deltaCurrent, sitePower, lp.phases())
+
+	surplus := -sitePower / 1e3
+
+	// in MinPV mode return at least minCurrent
+	if mode == api.ModeMinPV && targetCurrent < lp.MinCurrent {
+		lp.setState(stateEnabled, "surplus %.1fkW below min current, charging at min current %dA", surplus, lp.MinCurrent)
+		return lp.MinCurrent
+	}
+
+	// in PV mode disable charger if car not charging and minCurrent not possible
+	if mode == api.ModePV && !lp.chargingStatus(lp.status) {
+		lp.pvTimer = time.Time{}
+
+		if targetCurrent < lp.MinCurrent {
+			lp.setState(stateIdle, "surplus %.1fkW below min current %dA", surplus, lp.MinCurrent)
+			return 0
+		}
+
+		lp.setState(stateEnabled, "surplus %.1fkW sufficient for min current %dA", surplus, lp.MinCurrent)
+		return lp.MinCurrent
+	}
+
+	// read only once to simplify testing
+	enabled := lp.handler.Enabled()
+
+	// keep charging at min current once pv charging has started
+	if mode == api.ModePV && enabled && targetCurrent < lp.MinCurrent && lp.OnBelowMin == belowMinHold {
+		lp.pvTimer = time.Time{}
+		lp.setState(stateEnabled, "surplus %.1fkW below min current, holding min current %dA", surplus, lp.MinCurrent)
+		return lp.MinCurrent
+	}
+
+	if mode == api.ModePV && enabled && targetCurrent < lp.MinCurrent {
+		// kick off disable sequence
+		if sitePower >= lp.Disable.Threshold {
+			lp.log.DEBUG.Printf("site power %.0fW >= disable threshold %.0fW", sitePower, lp.Disable.Threshold)
+
+			if lp.pvTimer.IsZero() {
+				lp.log.DEBUG.Printf("start pv disable timer: %v", lp.Disable.Delay)
+				lp.pvTimer = lp.clock.Now()
+			}
+
+			elapsed := lp.clock.Since(lp.pvTimer)
+			if elapsed >= lp.Disable.Delay {
+				lp.log.DEBUG.Println("pv disable timer elapsed")
+				lp.setState(stateIdle, "site power %.0fW >= disable threshold %.0fW for %v", sitePower, lp.Disable.Threshold, lp.Disable.Delay)
+				return 0
+			}
+
+			lp.log.DEBUG.Printf("pv disable timer remaining: %v", (lp.Disable.Delay - elapsed).Round(time.Second))
+			lp.setState(stateDisabling, "site power %.0fW >= disable threshold %.0fW", sitePower, lp.Disable.Threshold)
+		} else {
+			// reset timer
+			lp.pvTimer = lp.clock.Now()
+			lp.setState(stateEnabled, "site power %.0fW < disable threshold %.0fW, charging at min current %dA", sitePower, lp.Disable.Threshold, lp.MinCurrent)
+		}
+
+		return lp.MinCurrent
+	}
+
+	if mode == api.ModePV && !enabled {
+		// kick off enable sequence
+		if targetCurrent >= lp.MinCurrent ||
+			(lp.Enable.Threshold != 0 && sitePower <= lp.Enable.Threshold) {
+			lp.log.DEBUG.Printf("site power %.0fW < enable threshold %.0fW", sitePower, lp.Enable.Threshold)
+
+			if lp.pvTimer.IsZero() {
+				lp.log.DEBUG.Printf("start pv enable timer: %v", lp.Enable.Delay)
+				lp.pvTimer = lp.clock.Now()
+			}
+
+			elapsed := lp.clock.Since(lp.pvTimer)
 			if elapsed >= lp.Enable.Delay {
 				lp.log.DEBUG.Println("pv enable timer elapsed")
+				lp.setState(stateEnabled, "surplus %.1fkW sufficient for min current %dA for %v", surplus, lp.MinCurrent, lp.Enable.Delay)
 				return lp.MinCurrent
 			}
 
 			lp.log.DEBUG.Printf("pv enable timer remaining: %v", (lp.Enable.Delay - elapsed).Round(time.Second))
+			lp.setState(stateEnabling, "surplus %.1fkW sufficient for min current %dA", surplus, lp.MinCurrent)
 		} else {
 			// reset timer
 			lp.pvTimer = lp.clock.Now()
+			lp.setState(stateIdle, "surplus %.1fkW below min current %dA", surplus, lp.MinCurrent)
 		}
 
 		return 0
@@ -522,6 +1699,8 @@ func (lp *LoadPoint) maxCurrent(mode api.ChargeMode, sitePower float64) int64 {
 	lp.log.DEBUG.Printf("pv timer reset")
 	lp.pvTimer = time.Time{}
 
+	lp.setState(stateEnabled, "surplus %.1fkW, charging at %dA", surplus, targetCurrent)
+
 	return targetCurrent
 }
 
@@ -564,6 +1743,133 @@ func (lp *LoadPoint) publishChargeProgress() {
 	lp.publish("chargeDuration", lp.chargeDuration)
 }
 
+// updateSession accumulates and publishes the current session's charged energy and duration.
+// Charge rater and timer may restart at each charge start, so their increase since the previous
+// update is added to the session. The first update of a session only records the current values.
+// Measured grid import up to the charge power is accounted as grid energy, the remainder as solar energy.
+func (lp *LoadPoint) updateSession(gridPower float64) {
+	energy := lp.chargedEnergy - lp.sessionRated
+	if energy < 0 {
+		energy = lp.chargedEnergy
+	}
+
+	duration := lp.chargeDuration - lp.sessionTimed
+	if duration < 0 {
+		duration = lp.chargeDuration
+	}
+
+	if !lp.sessionSynced {
+		energy, duration = 0, 0
+		lp.sessionSynced = true
+	}
+
+	lp.sessionRated = lp.chargedEnergy
+	lp.sessionTimed = lp.chargeDuration
+
+	lp.sessionDuration += duration
+
+	if energy > 0 {
+		lp.sessionEnergy += energy
+		lp.addCounters(energy)
+
+		if lp.chargePower > 0 {
+			imported := math.Min(math.Max(gridPower, 0), lp.chargePower)
+			lp.sessionSolar += energy * (lp.chargePower - imported) / lp.chargePower
+		}
+	}
+
+	lp.publish("sessionEnergy", lp.sessionEnergy)
+	lp.publish("sessionDuration", lp.sessionDuration.Round(time.Second))
+	lp.publish("sessionSolarPercentage", lp.solarPercentage())
+}
+
+// solarPercentage returns the share of the session's charged energy not imported from grid in percent
+func (lp *LoadPoint) solarPercentage() float64 {
+	if lp.sessionEnergy <= 0 {
+		return 0
+	}
+	return 100 * lp.sessionSolar / lp.sessionEnergy
+}
+
+// wakeUp wakes up vehicles sleeping on the connector. If the charger is enabled but the vehicle
+// doesn't start charging within the wakeup timeout, the configured wakeup strategy is executed.
+func (lp *LoadPoint) wakeUp(mode api.ChargeMode) error {
+	if lp.Wakeup.Strategy == "" {
+		return nil
+	}
+
+	if lp.status != api.StatusB {
+		// charging or disconnected
+		lp.wakeupTimer = time.Time{}
+		lp.wakeups = 0
+		return nil
+	}
+
+	// vehicle is not expected to charge
+	if !lp.handler.Enabled() || lp.completed || mode == api.ModeOff {
+		lp.wakeupTimer = time.Time{}
+		return nil
+	}
+
+	if lp.wakeupTimer.IsZero() {
+		lp.wakeupTimer = lp.clock.Now()
+	}
+
+	if lp.clock.Since(lp.wakeupTimer) < lp.Wakeup.Timeout || lp.wakeups >= lp.Wakeup.Retries {
+		return nil
+	}
+
+	// previous vehicle wakeup request still pending
+	if lp.wakeupDone != nil {
+		select {
+		case <-lp.wakeupDone:
+		default:
+			return nil
+		}
+	}
+
+	lp.wakeups++
+	lp.wakeupTimer = lp.clock.Now()
+	lp.log.INFO.Printf("wake up vehicle (%d/%d)", lp.wakeups, lp.Wakeup.Retries)
+
+	if lp.Wakeup.Strategy == wakeupVehicle {
+		if vw, ok := lp.vehicle.(api.VehicleWakeUp); ok {
+			// vehicle apis may be slow to respond, don't block the control loop
+			done := make(chan struct{})
+			lp.wakeupDone = done
+
+			go func() {
+				defer close(done)
+				if err := vw.WakeUp(); err != nil {
+					lp.log.ERROR.Printf("wake up vehicle: %v", err)
+				}
+			}()
+
+			return nil
+		}
+	}
+
+	return lp.handler.WakeUp()
+}
+
+// sessionLimitReached returns true if the session's charged energy has reached the configured cap.
+// Session energy is accumulated from the charge rater and therefore includes all charge starts of the session.
+func (lp *LoadPoint) sessionLimitReached() bool {
+	if lp.MaxSessionEnergy <= 0 {
+		return false
+	}
+
+	reached := lp.sessionEnergy >= 1e3*lp.MaxSessionEnergy
+	if reached && !lp.sessionLimited {
+		lp.log.INFO.Printf("session energy limit reached: %.1fkWh", lp.sessionEnergy/1e3)
+	}
+
+	lp.sessionLimited = reached
+	lp.publish("sessionLimited", lp.sessionLimited)
+
+	return reached
+}
+
 // remainingChargeDuration returns the remaining charge time
 func (lp *LoadPoint) remainingChargeDuration(chargePercent float64) time.Duration {
 	if !lp.charging {
@@ -571,48 +1877,464 @@ func (lp *LoadPoint) remainingChargeDuration(chargePercent float64) time.Duratio
 	}
 
 	if lp.chargePower > 0 && lp.vehicle != nil {
-		chargePercent = chargePercent / 100.0
-		targetPercent := float64(lp.TargetSoC) / 100
+		whRemaining := 1e3 * requiredEnergy(chargePercent, lp.effectiveTargetSoC(), lp.capacity())
+		return time.Duration(float64(time.Hour) * whRemaining / lp.chargePower).Round(time.Second)
+	}
 
-		if chargePercent >= targetPercent {
-			return 0
+	return -1
+}
+
+// capacity returns the vehicle's battery capacity in kWh or the default capacity if not configured
+func (lp *LoadPoint) capacity() int64 {
+	if lp.offline() {
+		return lp.SoC.Capacity
+	}
+
+	if lp.vehicle != nil {
+		if capacity := lp.vehicle.Capacity(); capacity > 0 {
+			return capacity
 		}
+	}
 
-		whTotal := float64(lp.vehicle.Capacity()) * 1e3
-		whRemaining := (targetPercent - chargePercent) * whTotal
-		return time.Duration(float64(time.Hour) * whRemaining / lp.chargePower).Round(time.Second)
+	return defaultCapacity
+}
+
+// climatePower returns the additional pv allowance while the vehicle is preconditioning
+func (lp *LoadPoint) climatePower() float64 {
+	climater, ok := lp.vehicle.(api.VehicleClimater)
+	if !lp.Climate.Boost || !ok || !lp.connected() {
+		return 0
 	}
 
-	return -1
+	active, _, _, err := climater.Climater()
+	if err != nil {
+		lp.log.ERROR.Printf("vehicle climater error: %v", err)
+		return 0
+	}
+
+	if !active {
+		return 0
+	}
+
+	lp.log.DEBUG.Printf("climate active, boost: %.0fW", lp.Climate.Power)
+	return lp.Climate.Power
+}
+
+// offline returns true if soc is estimated from charged energy without vehicle
+func (lp *LoadPoint) offline() bool {
+	return lp.vehicle == nil && lp.SoC.Capacity > 0
+}
+
+// chargeState returns the vehicle's soc. Charger-reported soc takes precedence over
+// the vehicle api if vehicle is connected and soc is available from the charging session.
+func (lp *LoadPoint) chargeState() (float64, error) {
+	if lp.battery != nil && lp.connected() {
+		f, err := lp.battery.SoC()
+		if err == nil {
+			return f, nil
+		}
+
+		if lp.vehicle == nil && !lp.offline() {
+			return 0, err
+		}
+
+		lp.log.DEBUG.Printf("charger soc unavailable: %v", err)
+	}
+
+	if lp.offline() {
+		return offlineSoC(float64(lp.GetStartSoC()), lp.chargedEnergy, lp.SoC.Capacity), nil
+	}
+
+	if lp.vehicle == nil {
+		return 0, errors.New("no soc source")
+	}
+
+	return lp.vehicle.ChargeState()
 }
 
 // publish state of charge and remaining charge duration
 func (lp *LoadPoint) publishSoC() {
-	if lp.vehicle == nil {
+	if lp.vehicle == nil && lp.battery == nil && !lp.offline() {
 		return
 	}
 
 	if lp.SoC.AlwaysUpdate || lp.connected() {
-		f, err := lp.vehicle.ChargeState()
+		f, err := lp.chargeState()
+		lp.socError = err
+
+		if err == nil {
+			lp.socFailure = time.Time{}
+		} else if lp.socFailure.IsZero() {
+			lp.socFailure = lp.clock.Now()
+		}
+
 		if err == nil {
 			lp.socCharge = f
 			lp.log.DEBUG.Printf("vehicle soc: %.0f%%", lp.socCharge)
 			lp.publish("socCharge", lp.socCharge)
-			lp.publish("chargeEstimate", lp.remainingChargeDuration(f))
+
+			// interpolate soc between vehicle updates
+			estimate := f
+			if lp.socEstimator != nil {
+				estimate = lp.socEstimator.SoC(f, lp.chargedEnergy)
+			}
+			lp.publish("socEstimate", estimate)
+
+			lp.publish("chargeEstimate", lp.remainingChargeDuration(estimate))
+			lp.publishRange(estimate)
+			lp.publishChargeLimit()
+			lp.publishBatteryTemperature()
 			return
 		}
-		lp.log.ERROR.Printf("vehicle error: %v", err)
+
+		if errors.Is(err, api.ErrNotSupported) {
+			lp.log.DEBUG.Printf("vehicle soc: %v", err)
+		} else {
+			lp.log.ERROR.Printf("vehicle error: %v", err)
+		}
 	}
 
 	lp.publish("socCharge", -1)
+	lp.publish("socEstimate", -1)
 	lp.publish("chargeEstimate", -1)
 }
 
-// Update is the main control function. It reevaluates meters and charger state
-func (lp *LoadPoint) Update(sitePower float64) {
+// hasSoC returns true if the vehicle soc is available
+func (lp *LoadPoint) hasSoC() bool {
+	return lp.vehicle != nil || lp.battery != nil || lp.offline()
+}
+
+// validSoC returns true if the vehicle soc could be read in the current cycle.
+// Soc-based targets are ignored otherwise.
+func (lp *LoadPoint) validSoC() bool {
+	return lp.hasSoC() && lp.socError == nil
+}
+
+// socFailed returns true if charging should be paused since the vehicle soc has been unavailable
+// for the grace period. Vehicles not supporting soc are always charged ignoring soc. Sleeping
+// vehicles are not paused as they can only be woken up while the charger is enabled.
+func (lp *LoadPoint) socFailed() bool {
+	if lp.OnSoCError != socErrorPause || lp.socError == nil ||
+		errors.Is(lp.socError, api.ErrNotSupported) || errors.Is(lp.socError, api.ErrAsleep) {
+		return false
+	}
+
+	return lp.clock.Since(lp.socFailure) >= socErrorGracePeriod
+}
+
+// minSocNotReached returns true if the vehicle soc is below the configured minimum soc
+func (lp *LoadPoint) minSocNotReached() bool {
+	return lp.SoC.Min > 0 && lp.validSoC() && lp.socCharge < float64(lp.SoC.Min)
+}
+
+// planActive returns true if charging at max current must start to reach the target soc at the target time.
+// The required duration is estimated from the remaining energy at max charge power. Vehicle soc below the
+// minimum soc is charged regardless of the plan, hence the plan only governs charging above the minimum soc.
+func (lp *LoadPoint) planActive() bool {
+	targetTime := lp.GetTargetTime()
+	if targetTime.IsZero() || !lp.validSoC() {
+		return false
+	}
+
+	energy := requiredEnergy(lp.socCharge, lp.effectiveTargetSoC(), lp.capacity())
+	if energy <= 0 {
+		return false
+	}
+
+	power := float64(lp.maxChargeCurrent()*lp.phases()) * lp.voltage()
+	if power <= 0 {
+		return false
+	}
+
+	duration := time.Duration(float64(time.Hour) * 1e3 * energy / power)
+
+	start := targetTime.Add(-duration)
+	if lp.clock.Now().Before(start) {
+		lp.log.DEBUG.Printf("target time plan: start charging at %s", start.Round(time.Second).Local())
+		return false
+	}
+
+	return true
+}
+
+// socTargetReached returns true if the vehicle soc is valid and has reached the effective target soc
+func (lp *LoadPoint) socTargetReached() bool {
+	return lp.socError == nil && lp.targetSocReached(lp.socCharge, lp.effectiveTargetSoC())
+}
+
+// publishBatteryTemperature publishes the vehicle's battery temperature
+func (lp *LoadPoint) publishBatteryTemperature() {
+	bt, ok := lp.vehicle.(api.BatteryTemperature)
+	if !ok {
+		return
+	}
+
+	temp, err := bt.BatteryTemperature()
+	if err != nil {
+		lp.log.ERROR.Printf("vehicle battery temperature error: %v", err)
+		return
+	}
+
+	lp.log.DEBUG.Printf("vehicle battery temperature: %.0f°C", temp)
+	lp.publish("batteryTemperature", temp)
+}
+
+// publishRange publishes the vehicle's range and the estimated range at target soc
+func (lp *LoadPoint) publishRange(soc float64) {
+	vr, ok := lp.vehicle.(api.VehicleRange)
+	if !ok {
+		return
+	}
+
+	rng, err := vr.Range()
+	if err != nil {
+		lp.log.ERROR.Printf("vehicle range error: %v", err)
+		return
+	}
+
+	lp.log.DEBUG.Printf("vehicle range: %dkm", rng)
+	lp.publish("range", rng)
+
+	if soc > 0 {
+		lp.publish("targetRange", targetRange(rng, soc, float64(lp.GetTargetSoC())))
+	}
+}
+
+// publishChargeLimit reads and publishes the vehicle-side charge limit if supported by the vehicle
+func (lp *LoadPoint) publishChargeLimit() {
+	vl, ok := lp.vehicle.(api.VehicleChargeLimit)
+	if !ok {
+		return
+	}
+
+	limit, err := vl.ChargeLimit()
+	if err != nil {
+		lp.log.ERROR.Printf("vehicle charge limit error: %v", err)
+		return
+	}
+
+	lp.log.DEBUG.Printf("vehicle charge limit: %d%%", limit)
+	lp.chargeLimit = limit
+	lp.publish("chargeLimit", limit)
+}
+
+// effectiveTargetSoC returns the target soc limited by the vehicle-side charge limit
+func (lp *LoadPoint) effectiveTargetSoC() float64 {
+	if lp.chargeLimit > 0 && lp.chargeLimit < int64(lp.TargetSoC) {
+		return float64(lp.chargeLimit)
+	}
+	return float64(lp.TargetSoC)
+}
+
+// publishVehicleStatus publishes the vehicle-side plug and charging status if supported by the vehicle
+func (lp *LoadPoint) publishVehicleStatus() {
+	vs, ok := lp.vehicle.(api.VehicleStatus)
+	if !ok {
+		return
+	}
+
+	status, err := vs.Status()
+	if err != nil {
+		lp.log.ERROR.Printf("vehicle status error: %v", err)
+		return
+	}
+
+	lp.log.DEBUG.Printf("vehicle status: %s", status)
+	lp.publish("vehicleConnected", status != api.StatusA)
+	lp.publish("vehicleCharging", lp.chargingStatus(status))
+}
+
+// updateDimming reads the grid operator dimming signal. If the signal can't be read, charging is dimmed.
+func (lp *LoadPoint) updateDimming() {
+	if lp.dimmingG == nil {
+		return
+	}
+
+	dimmed, err := lp.dimmingG()
+	if err != nil {
+		lp.log.ERROR.Printf("dimming signal error: %v", err)
+		dimmed = true
+	}
+
+	if dimmed != lp.dimmed {
+		lp.log.INFO.Printf("grid operator dimming: %v", dimmed)
+	}
+
+	lp.dimmed = dimmed
+	lp.publish("dimmed", dimmed)
+}
+
+// priceCharge charges at max current while the tariff price is below the configured threshold.
+// Charging is disabled if the price is at or above the threshold. If the price is unavailable, the last decision is kept.
+func (lp *LoadPoint) priceCharge() error {
+	if lp.priceG == nil {
+		lp.setState(stateIdle, "price mode requires tariff price")
+		return lp.handler.Ramp(0, true)
+	}
+
+	price, err := lp.priceG()
+	if err != nil {
+		// hold the last decision on transient tariff errors
+		lp.log.ERROR.Printf("tariff price error: %v", err)
+
+		if lp.priceCharging {
+			lp.setState(stateEnabled, "tariff price unavailable, continue charging")
+			return lp.handler.Ramp(lp.dimCurrent(lp.maxChargeCurrent()), true)
+		}
+
+		lp.setState(stateIdle, "tariff price unavailable")
+		return lp.handler.Ramp(0)
+	}
+
+	lp.log.DEBUG.Printf("tariff price: %.3f", price)
+	lp.publish("tariffPrice", price)
+
+	lp.priceCharging = price < lp.Tariff.Threshold
+	if lp.priceCharging {
+		lp.setState(stateEnabled, "price %.3f below threshold %.3f", price, lp.Tariff.Threshold)
+		return lp.handler.Ramp(lp.dimCurrent(lp.maxChargeCurrent()), true)
+	}
+
+	lp.setState(stateIdle, "price %.3f not below threshold %.3f", price, lp.Tariff.Threshold)
+	return lp.handler.Ramp(0, true)
+}
+
+// maxChargeCurrent returns the max current limited by the max power using the active phases.
+// Charging continues at least at min current.
+func (lp *LoadPoint) maxChargeCurrent() int64 {
+	if lp.MaxPower <= 0 {
+		return lp.MaxCurrent
+	}
+
+	limit := max(powerToCurrent(1e3*lp.MaxPower, lp.voltage(), lp.phases()), lp.MinCurrent)
+	return min(limit, lp.MaxCurrent)
+}
+
+// dimCurrent limits the current to the dimming power while dimmed. Charging continues at least at min current.
+func (lp *LoadPoint) dimCurrent(current int64) int64 {
+	if !lp.dimmed || current == 0 {
+		return current
+	}
+
+	limit := max(powerToCurrent(lp.Dimming.Power, lp.voltage(), lp.phases()), lp.MinCurrent)
+	if current > limit {
+		lp.log.DEBUG.Printf("dimmed charge current: %dA (%.0fW)", limit, lp.Dimming.Power)
+		return limit
+	}
+
+	return current
+}
+
+// chargerFailed counts consecutive charger errors and degrades the loadpoint once the error threshold is reached.
+// Degraded loadpoints are disabled.
+func (lp *LoadPoint) chargerFailed() {
+	lp.chargerErrors++
+
+	if lp.ErrorThreshold <= 0 || lp.chargerErrors < lp.ErrorThreshold {
+		return
+	}
+
+	if !lp.degraded {
+		lp.degraded = true
+		lp.degradations++
+		lp.log.WARN.Printf("charger failed %d times, disabling charging", lp.chargerErrors)
+		lp.notify(evChargerDegraded)
+		lp.publish("degraded", lp.degraded)
+	}
+
+	// retry until disabled
+	if err := lp.handler.Ramp(0, true); err != nil {
+		lp.log.ERROR.Println(err)
+	}
+}
+
+// errorCooldown returns the cool-down after the current degradation. It doubles with each
+// consecutive degradation up to maxErrorCooldown.
+func (lp *LoadPoint) errorCooldown() time.Duration {
+	cooldown := lp.ErrorCooldown
+	for i := 1; i < lp.degradations && cooldown < maxErrorCooldown; i++ {
+		cooldown *= 2
+	}
+
+	if cooldown > maxErrorCooldown {
+		cooldown = maxErrorCooldown
+	}
+
+	return cooldown
+}
+
+// coolingDown returns true while charging is held disabled after recovering from degraded state
+func (lp *LoadPoint) coolingDown() bool {
+	return lp.clock.Now().Before(lp.cooldownUntil)
+}
+
+// chargerRecovered resets the charger error count and recovers from degraded state.
+// Charging is re-enabled after the cool-down. The cool-down is reset once the charger
+// operated without errors for the duration of the last cool-down.
+func (lp *LoadPoint) chargerRecovered() {
+	lp.chargerErrors = 0
+
+	if lp.degraded {
+		lp.degraded = false
+
+		if cooldown := lp.errorCooldown(); cooldown > 0 {
+			lp.cooldownUntil = lp.clock.Now().Add(cooldown)
+			lp.log.INFO.Printf("charger recovered, re-enabling after %v", cooldown)
+		} else {
+			lp.log.INFO.Println("charger recovered")
+		}
+	} else if lp.degradations > 0 && lp.clock.Since(lp.cooldownUntil) >= lp.errorCooldown() {
+		lp.log.DEBUG.Println("charger stable, resetting error cool-down")
+		lp.degradations = 0
+	}
+
+	lp.publish("degraded", lp.degraded)
+}
+
+// SiteUnavailable is called instead of Update if site power can't be determined. In pv modes, the last
+// current is held for the fallback hold period. Afterwards, the safe fallback current is applied or
+// the charger is disabled. Min pv mode continues charging at min current at least.
+func (lp *LoadPoint) SiteUnavailable() {
+	if lp.siteFailure.IsZero() {
+		lp.siteFailure = lp.clock.Now()
+	}
+
+	mode := lp.GetMode()
+	if mode != api.ModePV && mode != api.ModeMinPV || !lp.connected() {
+		return
+	}
+
+	elapsed := lp.clock.Since(lp.siteFailure)
+	if elapsed < lp.Fallback.Hold {
+		lp.log.DEBUG.Printf("site power unavailable, holding current for %v", (lp.Fallback.Hold - elapsed).Round(time.Second))
+		return
+	}
+
+	current := lp.Fallback.Current
+	if mode == api.ModeMinPV && current < lp.MinCurrent {
+		current = lp.MinCurrent
+	}
+
+	lp.log.WARN.Printf("site power unavailable for %v, fallback current: %dA", elapsed.Round(time.Second), current)
+
+	if err := lp.handler.Ramp(current, true); err != nil {
+		lp.log.ERROR.Println(err)
+	}
+}
+
+// Update is the main control function. It reevaluates meters and charger state.
+// Site power is used for control, the raw grid meter power for the session's solar share.
+func (lp *LoadPoint) Update(sitePower, gridPower float64) {
+	lp.siteFailure = time.Time{}
+
+	lp.updateBoost()
+
 	mode := lp.GetMode()
 	lp.publish("mode", string(mode))
 
+	// apply vehicle selection before reading soc
+	lp.syncVehicle()
+
 	// read and publish meters first
 	lp.updateChargeMeter()
 
@@ -622,13 +2344,29 @@ func (lp *LoadPoint) Update(sitePower float64) {
 
 	// update progress and soc before status is updated
 	lp.publishChargeProgress()
+	lp.updateSession(gridPower)
 	lp.publishSoC()
+	lp.publishVehicleStatus()
 
 	// read and publish status
 	if err := lp.updateChargerStatus(); err != nil {
 		lp.log.ERROR.Printf("charge controller error: %v", err)
+
+		// notify once per error period
+		if !lp.chargerError {
+			lp.chargerError = true
+			lp.notify(evChargerError)
+		}
+
+		lp.chargerFailed()
+
+		lp.setState(stateError, "charger error: %v", err)
+		lp.publishTransition()
+
 		return
 	}
+	lp.chargerError = false
+	lp.chargerRecovered()
 
 	lp.publish("connected", lp.connected())
 	lp.publish("charging", lp.charging)
@@ -644,27 +2382,85 @@ func (lp *LoadPoint) Update(sitePower float64) {
 	// check if car connected and ready for charging
 	var err error
 
+	// reset completion once soc falls below target or vehicle disconnects
+	if !lp.connected() || !lp.socTargetReached() && !lp.targetEnergyReached() {
+		lp.completed = false
+	}
+
+	// reset ventilation warning once status D is left
+	if lp.status != api.StatusD {
+		lp.ventRejected = false
+	}
+
+	lp.updateDimming()
+
 	// execute loading strategy
 	switch {
+	case lp.status == api.StatusD && !lp.Ventilation:
+		lp.setState(stateRejected, "charging with ventilation not allowed")
+		err = lp.rejectVentilation()
+
 	case !lp.connected():
 		// always disable charger if not connected
 		// https://github.com/andig/evcc/issues/105
+		lp.setState(stateDisconnected, "vehicle not connected")
 		err = lp.handler.Ramp(0)
 
-	case lp.targetSocReached(lp.socCharge, float64(lp.TargetSoC)):
-		err = lp.handler.Ramp(0)
+	case lp.coolingDown():
+		lp.setState(stateCooldown, "re-enabling after charger errors at %s", lp.cooldownUntil.Format("15:04:05"))
+		err = lp.handler.Ramp(0, true)
 
-	case mode == api.ModeOff:
+	case lp.sessionLimitReached():
+		lp.setState(stateLimited, "session energy limit %.1fkWh reached", lp.MaxSessionEnergy)
 		err = lp.handler.Ramp(0, true)
 
+	case lp.socTargetReached() || lp.targetEnergyReached():
+		lp.setState(stateComplete, "charge target reached, on complete: %s", lp.OnComplete)
+		err = lp.complete()
+
+	case mode == api.ModeOff:
+		lp.setState(stateOff, "off mode")
+		err = lp.off()
+
+	case lp.socFailed():
+		lp.setState(statePaused, "vehicle soc unavailable: %v", lp.socError)
+		err = lp.handler.Ramp(0)
+
+	case lp.minSocNotReached():
+		lp.log.DEBUG.Printf("soc %.0f%% below minimum soc %d%%", lp.socCharge, lp.SoC.Min)
+		lp.setState(stateEnabled, "soc %.0f%% below minimum soc %d%%", lp.socCharge, lp.SoC.Min)
+		err = lp.handler.Ramp(lp.dimCurrent(lp.maxChargeCurrent()), true)
+
+	case lp.planActive():
+		lp.setState(stateEnabled, "target soc %.0f%% planned for %s", lp.effectiveTargetSoC(), lp.GetTargetTime().Round(time.Second).Local().Format("15:04"))
+		err = lp.handler.Ramp(lp.dimCurrent(lp.maxChargeCurrent()), true)
+
 	case mode == api.ModeNow:
-		err = lp.handler.Ramp(lp.MaxCurrent, true)
+		lp.setState(stateEnabled, "now mode")
+		err = lp.handler.Ramp(lp.dimCurrent(lp.maxChargeCurrent()), true)
+
+	case mode == api.ModePrice:
+		err = lp.priceCharge()
 
 	case mode == api.ModeMinPV || mode == api.ModePV:
-		targetCurrent := lp.maxCurrent(mode, sitePower)
-		lp.log.DEBUG.Printf("target charge current: %dA", targetCurrent)
+		sitePower -= lp.climatePower()
+
+		if lp.scalePhases(sitePower) {
+			lp.setState(stateSwitching, "pausing to switch phases")
+			err = lp.handler.Ramp(0)
+		} else {
+			targetCurrent := lp.dimCurrent(lp.maxCurrent(mode, sitePower))
+			lp.log.DEBUG.Printf("target charge current: %dA", targetCurrent)
+
+			err = lp.handler.Ramp(targetCurrent)
+		}
+	}
+
+	lp.publishTransition()
+	lp.updateDisplay(mode)
 
-		err = lp.handler.Ramp(targetCurrent)
+	if err == nil {
+		err = lp.wakeUp(mode)
 	}
 
 	if err != nil {
@@ -1,10 +1,15 @@
 package core
 
 import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/andig/evcc/api"
+	"github.com/andig/evcc/core/wrapper"
 	"github.com/andig/evcc/mock"
 	"github.com/andig/evcc/push"
 	"github.com/andig/evcc/util"
@@ -173,7 +178,7 @@ func TestUpdate(t *testing.T) {
 		}
 
 		lp.Mode = tc.mode
-		lp.Update(0)
+		lp.Update(0, 0)
 
 		ctrl.Finish()
 	}
@@ -386,6 +391,46 @@ func TestRemainingChargeDuration(t *testing.T) {
 	}
 }
 
+func TestRequiredEnergy(t *testing.T) {
+	tc := []struct {
+		soc, target float64
+		capacity    int64
+		energy      float64
+	}{
+		{20, 80, 10, 6},
+		{50, 100, 60, 30},
+		{0, 100, 40, 40},
+		{80, 80, 50, 0},
+		{90, 80, 50, 0},
+	}
+
+	for _, tc := range tc {
+		if energy := requiredEnergy(tc.soc, tc.target, tc.capacity); energy != tc.energy {
+			t.Errorf("%v: wanted %.1fkWh, got %.1fkWh", tc, tc.energy, energy)
+		}
+	}
+}
+
+func TestDefaultCapacity(t *testing.T) {
+	lp := NewLoadPoint(util.NewLogger("foo"))
+
+	ctrl := gomock.NewController(t)
+	vehicle := mock.NewMockVehicle(ctrl)
+	lp.vehicle = vehicle
+
+	vehicle.EXPECT().Capacity().Return(int64(0))
+	if capacity := lp.capacity(); capacity != defaultCapacity {
+		t.Errorf("wanted %d, got %d", defaultCapacity, capacity)
+	}
+
+	vehicle.EXPECT().Capacity().Return(int64(60))
+	if capacity := lp.capacity(); capacity != 60 {
+		t.Errorf("wanted %d, got %d", 60, capacity)
+	}
+
+	ctrl.Finish()
+}
+
 func TestDisableAndEnableAtTargetSoC(t *testing.T) {
 	clock := clock.NewMock()
 	ctrl := gomock.NewController(t)
@@ -419,7 +464,7 @@ func TestDisableAndEnableAtTargetSoC(t *testing.T) {
 	vehicle.EXPECT().ChargeState().Return(85.0, nil)
 	handler.EXPECT().SyncEnabled().Return()
 	handler.EXPECT().Ramp(int64(16), true).Return(nil)
-	lp.Update(500)
+	lp.Update(500, 500)
 
 	// charging above target deactivates charger
 	clock.Add(5 * time.Minute)
@@ -428,7 +473,7 @@ func TestDisableAndEnableAtTargetSoC(t *testing.T) {
 	vehicle.EXPECT().ChargeState().Return(90.0, nil)
 	handler.EXPECT().SyncEnabled().Return()
 	handler.EXPECT().Ramp(int64(0)).Return(nil)
-	lp.Update(500)
+	lp.Update(500, 500)
 
 	// deactivated charger changes status to B
 	clock.Add(5 * time.Minute)
@@ -438,7 +483,7 @@ func TestDisableAndEnableAtTargetSoC(t *testing.T) {
 	vehicle.EXPECT().ChargeState().Return(95.0, nil)
 	handler.EXPECT().SyncEnabled().Return()
 	handler.EXPECT().Ramp(int64(0)).Return(nil)
-	lp.Update(-5000)
+	lp.Update(-5000, -5000)
 
 	// soc has fallen below target
 	clock.Add(5 * time.Minute)
@@ -447,7 +492,7 @@ func TestDisableAndEnableAtTargetSoC(t *testing.T) {
 	vehicle.EXPECT().ChargeState().Return(85.0, nil)
 	handler.EXPECT().SyncEnabled().Return()
 	handler.EXPECT().Ramp(int64(16), true).Return(nil) // TODO don't treat this as forced change
-	lp.Update(-5000)
+	lp.Update(-5000, -5000)
 
 	ctrl.Finish()
 }
@@ -487,14 +532,14 @@ func TestSetModeAndSocAtDisconnect(t *testing.T) {
 	handler.EXPECT().Status().Return(api.StatusC, nil)
 	handler.EXPECT().SyncEnabled().Return()
 	handler.EXPECT().Ramp(int64(16), true).Return(nil)
-	lp.Update(500)
+	lp.Update(500, 500)
 
 	clock.Add(5 * time.Minute)
 	handler.EXPECT().TargetCurrent().Return(int64(16))
 	handler.EXPECT().Status().Return(api.StatusA, nil)
 	handler.EXPECT().TargetCurrent().Return(int64(0)) // once more for status changes
 	handler.EXPECT().Ramp(int64(0)).Return(nil)
-	lp.Update(-3000)
+	lp.Update(-3000, -3000)
 
 	if lp.Mode != api.ModeOff {
 		t.Error("unexpected mode", lp.Mode)
@@ -557,7 +602,7 @@ func TestChargedEnergyAtDisconnect(t *testing.T) {
 	handler.EXPECT().Status().Return(api.StatusC, nil)
 	handler.EXPECT().SyncEnabled().Return()
 	handler.EXPECT().Ramp(int64(16), true).Return(nil)
-	lp.Update(-1)
+	lp.Update(-1, -1)
 
 	// at 1:00h charging at 5 kWh
 	clock.Add(time.Hour)
@@ -567,7 +612,7 @@ func TestChargedEnergyAtDisconnect(t *testing.T) {
 	handler.EXPECT().SyncEnabled().Return()
 	// handler.EXPECT().TargetCurrent().Return(int64(0)) // once more for status changes
 	handler.EXPECT().Ramp(int64(16), true).Return(nil)
-	lp.Update(-1)
+	lp.Update(-1, -1)
 	expectCache("chargedEnergy", 5000.0)
 
 	// at 1:00h stop charging at 5 kWh
@@ -578,7 +623,7 @@ func TestChargedEnergyAtDisconnect(t *testing.T) {
 	handler.EXPECT().SyncEnabled().Return()
 	handler.EXPECT().TargetCurrent().Return(int64(0)) // once more for status changes
 	handler.EXPECT().Ramp(int64(16), true).Return(nil)
-	lp.Update(-1)
+	lp.Update(-1, -1)
 	expectCache("chargedEnergy", 5000.0)
 
 	// at 1:00h restart charging at 5 kWh
@@ -589,7 +634,7 @@ func TestChargedEnergyAtDisconnect(t *testing.T) {
 	handler.EXPECT().SyncEnabled().Return()
 	handler.EXPECT().TargetCurrent().Return(int64(0)) // once more for status changes
 	handler.EXPECT().Ramp(int64(16), true).Return(nil)
-	lp.Update(-1)
+	lp.Update(-1, -1)
 	expectCache("chargedEnergy", 5000.0)
 
 	// at 1:30h continue charging at 7.5 kWh
@@ -600,7 +645,7 @@ func TestChargedEnergyAtDisconnect(t *testing.T) {
 	handler.EXPECT().SyncEnabled().Return()
 	// handler.EXPECT().TargetCurrent().Return(int64(0)) // once more for status changes
 	handler.EXPECT().Ramp(int64(16), true).Return(nil)
-	lp.Update(-1)
+	lp.Update(-1, -1)
 	expectCache("chargedEnergy", 7500.0)
 
 	// at 2:00h stop charging at 10 kWh
@@ -611,8 +656,2936 @@ func TestChargedEnergyAtDisconnect(t *testing.T) {
 	handler.EXPECT().SyncEnabled().Return()
 	handler.EXPECT().TargetCurrent().Return(int64(0)) // once more for status changes
 	handler.EXPECT().Ramp(int64(16), true).Return(nil)
-	lp.Update(-1)
+	lp.Update(-1, -1)
 	expectCache("chargedEnergy", 10000.0)
 
 	ctrl.Finish()
 }
+
+func TestSoCSourcePrecedence(t *testing.T) {
+	tc := []struct {
+		status          api.ChargeStatus
+		battery         bool
+		batterySoC      float64
+		batteryErr      error
+		vehicle         bool
+		vehicleSoC      float64
+		expected        float64
+		expectedFailure bool
+	}{
+		// vehicle only
+		{api.StatusC, false, 0, nil, true, 50, 50, false},
+		// charger soc preferred over vehicle
+		{api.StatusC, true, 60, nil, true, 50, 60, false},
+		// charger soc unavailable, fallback to vehicle
+		{api.StatusB, true, 0, errors.New("no hlc"), true, 50, 50, false},
+		// charger soc not used when disconnected
+		{api.StatusA, true, 60, nil, true, 50, 50, false},
+		// charger soc without vehicle
+		{api.StatusC, true, 60, nil, false, 0, 60, false},
+		// charger soc unavailable without vehicle
+		{api.StatusC, true, 0, errors.New("no hlc"), false, 0, 0, true},
+		// no soc source when disconnected without vehicle
+		{api.StatusA, true, 60, nil, false, 0, 0, true},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		ctrl := gomock.NewController(t)
+
+		lp := &LoadPoint{
+			log:    util.NewLogger("foo"),
+			status: tc.status,
+		}
+
+		if tc.battery {
+			battery := mock.NewMockBattery(ctrl)
+			battery.EXPECT().SoC().Return(tc.batterySoC, tc.batteryErr).AnyTimes()
+			lp.battery = battery
+		}
+
+		if tc.vehicle {
+			vehicle := mock.NewMockVehicle(ctrl)
+			vehicle.EXPECT().ChargeState().Return(tc.vehicleSoC, nil).AnyTimes()
+			lp.vehicle = vehicle
+		}
+
+		soc, err := lp.chargeState()
+		if tc.expectedFailure {
+			if err == nil {
+				t.Error("expected error")
+			}
+		} else if err != nil || soc != tc.expected {
+			t.Errorf("wanted %.0f, got %.0f (%v)", tc.expected, soc, err)
+		}
+
+		ctrl.Finish()
+	}
+}
+
+func TestOnComplete(t *testing.T) {
+	tc := []struct {
+		action string
+		notify bool
+		expect func(h *mock.MockHandler)
+	}{
+		{"", false, func(h *mock.MockHandler) {
+			h.EXPECT().Ramp(int64(0)).Times(2)
+		}},
+		{completeStop, false, func(h *mock.MockHandler) {
+			h.EXPECT().Ramp(int64(0)).Times(2)
+		}},
+		{completeHold, false, func(h *mock.MockHandler) {
+			h.EXPECT().Ramp(lpMinCurrent).Times(2)
+		}},
+		{completeNotify, true, func(h *mock.MockHandler) {
+			h.EXPECT().Ramp(int64(0)).Times(2)
+		}},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		ctrl := gomock.NewController(t)
+		handler := mock.NewMockHandler(ctrl)
+		pushChan := make(chan push.Event, 2)
+
+		lp := &LoadPoint{
+			log:      util.NewLogger("foo"),
+			pushChan: pushChan,
+			HandlerConfig: HandlerConfig{
+				MinCurrent: lpMinCurrent,
+				MaxCurrent: lpMaxCurrent,
+			},
+			handler:    handler,
+			OnComplete: tc.action,
+		}
+
+		tc.expect(handler)
+
+		// repeated completion must only notify once
+		for i := 0; i < 2; i++ {
+			if err := lp.complete(); err != nil {
+				t.Error(err)
+			}
+		}
+
+		if notified := len(pushChan); tc.notify && notified != 1 || !tc.notify && notified != 0 {
+			t.Errorf("unexpected notifications: %d", notified)
+		}
+
+		if tc.notify {
+			if ev := <-pushChan; ev.Event != evChargeComplete {
+				t.Errorf("unexpected event: %s", ev.Event)
+			}
+		}
+
+		ctrl.Finish()
+	}
+}
+
+func TestChargerErrorNotification(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler := mock.NewMockHandler(ctrl)
+
+	uiChan := make(chan util.Param)
+	pushChan := make(chan push.Event, 10)
+	go func() {
+		for range uiChan {
+		}
+	}()
+
+	lp := &LoadPoint{
+		log:         util.NewLogger("foo"),
+		bus:         evbus.New(),
+		clock:       clock.NewMock(),
+		uiChan:      uiChan,
+		pushChan:    pushChan,
+		chargeMeter: &Null{}, //silence nil panics
+		chargeRater: &Null{}, //silence nil panics
+		chargeTimer: &Null{}, //silence nil panics
+		HandlerConfig: HandlerConfig{
+			MinCurrent: lpMinCurrent,
+			MaxCurrent: lpMaxCurrent,
+		},
+		handler: handler,
+		status:  api.StatusB,
+		Mode:    api.ModeOff,
+	}
+
+	// repeated errors notify once
+	for i := 0; i < 2; i++ {
+		handler.EXPECT().TargetCurrent().Return(int64(0))
+		handler.EXPECT().Status().Return(api.StatusNone, errors.New("timeout"))
+		lp.Update(0, 0)
+	}
+
+	// recover
+	handler.EXPECT().TargetCurrent().Return(int64(0))
+	handler.EXPECT().Status().Return(api.StatusB, nil)
+	handler.EXPECT().SyncEnabled()
+	handler.EXPECT().Ramp(int64(0), true)
+	lp.Update(0, 0)
+
+	// error again after recovery
+	handler.EXPECT().TargetCurrent().Return(int64(0))
+	handler.EXPECT().Status().Return(api.StatusNone, errors.New("timeout"))
+	lp.Update(0, 0)
+
+	if len(pushChan) != 2 {
+		t.Errorf("expected 2 notifications, got %d", len(pushChan))
+	}
+
+	for len(pushChan) > 0 {
+		if ev := <-pushChan; ev.Event != evChargerError {
+			t.Errorf("unexpected event: %s", ev.Event)
+		}
+	}
+
+	ctrl.Finish()
+}
+
+func TestPVDeadband(t *testing.T) {
+	const enable, disable = -1000, 200
+
+	tc := []struct {
+		enabled bool
+		site    float64
+		current int64
+	}{
+		// disabled: start above enable level only
+		{false, -999, 0},
+		{false, -500, 0},
+		{false, 0, 0},
+		{false, 199, 0},
+		{false, -1000, lpMinCurrent},
+		// enabled: stop below disable level only
+		{true, -999, lpMinCurrent},
+		{true, -500, lpMinCurrent},
+		{true, 0, lpMinCurrent},
+		{true, 199, lpMinCurrent},
+		{true, 200, 0},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		clck := clock.NewMock()
+		ctrl := gomock.NewController(t)
+		handler := mock.NewMockHandler(ctrl)
+
+		Voltage = 100
+		lp := &LoadPoint{
+			log:   util.NewLogger("foo"),
+			clock: clck,
+			HandlerConfig: HandlerConfig{
+				MinCurrent: lpMinCurrent,
+				MaxCurrent: lpMaxCurrent,
+			},
+			handler: handler,
+			Phases:  10,
+			Enable:  ThresholdConfig{Threshold: enable},
+			Disable: ThresholdConfig{Threshold: disable},
+			status:  api.StatusC,
+		}
+
+		// no delay configured: repeated evaluation must not toggle inside deadband
+		for i := 0; i < 3; i++ {
+			clck.Add(time.Minute)
+
+			handler.EXPECT().TargetCurrent().Return(int64(0))
+			handler.EXPECT().Enabled().Return(tc.enabled)
+
+			if current := lp.maxCurrent(api.ModePV, tc.site); current != tc.current {
+				t.Errorf("step %d: wanted %d, got %d", i, tc.current, current)
+			}
+		}
+
+		ctrl.Finish()
+	}
+}
+
+func TestStatusD(t *testing.T) {
+	tc := []struct {
+		ventilation bool
+		charging    bool
+		notify      bool
+		expect      func(h *mock.MockHandler)
+	}{
+		// reject: disable charger and notify once
+		{false, false, true, func(h *mock.MockHandler) {
+			h.EXPECT().Ramp(int64(0), true).Times(2)
+		}},
+		// allow: charge according to mode
+		{true, true, false, func(h *mock.MockHandler) {
+			h.EXPECT().Ramp(lpMaxCurrent, true).Times(2)
+		}},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		ctrl := gomock.NewController(t)
+		handler := mock.NewMockHandler(ctrl)
+
+		uiChan := make(chan util.Param)
+		pushChan := make(chan push.Event, 10)
+		go func() {
+			for range uiChan {
+			}
+		}()
+
+		lp := &LoadPoint{
+			log:         util.NewLogger("foo"),
+			bus:         evbus.New(),
+			clock:       clock.NewMock(),
+			uiChan:      uiChan,
+			pushChan:    pushChan,
+			chargeMeter: &Null{}, //silence nil panics
+			chargeRater: &Null{}, //silence nil panics
+			chargeTimer: &Null{}, //silence nil panics
+			HandlerConfig: HandlerConfig{
+				MinCurrent: lpMinCurrent,
+				MaxCurrent: lpMaxCurrent,
+			},
+			handler:     handler,
+			status:      api.StatusB,
+			Mode:        api.ModeNow,
+			Ventilation: tc.ventilation,
+		}
+
+		tc.expect(handler)
+
+		for i := 0; i < 2; i++ {
+			handler.EXPECT().TargetCurrent().Return(int64(0)).AnyTimes()
+			handler.EXPECT().Status().Return(api.StatusD, nil)
+			handler.EXPECT().SyncEnabled()
+			lp.Update(0, 0)
+		}
+
+		if lp.charging != tc.charging || lp.connected() != tc.charging {
+			t.Errorf("unexpected charging/connected state: %v/%v", lp.charging, lp.connected())
+		}
+
+		if notified := len(pushChan); tc.notify && notified != 1 || !tc.notify && notified != 0 {
+			t.Errorf("unexpected notifications: %d", notified)
+		}
+
+		if tc.notify {
+			if ev := <-pushChan; ev.Event != evVentilation {
+				t.Errorf("unexpected event: %s", ev.Event)
+			}
+		}
+
+		ctrl.Finish()
+		close(uiChan)
+	}
+}
+
+func TestPVStatusD(t *testing.T) {
+	tc := []struct {
+		ventilation bool
+		current     int64
+		power       float64
+	}{
+		// not charging: pv mode starts from zero current
+		{false, 0, 0},
+		// charging with ventilation: pv mode adjusts the effective current
+		{true, 13, 10 * 100},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		ctrl := gomock.NewController(t)
+		handler := mock.NewMockHandler(ctrl)
+
+		uiChan := make(chan util.Param)
+		go func() {
+			for range uiChan {
+			}
+		}()
+
+		Voltage = 100
+		meter := &wrapper.ChargeMeter{}
+
+		lp := &LoadPoint{
+			log:         util.NewLogger("foo"),
+			clock:       clock.NewMock(),
+			uiChan:      uiChan,
+			chargeMeter: meter,
+			HandlerConfig: HandlerConfig{
+				MinCurrent: lpMinCurrent,
+				MaxCurrent: lpMaxCurrent,
+			},
+			handler:     handler,
+			status:      api.StatusD,
+			Phases:      1,
+			Ventilation: tc.ventilation,
+		}
+
+		handler.EXPECT().TargetCurrent().Return(int64(10))
+		handler.EXPECT().Enabled().Return(true).AnyTimes()
+
+		// 300W export adds 3A
+		if current := lp.maxCurrent(api.ModePV, -300); current != tc.current {
+			t.Errorf("expected %dA, got %dA", tc.current, current)
+		}
+
+		// dummy charge meter
+		lp.evChargeCurrentHandler(10)
+		if power, _ := meter.CurrentPower(); power != tc.power {
+			t.Errorf("expected %.0fW, got %.0fW", tc.power, power)
+		}
+
+		ctrl.Finish()
+		close(uiChan)
+	}
+}
+
+func TestPauseAndDisconnect(t *testing.T) {
+	clck := clock.NewMock()
+	ctrl := gomock.NewController(t)
+	handler := mock.NewMockHandler(ctrl)
+
+	lp := &LoadPoint{
+		log:          util.NewLogger("foo"),
+		bus:          evbus.New(),
+		clock:        clck,
+		chargeMeter:  &Null{}, //silence nil panics
+		chargeRater:  &Null{}, //silence nil panics
+		chargeTimer:  &Null{}, //silence nil panics
+		handler:      handler,
+		socEstimator: NewSoCEstimator(util.NewLogger("foo"), 10),
+		status:       api.StatusB,
+	}
+
+	handler.EXPECT().Prepare().Return()
+	attachListeners(t, lp)
+	handler.EXPECT().TargetCurrent().Return(int64(0)).AnyTimes()
+
+	// connected session
+	lp.connectedTime = clck.Now()
+	lp.chargedEnergy = 1000
+	lp.socCharge = 50
+	lp.socEstimator.SoC(50, 0)
+
+	status := func(status api.ChargeStatus) {
+		clck.Add(time.Minute)
+		handler.EXPECT().Status().Return(status, nil)
+		if err := lp.updateChargerStatus(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	session := func(connected time.Time, energy, soc, estimate float64) {
+		t.Helper()
+
+		if !lp.connectedTime.Equal(connected) {
+			t.Errorf("connected time: expected %v, got %v", connected, lp.connectedTime)
+		}
+		if lp.chargedEnergy != energy {
+			t.Errorf("charged energy: expected %.0f, got %.0f", energy, lp.chargedEnergy)
+		}
+		if lp.socCharge != soc {
+			t.Errorf("soc: expected %.0f, got %.0f", soc, lp.socCharge)
+		}
+		if est := lp.socEstimator.SoC(50, 1000); est != estimate {
+			t.Errorf("soc estimate: expected %.0f, got %.0f", estimate, est)
+		}
+	}
+
+	// pause C->B->C preserves session
+	start := lp.connectedTime
+	status(api.StatusC)
+	status(api.StatusB)
+	status(api.StatusC)
+	session(start, 1000, 50, 60)
+
+	// unplug/replug C->A->B resets session
+	status(api.StatusA)
+	session(start, 1000, 0, 50)
+
+	status(api.StatusB)
+	session(clck.Now(), 0, 0, 50)
+
+	ctrl.Finish()
+}
+
+func TestClimateBoost(t *testing.T) {
+	tc := []struct {
+		boost   bool
+		active  bool
+		site    float64
+		current int64
+	}{
+		// boost disabled
+		{false, true, -600, 6},
+		// climate inactive
+		{true, false, -600, 6},
+		// climate active: 1000W added to pv allowance
+		{true, true, -600, 16},
+		{true, true, 0, 10},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		ctrl := gomock.NewController(t)
+		handler := mock.NewMockHandler(ctrl)
+		climater := mock.NewMockVehicleClimater(ctrl)
+
+		vehicle := struct {
+			*mock.MockVehicle
+			*mock.MockVehicleClimater
+		}{
+			mock.NewMockVehicle(ctrl),
+			climater,
+		}
+
+		Voltage = 100
+		lp := &LoadPoint{
+			log: util.NewLogger("foo"),
+			HandlerConfig: HandlerConfig{
+				MinCurrent: lpMinCurrent,
+				MaxCurrent: lpMaxCurrent,
+			},
+			handler: handler,
+			vehicle: vehicle,
+			Phases:  1,
+			status:  api.StatusC,
+		}
+		lp.Climate.Boost = tc.boost
+		lp.Climate.Power = 1000
+
+		climater.EXPECT().Climater().Return(tc.active, 0.0, 0.0, nil).AnyTimes()
+		handler.EXPECT().TargetCurrent().Return(int64(0))
+		handler.EXPECT().Enabled().Return(true)
+
+		if current := lp.maxCurrent(api.ModeMinPV, tc.site-lp.climatePower()); current != tc.current {
+			t.Errorf("wanted %d, got %d", tc.current, current)
+		}
+
+		ctrl.Finish()
+	}
+}
+
+func TestOfflineSoCEstimation(t *testing.T) {
+	uiChan := make(chan util.Param)
+	go func() {
+		for range uiChan {
+		}
+	}()
+	defer close(uiChan)
+
+	lp := &LoadPoint{
+		log:    util.NewLogger("foo"),
+		uiChan: uiChan,
+		status: api.StatusC,
+	}
+	lp.SoC.Capacity = 10
+	lp.SoC.Start = 20
+	lp.startSoC = lp.SoC.Start
+
+	if lp.capacity() != 10 {
+		t.Errorf("expected capacity 10, got %d", lp.capacity())
+	}
+
+	tc := []struct {
+		startSoC      int
+		chargedEnergy float64
+		soc           float64
+	}{
+		{0, 0, 20},     // configured start soc
+		{0, 2000, 40},  // 2kWh charged
+		{50, 2000, 70}, // start soc set via api
+		{50, 10000, 100},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		if tc.startSoC > 0 {
+			lp.SetStartSoC(tc.startSoC)
+		}
+		lp.chargedEnergy = tc.chargedEnergy
+
+		soc, err := lp.chargeState()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if soc != tc.soc {
+			t.Errorf("wanted %.0f, got %.0f", tc.soc, soc)
+		}
+	}
+
+	// disconnect restores configured start soc
+	lp.resetSession()
+	if lp.GetStartSoC() != 20 {
+		t.Errorf("expected start soc reset, got %d", lp.GetStartSoC())
+	}
+}
+
+func TestSessionCounters(t *testing.T) {
+	clck := clock.NewMock()
+	ctrl := gomock.NewController(t)
+	handler := mock.NewMockHandler(ctrl)
+
+	lp := &LoadPoint{
+		log:          util.NewLogger("foo"),
+		bus:          evbus.New(),
+		clock:        clck,
+		chargeMeter:  &Null{}, //silence nil panics
+		chargeRater:  &Null{}, //silence nil panics
+		chargeTimer:  &Null{}, //silence nil panics
+		handler:      handler,
+		socEstimator: NewSoCEstimator(util.NewLogger("foo"), 10),
+		status:       api.StatusB,
+	}
+
+	handler.EXPECT().Prepare().Return()
+	attachListeners(t, lp)
+	handler.EXPECT().TargetCurrent().Return(int64(0)).AnyTimes()
+
+	status := func(status api.ChargeStatus) {
+		handler.EXPECT().Status().Return(status, nil)
+		if err := lp.updateChargerStatus(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// charged energy and duration as reported by charge rater and timer
+	update := func(energy float64, duration time.Duration) {
+		lp.chargedEnergy = energy
+		lp.chargeDuration = duration
+		lp.updateSession(0)
+	}
+
+	expect := func(energy float64, duration time.Duration) {
+		t.Helper()
+
+		if lp.sessionEnergy != energy {
+			t.Errorf("session energy: expected %.0f, got %.0f", energy, lp.sessionEnergy)
+		}
+		if lp.sessionDuration != duration {
+			t.Errorf("session duration: expected %v, got %v", duration, lp.sessionDuration)
+		}
+	}
+
+	// connected, charge rater still reports previous charge
+	update(5000, time.Hour)
+	expect(0, 0)
+
+	// charging, charge rater restarts
+	status(api.StatusC)
+	update(0, 0)
+	expect(0, 0)
+
+	update(3000, 30*time.Minute)
+	expect(3000, 30*time.Minute)
+
+	update(6000, time.Hour)
+	expect(6000, time.Hour)
+
+	// pause does not accumulate but preserves counters
+	status(api.StatusB)
+	update(6000, time.Hour)
+	expect(6000, time.Hour)
+
+	// resume, charge rater restarts
+	status(api.StatusC)
+	update(1000, 10*time.Minute)
+	expect(7000, 70*time.Minute)
+
+	update(3000, time.Hour)
+	expect(9000, 2*time.Hour)
+
+	// unplug resets counters
+	status(api.StatusA)
+	expect(0, 0)
+
+	update(3000, time.Hour)
+	expect(0, 0)
+
+	ctrl.Finish()
+}
+
+func TestPhaseConversion(t *testing.T) {
+	tc := []struct {
+		phases, activePhases int64
+		sitePower            float64
+		current              int64
+	}{
+		// configured phases
+		{1, 0, -2300, 10},
+		{3, 0, -6900, 10},
+		{3, 0, -2300, 3},
+		// 3p charger feeding 1p vehicle
+		{3, 1, -2300, 10},
+		{3, 1, -6900, 30},
+		{3, 2, -4600, 10},
+	}
+
+	Voltage = 230
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		ctrl := gomock.NewController(t)
+		handler := mock.NewMockHandler(ctrl)
+
+		lp := &LoadPoint{
+			log:          util.NewLogger("foo"),
+			clock:        clock.NewMock(),
+			handler:      handler,
+			Phases:       tc.phases,
+			activePhases: tc.activePhases,
+			status:       api.StatusB,
+			HandlerConfig: HandlerConfig{
+				MaxCurrent: 100,
+			},
+		}
+
+		handler.EXPECT().TargetCurrent().Return(int64(0))
+		handler.EXPECT().Enabled().Return(true).AnyTimes()
+
+		if current := lp.maxCurrent(api.ModeMinPV, tc.sitePower); current != tc.current {
+			t.Errorf("expected %dA, got %dA", tc.current, current)
+		}
+
+		ctrl.Finish()
+	}
+}
+
+func TestOnBelowMin(t *testing.T) {
+	tc := []struct {
+		onBelowMin string
+		expected   []int64
+	}{
+		// disabled after disable delay
+		{"", []int64{lpMinCurrent, lpMinCurrent, 0}},
+		{belowMinStop, []int64{lpMinCurrent, lpMinCurrent, 0}},
+		// charging continues at min current
+		{belowMinHold, []int64{lpMinCurrent, lpMinCurrent, lpMinCurrent}},
+	}
+
+	Voltage = 230
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		ctrl := gomock.NewController(t)
+		handler := mock.NewMockHandler(ctrl)
+		clck := clock.NewMock()
+
+		lp := &LoadPoint{
+			log:        util.NewLogger("foo"),
+			clock:      clck,
+			handler:    handler,
+			Phases:     1,
+			status:     api.StatusC,
+			OnBelowMin: tc.onBelowMin,
+			HandlerConfig: HandlerConfig{
+				MinCurrent: lpMinCurrent,
+				MaxCurrent: lpMaxCurrent,
+			},
+		}
+		lp.Disable.Delay = time.Minute
+
+		handler.EXPECT().TargetCurrent().Return(int64(lpMinCurrent)).AnyTimes()
+		handler.EXPECT().Enabled().Return(true).AnyTimes()
+
+		// surplus drops below min current: 1kW grid import at min current
+		for i, step := range []time.Duration{0, 30 * time.Second, 30 * time.Second} {
+			clck.Add(step)
+
+			if current := lp.maxCurrent(api.ModePV, 1000); current != tc.expected[i] {
+				t.Errorf("step %d: expected %dA, got %dA", i, tc.expected[i], current)
+			}
+		}
+
+		ctrl.Finish()
+	}
+}
+
+func TestForceSinglePhase(t *testing.T) {
+	clck := clock.NewMock()
+	ctrl := gomock.NewController(t)
+	handler := mock.NewMockHandler(ctrl)
+
+	meter := struct {
+		*mock.MockMeter
+		*mock.MockMeterCurrent
+	}{
+		mock.NewMockMeter(ctrl),
+		mock.NewMockMeterCurrent(ctrl),
+	}
+
+	Voltage = 230
+
+	lp := &LoadPoint{
+		log:              util.NewLogger("foo"),
+		clock:            clck,
+		handler:          handler,
+		chargeMeter:      meter,
+		Phases:           3,
+		ForceSinglePhase: true,
+		charging:         true,
+		chargeStarted:    clck.Now(),
+		status:           api.StatusC,
+		uiChan:           make(chan util.Param, 10),
+		HandlerConfig: HandlerConfig{
+			MaxCurrent: 100,
+		},
+	}
+
+	// currents are not used for phase detection
+	clck.Add(phaseDetectionDelay)
+	meter.MockMeterCurrent.EXPECT().Currents().Return(16.0, 16.0, 16.0, nil)
+	lp.detectPhases()
+
+	if lp.activePhases != 0 || lp.phases() != 1 {
+		t.Errorf("expected 1p, got %dp (active %dp)", lp.phases(), lp.activePhases)
+	}
+
+	// 1p power conversion
+	handler.EXPECT().TargetCurrent().Return(int64(0))
+	handler.EXPECT().Enabled().Return(true).AnyTimes()
+
+	if current := lp.maxCurrent(api.ModeMinPV, -6900); current != 30 {
+		t.Errorf("expected 30A, got %dA", current)
+	}
+
+	ctrl.Finish()
+}
+
+func TestDetectPhasesFromPower(t *testing.T) {
+	tc := []struct {
+		phases   int64
+		charging bool
+		current  int64
+		power    float64
+		voltage  float64
+		expected int64
+	}{
+		{3, true, 10, 6900, 0, 3},
+		{3, true, 10, 2300, 0, 1},
+		{3, true, 16, 7360, 0, 2},
+		{1, true, 10, 6900, 0, 1},
+		// loadpoint voltage
+		{3, true, 16, 3840, 120, 2},
+		// not charging
+		{3, false, 10, 2300, 0, 0},
+		// no power measured
+		{3, true, 10, 0, 0, 0},
+	}
+
+	Voltage = 230
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		ctrl := gomock.NewController(t)
+		handler := mock.NewMockHandler(ctrl)
+		meter := mock.NewMockMeter(ctrl)
+
+		lp := &LoadPoint{
+			log:         util.NewLogger("foo"),
+			clock:       clock.NewMock(),
+			handler:     handler,
+			chargeMeter: meter,
+			Phases:      tc.phases,
+			Voltage:     tc.voltage,
+			charging:    tc.charging,
+			chargePower: tc.power,
+			uiChan:      make(chan util.Param, 1),
+		}
+
+		handler.EXPECT().TargetCurrent().Return(tc.current).AnyTimes()
+
+		lp.detectPhases()
+
+		if lp.activePhases != tc.expected {
+			t.Errorf("expected %dp, got %dp", tc.expected, lp.activePhases)
+		}
+
+		ctrl.Finish()
+	}
+}
+
+func TestDetectPhasesChargerLimit(t *testing.T) {
+	tc := []struct {
+		charger  int64
+		err      error
+		expected int64
+	}{
+		{1, nil, 1},                   // single phase installation
+		{3, nil, 3},                   // all phases energized
+		{0, nil, 3},                   // unknown
+		{1, errors.New("timeout"), 3}, // charger error
+	}
+
+	Voltage = 230
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		ctrl := gomock.NewController(t)
+		handler := mock.NewMockHandler(ctrl)
+		phases := mock.NewMockChargePhases(ctrl)
+
+		lp := &LoadPoint{
+			log:           util.NewLogger("foo"),
+			clock:         clock.NewMock(),
+			handler:       handler,
+			chargeMeter:   mock.NewMockMeter(ctrl),
+			chargerPhases: phases,
+			Phases:        3,
+			charging:      true,
+			chargePower:   11040,
+			uiChan:        make(chan util.Param, 1),
+		}
+
+		handler.EXPECT().TargetCurrent().Return(int64(16)).AnyTimes()
+		phases.EXPECT().ActivePhases().Return(tc.charger, tc.err)
+
+		lp.detectPhases()
+
+		if lp.activePhases != tc.expected {
+			t.Errorf("expected %dp, got %dp", tc.expected, lp.activePhases)
+		}
+
+		ctrl.Finish()
+	}
+}
+
+func TestCountPhases(t *testing.T) {
+	tc := []struct {
+		i1, i2, i3 float64
+		phases     int64
+	}{
+		{0, 0, 0, 0},
+		{16, 0, 0, 1},
+		{0, 0, 16, 1},
+		{16, 16, 0, 2},
+		{16, 16, 16, 3},
+		{6, 6.1, 5.9, 3},
+		// residual currents on inactive phases
+		{10, 0.3, 0.2, 1},
+		{1, 0.9, 0, 1},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		if phases := countPhases(tc.i1, tc.i2, tc.i3); phases != tc.phases {
+			t.Errorf("expected %dp, got %dp", tc.phases, phases)
+		}
+	}
+}
+
+func TestDetectPhasesTransient(t *testing.T) {
+	clck := clock.NewMock()
+	ctrl := gomock.NewController(t)
+
+	meter := struct {
+		*mock.MockMeter
+		*mock.MockMeterCurrent
+	}{
+		mock.NewMockMeter(ctrl),
+		mock.NewMockMeterCurrent(ctrl),
+	}
+
+	uiChan := make(chan util.Param, 10)
+
+	lp := &LoadPoint{
+		log:           util.NewLogger("foo"),
+		clock:         clck,
+		chargeMeter:   meter,
+		Phases:        3,
+		charging:      true,
+		chargeStarted: clck.Now(),
+		uiChan:        uiChan,
+	}
+
+	drain := func() {
+		for len(uiChan) > 0 {
+			<-uiChan
+		}
+	}
+
+	// currents still ramping at charge start
+	meter.MockMeterCurrent.EXPECT().Currents().Return(6.0, 0.5, 0.0, nil)
+	lp.detectPhases()
+	drain()
+
+	if lp.activePhases != 0 {
+		t.Errorf("expected no detection during ramp, got %dp", lp.activePhases)
+	}
+
+	clck.Add(phaseDetectionDelay)
+
+	meter.MockMeterCurrent.EXPECT().Currents().Return(6.0, 6.0, 6.0, nil)
+	lp.detectPhases()
+	drain()
+
+	if lp.activePhases != 3 {
+		t.Errorf("expected 3p, got %dp", lp.activePhases)
+	}
+
+	ctrl.Finish()
+}
+
+func TestRelayThreshold(t *testing.T) {
+	tc := []struct {
+		status    api.ChargeStatus
+		enabled   bool
+		sitePower float64
+		current   int64
+	}{
+		// not charging: surplus must exceed fixed draw of 10A @ 230V
+		{api.StatusB, false, -2200, 0},
+		{api.StatusB, false, -2300, 10},
+		{api.StatusB, false, -5000, 10},
+		// charging: keep charging as long as no grid import
+		{api.StatusC, true, -50, 10},
+		{api.StatusC, true, 0, 10},
+		{api.StatusC, true, 100, 0},
+	}
+
+	Voltage = 230
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		ctrl := gomock.NewController(t)
+		handler := mock.NewMockHandler(ctrl)
+
+		lp := &LoadPoint{
+			log:     util.NewLogger("foo"),
+			clock:   clock.NewMock(),
+			handler: handler,
+			Phases:  1,
+			status:  tc.status,
+			HandlerConfig: HandlerConfig{
+				MinCurrent: 10,
+				MaxCurrent: 10,
+				Relay:      true,
+			},
+		}
+
+		current := int64(0)
+		if tc.enabled {
+			current = 10
+		}
+
+		handler.EXPECT().TargetCurrent().Return(current).AnyTimes()
+		handler.EXPECT().Enabled().Return(tc.enabled).AnyTimes()
+
+		if current := lp.maxCurrent(api.ModePV, tc.sitePower); current != tc.current {
+			t.Errorf("expected %dA, got %dA", tc.current, current)
+		}
+
+		ctrl.Finish()
+	}
+}
+
+func TestChargerDegraded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler := mock.NewMockHandler(ctrl)
+
+	uiChan := make(chan util.Param)
+	pushChan := make(chan push.Event, 10)
+	go func() {
+		for range uiChan {
+		}
+	}()
+
+	lp := &LoadPoint{
+		log:         util.NewLogger("foo"),
+		bus:         evbus.New(),
+		clock:       clock.NewMock(),
+		uiChan:      uiChan,
+		pushChan:    pushChan,
+		chargeMeter: &Null{}, //silence nil panics
+		chargeRater: &Null{}, //silence nil panics
+		chargeTimer: &Null{}, //silence nil panics
+		HandlerConfig: HandlerConfig{
+			MinCurrent: lpMinCurrent,
+			MaxCurrent: lpMaxCurrent,
+		},
+		handler:        handler,
+		status:         api.StatusC,
+		Mode:           api.ModeNow,
+		ErrorThreshold: 3,
+	}
+
+	fail := func() {
+		handler.EXPECT().TargetCurrent().Return(int64(0))
+		handler.EXPECT().Status().Return(api.StatusNone, errors.New("timeout"))
+	}
+
+	events := func() (res []string) {
+		for len(pushChan) > 0 {
+			res = append(res, (<-pushChan).Event)
+		}
+		return res
+	}
+
+	// below threshold
+	for i := 0; i < 2; i++ {
+		fail()
+		lp.Update(0, 0)
+	}
+
+	if lp.degraded {
+		t.Error("unexpected degraded state")
+	}
+
+	// threshold reached: charger is disabled
+	fail()
+	handler.EXPECT().Ramp(int64(0), true)
+	lp.Update(0, 0)
+
+	if !lp.degraded {
+		t.Error("expected degraded state")
+	}
+
+	// disabling is retried while degraded
+	fail()
+	handler.EXPECT().Ramp(int64(0), true)
+	lp.Update(0, 0)
+
+	if ev := events(); len(ev) != 2 || ev[0] != evChargerError || ev[1] != evChargerDegraded {
+		t.Errorf("unexpected events: %v", ev)
+	}
+
+	// recover
+	handler.EXPECT().TargetCurrent().Return(int64(0))
+	handler.EXPECT().Status().Return(api.StatusC, nil)
+	handler.EXPECT().SyncEnabled()
+	handler.EXPECT().Ramp(lpMaxCurrent, true)
+	lp.Update(0, 0)
+
+	if lp.degraded || lp.chargerErrors != 0 {
+		t.Errorf("expected recovery, got degraded %v with %d errors", lp.degraded, lp.chargerErrors)
+	}
+
+	ctrl.Finish()
+}
+
+func TestChargerErrorCooldown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler := mock.NewMockHandler(ctrl)
+	clck := clock.NewMock()
+
+	uiChan := make(chan util.Param)
+	go func() {
+		for range uiChan {
+		}
+	}()
+
+	lp := &LoadPoint{
+		log:         util.NewLogger("foo"),
+		bus:         evbus.New(),
+		clock:       clck,
+		uiChan:      uiChan,
+		pushChan:    make(chan push.Event, 10),
+		chargeMeter: &Null{}, //silence nil panics
+		chargeRater: &Null{}, //silence nil panics
+		chargeTimer: &Null{}, //silence nil panics
+		HandlerConfig: HandlerConfig{
+			MinCurrent: lpMinCurrent,
+			MaxCurrent: lpMaxCurrent,
+		},
+		handler:        handler,
+		status:         api.StatusC,
+		Mode:           api.ModeNow,
+		ErrorThreshold: 1,
+		ErrorCooldown:  time.Minute,
+	}
+
+	fail := func() {
+		handler.EXPECT().TargetCurrent().Return(int64(0))
+		handler.EXPECT().Status().Return(api.StatusNone, errors.New("timeout"))
+		handler.EXPECT().Ramp(int64(0), true)
+		lp.Update(0, 0)
+	}
+
+	succeed := func(current int64) {
+		handler.EXPECT().TargetCurrent().Return(int64(0))
+		handler.EXPECT().Status().Return(api.StatusC, nil)
+		handler.EXPECT().SyncEnabled()
+		handler.EXPECT().Ramp(current, true)
+		lp.Update(0, 0)
+	}
+
+	// cool-down doubles on repeated degradation
+	for _, cooldown := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute} {
+		fail()
+		clck.Add(time.Second)
+
+		// recovered, charger stays disabled
+		succeed(0)
+		if remaining := lp.cooldownUntil.Sub(clck.Now()); remaining != cooldown {
+			t.Errorf("expected %v cool-down, got %v", cooldown, remaining)
+		}
+
+		clck.Add(cooldown - time.Second)
+		succeed(0)
+
+		// cool-down expired
+		clck.Add(time.Second)
+		succeed(lpMaxCurrent)
+	}
+
+	// cool-down resets after stable operation
+	clck.Add(4 * time.Minute)
+	succeed(lpMaxCurrent)
+
+	if lp.degradations != 0 {
+		t.Errorf("expected cool-down reset, got %d degradations", lp.degradations)
+	}
+
+	fail()
+	succeed(0)
+	if remaining := lp.cooldownUntil.Sub(clck.Now()); remaining != time.Minute {
+		t.Errorf("expected initial cool-down, got %v", remaining)
+	}
+
+	ctrl.Finish()
+}
+
+func TestErrorCooldownLimit(t *testing.T) {
+	lp := &LoadPoint{ErrorCooldown: time.Minute, degradations: 20}
+
+	if cooldown := lp.errorCooldown(); cooldown != maxErrorCooldown {
+		t.Errorf("expected %v, got %v", maxErrorCooldown, cooldown)
+	}
+}
+
+func TestTargetRange(t *testing.T) {
+	tc := []struct {
+		rng            int64
+		soc, targetSoC float64
+		expected       int64
+	}{
+		{100, 25, 100, 400},
+		{150, 50, 80, 240},
+		{300, 90, 80, 300},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		if rng := targetRange(tc.rng, tc.soc, tc.targetSoC); rng != tc.expected {
+			t.Errorf("expected %dkm, got %dkm", tc.expected, rng)
+		}
+	}
+}
+
+func TestMinSoC(t *testing.T) {
+	tc := []struct {
+		mode   api.ChargeMode
+		soc    float64
+		expect func(h *mock.MockHandler)
+	}{
+		// below minimum soc: charge from grid
+		{api.ModePV, 10, func(h *mock.MockHandler) {
+			h.EXPECT().Ramp(lpMaxCurrent, true)
+		}},
+		{api.ModeMinPV, 19, func(h *mock.MockHandler) {
+			h.EXPECT().Ramp(lpMaxCurrent, true)
+		}},
+		// mode off is respected
+		{api.ModeOff, 10, func(h *mock.MockHandler) {
+			h.EXPECT().Ramp(int64(0), true)
+		}},
+		// above minimum soc: normal mode
+		{api.ModeMinPV, 20, func(h *mock.MockHandler) {
+			h.EXPECT().Enabled().Return(true).AnyTimes()
+			h.EXPECT().Ramp(lpMinCurrent)
+		}},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		ctrl := gomock.NewController(t)
+		handler := mock.NewMockHandler(ctrl)
+		vehicle := mock.NewMockVehicle(ctrl)
+
+		lp := &LoadPoint{
+			log:         util.NewLogger("foo"),
+			bus:         evbus.New(),
+			clock:       clock.NewMock(),
+			chargeMeter: &Null{}, //silence nil panics
+			chargeRater: &Null{}, //silence nil panics
+			chargeTimer: &Null{}, //silence nil panics
+			vehicle:     vehicle,
+			HandlerConfig: HandlerConfig{
+				MinCurrent: lpMinCurrent,
+				MaxCurrent: lpMaxCurrent,
+			},
+			handler:   handler,
+			status:    api.StatusC,
+			charging:  true,
+			Mode:      tc.mode,
+			TargetSoC: 100,
+			Phases:    1,
+		}
+		lp.SoC.Min = 20
+
+		handler.EXPECT().Prepare().Return()
+		attachListeners(t, lp)
+
+		handler.EXPECT().TargetCurrent().Return(lpMinCurrent).AnyTimes()
+		handler.EXPECT().Status().Return(api.StatusC, nil)
+		handler.EXPECT().SyncEnabled()
+		vehicle.EXPECT().ChargeState().Return(tc.soc, nil)
+		tc.expect(handler)
+
+		lp.Update(0, 0)
+
+		ctrl.Finish()
+	}
+}
+
+func TestPublishVehicleStatus(t *testing.T) {
+	tc := []struct {
+		status              api.ChargeStatus
+		connected, charging bool
+	}{
+		{api.StatusA, false, false},
+		{api.StatusB, true, false},
+		{api.StatusC, true, true},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		ctrl := gomock.NewController(t)
+
+		vehicle := &struct {
+			*mock.MockVehicle
+			*mock.MockVehicleStatus
+		}{
+			mock.NewMockVehicle(ctrl),
+			mock.NewMockVehicleStatus(ctrl),
+		}
+
+		uiChan := make(chan util.Param, 2)
+
+		lp := &LoadPoint{
+			log:     util.NewLogger("foo"),
+			uiChan:  uiChan,
+			vehicle: vehicle,
+		}
+
+		vehicle.MockVehicleStatus.EXPECT().Status().Return(tc.status, nil)
+		lp.publishVehicleStatus()
+
+		for _, expected := range []util.Param{
+			{Key: "vehicleConnected", Val: tc.connected},
+			{Key: "vehicleCharging", Val: tc.charging},
+		} {
+			if p := <-uiChan; p != expected {
+				t.Errorf("expected %v, got %v", expected, p)
+			}
+		}
+
+		ctrl.Finish()
+	}
+}
+
+func TestOnOff(t *testing.T) {
+	tc := []struct {
+		behavior string
+		enabled  bool
+		current  int64
+		expect   func(c *mock.MockCharger)
+	}{
+		{"", true, lpMinCurrent, func(c *mock.MockCharger) {
+			c.EXPECT().Enable(false)
+		}},
+		{offDisable, true, lpMinCurrent, func(c *mock.MockCharger) {
+			c.EXPECT().Enable(false)
+		}},
+		{offHold, false, lpMinCurrent, func(c *mock.MockCharger) {
+			c.EXPECT().Enable(true)
+		}},
+		{offHold, true, lpMaxCurrent, func(c *mock.MockCharger) {
+			c.EXPECT().MaxCurrent(lpMinCurrent)
+		}},
+		// already holding at min current
+		{offHold, true, lpMinCurrent, func(c *mock.MockCharger) {}},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		ctrl := gomock.NewController(t)
+		charger := mock.NewMockCharger(ctrl)
+
+		handler := &ChargerHandler{
+			log:     util.NewLogger("foo"),
+			clock:   clock.NewMock(),
+			bus:     evbus.New(),
+			charger: charger,
+			HandlerConfig: HandlerConfig{
+				MinCurrent:  lpMinCurrent,
+				MaxCurrent:  lpMaxCurrent,
+				Sensitivity: lpMaxCurrent,
+			},
+			enabled:       tc.enabled,
+			targetCurrent: tc.current,
+		}
+
+		lp := &LoadPoint{
+			log:           util.NewLogger("foo"),
+			HandlerConfig: handler.HandlerConfig,
+			handler:       handler,
+			OnOff:         tc.behavior,
+		}
+
+		tc.expect(charger)
+
+		if err := lp.off(); err != nil {
+			t.Error(err)
+		}
+
+		ctrl.Finish()
+	}
+}
+
+func TestVehicleSelection(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler := mock.NewMockHandler(ctrl)
+
+	car := mock.NewMockVehicle(ctrl)
+	other := mock.NewMockVehicle(ctrl)
+	other.EXPECT().Title().Return("other").AnyTimes()
+	other.EXPECT().Capacity().Return(int64(40)).AnyTimes()
+	car.EXPECT().Title().Return("car").AnyTimes()
+	car.EXPECT().Capacity().Return(int64(60)).AnyTimes()
+
+	lp := &LoadPoint{
+		log:         util.NewLogger("foo"),
+		bus:         evbus.New(),
+		clock:       clock.NewMock(),
+		chargeMeter: &Null{}, //silence nil panics
+		chargeRater: &Null{}, //silence nil panics
+		chargeTimer: &Null{}, //silence nil panics
+		handler:     handler,
+		VehicleRef:  "car",
+		vehicle:     car,
+		vehicleName: "car",
+		vehicles:    map[string]api.Vehicle{"car": car, "other": other},
+	}
+
+	handler.EXPECT().Prepare().Return()
+	attachListeners(t, lp)
+
+	expect := func(name string, vehicle api.Vehicle) {
+		t.Helper()
+
+		lp.syncVehicle()
+
+		if n := lp.GetVehicle(); n != name {
+			t.Errorf("expected vehicle %s, got %s", name, n)
+		}
+		if lp.vehicle != vehicle {
+			t.Errorf("expected vehicle %v, got %v", vehicle, lp.vehicle)
+		}
+	}
+
+	expect("car", car)
+
+	if err := lp.SetVehicle("invalid"); err == nil {
+		t.Error("expected error for invalid vehicle")
+	}
+	expect("car", car)
+
+	// select
+	if err := lp.SetVehicle("other"); err != nil {
+		t.Error(err)
+	}
+	expect("other", other)
+
+	if lp.capacity() != 40 {
+		t.Errorf("expected capacity of selected vehicle, got %d", lp.capacity())
+	}
+
+	// clear
+	if err := lp.SetVehicle(""); err != nil {
+		t.Error(err)
+	}
+	expect("car", car)
+
+	// disconnect clears selection
+	if err := lp.SetVehicle("other"); err != nil {
+		t.Error(err)
+	}
+	expect("other", other)
+
+	lp.evVehicleDisconnectHandler()
+	expect("car", car)
+
+	ctrl.Finish()
+}
+
+func TestSinglePhaseWarning(t *testing.T) {
+	tc := []struct {
+		phases  int64
+		samples [][3]float64
+		warned  bool
+	}{
+		// balanced
+		{3, [][3]float64{{16, 16, 16}, {16, 16, 16}, {16, 16, 16}}, false},
+		// persistent single phase
+		{3, [][3]float64{{16, 0, 0}, {16, 0, 0}, {16, 0, 0}}, true},
+		// intermittent single phase
+		{3, [][3]float64{{16, 0, 0}, {16, 16, 16}, {16, 0, 0}}, false},
+		// single phase installation
+		{1, [][3]float64{{16, 0, 0}, {16, 0, 0}, {16, 0, 0}}, false},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		clck := clock.NewMock()
+		ctrl := gomock.NewController(t)
+
+		meter := struct {
+			*mock.MockMeter
+			*mock.MockMeterCurrent
+		}{
+			mock.NewMockMeter(ctrl),
+			mock.NewMockMeterCurrent(ctrl),
+		}
+
+		uiChan := make(chan util.Param, 10)
+
+		lp := &LoadPoint{
+			log:           util.NewLogger("foo"),
+			clock:         clck,
+			chargeMeter:   meter,
+			Phases:        tc.phases,
+			charging:      true,
+			chargeStarted: clck.Now(),
+			uiChan:        uiChan,
+		}
+
+		clck.Add(phaseDetectionDelay)
+
+		for _, s := range tc.samples {
+			meter.MockMeterCurrent.EXPECT().Currents().Return(s[0], s[1], s[2], nil)
+			lp.detectPhases()
+
+			for len(uiChan) > 0 {
+				<-uiChan
+			}
+
+			clck.Add(singlePhaseWarning / 2)
+		}
+
+		if lp.phaseWarned != tc.warned {
+			t.Errorf("expected warning %v, got %v", tc.warned, lp.phaseWarned)
+		}
+
+		ctrl.Finish()
+	}
+}
+
+func TestDimming(t *testing.T) {
+	Voltage = 230
+	errDimming := errors.New("signal error")
+
+	tc := []struct {
+		mode          api.ChargeMode
+		phases        int64
+		power         float64
+		dimmed        bool
+		err           error
+		sitePower     float64
+		targetCurrent int64
+	}{
+		// not dimmed
+		{api.ModeNow, 3, 4200, false, nil, 0, lpMaxCurrent},
+		// dimmed to 6A @ 3p
+		{api.ModeNow, 3, 4200, true, nil, 0, 6},
+		// dimmed to 10A @ 1p
+		{api.ModeNow, 1, 2300, true, nil, 0, 10},
+		// never below min current
+		{api.ModeNow, 3, 1000, true, nil, 0, lpMinCurrent},
+		// signal error is treated as dimmed
+		{api.ModeNow, 1, 2300, false, errDimming, 0, 10},
+		// pv surplus is capped
+		{api.ModePV, 1, 2300, true, nil, -5000, 10},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		ctrl := gomock.NewController(t)
+		handler := mock.NewMockHandler(ctrl)
+
+		lp := &LoadPoint{
+			log:         util.NewLogger("foo"),
+			bus:         evbus.New(),
+			clock:       clock.NewMock(),
+			chargeMeter: &Null{}, //silence nil panics
+			chargeRater: &Null{}, //silence nil panics
+			chargeTimer: &Null{}, //silence nil panics
+			HandlerConfig: HandlerConfig{
+				MinCurrent: lpMinCurrent,
+				MaxCurrent: lpMaxCurrent,
+			},
+			handler:   handler,
+			status:    api.StatusC,
+			charging:  true,
+			Mode:      tc.mode,
+			TargetSoC: 100,
+			Phases:    tc.phases,
+			dimmingG: func() (bool, error) {
+				return tc.dimmed, tc.err
+			},
+		}
+		lp.Dimming.Power = tc.power
+
+		handler.EXPECT().Prepare().Return()
+		attachListeners(t, lp)
+
+		handler.EXPECT().TargetCurrent().Return(lpMinCurrent).AnyTimes()
+		handler.EXPECT().Enabled().Return(true).AnyTimes()
+		handler.EXPECT().Status().Return(api.StatusC, nil)
+		handler.EXPECT().SyncEnabled()
+
+		if tc.mode == api.ModeNow {
+			handler.EXPECT().Ramp(tc.targetCurrent, true)
+		} else {
+			handler.EXPECT().Ramp(tc.targetCurrent)
+		}
+
+		lp.Update(tc.sitePower, tc.sitePower)
+
+		ctrl.Finish()
+	}
+}
+
+func TestChargeLimit(t *testing.T) {
+	tc := []struct {
+		soc    float64
+		limit  int64
+		expect func(h *mock.MockHandler)
+	}{
+		// below vehicle limit
+		{75, 80, func(h *mock.MockHandler) {
+			h.EXPECT().Ramp(lpMaxCurrent, true)
+		}},
+		// vehicle stopped at its limit
+		{80, 80, func(h *mock.MockHandler) {
+			h.EXPECT().Ramp(int64(0))
+		}},
+		// loadpoint target below vehicle limit
+		{90, 95, func(h *mock.MockHandler) {
+			h.EXPECT().Ramp(int64(0))
+		}},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		ctrl := gomock.NewController(t)
+		handler := mock.NewMockHandler(ctrl)
+
+		vehicle := &struct {
+			*mock.MockVehicle
+			*mock.MockVehicleChargeLimit
+		}{
+			mock.NewMockVehicle(ctrl),
+			mock.NewMockVehicleChargeLimit(ctrl),
+		}
+
+		lp := &LoadPoint{
+			log:         util.NewLogger("foo"),
+			bus:         evbus.New(),
+			clock:       clock.NewMock(),
+			chargeMeter: &Null{}, //silence nil panics
+			chargeRater: &Null{}, //silence nil panics
+			chargeTimer: &Null{}, //silence nil panics
+			vehicle:     vehicle,
+			HandlerConfig: HandlerConfig{
+				MinCurrent: lpMinCurrent,
+				MaxCurrent: lpMaxCurrent,
+			},
+			handler:   handler,
+			status:    api.StatusC,
+			charging:  true,
+			Mode:      api.ModeNow,
+			TargetSoC: 90,
+			Phases:    1,
+		}
+
+		handler.EXPECT().Prepare().Return()
+		attachListeners(t, lp)
+
+		handler.EXPECT().TargetCurrent().Return(lpMinCurrent).AnyTimes()
+		handler.EXPECT().Status().Return(api.StatusC, nil)
+		handler.EXPECT().SyncEnabled()
+		vehicle.MockVehicle.EXPECT().ChargeState().Return(tc.soc, nil)
+		vehicle.MockVehicleChargeLimit.EXPECT().ChargeLimit().Return(tc.limit, nil)
+		tc.expect(handler)
+
+		lp.Update(0, 0)
+
+		ctrl.Finish()
+	}
+}
+
+func TestMaxSessionEnergy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler := mock.NewMockHandler(ctrl)
+	meter := mock.NewMockMeter(ctrl)
+	rater := mock.NewMockChargeRater(ctrl)
+	clck := clock.NewMock()
+
+	lp := &LoadPoint{
+		log:         util.NewLogger("foo"),
+		bus:         evbus.New(),
+		clock:       clck,
+		chargeMeter: meter,
+		chargeRater: rater,
+		chargeTimer: &Null{}, //silence nil panics
+		HandlerConfig: HandlerConfig{
+			MinCurrent: lpMinCurrent,
+			MaxCurrent: lpMaxCurrent,
+		},
+		handler:          handler,
+		status:           api.StatusC,
+		charging:         true,
+		Mode:             api.ModeNow,
+		Phases:           3,
+		MaxSessionEnergy: 2, // kWh
+	}
+
+	handler.EXPECT().Prepare().Return()
+	attachListeners(t, lp)
+
+	handler.EXPECT().TargetCurrent().Return(lpMaxCurrent).AnyTimes()
+	handler.EXPECT().Enabled().Return(true).AnyTimes()
+	handler.EXPECT().Status().Return(api.StatusC, nil).AnyTimes()
+	handler.EXPECT().SyncEnabled().AnyTimes()
+	meter.EXPECT().CurrentPower().Return(11000.0, nil).AnyTimes()
+
+	// charge rater adds 1.1kWh per cycle
+	tc := []struct {
+		rated, energy float64
+		current       int64
+	}{
+		{0.5, 0, lpMaxCurrent},
+		{1.6, 1100, lpMaxCurrent},
+		{2.7, 2200, 0},
+		{3.8, 3300, 0},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		rater.EXPECT().ChargedEnergy().Return(tc.rated, nil)
+		handler.EXPECT().Ramp(tc.current, true)
+		lp.Update(0, 0)
+
+		if lp.sessionEnergy != tc.energy {
+			t.Errorf("expected session energy %.0fWh, got %.0fWh", tc.energy, lp.sessionEnergy)
+		}
+
+		clck.Add(6 * time.Minute)
+	}
+
+	// disconnect resets the session
+	lp.evVehicleDisconnectHandler()
+
+	if lp.sessionLimited {
+		t.Error("expected session limit to be reset")
+	}
+
+	rater.EXPECT().ChargedEnergy().Return(3.8, nil)
+	handler.EXPECT().Ramp(lpMaxCurrent, true)
+	lp.Update(0, 0)
+
+	ctrl.Finish()
+}
+
+// sleepyVehicle wakes up after given number of wakeup attempts
+type sleepyVehicle struct {
+	*mock.MockVehicle
+	wakeAfter, wakeups int
+}
+
+func (v *sleepyVehicle) WakeUp() error {
+	v.wakeups++
+	return nil
+}
+
+func (v *sleepyVehicle) awake() bool {
+	return v.wakeups >= v.wakeAfter
+}
+
+func TestWakeUp(t *testing.T) {
+	tc := []struct {
+		strategy           string
+		wakeAfter, wakeups int
+	}{
+		{wakeupVehicle, 2, 2},
+		{wakeupToggle, 2, 2},
+		// retries exhausted
+		{wakeupVehicle, 5, 3},
+		{wakeupToggle, 5, 3},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		ctrl := gomock.NewController(t)
+		handler := mock.NewMockHandler(ctrl)
+		clck := clock.NewMock()
+
+		vehicle := &sleepyVehicle{
+			MockVehicle: mock.NewMockVehicle(ctrl),
+			wakeAfter:   tc.wakeAfter,
+		}
+
+		lp := &LoadPoint{
+			log:         util.NewLogger("foo"),
+			bus:         evbus.New(),
+			clock:       clck,
+			chargeMeter: &Null{}, //silence nil panics
+			chargeRater: &Null{}, //silence nil panics
+			chargeTimer: &Null{}, //silence nil panics
+			HandlerConfig: HandlerConfig{
+				MinCurrent: lpMinCurrent,
+				MaxCurrent: lpMaxCurrent,
+			},
+			handler:   handler,
+			vehicle:   vehicle,
+			status:    api.StatusB,
+			Mode:      api.ModeNow,
+			TargetSoC: 100,
+		}
+		lp.Wakeup.Strategy = tc.strategy
+		lp.Wakeup.Timeout = time.Minute
+		lp.Wakeup.Retries = 3
+
+		handler.EXPECT().Prepare().Return()
+		attachListeners(t, lp)
+
+		vehicle.MockVehicle.EXPECT().ChargeState().Return(50.0, nil).AnyTimes()
+		vehicle.MockVehicle.EXPECT().Capacity().Return(int64(50)).AnyTimes()
+
+		handler.EXPECT().TargetCurrent().Return(lpMaxCurrent).AnyTimes()
+		handler.EXPECT().Enabled().Return(true).AnyTimes()
+		handler.EXPECT().SyncEnabled().AnyTimes()
+		handler.EXPECT().Ramp(lpMaxCurrent, true).AnyTimes()
+		handler.EXPECT().Status().DoAndReturn(func() (api.ChargeStatus, error) {
+			if vehicle.awake() {
+				return api.StatusC, nil
+			}
+			return api.StatusB, nil
+		}).AnyTimes()
+
+		if tc.strategy == wakeupToggle {
+			handler.EXPECT().WakeUp().DoAndReturn(vehicle.WakeUp).Times(tc.wakeups)
+		}
+
+		for i := 0; i < 20; i++ {
+			lp.Update(0, 0)
+			clck.Add(30 * time.Second)
+
+			// vehicle wakeup is asynchronous
+			if lp.wakeupDone != nil {
+				<-lp.wakeupDone
+			}
+		}
+
+		if vehicle.wakeups != tc.wakeups {
+			t.Errorf("expected %d wakeups, got %d", tc.wakeups, vehicle.wakeups)
+		}
+
+		if vehicle.awake() != (lp.status == api.StatusC) {
+			t.Errorf("unexpected status %s", lp.status)
+		}
+
+		ctrl.Finish()
+	}
+}
+
+func TestChargerEnergyFallback(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	charger := &struct {
+		*mock.MockCharger
+		*mock.MockMeterEnergy
+	}{
+		mock.NewMockCharger(ctrl),
+		mock.NewMockMeterEnergy(ctrl),
+	}
+
+	lp := &LoadPoint{
+		log: util.NewLogger("foo"),
+		bus: evbus.New(),
+	}
+
+	lp.configureChargerType(charger)
+
+	gomock.InOrder(
+		charger.MockMeterEnergy.EXPECT().TotalEnergy().Return(10.0, nil),
+		charger.MockMeterEnergy.EXPECT().TotalEnergy().Return(12.5, nil),
+	)
+
+	lp.bus.Publish(evChargeStart)
+
+	if f, err := lp.chargeRater.ChargedEnergy(); f != 2.5 || err != nil {
+		t.Errorf("expected 2.5kWh, got %.1f %v", f, err)
+	}
+
+	ctrl.Finish()
+}
+
+func TestTargetEnergy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler := mock.NewMockHandler(ctrl)
+	rater := mock.NewMockChargeRater(ctrl)
+
+	Voltage = 230
+
+	lp := &LoadPoint{
+		log:         util.NewLogger("foo"),
+		bus:         evbus.New(),
+		clock:       clock.NewMock(),
+		chargeMeter: &Null{}, //silence nil panics
+		chargeRater: rater,
+		chargeTimer: &Null{}, //silence nil panics
+		HandlerConfig: HandlerConfig{
+			MinCurrent: lpMinCurrent,
+			MaxCurrent: lpMaxCurrent,
+		},
+		handler:      handler,
+		status:       api.StatusC,
+		charging:     true,
+		Mode:         api.ModePV,
+		Phases:       1,
+		TargetEnergy: 10, // kWh
+		targetEnergy: 10, // kWh
+	}
+
+	handler.EXPECT().Prepare().Return()
+	attachListeners(t, lp)
+
+	handler.EXPECT().TargetCurrent().Return(int64(10)).AnyTimes()
+	handler.EXPECT().Enabled().Return(true).AnyTimes()
+	handler.EXPECT().Status().Return(api.StatusC, nil).AnyTimes()
+	handler.EXPECT().SyncEnabled().AnyTimes()
+
+	// charge current follows pv surplus until target energy is reached
+	tc := []struct {
+		sitePower, energy float64
+		current           int64
+	}{
+		{-1150, 0, 15},
+		{460, 4, 8},
+		{0, 9.9, 10},
+		{-1150, 10, 0},
+		{-1150, 10.2, 0},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		rater.EXPECT().ChargedEnergy().Return(tc.energy, nil)
+		handler.EXPECT().Ramp(tc.current)
+
+		lp.Update(tc.sitePower, tc.sitePower)
+	}
+
+	if !lp.completed {
+		t.Error("expected charging completed")
+	}
+
+	// disabled target energy resumes charging
+	lp.SetTargetEnergy(0)
+
+	rater.EXPECT().ChargedEnergy().Return(10.2, nil)
+	handler.EXPECT().Ramp(int64(15))
+	lp.Update(-1150, -1150)
+
+	if lp.completed {
+		t.Error("expected charging resumed")
+	}
+
+	// disconnect restores the configured target energy
+	uiChan := make(chan util.Param, 100)
+	lp.uiChan = uiChan
+	lp.evVehicleDisconnectHandler()
+
+	if lp.GetTargetEnergy() != 10 {
+		t.Errorf("expected target energy restored, got %.1fkWh", lp.GetTargetEnergy())
+	}
+
+	// published in Wh like all energy values
+	close(uiChan)
+
+	var published interface{}
+	for p := range uiChan {
+		if p.Key == "targetEnergy" {
+			published = p.Val
+		}
+	}
+
+	if published != 10000.0 {
+		t.Errorf("expected target energy published as 10000Wh, got %v", published)
+	}
+
+	ctrl.Finish()
+}
+
+func TestSessionSolarPercentage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler := mock.NewMockHandler(ctrl)
+
+	lp := &LoadPoint{
+		log:      util.NewLogger("foo"),
+		bus:      evbus.New(),
+		clock:    clock.NewMock(),
+		handler:  handler,
+		charging: true,
+	}
+
+	handler.EXPECT().Prepare().Return()
+	attachListeners(t, lp)
+
+	// charge power, grid power and charged energy per cycle
+	tc := []struct {
+		chargePower, gridPower, chargedEnergy float64
+		solar, energy                         float64
+	}{
+		{6000, 0, 0, 0, 0},                // session start
+		{6000, -1000, 6000, 6000, 6000},   // surplus covers charge power
+		{6000, 2000, 12000, 10000, 12000}, // partial grid import
+		{6000, 8000, 18000, 10000, 18000}, // grid import exceeds charge power
+		{0, -3000, 18000, 10000, 18000},   // paused
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		lp.chargePower = tc.chargePower
+		lp.chargedEnergy = tc.chargedEnergy
+		lp.updateSession(tc.gridPower)
+
+		if lp.sessionSolar != tc.solar || lp.sessionEnergy != tc.energy {
+			t.Errorf("expected %.0f/%.0fWh, got %.0f/%.0fWh", tc.solar, tc.energy, lp.sessionSolar, lp.sessionEnergy)
+		}
+	}
+
+	if pct := lp.solarPercentage(); math.Round(pct) != 56 {
+		t.Errorf("expected 56%%, got %.1f%%", pct)
+	}
+
+	lp.resetSession()
+
+	if pct := lp.solarPercentage(); pct != 0 {
+		t.Errorf("expected reset, got %.1f%%", pct)
+	}
+
+	ctrl.Finish()
+}
+
+func TestTransitions(t *testing.T) {
+	Voltage = 230
+	clck := clock.NewMock()
+	ctrl := gomock.NewController(t)
+	handler := mock.NewMockHandler(ctrl)
+
+	lp := &LoadPoint{
+		log:         util.NewLogger("foo"),
+		bus:         evbus.New(),
+		clock:       clck,
+		chargeMeter: &Null{}, //silence nil panics
+		chargeRater: &Null{}, //silence nil panics
+		chargeTimer: &Null{}, //silence nil panics
+		HandlerConfig: HandlerConfig{
+			MinCurrent: lpMinCurrent,
+			MaxCurrent: lpMaxCurrent,
+		},
+		Phases:  3,
+		Enable:  ThresholdConfig{Delay: time.Minute},
+		Disable: ThresholdConfig{Delay: time.Minute},
+		handler: handler,
+		status:  api.StatusC,
+		Mode:    api.ModePV,
+	}
+
+	handler.EXPECT().Prepare().Return()
+	attachListeners(t, lp)
+
+	var current int64
+	handler.EXPECT().TargetCurrent().DoAndReturn(func() int64 { return current }).AnyTimes()
+	handler.EXPECT().Enabled().DoAndReturn(func() bool { return current > 0 }).AnyTimes()
+	handler.EXPECT().Status().Return(api.StatusC, nil).AnyTimes()
+	handler.EXPECT().SyncEnabled().Return().AnyTimes()
+	handler.EXPECT().Ramp(gomock.Any()).DoAndReturn(func(c int64, force ...bool) error {
+		current = c
+		return nil
+	}).AnyTimes()
+
+	// simulated surplus for each cycle
+	tc := []struct {
+		step      time.Duration
+		sitePower float64
+		state     string
+	}{
+		{0, 500, stateIdle},
+		{10 * time.Second, -5000, stateEnabling},
+		{time.Minute, -5000, stateEnabled},
+		{10 * time.Second, -5000, stateEnabled},
+		{10 * time.Second, 5000, stateDisabling},
+		{time.Minute, 5000, stateIdle},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		clck.Add(tc.step)
+		lp.Update(tc.sitePower, tc.sitePower)
+
+		if lp.state != tc.state {
+			t.Errorf("expected state %s, got %s (%s)", tc.state, lp.state, lp.stateReason)
+		}
+	}
+
+	expected := []string{"", stateIdle, stateEnabling, stateEnabled, stateDisabling, stateIdle}
+
+	res := lp.Transitions()
+	if len(res) != len(expected)-1 {
+		t.Fatalf("expected %d transitions, got %v", len(expected)-1, res)
+	}
+
+	for i, tr := range res {
+		if tr.From != expected[i] || tr.To != expected[i+1] || tr.Reason == "" {
+			t.Errorf("expected %s→%s, got %v", expected[i], expected[i+1], tr)
+		}
+	}
+
+	if s := res[2].String(); s != "enabling→enabled: surplus 5.0kW sufficient for min current 6A for 1m0s" {
+		t.Errorf("unexpected transition: %s", s)
+	}
+
+	ctrl.Finish()
+}
+
+func TestBoost(t *testing.T) {
+	clck := clock.NewMock()
+	ctrl := gomock.NewController(t)
+	handler := mock.NewMockHandler(ctrl)
+
+	lp := &LoadPoint{
+		log:         util.NewLogger("foo"),
+		bus:         evbus.New(),
+		clock:       clck,
+		chargeMeter: &Null{}, //silence nil panics
+		chargeRater: &Null{}, //silence nil panics
+		chargeTimer: &Null{}, //silence nil panics
+		HandlerConfig: HandlerConfig{
+			MinCurrent: lpMinCurrent,
+			MaxCurrent: lpMaxCurrent,
+		},
+		BoostDuration: time.Hour,
+		handler:       handler,
+		status:        api.StatusC,
+		Mode:          api.ModePV,
+	}
+
+	handler.EXPECT().Prepare().Return()
+	attachListeners(t, lp)
+
+	handler.EXPECT().TargetCurrent().Return(int64(0)).AnyTimes()
+	handler.EXPECT().Enabled().Return(false).AnyTimes()
+	handler.EXPECT().Status().Return(api.StatusC, nil).AnyTimes()
+	handler.EXPECT().SyncEnabled().Return().AnyTimes()
+
+	// boost charges at max current
+	lp.Boost(0)
+	if lp.GetMode() != api.ModeNow || !lp.GetBoost().Equal(clck.Now().Add(time.Hour)) {
+		t.Errorf("unexpected boost: %s until %v", lp.GetMode(), lp.GetBoost())
+	}
+
+	handler.EXPECT().Ramp(lpMaxCurrent, true).Return(nil)
+	lp.Update(0, 0)
+
+	// boosting again extends the boost and keeps the mode to restore
+	clck.Add(30 * time.Minute)
+	lp.Boost(time.Hour)
+
+	clck.Add(time.Hour - time.Second)
+	handler.EXPECT().Ramp(lpMaxCurrent, true).Return(nil)
+	lp.Update(0, 0)
+
+	if lp.GetMode() != api.ModeNow {
+		t.Errorf("expected %s, got %s", api.ModeNow, lp.GetMode())
+	}
+
+	// previous mode is restored once the boost has elapsed
+	clck.Add(time.Second)
+	handler.EXPECT().Ramp(int64(0)).Return(nil)
+	lp.Update(0, 0)
+
+	if lp.GetMode() != api.ModePV || !lp.GetBoost().IsZero() {
+		t.Errorf("expected %s, got %s until %v", api.ModePV, lp.GetMode(), lp.GetBoost())
+	}
+
+	// changing the mode ends the boost
+	lp.Boost(0)
+	lp.SetMode(api.ModeOff)
+
+	clck.Add(time.Hour)
+	lp.updateBoost()
+
+	if lp.GetMode() != api.ModeOff || !lp.GetBoost().IsZero() {
+		t.Errorf("expected %s, got %s until %v", api.ModeOff, lp.GetMode(), lp.GetBoost())
+	}
+
+	// cancelling restores the previous mode
+	lp.Boost(0)
+	lp.CancelBoost()
+
+	if lp.GetMode() != api.ModeOff || !lp.GetBoost().IsZero() {
+		t.Errorf("expected %s, got %s until %v", api.ModeOff, lp.GetMode(), lp.GetBoost())
+	}
+
+	ctrl.Finish()
+}
+
+func TestMaxPower(t *testing.T) {
+	Voltage = 230
+
+	tc := []struct {
+		power, voltage          float64
+		phases, active, current int64
+	}{
+		{0, 0, 3, 0, 32},           // disabled
+		{11, 0, 3, 0, 15},          // 11kW @ 3p = 15.9A
+		{11, 0, 1, 0, 32},          // limited by max current
+		{3.7, 0, 1, 0, 16},         // 3.7kW @ 1p = 16.1A
+		{22, 0, 3, 0, 31},          // 22kW @ 3p = 31.9A
+		{3.7, 0, 3, 1, 16},         // single phase vehicle detected on 3p loadpoint
+		{2, 0, 3, 0, lpMinCurrent}, // not below min current
+		{3.7, 120, 1, 0, 30},       // loadpoint voltage: 3.7kW @ 120V = 30.8A
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		lp := &LoadPoint{
+			HandlerConfig: HandlerConfig{
+				MinCurrent: lpMinCurrent,
+				MaxCurrent: 32,
+			},
+			MaxPower:     tc.power,
+			Voltage:      tc.voltage,
+			Phases:       tc.phases,
+			activePhases: tc.active,
+		}
+
+		if current := lp.maxChargeCurrent(); current != tc.current {
+			t.Errorf("expected %dA, got %dA", tc.current, current)
+		}
+	}
+}
+
+func TestCheckCurrents(t *testing.T) {
+	tc := []struct {
+		min, max, limit int64
+		relay, err      bool
+		warnings        int
+	}{
+		{6, 16, 0, false, false, 0},
+		{16, 6, 0, false, true, 0},  // min above max
+		{0, 16, 0, false, true, 0},  // missing min
+		{6, -1, 0, false, true, 0},  // invalid max
+		{4, 16, 0, false, false, 1}, // below IEC 61851
+		{4, 4, 0, true, false, 0},   // relay
+		{6, 100, 0, false, false, 1},
+		{6, 16, 32, false, false, 0},
+		{6, 32, 20, false, false, 1},  // max above cable rating
+		{16, 32, 13, false, false, 1}, // min above cable rating
+		{16, 32, 0, false, false, 0},  // no cable
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		ctrl := gomock.NewController(t)
+		mc := mock.NewMockCharger(ctrl)
+		cl := mock.NewMockCurrentLimiter(ctrl)
+
+		charger := &struct {
+			*mock.MockCharger
+			*mock.MockCurrentLimiter
+		}{mc, cl}
+
+		cl.EXPECT().CurrentLimit().Return(tc.limit, nil).AnyTimes()
+
+		warnings, err := checkCurrents(HandlerConfig{MinCurrent: tc.min, MaxCurrent: tc.max, Relay: tc.relay}, charger)
+		if (err != nil) != tc.err {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if len(warnings) != tc.warnings {
+			t.Errorf("expected %d warnings, got %v", tc.warnings, warnings)
+		}
+
+		ctrl.Finish()
+	}
+
+	// current limit is optional
+	ctrl := gomock.NewController(t)
+	if warnings, err := checkCurrents(HandlerConfig{MinCurrent: 6, MaxCurrent: 16}, mock.NewMockCharger(ctrl)); err != nil || len(warnings) != 0 {
+		t.Errorf("unexpected result: %v %v", warnings, err)
+	}
+	ctrl.Finish()
+}
+
+func TestConnectDebounce(t *testing.T) {
+	clck := clock.NewMock()
+	ctrl := gomock.NewController(t)
+	handler := mock.NewMockHandler(ctrl)
+
+	lp := &LoadPoint{
+		log:      util.NewLogger("foo"),
+		bus:      evbus.New(),
+		clock:    clck,
+		handler:  handler,
+		status:   api.StatusA,
+		Debounce: 10 * time.Second,
+	}
+
+	var connects, disconnects int
+	_ = lp.bus.Subscribe(evVehicleConnect, func() { connects++ })
+	_ = lp.bus.Subscribe(evVehicleDisconnect, func() { disconnects++ })
+
+	handler.EXPECT().TargetCurrent().Return(int64(0)).AnyTimes()
+
+	tc := []struct {
+		status                api.ChargeStatus
+		expected              api.ChargeStatus
+		connects, disconnects int
+	}{
+		// flickering plug-in
+		{api.StatusB, api.StatusA, 0, 0},
+		{api.StatusA, api.StatusA, 0, 0},
+		{api.StatusB, api.StatusA, 0, 0},
+		{api.StatusB, api.StatusA, 0, 0},
+		{api.StatusB, api.StatusA, 0, 0},
+		{api.StatusB, api.StatusA, 0, 0},
+		{api.StatusB, api.StatusA, 0, 0},
+		{api.StatusB, api.StatusB, 1, 0},
+		// charging is not debounced
+		{api.StatusC, api.StatusC, 1, 0},
+		// flickering while connected
+		{api.StatusA, api.StatusC, 1, 0},
+		{api.StatusC, api.StatusC, 1, 0},
+		{api.StatusA, api.StatusC, 1, 0},
+		{api.StatusA, api.StatusC, 1, 0},
+		{api.StatusA, api.StatusC, 1, 0},
+		{api.StatusA, api.StatusC, 1, 0},
+		{api.StatusA, api.StatusC, 1, 0},
+		{api.StatusA, api.StatusA, 1, 1},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		clck.Add(2 * time.Second)
+
+		handler.EXPECT().Status().Return(tc.status, nil)
+		if err := lp.updateChargerStatus(); err != nil {
+			t.Fatal(err)
+		}
+
+		if lp.status != tc.expected {
+			t.Errorf("expected status %s, got %s", tc.expected, lp.status)
+		}
+
+		if connects != tc.connects || disconnects != tc.disconnects {
+			t.Errorf("expected %d/%d connects/disconnects, got %d/%d", tc.connects, tc.disconnects, connects, disconnects)
+		}
+	}
+
+	ctrl.Finish()
+}
+
+func TestConnectDebounceStartup(t *testing.T) {
+	for _, status := range []api.ChargeStatus{api.StatusA, api.StatusB} {
+		t.Log(status)
+
+		ctrl := gomock.NewController(t)
+		handler := mock.NewMockHandler(ctrl)
+
+		lp := &LoadPoint{
+			log:      util.NewLogger("foo"),
+			bus:      evbus.New(),
+			clock:    clock.NewMock(),
+			handler:  handler,
+			Debounce: 10 * time.Second,
+		}
+
+		handler.EXPECT().TargetCurrent().Return(int64(0)).AnyTimes()
+		handler.EXPECT().Status().Return(status, nil)
+
+		// initial status is not debounced
+		if err := lp.updateChargerStatus(); err != nil {
+			t.Fatal(err)
+		}
+
+		if lp.status != status {
+			t.Errorf("expected status %s, got %s", status, lp.status)
+		}
+
+		ctrl.Finish()
+	}
+}
+
+func TestOnSoCError(t *testing.T) {
+	tc := []struct {
+		onSoCError string
+		mode       api.ChargeMode
+		err        error
+		failed     time.Duration // time the soc has already been unavailable
+		expect     func(h *mock.MockHandler)
+	}{
+		// continue ignoring soc and min soc
+		{socErrorContinue, api.ModeMinPV, errors.New("vehicle api down"), socErrorGracePeriod, func(h *mock.MockHandler) {
+			h.EXPECT().Enabled().Return(true).AnyTimes()
+			h.EXPECT().Ramp(lpMinCurrent)
+		}},
+		{"", api.ModeNow, errors.New("vehicle api down"), socErrorGracePeriod, func(h *mock.MockHandler) {
+			h.EXPECT().Ramp(lpMaxCurrent, true)
+		}},
+		// single failure within grace period is not paused
+		{socErrorPause, api.ModeNow, errors.New("vehicle api down"), 0, func(h *mock.MockHandler) {
+			h.EXPECT().Ramp(lpMaxCurrent, true)
+		}},
+		// pause on persisting error without bypassing the guard
+		{socErrorPause, api.ModeNow, errors.New("vehicle api down"), socErrorGracePeriod, func(h *mock.MockHandler) {
+			h.EXPECT().Ramp(int64(0))
+		}},
+		{socErrorPause, api.ModeMinPV, errors.New("vehicle api down"), socErrorGracePeriod, func(h *mock.MockHandler) {
+			h.EXPECT().Ramp(int64(0))
+		}},
+		// sleeping vehicle is not paused to allow waking it up
+		{socErrorPause, api.ModeNow, api.ErrAsleep, socErrorGracePeriod, func(h *mock.MockHandler) {
+			h.EXPECT().Ramp(lpMaxCurrent, true)
+		}},
+		// soc not supported by vehicle is not paused
+		{socErrorPause, api.ModeNow, api.ErrNotSupported, socErrorGracePeriod, func(h *mock.MockHandler) {
+			h.EXPECT().Ramp(lpMaxCurrent, true)
+		}},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		ctrl := gomock.NewController(t)
+		handler := mock.NewMockHandler(ctrl)
+		vehicle := mock.NewMockVehicle(ctrl)
+		clck := clock.NewMock()
+
+		lp := &LoadPoint{
+			log:         util.NewLogger("foo"),
+			bus:         evbus.New(),
+			clock:       clck,
+			chargeMeter: &Null{}, //silence nil panics
+			chargeRater: &Null{}, //silence nil panics
+			chargeTimer: &Null{}, //silence nil panics
+			vehicle:     vehicle,
+			HandlerConfig: HandlerConfig{
+				MinCurrent: lpMinCurrent,
+				MaxCurrent: lpMaxCurrent,
+			},
+			handler:    handler,
+			status:     api.StatusC,
+			charging:   true,
+			Mode:       tc.mode,
+			TargetSoC:  80,
+			Phases:     1,
+			OnSoCError: tc.onSoCError,
+		}
+		lp.SoC.Min = 20
+
+		// stale soc below min soc must not be used
+		lp.socCharge = 10
+
+		if tc.failed > 0 {
+			lp.socFailure = clck.Now().Add(-tc.failed)
+		}
+
+		handler.EXPECT().Prepare().Return()
+		attachListeners(t, lp)
+
+		handler.EXPECT().TargetCurrent().Return(lpMinCurrent).AnyTimes()
+		handler.EXPECT().Status().Return(api.StatusC, nil)
+		handler.EXPECT().SyncEnabled()
+		vehicle.EXPECT().ChargeState().Return(float64(0), tc.err)
+		tc.expect(handler)
+
+		lp.Update(0, 0)
+
+		ctrl.Finish()
+	}
+}
+
+func TestPriceMode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler := mock.NewMockHandler(ctrl)
+
+	var price float64
+	var priceErr error
+
+	lp := &LoadPoint{
+		log:         util.NewLogger("foo"),
+		bus:         evbus.New(),
+		clock:       clock.NewMock(),
+		chargeMeter: &Null{}, //silence nil panics
+		chargeRater: &Null{}, //silence nil panics
+		chargeTimer: &Null{}, //silence nil panics
+		HandlerConfig: HandlerConfig{
+			MinCurrent: lpMinCurrent,
+			MaxCurrent: lpMaxCurrent,
+		},
+		handler: handler,
+		status:  api.StatusC,
+		Mode:    api.ModePrice,
+		Phases:  1,
+		priceG: func() (float64, error) {
+			return price, priceErr
+		},
+	}
+	lp.Tariff.Threshold = 0.10
+
+	handler.EXPECT().Prepare().Return()
+	attachListeners(t, lp)
+
+	handler.EXPECT().TargetCurrent().Return(lpMinCurrent).AnyTimes()
+
+	tc := []struct {
+		price    float64
+		err      error
+		expected int64
+		force    bool
+	}{
+		{0.08, nil, lpMaxCurrent, true},
+		{0.099, nil, lpMaxCurrent, true},
+		{0.10, nil, 0, true},
+		{0.25, nil, 0, true},
+		{0.25, errors.New("tariff unavailable"), 0, false}, // keep disabled
+		{0.05, nil, lpMaxCurrent, true},
+		{0.05, errors.New("tariff unavailable"), lpMaxCurrent, true}, // keep charging
+		{-0.01, nil, lpMaxCurrent, true},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		price, priceErr = tc.price, tc.err
+
+		handler.EXPECT().Status().Return(api.StatusC, nil)
+		handler.EXPECT().SyncEnabled()
+		if tc.force {
+			handler.EXPECT().Ramp(tc.expected, true)
+		} else {
+			handler.EXPECT().Ramp(tc.expected)
+		}
+
+		lp.Update(0, 0)
+	}
+
+	ctrl.Finish()
+}
+
+type testDisplay struct {
+	texts []string
+}
+
+func (d *testDisplay) Display(text string) error {
+	d.texts = append(d.texts, text)
+	return nil
+}
+
+func TestChargerDisplay(t *testing.T) {
+	clck := clock.NewMock()
+	display := &testDisplay{}
+
+	lp := &LoadPoint{
+		log:     util.NewLogger("foo"),
+		clock:   clck,
+		display: display,
+	}
+
+	update := func(state string, charging bool, power float64) {
+		lp.state = state
+		lp.charging = charging
+		lp.chargePower = power
+		lp.updateDisplay(api.ModePV)
+	}
+
+	update(stateIdle, false, 0)
+	update(stateEnabled, true, 3200)
+
+	// rate-limited
+	clck.Add(displayInterval / 2)
+	update(stateEnabled, true, 3300)
+
+	clck.Add(displayInterval / 2)
+	update(stateEnabled, true, 3300)
+
+	// unchanged text not repeated
+	clck.Add(displayInterval)
+	update(stateEnabled, true, 3300)
+
+	// nothing shown while disconnected
+	clck.Add(displayInterval)
+	update(stateDisconnected, false, 0)
+
+	update(stateComplete, false, 0)
+
+	expected := []string{"Waiting for PV", "PV charging 3.3kW", "Full"}
+	if !reflect.DeepEqual(display.texts, expected) {
+		t.Errorf("expected %v, got %v", expected, display.texts)
+	}
+}
+
+func TestStateDuration(t *testing.T) {
+	clck := clock.NewMock()
+
+	uiChan := make(chan util.Param)
+	go func() {
+		for range uiChan {
+		}
+	}()
+	defer close(uiChan)
+
+	lp := &LoadPoint{
+		log:    util.NewLogger("foo"),
+		clock:  clck,
+		uiChan: uiChan,
+	}
+
+	if d := lp.StateDuration(); d != 0 {
+		t.Errorf("expected 0 before first state, got %v", d)
+	}
+
+	tc := []struct {
+		step     time.Duration
+		state    string
+		duration time.Duration
+	}{
+		{0, stateIdle, 0},
+		{10 * time.Minute, stateIdle, 10 * time.Minute},
+		// reset on transition
+		{time.Minute, stateEnabled, 0},
+		{2 * time.Hour, stateEnabled, 2 * time.Hour},
+		{30 * time.Second, stateComplete, 0},
+		{time.Minute, stateComplete, time.Minute},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		clck.Add(tc.step)
+		lp.setState(tc.state, "test")
+		lp.publishTransition()
+
+		if d := lp.StateDuration(); d != tc.duration {
+			t.Errorf("expected %v, got %v", tc.duration, d)
+		}
+	}
+}
+
+func TestMinSoCTargetTime(t *testing.T) {
+	Voltage = 230
+
+	tc := []struct {
+		soc        float64
+		targetTime time.Duration
+		state      string
+		expect     func(h *mock.MockHandler)
+	}{
+		// below minimum soc: charge immediately regardless of plan
+		{10, 24 * time.Hour, "soc 10% below minimum soc 20%", func(h *mock.MockHandler) {
+			h.EXPECT().Ramp(lpMaxCurrent, true)
+		}},
+		{10, time.Hour, "soc 10% below minimum soc 20%", func(h *mock.MockHandler) {
+			h.EXPECT().Ramp(lpMaxCurrent, true)
+		}},
+		// minimum soc reached, plan not yet due: pv mode
+		{20, 24 * time.Hour, "surplus 1.0kW below min current 6A", func(h *mock.MockHandler) {
+			h.EXPECT().Enabled().Return(false).AnyTimes()
+			h.EXPECT().Ramp(int64(0))
+		}},
+		// plan due: 30kWh at 3.7kW take 8.2h
+		{20, 8 * time.Hour, "target soc 80% planned for", func(h *mock.MockHandler) {
+			h.EXPECT().Ramp(lpMaxCurrent, true)
+		}},
+		// no plan: pv mode
+		{40, 0, "surplus 1.0kW below min current 6A", func(h *mock.MockHandler) {
+			h.EXPECT().Enabled().Return(false).AnyTimes()
+			h.EXPECT().Ramp(int64(0))
+		}},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		clck := clock.NewMock()
+		ctrl := gomock.NewController(t)
+		handler := mock.NewMockHandler(ctrl)
+		vehicle := mock.NewMockVehicle(ctrl)
+
+		lp := &LoadPoint{
+			log:         util.NewLogger("foo"),
+			bus:         evbus.New(),
+			clock:       clck,
+			chargeMeter: &Null{}, //silence nil panics
+			chargeRater: &Null{}, //silence nil panics
+			chargeTimer: &Null{}, //silence nil panics
+			vehicle:     vehicle,
+			HandlerConfig: HandlerConfig{
+				MinCurrent: lpMinCurrent,
+				MaxCurrent: lpMaxCurrent,
+			},
+			handler:   handler,
+			status:    api.StatusC,
+			charging:  true,
+			Mode:      api.ModePV,
+			TargetSoC: 80,
+			Phases:    1,
+		}
+		lp.SoC.Min = 20
+
+		if tc.targetTime > 0 {
+			lp.targetTime = clck.Now().Add(tc.targetTime)
+		}
+
+		handler.EXPECT().Prepare().Return()
+		attachListeners(t, lp)
+
+		handler.EXPECT().TargetCurrent().Return(int64(0)).AnyTimes()
+		handler.EXPECT().Status().Return(api.StatusC, nil)
+		handler.EXPECT().SyncEnabled()
+		vehicle.EXPECT().ChargeState().Return(tc.soc, nil)
+		vehicle.EXPECT().Capacity().Return(int64(50)).AnyTimes()
+		tc.expect(handler)
+
+		lp.Update(-1000, -1000)
+
+		if !strings.HasPrefix(lp.stateReason, tc.state) {
+			t.Errorf("expected state reason %q, got %q", tc.state, lp.stateReason)
+		}
+
+		ctrl.Finish()
+	}
+}
+
+type testPhaseSwitcher struct {
+	switches []int
+}
+
+func (ps *testPhaseSwitcher) Phases1p3p(phases int) error {
+	ps.switches = append(ps.switches, phases)
+	return nil
+}
+
+func TestPhaseSwitchCooldown(t *testing.T) {
+	Voltage = 230
+	clck := clock.NewMock()
+	ps := &testPhaseSwitcher{}
+
+	uiChan := make(chan util.Param)
+	go func() {
+		for range uiChan {
+		}
+	}()
+	defer close(uiChan)
+
+	ctrl := gomock.NewController(t)
+	handler := mock.NewMockHandler(ctrl)
+	handler.EXPECT().Enabled().Return(false).AnyTimes()
+
+	lp := &LoadPoint{
+		log:     util.NewLogger("foo"),
+		clock:   clck,
+		uiChan:  uiChan,
+		handler: handler,
+		HandlerConfig: HandlerConfig{
+			MinCurrent: lpMinCurrent,
+			MaxCurrent: lpMaxCurrent,
+		},
+		Phases:              3,
+		PhaseSwitchCooldown: 5 * time.Minute,
+		phaseSwitcher:       ps,
+	}
+
+	// min current at 3p requires 4.1kW, switching back to 3p 4.8kW
+	tc := []struct {
+		step      time.Duration
+		sitePower float64
+		switches  []int
+	}{
+		{0, -5000, nil},
+		// insufficient for 3p
+		{0, -2000, []int{1}},
+		// sufficient for 3p again, within cool-down
+		{time.Minute, -5000, []int{1}},
+		{3 * time.Minute, -5000, []int{1}},
+		// cool-down elapsed
+		{time.Minute, -5000, []int{1, 3}},
+		// insufficient again, within cool-down
+		{time.Minute, -2000, []int{1, 3}},
+		{5 * time.Minute, -2000, []int{1, 3, 1}},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		clck.Add(tc.step)
+		lp.scalePhases(tc.sitePower)
+
+		if !reflect.DeepEqual(ps.switches, tc.switches) {
+			t.Errorf("expected switches %v, got %v", tc.switches, ps.switches)
+		}
+	}
+
+	if phases := lp.phases(); phases != 1 {
+		t.Errorf("expected 1p, got %dp", phases)
+	}
+
+	ctrl.Finish()
+}
+
+func TestPhaseSwitchHysteresis(t *testing.T) {
+	Voltage = 230
+	ps := &testPhaseSwitcher{}
+
+	ctrl := gomock.NewController(t)
+	handler := mock.NewMockHandler(ctrl)
+	handler.EXPECT().Enabled().Return(false).AnyTimes()
+
+	lp := &LoadPoint{
+		log:     util.NewLogger("foo"),
+		clock:   clock.NewMock(),
+		uiChan:  make(chan util.Param, 10),
+		handler: handler,
+		HandlerConfig: HandlerConfig{
+			MinCurrent: lpMinCurrent,
+			MaxCurrent: lpMaxCurrent,
+		},
+		Phases:         3,
+		phaseSwitcher:  ps,
+		switchedPhases: 1,
+	}
+
+	// min current at 3p requires 4.1kW, switching back to 3p 4.8kW
+	tc := []struct {
+		sitePower float64
+		switches  []int
+	}{
+		{-4500, nil},
+		{-5000, []int{3}},
+		{-4500, []int{3}},
+		{-4200, []int{3}},
+		{-4000, []int{3, 1}},
+		{-4500, []int{3, 1}},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		lp.scalePhases(tc.sitePower)
+
+		if !reflect.DeepEqual(ps.switches, tc.switches) {
+			t.Errorf("expected switches %v, got %v", tc.switches, ps.switches)
+		}
+	}
+
+	ctrl.Finish()
+}
+
+func TestPhaseSwitchPause(t *testing.T) {
+	Voltage = 230
+	ps := &testPhaseSwitcher{}
+
+	ctrl := gomock.NewController(t)
+	handler := mock.NewMockHandler(ctrl)
+
+	lp := &LoadPoint{
+		log:     util.NewLogger("foo"),
+		clock:   clock.NewMock(),
+		uiChan:  make(chan util.Param, 10),
+		handler: handler,
+		HandlerConfig: HandlerConfig{
+			MinCurrent: lpMinCurrent,
+			MaxCurrent: lpMaxCurrent,
+		},
+		Phases:        3,
+		phaseSwitcher: ps,
+	}
+
+	// charging is paused first
+	handler.EXPECT().Enabled().Return(true)
+	if !lp.scalePhases(-2000) || len(ps.switches) > 0 {
+		t.Errorf("expected pause before switching, got switches %v", ps.switches)
+	}
+
+	// switched once disabled
+	handler.EXPECT().Enabled().Return(false)
+	if lp.scalePhases(-2000) || !reflect.DeepEqual(ps.switches, []int{1}) {
+		t.Errorf("expected switch to 1p, got switches %v", ps.switches)
+	}
+
+	ctrl.Finish()
+}
+
+func TestPhaseSwitchSinglePhaseVehicle(t *testing.T) {
+	Voltage = 230
+	ps := &testPhaseSwitcher{}
+
+	ctrl := gomock.NewController(t)
+	handler := mock.NewMockHandler(ctrl)
+	handler.EXPECT().Enabled().Return(false).AnyTimes()
+
+	lp := &LoadPoint{
+		log:     util.NewLogger("foo"),
+		clock:   clock.NewMock(),
+		uiChan:  make(chan util.Param, 10),
+		handler: handler,
+		HandlerConfig: HandlerConfig{
+			MinCurrent: lpMinCurrent,
+			MaxCurrent: lpMaxCurrent,
+		},
+		Phases:         3,
+		phaseSwitcher:  ps,
+		switchedPhases: 3,
+		activePhases:   1, // detected from currents
+	}
+
+	for _, sitePower := range []float64{-2000, -5000, -2000} {
+		if lp.scalePhases(sitePower) || len(ps.switches) > 0 {
+			t.Errorf("unexpected switches for single phase vehicle: %v", ps.switches)
+		}
+	}
+
+	ctrl.Finish()
+}
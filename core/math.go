@@ -26,3 +26,11 @@ func clamp(x, min, max int64) int64 {
 	}
 	return x
 }
+
+// abs calculates absolute value of an integer value
+func abs(x int64) int64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
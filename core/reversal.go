@@ -0,0 +1,39 @@
+package core
+
+import "math"
+
+const (
+	reversalTolerance = 500 // W export in excess of generation attributed to meter timing
+	reversalSamples   = 5   // consecutive implausible samples before warning
+)
+
+// reversalDetector flags grid meters with likely reversed current transformer clamps.
+// A reversed clamp reports import as export. Since the site cannot export more than
+// is generated by pv and battery, consistent excess export indicates a wiring problem.
+type reversalDetector struct {
+	count  int
+	warned bool
+}
+
+// plausible returns false if the grid export cannot be explained by pv and battery
+func (d *reversalDetector) plausible(grid, pv, battery float64) bool {
+	// pv meter may be reversed itself
+	generation := math.Abs(pv) + math.Max(battery, 0)
+	return -grid <= generation+reversalTolerance
+}
+
+// Check adds a sample and returns true once the grid meter is considered reversed
+func (d *reversalDetector) Check(grid, pv, battery float64) bool {
+	if d.plausible(grid, pv, battery) {
+		d.count = 0
+		return false
+	}
+
+	d.count++
+	if d.count < reversalSamples || d.warned {
+		return false
+	}
+
+	d.warned = true
+	return true
+}
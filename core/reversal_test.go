@@ -0,0 +1,58 @@
+package core
+
+import "testing"
+
+func TestReversalDetector(t *testing.T) {
+	tc := []struct {
+		name              string
+		grid, pv, battery float64
+		samples           int
+		expected          bool
+	}{
+		{"night import", 500, 0, 0, 10, false},
+		{"pv export", -3000, 4000, 0, 10, false},
+		{"pv and battery export", -5000, 4000, 2000, 10, false},
+		{"timing jitter", -4300, 4000, 0, 10, false},
+		{"reversed at night", -500, 0, 0, 10, false},
+		{"reversed at night", -1500, 0, 0, 10, true},
+		{"reversed during charging", -11000, 2000, 0, 10, true},
+		{"reversed pv meter", -3000, -4000, 0, 10, false},
+		{"too few samples", -11000, 2000, 0, reversalSamples - 1, false},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		d := new(reversalDetector)
+
+		var warnings int
+		for i := 0; i < tc.samples; i++ {
+			if d.Check(tc.grid, tc.pv, tc.battery) {
+				warnings++
+			}
+		}
+
+		if tc.expected && warnings != 1 {
+			t.Errorf("%s: expected single warning, got %d", tc.name, warnings)
+		}
+		if !tc.expected && warnings != 0 {
+			t.Errorf("%s: expected no warning, got %d", tc.name, warnings)
+		}
+	}
+}
+
+func TestReversalDetectorReset(t *testing.T) {
+	d := new(reversalDetector)
+
+	// plausible sample resets the counter
+	for i := 0; i < 2*reversalSamples; i++ {
+		if i%reversalSamples == reversalSamples-1 {
+			d.Check(500, 0, 0)
+			continue
+		}
+
+		if d.Check(-2000, 0, 0) {
+			t.Fatalf("%d: unexpected warning", i)
+		}
+	}
+}
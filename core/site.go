@@ -1,6 +1,7 @@
 package core
 
 import (
+	"sync"
 	"time"
 
 	"github.com/andig/evcc/api"
@@ -8,6 +9,7 @@ import (
 	"github.com/andig/evcc/push"
 	"github.com/andig/evcc/util"
 	"github.com/avast/retry-go"
+	"github.com/benbjohnson/clock"
 	"github.com/pkg/errors"
 )
 
@@ -15,7 +17,8 @@ import (
 
 // Updater abstracts the LoadPoint implementation for testing
 type Updater interface {
-	Update(float64)
+	Update(sitePower, gridPower float64)
+	SiteUnavailable()
 }
 
 // Site is the main configuration container. A site can host multiple loadpoints.
@@ -26,10 +29,13 @@ type Site struct {
 	log *util.Logger
 
 	// configuration
-	Title         string       `mapstructure:"title"`         // UI title
-	Voltage       float64      `mapstructure:"voltage"`       // Operating voltage. 230V for Germany.
-	ResidualPower float64      `mapstructure:"residualPower"` // PV meter only: household usage. Grid meter: household safety margin
-	Meters        MetersConfig // Meter references
+	Title         string        `mapstructure:"title"`         // UI title
+	Voltage       float64       `mapstructure:"voltage"`       // Operating voltage. 230V for Germany.
+	ResidualPower float64       `mapstructure:"residualPower"` // PV meter only: household usage. Grid meter: household safety margin
+	Meters        MetersConfig  // Meter references
+	Filter        time.Duration `mapstructure:"filter"` // Site power low-pass filter time constant, 0 to disable
+
+	ConcurrentMeters bool `mapstructure:"concurrentMeters"` // Read meters in parallel instead of sequentially
 
 	// meters
 	gridMeter    api.Meter // Grid usage meter
@@ -37,18 +43,24 @@ type Site struct {
 	batteryMeter api.Meter // Battery charging meter
 
 	loadpoints []*LoadPoint // Loadpoints
+	filter     *ema         // Site power filter
+	reversal   *reversalDetector
 
 	// cached state
 	gridPower    float64 // Grid power
 	pvPower      float64 // PV power
 	batteryPower float64 // Battery charge power
+
+	mux       sync.Mutex // guard home power
+	homePower float64    // Home consumption without loadpoints, guarded by mux
+	homeValid bool       // Home power available, guarded by mux
 }
 
 // MetersConfig contains the loadpoint's meter configuration
 type MetersConfig struct {
-	GridMeterRef    string `mapstructure:"grid"`    // Grid usage meter reference
-	PVMeterRef      string `mapstructure:"pv"`      // PV generation meter reference
-	BatteryMeterRef string `mapstructure:"battery"` // Battery charging meter reference
+	GridMeterRef    []string `mapstructure:"grid"`    // Grid usage meter references
+	PVMeterRef      []string `mapstructure:"pv"`      // PV generation meter references
+	BatteryMeterRef []string `mapstructure:"battery"` // Battery charging meter references
 }
 
 // NewSiteFromConfig creates a new site
@@ -66,24 +78,44 @@ func NewSiteFromConfig(
 	Voltage = site.Voltage
 	site.loadpoints = loadpoints
 
+	if site.Filter > 0 {
+		site.filter = newEMA(clock.New(), site.Filter)
+	}
+
 	// configure meter from references
 	// if site.Meters.PVMeterRef == "" && site.Meters.GridMeterRef == "" {
 	// 	site.log.FATAL.Fatal("missing either pv or grid meter")
 	// }
-	if site.Meters.GridMeterRef == "" {
+	if len(site.Meters.GridMeterRef) == 0 {
 		site.log.FATAL.Fatal("missing grid meter")
 	}
-	if site.Meters.GridMeterRef != "" {
-		site.gridMeter = cp.Meter(site.Meters.GridMeterRef)
+	site.gridMeter = site.meter(cp, site.Meters.GridMeterRef)
+	site.pvMeter = site.meter(cp, site.Meters.PVMeterRef)
+	site.batteryMeter = site.meter(cp, site.Meters.BatteryMeterRef)
+
+	// export can only be judged against metered generation
+	if site.pvMeter != nil {
+		site.reversal = new(reversalDetector)
 	}
-	if site.Meters.PVMeterRef != "" {
-		site.pvMeter = cp.Meter(site.Meters.PVMeterRef)
+
+	return site
+}
+
+// meter resolves meter references. Multiple meters are summed up.
+func (site *Site) meter(cp configProvider, refs []string) api.Meter {
+	switch len(refs) {
+	case 0:
+		return nil
+	case 1:
+		return cp.Meter(refs[0])
 	}
-	if site.Meters.BatteryMeterRef != "" {
-		site.batteryMeter = cp.Meter(site.Meters.BatteryMeterRef)
+
+	meters := make([]api.Meter, 0, len(refs))
+	for _, ref := range refs {
+		meters = append(meters, cp.Meter(ref))
 	}
 
-	return site
+	return wrapper.NewSumMeter(site.log, refs, meters)
 }
 
 // NewSite creates a Site with sane defaults
@@ -113,6 +145,7 @@ type LoadpointConfiguration struct {
 	MinCurrent  int64  `json:"minCurrent"`
 	MaxCurrent  int64  `json:"maxCurrent"`
 	ChargeMeter bool   `json:"chargeMeter"`
+	Tariff      bool   `json:"tariff"`
 	SoC         bool   `json:"soc"`
 	SoCCapacity int64  `json:"socCapacity"`
 	SoCTitle    string `json:"socTitle"`
@@ -173,16 +206,22 @@ func (site *Site) Configuration() SiteConfiguration {
 			MinCurrent:  lp.MinCurrent,
 			MaxCurrent:  lp.MaxCurrent,
 			ChargeMeter: lp.hasChargeMeter(),
+			Tariff:      lp.priceG != nil,
 		}
 
-		if lp.vehicle != nil {
+		lp.Lock()
+		if lp.vehicle != nil || lp.offline() {
 			lpc.SoC = true
-			lpc.SoCCapacity = lp.vehicle.Capacity()
-			lpc.SoCTitle = lp.vehicle.Title()
+			lpc.SoCCapacity = lp.capacity()
 			lpc.SoCLevels = lp.SoC.Levels
 			lpc.TargetSoC = lp.TargetSoC
 		}
 
+		if lp.vehicle != nil {
+			lpc.SoCTitle = lp.vehicle.Title()
+		}
+		lp.Unlock()
+
 		c.LoadPoints = append(c.LoadPoints, lpc)
 	}
 
@@ -195,6 +234,10 @@ func (site *Site) DumpConfig() {
 	site.log.INFO.Printf("  grid %s", presence[site.gridMeter != nil])
 	site.log.INFO.Printf("  pv %s", presence[site.pvMeter != nil])
 	site.log.INFO.Printf("  battery %s", presence[site.batteryMeter != nil])
+	site.log.INFO.Printf("  residual power %.0fW", site.ResidualPower)
+	if site.filter != nil {
+		site.log.INFO.Printf("  filter %v", site.Filter)
+	}
 
 	if site.gridMeter != nil {
 		_, power := site.gridMeter.(api.Meter)
@@ -212,6 +255,9 @@ func (site *Site) DumpConfig() {
 
 		lp.log.INFO.Printf("  vehicle %s", presence[lp.vehicle != nil])
 		lp.log.INFO.Printf("  charge %s", presence[lp.hasChargeMeter()])
+		if lp.Interval > 0 {
+			lp.log.INFO.Printf("  interval %v", lp.Interval)
+		}
 
 		charger := lp.handler.(*ChargerHandler).charger
 		_, power := charger.(api.Meter)
@@ -274,12 +320,40 @@ func (site *Site) updateMeters() error {
 		return err
 	}
 
-	// pv meter is not critical for operation
-	_ = retryMeter("pv", site.pvMeter, &site.pvPower)
+	var err error
+
+	if site.ConcurrentMeters {
+		var wg sync.WaitGroup
+		var gridErr, batteryErr error
+
+		wg.Add(3)
+		go func() {
+			// pv meter is not critical for operation
+			_ = retryMeter("pv", site.pvMeter, &site.pvPower)
+			wg.Done()
+		}()
+		go func() {
+			gridErr = retryMeter("grid", site.gridMeter, &site.gridPower)
+			wg.Done()
+		}()
+		go func() {
+			batteryErr = retryMeter("battery", site.batteryMeter, &site.batteryPower)
+			wg.Done()
+		}()
+		wg.Wait()
+
+		if err = gridErr; err == nil {
+			err = batteryErr
+		}
+	} else {
+		// read one meter at a time in fixed order, e.g. for meters sharing a modbus gateway
+		// pv meter is not critical for operation
+		_ = retryMeter("pv", site.pvMeter, &site.pvPower)
 
-	err := retryMeter("grid", site.gridMeter, &site.gridPower)
-	if err == nil {
-		err = retryMeter("battery", site.batteryMeter, &site.batteryPower)
+		err = retryMeter("grid", site.gridMeter, &site.gridPower)
+		if err == nil {
+			err = retryMeter("battery", site.batteryMeter, &site.batteryPower)
+		}
 	}
 
 	// currents
@@ -291,6 +365,15 @@ func (site *Site) updateMeters() error {
 		}
 	}
 
+	// battery soc
+	if battery, ok := site.batteryMeter.(api.Battery); err == nil && ok {
+		soc, err := battery.SoC()
+		if err == nil {
+			site.log.DEBUG.Printf("battery soc: %.0f%%", soc)
+			site.publish("batterySoC", soc)
+		}
+	}
+
 	return err
 }
 
@@ -306,8 +389,20 @@ func (site *Site) sitePower() (float64, error) {
 		return 0, err
 	}
 
+	if site.reversal != nil && site.reversal.Check(site.gridPower, site.pvPower, site.batteryPower) {
+		site.log.WARN.Printf("grid meter reports %.0fW export exceeding %.0fW pv generation, check for reversed current transformer clamps",
+			-site.gridPower, site.pvPower)
+	}
+
 	sitePower := sitePower(site.gridPower, site.batteryPower, site.ResidualPower)
-	site.log.DEBUG.Printf("site power: %.0fW", sitePower)
+
+	if site.filter != nil {
+		raw := sitePower
+		sitePower = site.filter.Add(raw)
+		site.log.DEBUG.Printf("site power: %.0fW (raw %.0fW)", sitePower, raw)
+	} else {
+		site.log.DEBUG.Printf("site power: %.0fW", sitePower)
+	}
 
 	return sitePower, nil
 }
@@ -315,9 +410,15 @@ func (site *Site) sitePower() (float64, error) {
 func (site *Site) update(lp Updater) {
 	site.log.DEBUG.Println("----")
 
-	if sitePower, err := site.sitePower(); err == nil {
-		lp.Update(sitePower)
+	sitePower, err := site.sitePower()
+	if err == nil {
+		lp.Update(sitePower, site.gridPower)
+		site.updateHomePower(true)
+		return
 	}
+
+	site.updateHomePower(false)
+	lp.SiteUnavailable()
 }
 
 // Prepare attaches communication channels to site and loadpoints
@@ -356,6 +457,14 @@ func (site *Site) loopLoadpoints(next chan<- Updater) {
 	}
 }
 
+// scheduled returns true if the loadpoint's own update interval has elapsed
+func scheduled(lp Updater) bool {
+	if lp, ok := lp.(*LoadPoint); ok {
+		return lp.updateDue()
+	}
+	return true
+}
+
 // Run is the main control loop. It reacts to trigger events by
 // updating measurements and executing control logic.
 func (site *Site) Run(interval time.Duration) {
@@ -363,12 +472,17 @@ func (site *Site) Run(interval time.Duration) {
 	go site.loopLoadpoints(loadpointChan)
 
 	ticker := time.NewTicker(interval)
-	site.update(<-loadpointChan) // start immediately
+	if lp := <-loadpointChan; scheduled(lp) {
+		site.update(lp) // start immediately
+	}
 
 	for {
 		select {
 		case <-ticker.C:
-			site.update(<-loadpointChan)
+			// skip loadpoints with slower update interval
+			if lp := <-loadpointChan; scheduled(lp) {
+				site.update(lp)
+			}
 		case lp := <-site.lpUpdateChan:
 			site.update(lp)
 		}
@@ -1,7 +1,17 @@
 package core
 
 import (
+	"errors"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/andig/evcc/api"
+	"github.com/andig/evcc/mock"
+	"github.com/andig/evcc/util"
+	"github.com/benbjohnson/clock"
+	"github.com/golang/mock/gomock"
 )
 
 func TestSitePower(t *testing.T) {
@@ -24,3 +34,334 @@ func TestSitePower(t *testing.T) {
 		}
 	}
 }
+
+func TestSitePowerResidual(t *testing.T) {
+	tc := []struct {
+		grid, battery, residual, site float64
+	}{
+		{0, 0, 100, 100},      // zero import treated as import
+		{-100, 0, 100, 0},     // export consumed by residual margin
+		{-500, 0, 100, -400},  // export reduced by residual margin
+		{-500, 0, -100, -600}, // negative residual allows grid import
+	}
+
+	for _, tc := range tc {
+		res := sitePower(tc.grid, tc.battery, tc.residual)
+		if res != tc.site {
+			t.Errorf("sitePower wanted %.f, got %.f", tc.site, res)
+		}
+	}
+}
+
+func TestResidualPowerShiftsThresholds(t *testing.T) {
+	dt := time.Minute
+
+	tc := []struct {
+		enabled         bool
+		grid, residual  float64
+		enable, disable float64
+		current         int64
+	}{
+		// enable threshold met without residual
+		{false, -500, 0, -500, 0, lpMinCurrent},
+		// enable threshold no longer met with residual
+		{false, -500, 100, -500, 0, 0},
+		// enable threshold met with residual if export is larger
+		{false, -600, 100, -500, 0, lpMinCurrent},
+		// disable threshold not met without residual
+		{true, 400, 0, 0, 500, lpMinCurrent},
+		// disable threshold met with residual
+		{true, 400, 100, 0, 500, 0},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		clck := clock.NewMock()
+		ctrl := gomock.NewController(t)
+		handler := mock.NewMockHandler(ctrl)
+
+		Voltage = 100
+		lp := &LoadPoint{
+			log:   util.NewLogger("foo"),
+			clock: clck,
+			HandlerConfig: HandlerConfig{
+				MinCurrent: lpMinCurrent,
+				MaxCurrent: lpMaxCurrent,
+			},
+			handler: handler,
+			Phases:  10,
+			Enable: ThresholdConfig{
+				Threshold: tc.enable,
+				Delay:     dt,
+			},
+			Disable: ThresholdConfig{
+				Threshold: tc.disable,
+				Delay:     dt,
+			},
+			status: api.StatusC,
+		}
+
+		sitePower := sitePower(tc.grid, 0, tc.residual)
+
+		var current int64
+		for _, delay := range []time.Duration{0, dt + 1} {
+			clck.Add(delay)
+
+			handler.EXPECT().TargetCurrent().Return(int64(0))
+			handler.EXPECT().Enabled().Return(tc.enabled)
+
+			current = lp.maxCurrent(api.ModePV, sitePower)
+		}
+
+		if current != tc.current {
+			t.Errorf("wanted %d, got %d", tc.current, current)
+		}
+
+		ctrl.Finish()
+	}
+}
+
+func TestLoadpointInterval(t *testing.T) {
+	clck := clock.NewMock()
+
+	lp := &LoadPoint{
+		log:      util.NewLogger("foo"),
+		clock:    clck,
+		Interval: 30 * time.Second,
+	}
+
+	// site interval ticks
+	tc := []struct {
+		elapsed time.Duration
+		due     bool
+	}{
+		{0, true},
+		{10 * time.Second, false},
+		{10 * time.Second, false},
+		{10 * time.Second, true},
+		{10 * time.Second, false},
+		{19 * time.Second, false},
+		{time.Second, true},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+		clck.Add(tc.elapsed)
+
+		if due := scheduled(lp); due != tc.due {
+			t.Errorf("expected due %v, got %v", tc.due, due)
+		}
+	}
+
+	// default interval follows site interval
+	lp.Interval = 0
+	if !scheduled(lp) || !scheduled(lp) {
+		t.Error("expected loadpoint without interval to always be due")
+	}
+}
+
+type meterProvider map[string]api.Meter
+
+func (cp meterProvider) Meter(name string) api.Meter     { return cp[name] }
+func (cp meterProvider) Charger(name string) api.Charger { return nil }
+func (cp meterProvider) Vehicle(name string) api.Vehicle { return nil }
+
+func TestSiteMultipleMeters(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	grid := mock.NewMockMeter(ctrl)
+	pv1 := mock.NewMockMeter(ctrl)
+	pv2 := mock.NewMockMeter(ctrl)
+
+	cp := meterProvider{"grid": grid, "pv1": pv1, "pv2": pv2}
+
+	site := NewSiteFromConfig(util.NewLogger("foo"), cp, map[string]interface{}{
+		"meters": map[string]interface{}{
+			"grid": "grid",
+			"pv":   []interface{}{"pv1", "pv2"},
+		},
+	}, nil)
+
+	if site.gridMeter != grid {
+		t.Error("single grid meter should not be wrapped")
+	}
+
+	pv1.EXPECT().CurrentPower().Return(1000.0, nil)
+	pv2.EXPECT().CurrentPower().Return(2000.0, nil)
+
+	if power, err := site.pvMeter.CurrentPower(); err != nil || power != 3000 {
+		t.Errorf("expected 3000W, got %.0f %v", power, err)
+	}
+
+	ctrl.Finish()
+}
+
+func TestSiteUpdateGridPower(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	grid := mock.NewMockMeter(ctrl)
+	battery := mock.NewMockMeter(ctrl)
+	lp := mock.NewMockUpdater(ctrl)
+
+	site := &Site{
+		log:           util.NewLogger("foo"),
+		gridMeter:     grid,
+		batteryMeter:  battery,
+		ResidualPower: 100,
+	}
+
+	grid.EXPECT().CurrentPower().Return(500.0, nil)
+	battery.EXPECT().CurrentPower().Return(1000.0, nil)
+
+	// site power includes battery and residual power, grid power is the raw meter value
+	lp.EXPECT().Update(1600.0, 500.0)
+
+	site.update(lp)
+
+	ctrl.Finish()
+}
+
+func TestSiteUpdateUnavailable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	grid := mock.NewMockMeter(ctrl)
+	lp := mock.NewMockUpdater(ctrl)
+
+	site := &Site{
+		log:       util.NewLogger("foo"),
+		gridMeter: grid,
+	}
+
+	grid.EXPECT().CurrentPower().Return(0.0, errors.New("timeout")).AnyTimes()
+
+	// fallback instead of update
+	lp.EXPECT().SiteUnavailable()
+
+	site.update(lp)
+
+	ctrl.Finish()
+}
+
+func TestSiteUnavailable(t *testing.T) {
+	tc := []struct {
+		mode     api.ChargeMode
+		fallback int64
+		current  int64
+	}{
+		{api.ModePV, 0, 0},
+		{api.ModePV, 8, 8},
+		{api.ModeMinPV, 0, lpMinCurrent},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		ctrl := gomock.NewController(t)
+		handler := mock.NewMockHandler(ctrl)
+		grid := mock.NewMockMeter(ctrl)
+		clck := clock.NewMock()
+
+		site := &Site{
+			log:       util.NewLogger("foo"),
+			gridMeter: grid,
+		}
+
+		lp := &LoadPoint{
+			log:     util.NewLogger("foo"),
+			clock:   clck,
+			handler: handler,
+			status:  api.StatusC,
+			Mode:    tc.mode,
+			HandlerConfig: HandlerConfig{
+				MinCurrent: lpMinCurrent,
+				MaxCurrent: lpMaxCurrent,
+			},
+		}
+		lp.Fallback.Hold = 2 * time.Minute
+		lp.Fallback.Current = tc.fallback
+
+		grid.EXPECT().CurrentPower().Return(0.0, errors.New("timeout")).AnyTimes()
+
+		// last current is held
+		site.update(lp)
+		clck.Add(time.Minute)
+		lp.SiteUnavailable()
+
+		// safe current after hold period
+		clck.Add(time.Minute)
+		handler.EXPECT().Ramp(tc.current, true)
+		lp.SiteUnavailable()
+
+		ctrl.Finish()
+	}
+}
+
+// recordingMeter records the order of reads and waits for all meters to be read when blocking
+type recordingMeter struct {
+	name  string
+	mu    *sync.Mutex
+	reads *[]string
+	wg    *sync.WaitGroup // concurrent reads only
+}
+
+func (m *recordingMeter) CurrentPower() (float64, error) {
+	m.mu.Lock()
+	*m.reads = append(*m.reads, m.name)
+	m.mu.Unlock()
+
+	if m.wg != nil {
+		m.wg.Done()
+
+		done := make(chan struct{})
+		go func() {
+			m.wg.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			return 0, errors.New("meters not read concurrently")
+		}
+	}
+
+	return 0, nil
+}
+
+func TestSiteMeterConcurrency(t *testing.T) {
+	for _, concurrent := range []bool{false, true} {
+		t.Log(concurrent)
+
+		var mu sync.Mutex
+		var reads []string
+
+		var wg *sync.WaitGroup
+		if concurrent {
+			wg = new(sync.WaitGroup)
+			wg.Add(3)
+		}
+
+		meter := func(name string) api.Meter {
+			return &recordingMeter{name: name, mu: &mu, reads: &reads, wg: wg}
+		}
+
+		site := &Site{
+			log:              util.NewLogger("foo"),
+			ConcurrentMeters: concurrent,
+			pvMeter:          meter("pv"),
+			gridMeter:        meter("grid"),
+			batteryMeter:     meter("battery"),
+		}
+
+		if err := site.updateMeters(); err != nil {
+			t.Error(err)
+		}
+
+		if len(reads) != 3 {
+			t.Fatalf("expected 3 reads, got %v", reads)
+		}
+
+		if !concurrent && strings.Join(reads, ",") != "pv,grid,battery" {
+			t.Errorf("unexpected read order: %v", reads)
+		}
+	}
+}
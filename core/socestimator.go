@@ -0,0 +1,65 @@
+package core
+
+import (
+	"math"
+
+	"github.com/andig/evcc/util"
+)
+
+// offlineSoC estimates the soc from the assumed start soc and the energy (Wh)
+// charged since plugging in for vehicles without api
+func offlineSoC(startSoC, chargedEnergy float64, capacity int64) float64 {
+	if capacity <= 0 {
+		return startSoC
+	}
+
+	return math.Min(startSoC+chargedEnergy/1e3/float64(capacity)*100, 100)
+}
+
+// SoCEstimator interpolates the vehicle's soc between infrequent vehicle api polls
+// using the charged energy and battery capacity. The estimate snaps back to the
+// measured value whenever a new value is reported by the vehicle.
+type SoCEstimator struct {
+	log      *util.Logger
+	capacity float64 // kWh
+
+	initialized bool
+	measuredSoC float64 // last soc reported by vehicle
+	energyBase  float64 // charged energy when soc was reported (Wh)
+}
+
+// NewSoCEstimator creates a soc estimator for the given battery capacity in kWh
+func NewSoCEstimator(log *util.Logger, capacity int64) *SoCEstimator {
+	return &SoCEstimator{
+		log:      log,
+		capacity: float64(capacity),
+	}
+}
+
+// SoC returns the estimated soc given the measured soc and charged energy in Wh
+func (s *SoCEstimator) SoC(measuredSoC, chargedEnergy float64) float64 {
+	// snap back to measured value on vehicle update or charged energy reset
+	if !s.initialized || measuredSoC != s.measuredSoC || chargedEnergy < s.energyBase {
+		s.initialized = true
+		s.measuredSoC = measuredSoC
+		s.energyBase = chargedEnergy
+
+		return measuredSoC
+	}
+
+	if s.capacity <= 0 {
+		return measuredSoC
+	}
+
+	delta := (chargedEnergy - s.energyBase) / 1e3 / s.capacity * 100
+	estimate := math.Min(measuredSoC+delta, 100)
+
+	s.log.TRACE.Printf("estimated soc: %.1f%% (%.0f%% + %.0fWh)", estimate, measuredSoC, chargedEnergy-s.energyBase)
+
+	return estimate
+}
+
+// Reset discards the measured soc, e.g. when the vehicle is disconnected
+func (s *SoCEstimator) Reset() {
+	s.initialized = false
+}
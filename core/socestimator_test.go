@@ -0,0 +1,58 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/andig/evcc/util"
+)
+
+func TestSoCEstimator(t *testing.T) {
+	// 10kWh capacity: 100Wh per percent
+	s := NewSoCEstimator(util.NewLogger("foo"), 10)
+
+	tc := []struct {
+		measured, energy, estimate float64
+	}{
+		{20, 0, 20},       // initial value
+		{20, 500, 25},     // interpolate
+		{20, 1000, 30},    // interpolate
+		{28, 1000, 28},    // snap back on vehicle update
+		{28, 1200, 30},    // interpolate from new base
+		{30, 1200, 30},    // snap back
+		{30, 0, 30},       // snap back on energy reset
+		{30, 100000, 100}, // capped at 100%
+	}
+
+	for step, tc := range tc {
+		if estimate := s.SoC(tc.measured, tc.energy); estimate != tc.estimate {
+			t.Errorf("step %d: wanted %.1f, got %.1f", step, tc.estimate, estimate)
+		}
+	}
+}
+
+func TestOfflineSoC(t *testing.T) {
+	tc := []struct {
+		start, energy float64
+		capacity      int64
+		soc           float64
+	}{
+		{20, 0, 10, 20},        // plugged in
+		{20, 1000, 10, 30},     // 1kWh into 10kWh
+		{20, 5000, 50, 30},     // 5kWh into 50kWh
+		{50, 8000, 10, 100},    // capped at 100%
+		{40, 1000, 0, 40},      // no capacity
+		{0, 2500, 50, 5},       // empty start
+		{20, 10000, 40, 45},    // cumulative energy
+		{20, 20000, 40, 70},    // cumulative energy
+		{20, 100000, 40, 100},  // capped
+		{100, 100000, 40, 100}, // full
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		if soc := offlineSoC(tc.start, tc.energy, tc.capacity); soc != tc.soc {
+			t.Errorf("wanted %.1f, got %.1f", tc.soc, soc)
+		}
+	}
+}
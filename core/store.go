@@ -0,0 +1,138 @@
+package core
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/andig/evcc/api"
+)
+
+// StateFile is the path of the persistent loadpoint state store. State is not persisted if empty.
+var StateFile string
+
+var (
+	stateStoresMux sync.Mutex
+	stateStores    = make(map[string]*stateStore)
+)
+
+// storedState is the persisted state of a loadpoint. The mode is only persisted if enabled by the loadpoint.
+type storedState struct {
+	Counters
+	Mode api.ChargeMode `json:"mode,omitempty"` // Last runtime charge mode
+}
+
+// stateStore persists loadpoint state like counters and mode across restarts.
+// A nil stateStore does not persist state.
+type stateStore struct {
+	mux    sync.Mutex
+	file   string
+	states map[string]storedState
+}
+
+// sharedStateStore returns the state store for StateFile or nil if state persistence is disabled
+func sharedStateStore() (*stateStore, error) {
+	if StateFile == "" {
+		return nil, nil
+	}
+
+	stateStoresMux.Lock()
+	defer stateStoresMux.Unlock()
+
+	if ss, ok := stateStores[StateFile]; ok {
+		return ss, nil
+	}
+
+	ss, err := newStateStore(StateFile)
+	if err == nil {
+		stateStores[StateFile] = ss
+	}
+
+	return ss, err
+}
+
+// newStateStore creates a state store backed by the given file
+func newStateStore(file string) (*stateStore, error) {
+	ss := &stateStore{
+		file:   file,
+		states: make(map[string]storedState),
+	}
+
+	b, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return ss, nil
+	}
+
+	if err == nil {
+		err = json.Unmarshal(b, &ss.states)
+	}
+
+	return ss, err
+}
+
+// LoadCounters returns the persisted counters for key
+func (ss *stateStore) LoadCounters(key string) Counters {
+	if ss == nil {
+		return Counters{}
+	}
+
+	ss.mux.Lock()
+	defer ss.mux.Unlock()
+
+	return ss.states[key].Counters
+}
+
+// SaveCounters persists the counters for key
+func (ss *stateStore) SaveCounters(key string, c Counters) error {
+	if ss == nil {
+		return nil
+	}
+
+	ss.mux.Lock()
+	defer ss.mux.Unlock()
+
+	state := ss.states[key]
+	state.Counters = c
+	ss.states[key] = state
+
+	return ss.write()
+}
+
+// LoadMode returns the persisted mode for key, empty if not persisted
+func (ss *stateStore) LoadMode(key string) api.ChargeMode {
+	if ss == nil {
+		return ""
+	}
+
+	ss.mux.Lock()
+	defer ss.mux.Unlock()
+
+	return ss.states[key].Mode
+}
+
+// SaveMode persists the mode for key
+func (ss *stateStore) SaveMode(key string, mode api.ChargeMode) error {
+	if ss == nil {
+		return nil
+	}
+
+	ss.mux.Lock()
+	defer ss.mux.Unlock()
+
+	state := ss.states[key]
+	state.Mode = mode
+	ss.states[key] = state
+
+	return ss.write()
+}
+
+// write saves all loadpoint states to the store's file. Must be called with mutex held.
+func (ss *stateStore) write() error {
+	b, err := json.MarshalIndent(ss.states, "", "  ")
+	if err == nil {
+		err = ioutil.WriteFile(ss.file, b, 0644)
+	}
+
+	return err
+}
@@ -0,0 +1,138 @@
+package core
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/andig/evcc/api"
+	"github.com/andig/evcc/mock"
+	"github.com/andig/evcc/util"
+	"github.com/golang/mock/gomock"
+)
+
+func TestStateStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "evcc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "state.json")
+
+	ss, err := newStateStore(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := Counters{LifetimeEnergy: 20, LifetimeCost: 6, Energy: 7, Cost: 2.1, Reset: time.Unix(1600000000, 0).UTC()}
+	if err := ss.SaveCounters("wallbe", c); err != nil {
+		t.Fatal(err)
+	}
+
+	// restart
+	if ss, err = newStateStore(file); err != nil {
+		t.Fatal(err)
+	}
+
+	if res := ss.LoadCounters("wallbe"); res != c {
+		t.Errorf("expected %v, got %v", c, res)
+	}
+
+	if res := ss.LoadCounters("other"); res != (Counters{}) {
+		t.Errorf("expected empty counters, got %v", res)
+	}
+
+	// disabled persistence
+	var nilStore *stateStore
+	if err := nilStore.SaveCounters("wallbe", c); err != nil {
+		t.Error(err)
+	}
+	if res := nilStore.LoadCounters("wallbe"); res != (Counters{}) {
+		t.Errorf("expected empty counters, got %v", res)
+	}
+}
+
+type testConfigProvider struct {
+	charger api.Charger
+}
+
+func (cp *testConfigProvider) Meter(string) api.Meter {
+	return nil
+}
+
+func (cp *testConfigProvider) Charger(string) api.Charger {
+	return cp.charger
+}
+
+func (cp *testConfigProvider) Vehicle(string) api.Vehicle {
+	return nil
+}
+
+func TestPersistMode(t *testing.T) {
+	dir, err := ioutil.TempDir("", "evcc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	StateFile = filepath.Join(dir, "state.json")
+	defer func() { StateFile = "" }()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cp := &testConfigProvider{charger: mock.NewMockCharger(ctrl)}
+
+	uiChan := make(chan util.Param)
+	go func() {
+		for range uiChan {
+		}
+	}()
+	defer close(uiChan)
+
+	newLoadPoint := func(persist bool) *LoadPoint {
+		lp := NewLoadPointFromConfig(util.NewLogger("foo"), cp, map[string]interface{}{
+			"charger":     "charger",
+			"mode":        "pv",
+			"persistMode": persist,
+		})
+		lp.uiChan = uiChan
+		return lp
+	}
+
+	// configured startup mode
+	lp := newLoadPoint(true)
+	if mode := lp.GetMode(); mode != api.ModePV {
+		t.Errorf("expected startup mode %s, got %s", api.ModePV, mode)
+	}
+
+	lp.SetMode(api.ModeNow)
+
+	// persisted mode restored
+	if mode := newLoadPoint(true).GetMode(); mode != api.ModeNow {
+		t.Errorf("expected restored mode %s, got %s", api.ModeNow, mode)
+	}
+
+	// persisted mode ignored if disabled
+	if mode := newLoadPoint(false).GetMode(); mode != api.ModePV {
+		t.Errorf("expected startup mode %s, got %s", api.ModePV, mode)
+	}
+
+	// boost is not persisted
+	lp = newLoadPoint(true)
+	lp.SetMode(api.ModeMinPV)
+	lp.Boost(0)
+
+	if mode := newLoadPoint(true).GetMode(); mode != api.ModeMinPV {
+		t.Errorf("expected restored mode %s, got %s", api.ModeMinPV, mode)
+	}
+
+	// saving counters keeps the persisted mode
+	lp.ResetCounters()
+	if mode := newLoadPoint(true).GetMode(); mode != api.ModeMinPV {
+		t.Errorf("expected restored mode %s, got %s", api.ModeMinPV, mode)
+	}
+}
@@ -0,0 +1,148 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andig/evcc/api"
+	"github.com/andig/evcc/push"
+	"github.com/andig/evcc/util"
+)
+
+// webhook events
+const (
+	hookConnect    = "connect"    // vehicle connected
+	hookStart      = "start"      // charging started
+	hookStop       = "stop"       // charging stopped
+	hookDisconnect = "disconnect" // vehicle disconnected
+	hookComplete   = "complete"   // charge target reached
+)
+
+var hookEvents = []string{hookConnect, hookStart, hookStop, hookDisconnect, hookComplete}
+
+// webhookQueue is the number of events buffered per webhook while sending
+const webhookQueue = 16
+
+// WebhookConfig is the configuration of a charger status webhook
+type WebhookConfig struct {
+	URI     string            `mapstructure:"uri"`     // Webhook url
+	Method  string            `mapstructure:"method"`  // Http method, defaults to POST
+	Headers map[string]string `mapstructure:"headers"` // Additional request headers
+	Events  []string          `mapstructure:"events"`  // Events to send, all if empty
+}
+
+// webhookPayload is the json body sent to webhooks
+type webhookPayload struct {
+	Event     string           `json:"event"`
+	LoadPoint string           `json:"loadpoint"`
+	From      api.ChargeStatus `json:"from"`
+	To        api.ChargeStatus `json:"to"`
+	Time      time.Time        `json:"time"`
+	Session   webhookSession   `json:"session"`
+}
+
+// webhookSession contains the session statistics at the time of the event
+type webhookSession struct {
+	Energy          float64 `json:"energy"`          // kWh
+	Duration        int64   `json:"duration"`        // s
+	SolarPercentage float64 `json:"solarPercentage"` // %
+}
+
+// webhook sends charger status transitions to home automation systems using the push webhook sender.
+// Events are queued and delivered in order by a single goroutine per webhook.
+type webhook struct {
+	*push.Webhook
+	log    *util.Logger
+	events []string
+	queue  chan webhookPayload
+}
+
+// newWebhook creates a charger status webhook
+func newWebhook(log *util.Logger, cc WebhookConfig) (*webhook, error) {
+	for _, ev := range cc.Events {
+		var valid bool
+		for _, e := range hookEvents {
+			valid = valid || e == ev
+		}
+
+		if !valid {
+			return nil, fmt.Errorf("webhook: invalid event: %s (valid events: %s)", ev, strings.Join(hookEvents, ", "))
+		}
+	}
+
+	sender, err := push.NewWebhookMessenger(cc.URI, cc.Method, cc.Headers, "")
+	if err != nil {
+		return nil, err
+	}
+
+	h := &webhook{
+		Webhook: sender,
+		log:     log,
+		events:  cc.Events,
+		queue:   make(chan webhookPayload, webhookQueue),
+	}
+
+	go h.run()
+
+	return h, nil
+}
+
+// subscribed returns true if the webhook sends the given event
+func (h *webhook) subscribed(event string) bool {
+	if len(h.events) == 0 {
+		return true
+	}
+
+	for _, ev := range h.events {
+		if ev == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+// run sends the queued payloads to the webhook. Errors are only logged.
+func (h *webhook) run() {
+	for payload := range h.queue {
+		if err := h.SendJSON(payload); err != nil {
+			h.log.ERROR.Printf("webhook %s: %v", payload.Event, err)
+		}
+	}
+}
+
+// enqueue queues the payload for sending, it is dropped if the queue is full
+func (h *webhook) enqueue(payload webhookPayload) {
+	select {
+	case h.queue <- payload:
+	default:
+		h.log.WARN.Printf("webhook %s: queue full, event dropped", payload.Event)
+	}
+}
+
+// fireWebhooks sends the event to all subscribed webhooks without blocking the control loop
+func (lp *LoadPoint) fireWebhooks(event string, from, to api.ChargeStatus) {
+	if len(lp.webhooks) == 0 {
+		return
+	}
+
+	payload := webhookPayload{
+		Event:     event,
+		LoadPoint: lp.Title,
+		From:      from,
+		To:        to,
+		Time:      lp.clock.Now(),
+		Session: webhookSession{
+			Energy:          lp.sessionEnergy / 1e3,
+			Duration:        int64(lp.sessionDuration / time.Second),
+			SolarPercentage: lp.solarPercentage(),
+		},
+	}
+
+	for _, h := range lp.webhooks {
+		if h.subscribed(event) {
+			h.enqueue(payload)
+		}
+	}
+}
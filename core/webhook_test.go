@@ -0,0 +1,154 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/andig/evcc/api"
+	"github.com/andig/evcc/mock"
+	"github.com/andig/evcc/util"
+	evbus "github.com/asaskevich/EventBus"
+	"github.com/benbjohnson/clock"
+	"github.com/golang/mock/gomock"
+)
+
+func TestWebhookConfig(t *testing.T) {
+	log := util.NewLogger("foo")
+
+	if _, err := newWebhook(log, WebhookConfig{}); err == nil {
+		t.Error("missing uri not detected")
+	}
+
+	if _, err := newWebhook(log, WebhookConfig{URI: "http://foo", Events: []string{"plug"}}); err == nil {
+		t.Error("invalid event not detected")
+	}
+
+	h, err := newWebhook(log, WebhookConfig{URI: "http://foo", Method: "put", Events: []string{hookComplete}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !h.subscribed(hookComplete) || h.subscribed(hookStart) {
+		t.Errorf("unexpected webhook events: %v", h.events)
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	reqC := make(chan webhookPayload, 10)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload webhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Error(err)
+		}
+
+		if r.Method != http.MethodPost || r.Header.Get("X-Token") != "secret" {
+			t.Errorf("unexpected request: %s %v", r.Method, r.Header)
+		}
+
+		reqC <- payload
+
+		// failures must not affect control
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	ctrl := gomock.NewController(t)
+	handler := mock.NewMockHandler(ctrl)
+	clck := clock.NewMock()
+
+	log := util.NewLogger("foo")
+	h, err := newWebhook(log, WebhookConfig{URI: srv.URL, Headers: map[string]string{"X-Token": "secret"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lp := &LoadPoint{
+		log:             log,
+		bus:             evbus.New(),
+		clock:           clck,
+		handler:         handler,
+		Title:           "Garage",
+		status:          api.StatusA,
+		webhooks:        []*webhook{h},
+		sessionEnergy:   6000,
+		sessionSolar:    1500,
+		sessionDuration: time.Hour,
+	}
+
+	tc := []struct {
+		status api.ChargeStatus
+		events []string
+	}{
+		{api.StatusB, []string{hookConnect}},
+		{api.StatusC, []string{hookStart}},
+		{api.StatusC, nil},
+		{api.StatusA, []string{hookStop, hookDisconnect}},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		prev := lp.status
+		handler.EXPECT().Status().Return(tc.status, nil)
+		handler.EXPECT().TargetCurrent().Return(int64(0)).AnyTimes()
+
+		if err := lp.updateChargerStatus(); err != nil {
+			t.Error(err)
+		}
+
+		// events are received in order
+		for _, ev := range tc.events {
+			var payload webhookPayload
+			select {
+			case payload = <-reqC:
+			case <-time.After(time.Second):
+				t.Fatal("webhook not received")
+			}
+
+			expected := webhookPayload{
+				Event:     ev,
+				LoadPoint: "Garage",
+				From:      prev,
+				To:        tc.status,
+				Time:      clck.Now(),
+				Session:   webhookSession{Energy: 6, Duration: 3600, SolarPercentage: 25},
+			}
+
+			if !payload.Time.Equal(expected.Time) {
+				t.Errorf("unexpected time: %v", payload.Time)
+			}
+			payload.Time = expected.Time
+
+			if payload != expected {
+				t.Errorf("expected %+v, got %+v", expected, payload)
+			}
+		}
+	}
+
+	// charge target reached
+	handler.EXPECT().Ramp(int64(0)).Return(nil)
+	if err := lp.complete(); err != nil {
+		t.Error(err)
+	}
+
+	select {
+	case payload := <-reqC:
+		if payload.Event != hookComplete || payload.From != api.StatusA || payload.To != api.StatusA {
+			t.Errorf("unexpected event: %+v", payload)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("webhook not received")
+	}
+
+	select {
+	case payload := <-reqC:
+		t.Errorf("unexpected event: %+v", payload)
+	default:
+	}
+
+	ctrl.Finish()
+}
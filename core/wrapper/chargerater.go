@@ -13,6 +13,7 @@ import (
 // ChargeRater is responsible for providing charged energy amount
 // by implementing api.ChargeRater. It uses the charge meter's TotalEnergy or
 // keeps track of consumed energy by regularly updating consumed power.
+// Lifetime energy counters are differenced, counter resets are tolerated.
 type ChargeRater struct {
 	sync.Mutex
 	log           *util.Logger
@@ -20,7 +21,8 @@ type ChargeRater struct {
 	meter         api.Meter
 	charging      bool
 	start         time.Time
-	startEnergy   float64
+	lastEnergy    float64 // last meter energy reading
+	hasEnergy     bool    // last meter energy reading is valid
 	chargedEnergy float64
 }
 
@@ -43,19 +45,46 @@ func (cr *ChargeRater) StartCharge() {
 	cr.charging = true
 	cr.start = cr.clck.Now()
 
-	// get end energy amount
+	cr.chargedEnergy = 0
+	cr.hasEnergy = false
+
+	// get start energy amount
 	if m, ok := cr.meter.(api.MeterEnergy); ok {
-		if f, err := m.TotalEnergy(); err == nil {
-			cr.startEnergy = f
-			cr.log.DEBUG.Printf("charge start energy: %.0fkWh", f)
+		if err := cr.updateEnergy(m); err == nil {
+			cr.log.DEBUG.Printf("charge start energy: %.0fkWh", cr.lastEnergy)
 		} else {
-			cr.log.ERROR.Printf("charge meter error %v", err)
+			cr.log.ERROR.Println(err)
 		}
-	} else {
-		cr.chargedEnergy = 0
 	}
 }
 
+// updateEnergy adds the meter's energy increase since last reading to the charged energy.
+// If the meter's counter decreased it has been reset or wrapped around and restarted from zero.
+func (cr *ChargeRater) updateEnergy(m api.MeterEnergy) error {
+	f, err := m.TotalEnergy()
+	if err != nil {
+		return fmt.Errorf("charge meter error %v", err)
+	}
+
+	// first reading after charge start
+	if !cr.hasEnergy {
+		cr.lastEnergy = f
+		cr.hasEnergy = true
+		return nil
+	}
+
+	delta := f - cr.lastEnergy
+	if delta < 0 {
+		cr.log.WARN.Printf("charge meter energy counter reset: %.3fkWh -> %.3fkWh", cr.lastEnergy, f)
+		delta = f
+	}
+
+	cr.chargedEnergy += delta
+	cr.lastEnergy = f
+
+	return nil
+}
+
 // StopCharge records meter stop energy. If meter does not supply TotalEnergy,
 // stop time is recorded and accumulating energy though SetChargePower stopped.
 func (cr *ChargeRater) StopCharge() {
@@ -66,11 +95,10 @@ func (cr *ChargeRater) StopCharge() {
 
 	// get end energy amount
 	if m, ok := cr.meter.(api.MeterEnergy); ok {
-		if f, err := m.TotalEnergy(); err == nil {
-			cr.chargedEnergy = f - cr.startEnergy
+		if err := cr.updateEnergy(m); err == nil {
 			cr.log.DEBUG.Printf("final charge energy: %.0fkWh", cr.chargedEnergy)
 		} else {
-			cr.log.ERROR.Printf("charge meter error %v", err)
+			cr.log.ERROR.Println(err)
 		}
 	}
 }
@@ -106,13 +134,9 @@ func (cr *ChargeRater) ChargedEnergy() (float64, error) {
 
 	// get current energy amount
 	if m, ok := cr.meter.(api.MeterEnergy); ok {
-		f, err := m.TotalEnergy()
-
-		if err == nil {
-			return f - cr.startEnergy, nil
+		if err := cr.updateEnergy(m); err != nil {
+			return 0, err
 		}
-
-		return 0, fmt.Errorf("charge meter error %v", err)
 	}
 
 	// return charged energy sofar if meter is not used
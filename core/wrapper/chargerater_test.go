@@ -1,6 +1,7 @@
 package wrapper
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -84,3 +85,66 @@ func TestWrappedMeter(t *testing.T) {
 		t.Errorf("energy: %.1f %v", f, err)
 	}
 }
+
+func TestLifetimeEnergy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mm := mock.NewMockMeter(ctrl)
+	me := mock.NewMockMeterEnergy(ctrl)
+
+	cm := &struct {
+		api.Meter
+		api.MeterEnergy
+	}{mm, me}
+
+	cr := NewChargeRater(util.NewLogger("foo"), cm)
+
+	tc := []struct {
+		total, charged float64
+	}{
+		{1000, 0}, // start
+		{1001, 1}, // rising
+		{1002.5, 2.5},
+		{0.5, 3}, // counter reset
+		{1.5, 4},
+	}
+
+	for i, tc := range tc {
+		t.Log(tc)
+
+		me.EXPECT().TotalEnergy().Return(tc.total, nil)
+
+		if i == 0 {
+			cr.StartCharge()
+			continue
+		}
+
+		if f, err := cr.ChargedEnergy(); f != tc.charged || err != nil {
+			t.Errorf("expected %.1fkWh, got %.1f %v", tc.charged, f, err)
+		}
+	}
+
+	// meter error
+	me.EXPECT().TotalEnergy().Return(0.0, errors.New("timeout"))
+	if _, err := cr.ChargedEnergy(); err == nil {
+		t.Error("expected error")
+	}
+
+	// session end
+	me.EXPECT().TotalEnergy().Return(2.0, nil)
+	cr.StopCharge()
+
+	if f, err := cr.ChargedEnergy(); f != 4.5 || err != nil {
+		t.Errorf("expected 4.5kWh, got %.1f %v", f, err)
+	}
+
+	// next session starts from zero
+	me.EXPECT().TotalEnergy().Return(2.0, nil)
+	cr.StartCharge()
+
+	me.EXPECT().TotalEnergy().Return(3.0, nil)
+	if f, err := cr.ChargedEnergy(); f != 1 || err != nil {
+		t.Errorf("expected 1kWh, got %.1f %v", f, err)
+	}
+}
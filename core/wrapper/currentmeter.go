@@ -0,0 +1,30 @@
+package wrapper
+
+import "github.com/andig/evcc/api"
+
+// CurrentMeter is a replacement for a physical charge meter.
+// It estimates power consumption from the phase currents of a charger that doesn't report power.
+type CurrentMeter struct {
+	api.MeterCurrent
+	voltage func() float64
+}
+
+// NewCurrentMeter creates a meter calculating power from phase currents and nominal voltage.
+// Voltage is evaluated on each reading as it may be configured after the meter is created.
+func NewCurrentMeter(meter api.MeterCurrent, voltage func() float64) *CurrentMeter {
+	return &CurrentMeter{
+		MeterCurrent: meter,
+		voltage:      voltage,
+	}
+}
+
+// CurrentPower implements the Meter.CurrentPower interface
+func (m *CurrentMeter) CurrentPower() (float64, error) {
+	i1, i2, i3, err := m.Currents()
+	if err != nil {
+		return 0, err
+	}
+
+	// unused phases report zero current
+	return (i1 + i2 + i3) * m.voltage(), nil
+}
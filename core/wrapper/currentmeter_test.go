@@ -0,0 +1,45 @@
+package wrapper
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/andig/evcc/mock"
+	"github.com/golang/mock/gomock"
+)
+
+func TestCurrentMeter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tc := []struct {
+		i1, i2, i3, voltage, power float64
+	}{
+		{0, 0, 0, 230, 0},
+		{16, 0, 0, 230, 3680},
+		{16, 16, 16, 230, 11040},
+		{10, 10, 10, 120, 3600},
+		{6.5, 6.5, 6, 230, 4370},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		mc := mock.NewMockMeterCurrent(ctrl)
+		mc.EXPECT().Currents().Return(tc.i1, tc.i2, tc.i3, nil)
+
+		m := NewCurrentMeter(mc, func() float64 { return tc.voltage })
+
+		if p, err := m.CurrentPower(); p != tc.power || err != nil {
+			t.Errorf("power: expected %.0f, got %.0f %v", tc.power, p, err)
+		}
+	}
+
+	mc := mock.NewMockMeterCurrent(ctrl)
+	mc.EXPECT().Currents().Return(0.0, 0.0, 0.0, errors.New("timeout"))
+
+	m := NewCurrentMeter(mc, func() float64 { return 230 })
+	if _, err := m.CurrentPower(); err == nil {
+		t.Error("expected error")
+	}
+}
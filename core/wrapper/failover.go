@@ -0,0 +1,118 @@
+package wrapper
+
+import (
+	"sync"
+
+	"github.com/andig/evcc/api"
+	"github.com/andig/evcc/util"
+)
+
+// FailoverCharger controls the first available of multiple chargers, e.g. a primary and a backup charger.
+// If the active charger fails, control is transferred to the next charger which receives the last
+// requested enabled state and current.
+type FailoverCharger struct {
+	sync.Mutex
+	log      *util.Logger
+	names    []string
+	chargers []api.Charger
+	active   int
+
+	// last requested state, nil if not set yet
+	enabled *bool
+	current *int64
+}
+
+// NewFailoverCharger creates a charger failing over between the given chargers in order
+func NewFailoverCharger(log *util.Logger, names []string, chargers []api.Charger) *FailoverCharger {
+	return &FailoverCharger{
+		log:      log,
+		names:    names,
+		chargers: chargers,
+	}
+}
+
+// Active returns the name of the active charger
+func (c *FailoverCharger) Active() string {
+	c.Lock()
+	defer c.Unlock()
+	return c.names[c.active]
+}
+
+// restore applies the last requested state to the active charger
+func (c *FailoverCharger) restore() error {
+	charger := c.chargers[c.active]
+
+	if c.current != nil {
+		if err := charger.MaxCurrent(*c.current); err != nil {
+			return err
+		}
+	}
+
+	if c.enabled != nil {
+		return charger.Enable(*c.enabled)
+	}
+
+	return nil
+}
+
+// call executes fn on the active charger. On error, control is transferred to the next charger.
+func (c *FailoverCharger) call(fn func(api.Charger) error) error {
+	c.Lock()
+	defer c.Unlock()
+
+	err := fn(c.chargers[c.active])
+
+	for i := 1; err != nil && i < len(c.chargers); i++ {
+		failed := c.names[c.active]
+		c.active = (c.active + 1) % len(c.chargers)
+		c.log.WARN.Printf("%s charger: %v, failing over to %s charger", failed, err, c.names[c.active])
+
+		if err = c.restore(); err == nil {
+			err = fn(c.chargers[c.active])
+		}
+	}
+
+	return err
+}
+
+// Status implements the Charger.Status interface
+func (c *FailoverCharger) Status() (status api.ChargeStatus, err error) {
+	err = c.call(func(charger api.Charger) error {
+		status, err = charger.Status()
+		return err
+	})
+
+	return status, err
+}
+
+// Enabled implements the Charger.Enabled interface
+func (c *FailoverCharger) Enabled() (enabled bool, err error) {
+	err = c.call(func(charger api.Charger) error {
+		enabled, err = charger.Enabled()
+		return err
+	})
+
+	return enabled, err
+}
+
+// Enable implements the Charger.Enable interface
+func (c *FailoverCharger) Enable(enable bool) error {
+	return c.call(func(charger api.Charger) error {
+		err := charger.Enable(enable)
+		if err == nil {
+			c.enabled = &enable
+		}
+		return err
+	})
+}
+
+// MaxCurrent implements the Charger.MaxCurrent interface
+func (c *FailoverCharger) MaxCurrent(current int64) error {
+	return c.call(func(charger api.Charger) error {
+		err := charger.MaxCurrent(current)
+		if err == nil {
+			c.current = &current
+		}
+		return err
+	})
+}
@@ -0,0 +1,63 @@
+package wrapper
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/andig/evcc/api"
+	"github.com/andig/evcc/mock"
+	"github.com/andig/evcc/util"
+	"github.com/golang/mock/gomock"
+)
+
+func TestFailoverCharger(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	primary := mock.NewMockCharger(ctrl)
+	secondary := mock.NewMockCharger(ctrl)
+
+	c := NewFailoverCharger(util.NewLogger("foo"), []string{"primary", "secondary"}, []api.Charger{primary, secondary})
+
+	// primary in control
+	primary.EXPECT().MaxCurrent(int64(10)).Return(nil)
+	primary.EXPECT().Enable(true).Return(nil)
+
+	if err := c.MaxCurrent(10); err != nil {
+		t.Error(err)
+	}
+	if err := c.Enable(true); err != nil {
+		t.Error(err)
+	}
+
+	// primary fails, secondary receives last state
+	gomock.InOrder(
+		primary.EXPECT().Status().Return(api.StatusNone, errors.New("timeout")),
+		secondary.EXPECT().MaxCurrent(int64(10)).Return(nil),
+		secondary.EXPECT().Enable(true).Return(nil),
+		secondary.EXPECT().Status().Return(api.StatusC, nil),
+	)
+
+	if status, err := c.Status(); err != nil || status != api.StatusC {
+		t.Errorf("expected status C, got %v %v", status, err)
+	}
+
+	if active := c.Active(); active != "secondary" {
+		t.Errorf("expected secondary charger, got %s", active)
+	}
+
+	// secondary stays in control
+	secondary.EXPECT().MaxCurrent(int64(16)).Return(nil)
+
+	if err := c.MaxCurrent(16); err != nil {
+		t.Error(err)
+	}
+
+	// all chargers fail
+	secondary.EXPECT().Enabled().Return(false, errors.New("timeout"))
+	primary.EXPECT().MaxCurrent(int64(16)).Return(errors.New("timeout"))
+
+	if _, err := c.Enabled(); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -0,0 +1,71 @@
+package wrapper
+
+import (
+	"fmt"
+
+	"github.com/andig/evcc/api"
+	"github.com/andig/evcc/util"
+)
+
+// SumMeter aggregates multiple meters of the same role, e.g. multiple pv inverters.
+// Power readings of failing meters are skipped as long as at least one meter is available.
+type SumMeter struct {
+	log    *util.Logger
+	names  []string
+	meters []api.Meter
+}
+
+// NewSumMeter creates a meter summing up the given meters
+func NewSumMeter(log *util.Logger, names []string, meters []api.Meter) *SumMeter {
+	return &SumMeter{
+		log:    log,
+		names:  names,
+		meters: meters,
+	}
+}
+
+// CurrentPower implements the Meter.CurrentPower interface
+func (m *SumMeter) CurrentPower() (float64, error) {
+	var sum float64
+	var err error
+	var ok bool
+
+	for i, meter := range m.meters {
+		var power float64
+		if power, err = meter.CurrentPower(); err != nil {
+			m.log.ERROR.Printf("%s meter: %v", m.names[i], err)
+			continue
+		}
+
+		sum += power
+		ok = true
+	}
+
+	if ok {
+		return sum, nil
+	}
+
+	return 0, err
+}
+
+// TotalEnergy implements the MeterEnergy.TotalEnergy interface.
+// Partial energy totals would create jumps in the time series, hence all meters must be available.
+func (m *SumMeter) TotalEnergy() (float64, error) {
+	var sum float64
+
+	for i, meter := range m.meters {
+		em, ok := meter.(api.MeterEnergy)
+		if !ok {
+			return 0, fmt.Errorf("%s meter: energy not supported", m.names[i])
+		}
+
+		energy, err := em.TotalEnergy()
+		if err != nil {
+			return 0, fmt.Errorf("%s meter: %v", m.names[i], err)
+		}
+
+		sum += energy
+	}
+
+	return sum, nil
+}
@@ -0,0 +1,91 @@
+package wrapper
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/andig/evcc/api"
+	"github.com/andig/evcc/mock"
+	"github.com/andig/evcc/util"
+	"github.com/golang/mock/gomock"
+)
+
+func TestSumMeter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	pv1 := mock.NewMockMeter(ctrl)
+	pv2 := mock.NewMockMeter(ctrl)
+
+	m := NewSumMeter(util.NewLogger("foo"), []string{"pv1", "pv2"}, []api.Meter{pv1, pv2})
+
+	tc := []struct {
+		p1, p2   float64
+		e1, e2   error
+		expected float64
+		err      bool
+	}{
+		{1000, 2000, nil, nil, 3000, false},
+		{1000, 2000, errors.New("timeout"), nil, 2000, false},
+		{1000, 2000, nil, errors.New("timeout"), 1000, false},
+		{1000, 2000, errors.New("timeout"), errors.New("timeout"), 0, true},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		pv1.EXPECT().CurrentPower().Return(tc.p1, tc.e1)
+		pv2.EXPECT().CurrentPower().Return(tc.p2, tc.e2)
+
+		power, err := m.CurrentPower()
+		if tc.err != (err != nil) {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if power != tc.expected {
+			t.Errorf("expected %.0f, got %.0f", tc.expected, power)
+		}
+	}
+
+	ctrl.Finish()
+}
+
+func TestSumMeterEnergy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	type energyMeter struct {
+		*mock.MockMeter
+		*mock.MockMeterEnergy
+	}
+
+	em1 := mock.NewMockMeterEnergy(ctrl)
+	em2 := mock.NewMockMeterEnergy(ctrl)
+	m1 := energyMeter{mock.NewMockMeter(ctrl), em1}
+	m2 := energyMeter{mock.NewMockMeter(ctrl), em2}
+
+	m := NewSumMeter(util.NewLogger("foo"), []string{"pv1", "pv2"}, []api.Meter{m1, m2})
+
+	em1.EXPECT().TotalEnergy().Return(1.5, nil)
+	em2.EXPECT().TotalEnergy().Return(2.5, nil)
+
+	if energy, err := m.TotalEnergy(); err != nil || energy != 4 {
+		t.Errorf("expected 4kWh, got %.1f %v", energy, err)
+	}
+
+	// partial totals are not allowed
+	em1.EXPECT().TotalEnergy().Return(1.5, nil)
+	em2.EXPECT().TotalEnergy().Return(0.0, errors.New("timeout"))
+
+	if _, err := m.TotalEnergy(); err == nil {
+		t.Error("expected error")
+	}
+
+	// meter without energy
+	m = NewSumMeter(util.NewLogger("foo"), []string{"pv1", "pv2"}, []api.Meter{m1, mock.NewMockMeter(ctrl)})
+	em1.EXPECT().TotalEnergy().Return(1.5, nil)
+
+	if _, err := m.TotalEnergy(); err == nil {
+		t.Error("expected error")
+	}
+
+	ctrl.Finish()
+}
@@ -15,8 +15,14 @@ func NewFromConfig(typ string, other map[string]interface{}) (meter api.Meter, e
 		meter, err = NewConfigurableFromConfig(other)
 	case "modbus":
 		meter, err = NewModbusFromConfig(other)
+	case "kostal", "plenticore":
+		meter, err = NewKostalFromConfig(other)
 	case "sma":
 		meter, err = NewSMAFromConfig(other)
+	case "sungrow":
+		meter, err = NewSungrowFromConfig(other)
+	case "victron", "venus":
+		meter, err = NewVictronFromConfig(other)
 	case "tesla", "powerwall":
 		meter, err = NewTeslaFromConfig(other)
 	default:
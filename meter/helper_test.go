@@ -0,0 +1,23 @@
+package meter
+
+import (
+	gridx "github.com/grid-x/modbus"
+	"github.com/volkszaehler/mbmd/meters"
+)
+
+// registerClient answers input and holding register reads from a register map
+type registerClient struct {
+	gridx.Client
+	meters.Connection
+	registers map[uint16][]byte
+}
+
+func (c *registerClient) ReadInputRegisters(address, quantity uint16) ([]byte, error) {
+	return c.registers[address], nil
+}
+
+func (c *registerClient) ReadHoldingRegisters(address, quantity uint16) ([]byte, error) {
+	return c.registers[address], nil
+}
+
+func (c *registerClient) Close() {}
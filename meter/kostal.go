@@ -0,0 +1,177 @@
+package meter
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/andig/evcc/api"
+	"github.com/andig/evcc/util"
+	"github.com/andig/evcc/util/modbus"
+	gridx "github.com/grid-x/modbus"
+	"github.com/volkszaehler/mbmd/meters"
+)
+
+// Kostal Plenticore holding registers. Float values use the inverter's
+// default little-endian (CDAB) byte order, least significant word first.
+const (
+	ksRegPVPower      = 100 // Float, W total dc power
+	ksRegHomeBattery  = 106 // Float, W home consumption from battery
+	ksRegHomeGrid     = 108 // Float, W home consumption from grid
+	ksRegHomePV       = 116 // Float, W home consumption from pv
+	ksRegHomeEnergy   = 118 // Float, Wh total home consumption
+	ksRegGridPower    = 252 // Float, W total active power (powermeter), positive import
+	ksRegPVEnergy     = 320 // Float, Wh total yield
+	ksRegBatterySoC   = 514 // U16, %
+	ksRegBatteryPower = 582 // S16, W, negative charge
+)
+
+// Kostal is an api.Meter implementation for Kostal Plenticore inverters.
+// It uses Modbus TCP to read either home consumption, grid, pv or battery values.
+type Kostal struct {
+	log     *util.Logger
+	client  gridx.Client
+	handler meters.Connection // alias for close method
+	usage   string
+}
+
+// NewKostalFromConfig creates a Kostal meter from generic config
+func NewKostalFromConfig(other map[string]interface{}) (api.Meter, error) {
+	cc := struct {
+		URI   string
+		ID    uint8
+		Usage string
+	}{
+		ID: 71,
+	}
+
+	if err := util.DecodeOther(other, &cc); err != nil {
+		return nil, err
+	}
+
+	return NewKostal(cc.URI, cc.ID, cc.Usage)
+}
+
+// NewKostal creates a Kostal meter
+func NewKostal(uri string, id uint8, usage string) (api.Meter, error) {
+	usage = strings.ToLower(usage)
+	switch usage {
+	case "home", "grid", "pv", "battery":
+	default:
+		return nil, fmt.Errorf("invalid usage: %s", usage)
+	}
+
+	conn, err := modbus.NewConnection(uri, "", "", 0, false)
+	if err != nil {
+		return nil, err
+	}
+
+	conn.Slave(id)
+
+	m := &Kostal{
+		log:     util.NewLogger("kostal"),
+		client:  conn.ModbusClient(),
+		handler: conn,
+		usage:   usage,
+	}
+
+	// decorate api.MeterEnergy
+	// grid and battery have no energy counters: the powermeter registers only provide power
+	// and the inverter does not total battery charge/discharge in its default register map
+	if usage == "home" || usage == "pv" {
+		return &KostalEnergy{m}, nil
+	}
+
+	// decorate api.Battery
+	if usage == "battery" {
+		return &KostalBattery{m}, nil
+	}
+
+	return m, nil
+}
+
+// ksFloat32 decodes a float transferred least significant word first
+func ksFloat32(b []byte) float64 {
+	bits := uint32(binary.BigEndian.Uint16(b[2:]))<<16 | uint32(binary.BigEndian.Uint16(b))
+	return float64(math.Float32frombits(bits))
+}
+
+// read reads holding registers and closes the connection on error
+func (m *Kostal) read(address, quantity uint16) ([]byte, error) {
+	return modbus.ReadRegisters(m.handler, m.log.TRACE, m.client.ReadHoldingRegisters, address, quantity)
+}
+
+// readFloat reads a float register
+func (m *Kostal) readFloat(address uint16) (float64, error) {
+	b, err := m.read(address, 2)
+	if err != nil {
+		return 0, err
+	}
+
+	return ksFloat32(b), nil
+}
+
+// CurrentPower implements the Meter.CurrentPower interface
+func (m *Kostal) CurrentPower() (float64, error) {
+	switch m.usage {
+	case "grid":
+		return m.readFloat(ksRegGridPower)
+
+	case "pv":
+		return m.readFloat(ksRegPVPower)
+
+	case "battery":
+		b, err := m.read(ksRegBatteryPower, 1)
+		if err != nil {
+			return 0, err
+		}
+
+		return float64(int16(binary.BigEndian.Uint16(b))), nil
+
+	default:
+		// home consumption is split by source
+		var power float64
+		for _, address := range []uint16{ksRegHomeBattery, ksRegHomeGrid, ksRegHomePV} {
+			f, err := m.readFloat(address)
+			if err != nil {
+				return 0, err
+			}
+
+			power += f
+		}
+
+		return power, nil
+	}
+}
+
+// KostalEnergy decorates Kostal with api.MeterEnergy interface
+type KostalEnergy struct {
+	*Kostal
+}
+
+// TotalEnergy implements the api.MeterEnergy interface
+func (m *KostalEnergy) TotalEnergy() (float64, error) {
+	address := uint16(ksRegHomeEnergy)
+	if m.usage == "pv" {
+		address = ksRegPVEnergy
+	}
+
+	f, err := m.readFloat(address)
+	return f / 1e3, err
+}
+
+// KostalBattery decorates Kostal with api.Battery interface
+type KostalBattery struct {
+	*Kostal
+}
+
+// SoC implements the api.Battery interface
+func (m *KostalBattery) SoC() (float64, error) {
+	b, err := m.read(ksRegBatterySoC, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	return float64(binary.BigEndian.Uint16(b)), nil
+}
@@ -0,0 +1,103 @@
+package meter
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/andig/evcc/util"
+)
+
+func TestKostalPower(t *testing.T) {
+	tc := []struct {
+		usage     string
+		registers map[uint16][]byte
+		power     float64
+	}{
+		// float values least significant word first
+		{"grid", map[uint16][]byte{ksRegGridPower: {0x80, 0x00, 0x43, 0x66}}, 230.5},
+		{"grid", map[uint16][]byte{ksRegGridPower: {0x80, 0x00, 0xC4, 0xBB}}, -1500},
+		{"pv", map[uint16][]byte{ksRegPVPower: {0xA0, 0x00, 0x45, 0x8C}}, 4500},
+		{"home", map[uint16][]byte{
+			ksRegHomeBattery: {0x00, 0x00, 0x43, 0x7A},
+			ksRegHomeGrid:    {0x00, 0x00, 0x43, 0xFA},
+			ksRegHomePV:      {0x00, 0x00, 0x44, 0x7A},
+		}, 1750},
+		{"battery", map[uint16][]byte{ksRegBatteryPower: {0xF8, 0x30}}, -2000},
+		{"battery", map[uint16][]byte{ksRegBatteryPower: {0x07, 0xD0}}, 2000},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		client := &registerClient{registers: tc.registers}
+		m := &Kostal{
+			log:     util.NewLogger("foo"),
+			client:  client,
+			handler: client,
+			usage:   tc.usage,
+		}
+
+		power, err := m.CurrentPower()
+		if err != nil {
+			t.Error(err)
+		}
+
+		if power != tc.power {
+			t.Errorf("expected %.1fW, got %.1fW", tc.power, power)
+		}
+	}
+}
+
+func TestKostalEnergy(t *testing.T) {
+	client := &registerClient{registers: map[uint16][]byte{
+		ksRegPVEnergy:   {0x5E, 0xA8, 0x4B, 0x3C}, // 12345000Wh
+		ksRegBatterySoC: {0x00, 0x43},             // 67%
+	}}
+
+	m := &Kostal{
+		log:     util.NewLogger("foo"),
+		client:  client,
+		handler: client,
+		usage:   "pv",
+	}
+
+	if energy, err := (&KostalEnergy{m}).TotalEnergy(); err != nil || energy != 12345 {
+		t.Errorf("expected 12345kWh, got %.1fkWh (%v)", energy, err)
+	}
+
+	if soc, err := (&KostalBattery{m}).SoC(); err != nil || soc != 67 {
+		t.Errorf("expected 67%%, got %.1f%% (%v)", soc, err)
+	}
+}
+
+func TestKostalUsage(t *testing.T) {
+	tc := []struct {
+		usage string
+		typ   interface{}
+	}{
+		{"Home", &KostalEnergy{}},
+		{"pv", &KostalEnergy{}},
+		{"grid", &Kostal{}},
+		{"battery", &KostalBattery{}},
+		{"foo", nil},
+	}
+
+	for _, tc := range tc {
+		m, err := NewKostal("127.0.0.1:1502", 71, tc.usage)
+
+		if tc.typ == nil {
+			if err == nil {
+				t.Errorf("%s: expected invalid usage error", tc.usage)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if fmt.Sprintf("%T", m) != fmt.Sprintf("%T", tc.typ) {
+			t.Errorf("%s: expected %T, got %T", tc.usage, tc.typ, m)
+		}
+	}
+}
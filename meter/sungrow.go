@@ -0,0 +1,174 @@
+package meter
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andig/evcc/api"
+	"github.com/andig/evcc/util"
+	"github.com/andig/evcc/util/modbus"
+	gridx "github.com/grid-x/modbus"
+	"github.com/volkszaehler/mbmd/meters"
+)
+
+// Sungrow SH series hybrid inverter input registers (0-based addresses).
+// 32 bit values are transferred least significant word first.
+const (
+	sgRegPVPower       = 5016  // U32, W
+	sgRegRunningState  = 13000 // U16, bitfield
+	sgRegPVEnergy      = 13002 // U32, 0.1kWh total pv generation
+	sgRegGridPower     = 13009 // S32, W, positive export
+	sgRegBatteryPower  = 13021 // U16, W, direction from running state
+	sgRegBatterySoC    = 13022 // U16, 0.1%
+	sgRegBatteryEnergy = 13026 // U32, 0.1kWh total battery discharge
+	sgRegGridEnergy    = 13036 // U32, 0.1kWh total grid import
+
+	sgStateCharging = 1 << 1 // running state battery charging bit
+
+	// sgBatchMaxAge is the max age of batched register reads, a meter's registers are read within one cycle
+	sgBatchMaxAge = time.Second
+)
+
+// sgStatusBlock covers running state, energy, grid and battery input registers
+var sgStatusBlock = modbus.Block{Input: true, Start: sgRegRunningState, Quantity: sgRegGridEnergy + 2 - sgRegRunningState}
+
+// Sungrow is an api.Meter implementation for Sungrow SH series hybrid inverters.
+// It uses Modbus TCP to read either grid, pv or battery values.
+type Sungrow struct {
+	log     *util.Logger
+	client  gridx.Client
+	handler meters.Connection // alias for close method
+	usage   string
+}
+
+// NewSungrowFromConfig creates a Sungrow meter from generic config
+func NewSungrowFromConfig(other map[string]interface{}) (api.Meter, error) {
+	cc := struct {
+		URI   string
+		ID    uint8
+		Usage string
+	}{
+		ID: 1,
+	}
+
+	if err := util.DecodeOther(other, &cc); err != nil {
+		return nil, err
+	}
+
+	return NewSungrow(cc.URI, cc.ID, cc.Usage)
+}
+
+// NewSungrow creates a Sungrow meter
+func NewSungrow(uri string, id uint8, usage string) (api.Meter, error) {
+	usage = strings.ToLower(usage)
+	switch usage {
+	case "grid", "pv", "battery":
+	default:
+		return nil, fmt.Errorf("invalid usage: %s", usage)
+	}
+
+	conn, err := modbus.NewConnection(uri, "", "", 0, false)
+	if err != nil {
+		return nil, err
+	}
+
+	conn.Slave(id)
+
+	m := &Sungrow{
+		log:     util.NewLogger("sungrow"),
+		client:  modbus.NewBatchClient(conn.ModbusClient(), sgBatchMaxAge, sgStatusBlock),
+		handler: conn,
+		usage:   usage,
+	}
+
+	// decorate api.Battery
+	if usage == "battery" {
+		return &SungrowBattery{m}, nil
+	}
+
+	return m, nil
+}
+
+// sgUint32 decodes a 32 bit value transferred least significant word first
+func sgUint32(b []byte) uint32 {
+	return uint32(binary.BigEndian.Uint16(b[2:]))<<16 | uint32(binary.BigEndian.Uint16(b))
+}
+
+// read reads input registers and closes the connection on error
+func (m *Sungrow) read(address, quantity uint16) ([]byte, error) {
+	return modbus.ReadRegisters(m.handler, m.log.TRACE, m.client.ReadInputRegisters, address, quantity)
+}
+
+// CurrentPower implements the Meter.CurrentPower interface
+func (m *Sungrow) CurrentPower() (float64, error) {
+	switch m.usage {
+	case "grid":
+		b, err := m.read(sgRegGridPower, 2)
+		if err != nil {
+			return 0, err
+		}
+
+		// export is reported as positive value
+		return -float64(int32(sgUint32(b))), nil
+
+	case "pv":
+		b, err := m.read(sgRegPVPower, 2)
+		if err != nil {
+			return 0, err
+		}
+
+		return float64(sgUint32(b)), nil
+
+	default:
+		state, err := m.read(sgRegRunningState, 1)
+		if err != nil {
+			return 0, err
+		}
+
+		b, err := m.read(sgRegBatteryPower, 1)
+		if err != nil {
+			return 0, err
+		}
+
+		// power is unsigned, charging is reported as negative value
+		power := float64(binary.BigEndian.Uint16(b))
+		if binary.BigEndian.Uint16(state)&sgStateCharging != 0 {
+			power = -power
+		}
+
+		return power, nil
+	}
+}
+
+// TotalEnergy implements the api.MeterEnergy interface
+func (m *Sungrow) TotalEnergy() (float64, error) {
+	address := map[string]uint16{
+		"grid":    sgRegGridEnergy,
+		"pv":      sgRegPVEnergy,
+		"battery": sgRegBatteryEnergy,
+	}[m.usage]
+
+	b, err := m.read(address, 2)
+	if err != nil {
+		return 0, err
+	}
+
+	return float64(sgUint32(b)) / 10, nil
+}
+
+// SungrowBattery decorates Sungrow with api.Battery interface
+type SungrowBattery struct {
+	*Sungrow
+}
+
+// SoC implements the api.Battery interface
+func (m *SungrowBattery) SoC() (float64, error) {
+	b, err := m.read(sgRegBatterySoC, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	return float64(binary.BigEndian.Uint16(b)) / 10, nil
+}
@@ -0,0 +1,84 @@
+package meter
+
+import (
+	"testing"
+
+	"github.com/andig/evcc/util"
+)
+
+func TestSungrowPower(t *testing.T) {
+	tc := []struct {
+		usage     string
+		registers map[uint16][]byte
+		power     float64
+	}{
+		// least significant word first
+		{"grid", map[uint16][]byte{sgRegGridPower: {0x0B, 0xB8, 0x00, 0x00}}, -3000},
+		{"grid", map[uint16][]byte{sgRegGridPower: {0xF4, 0x48, 0xFF, 0xFF}}, 3000},
+		{"grid", map[uint16][]byte{sgRegGridPower: {0x86, 0xA0, 0x00, 0x01}}, -100000},
+		{"pv", map[uint16][]byte{sgRegPVPower: {0x86, 0xA0, 0x00, 0x01}}, 100000},
+		// charging bit set
+		{"battery", map[uint16][]byte{sgRegRunningState: {0x00, 0x03}, sgRegBatteryPower: {0x07, 0xD0}}, -2000},
+		// discharging bit set
+		{"battery", map[uint16][]byte{sgRegRunningState: {0x00, 0x05}, sgRegBatteryPower: {0x07, 0xD0}}, 2000},
+		{"battery", map[uint16][]byte{sgRegRunningState: {0x00, 0x01}, sgRegBatteryPower: {0x00, 0x00}}, 0},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		client := &registerClient{registers: tc.registers}
+		m := &Sungrow{
+			log:     util.NewLogger("foo"),
+			client:  client,
+			handler: client,
+			usage:   tc.usage,
+		}
+
+		power, err := m.CurrentPower()
+		if err != nil {
+			t.Error(err)
+		}
+
+		if power != tc.power {
+			t.Errorf("expected %.0fW, got %.0fW", tc.power, power)
+		}
+	}
+}
+
+func TestSungrowBattery(t *testing.T) {
+	client := &registerClient{registers: map[uint16][]byte{
+		sgRegBatterySoC:    {0x02, 0x9E},             // 67.0%
+		sgRegBatteryEnergy: {0x30, 0x39, 0x00, 0x00}, // 1234.5kWh
+	}}
+
+	m := &SungrowBattery{&Sungrow{
+		log:     util.NewLogger("foo"),
+		client:  client,
+		handler: client,
+		usage:   "battery",
+	}}
+
+	if soc, err := m.SoC(); err != nil || soc != 67 {
+		t.Errorf("expected 67%%, got %.1f%% (%v)", soc, err)
+	}
+
+	if energy, err := m.TotalEnergy(); err != nil || energy != 1234.5 {
+		t.Errorf("expected 1234.5kWh, got %.1fkWh (%v)", energy, err)
+	}
+}
+
+func TestSungrowUsage(t *testing.T) {
+	if _, err := NewSungrow("127.0.0.1:502", 1, "foo"); err == nil {
+		t.Error("expected invalid usage error")
+	}
+
+	m, err := NewSungrow("127.0.0.1:502", 1, "Battery")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := m.(*SungrowBattery); !ok {
+		t.Errorf("expected battery meter, got %T", m)
+	}
+}
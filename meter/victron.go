@@ -0,0 +1,386 @@
+package meter
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andig/evcc/api"
+	"github.com/andig/evcc/provider"
+	"github.com/andig/evcc/util"
+	"github.com/andig/evcc/util/modbus"
+	gridx "github.com/grid-x/modbus"
+	"github.com/volkszaehler/mbmd/meters"
+)
+
+// Victron VenusOS (e.g. Cerbo GX) com.victronenergy.system holding registers.
+// The system service is available at modbus unit id 100.
+const (
+	vicSystemID = 100
+
+	vicRegPVOutput     = 808 // U16 x3, W ac coupled pv on output L1-L3
+	vicRegPVInput      = 811 // U16 x3, W ac coupled pv on input L1-L3
+	vicRegGridPower    = 820 // S16 x3, W grid L1-L3, positive import
+	vicRegBatteryPower = 842 // S16, W, positive charge
+	vicRegBatterySoC   = 843 // U16, %
+	vicRegPVDC         = 850 // U16, W dc coupled pv
+
+	// com.victronenergy.grid holding registers, unit id is the grid meter's device instance
+	vicRegGridEnergy = 2634 // U32, 0.01kWh total energy from net
+
+	vicGridInstance = 30 // default grid meter device instance
+
+	vicKeepalive = 30 * time.Second // VenusOS stops publishing without keepalive after 60s
+)
+
+// Victron VenusOS mqtt topics relative to N/<portal id>/
+var vicTopics = map[string][]string{
+	"grid": {
+		"system/0/Ac/Grid/L1/Power",
+		"system/0/Ac/Grid/L2/Power",
+		"system/0/Ac/Grid/L3/Power",
+	},
+	"pv": {
+		"system/0/Ac/PvOnOutput/L1/Power",
+		"system/0/Ac/PvOnOutput/L2/Power",
+		"system/0/Ac/PvOnOutput/L3/Power",
+		"system/0/Ac/PvOnGrid/L1/Power",
+		"system/0/Ac/PvOnGrid/L2/Power",
+		"system/0/Ac/PvOnGrid/L3/Power",
+		"system/0/Dc/Pv/Power",
+	},
+	"battery": {
+		"system/0/Dc/Battery/Power",
+	},
+}
+
+const (
+	vicTopicBatterySoC = "system/0/Dc/Battery/Soc"
+	vicTopicGridEnergy = "grid/%d/Ac/Energy/Forward" // kWh
+)
+
+// Victron is an api.Meter implementation for Victron VenusOS systems.
+// It uses Modbus TCP or MQTT to read either grid, pv or battery values.
+type Victron struct {
+	powerG  func() (float64, error)
+	energyG func() (float64, error)
+	socG    func() (float64, error)
+}
+
+// NewVictronFromConfig creates a Victron meter from generic config
+func NewVictronFromConfig(other map[string]interface{}) (api.Meter, error) {
+	cc := struct {
+		URI          string
+		PortalID     string
+		GridInstance uint8
+		Usage        string
+	}{
+		GridInstance: vicGridInstance,
+	}
+
+	if err := util.DecodeOther(other, &cc); err != nil {
+		return nil, err
+	}
+
+	usage := strings.ToLower(cc.Usage)
+	if _, ok := vicTopics[usage]; !ok {
+		return nil, fmt.Errorf("invalid usage: %s", cc.Usage)
+	}
+
+	if cc.URI != "" && cc.PortalID != "" {
+		return nil, errors.New("cannot use both uri and portalid")
+	}
+
+	if cc.PortalID != "" {
+		if provider.MQTT == nil {
+			return nil, errors.New("mqtt not configured")
+		}
+
+		mqtt := provider.MQTT
+		publish := func(topic string) {
+			mqtt.WaitForToken(mqtt.Client.Publish(topic, mqtt.Qos, false, ""))
+		}
+
+		return NewVictronMqtt(cc.PortalID, cc.GridInstance, usage, mqtt.Listen, publish), nil
+	}
+
+	if cc.URI == "" {
+		return nil, errors.New("missing uri or portalid")
+	}
+
+	return NewVictronModbus(cc.URI, cc.GridInstance, usage)
+}
+
+// decorate adds api.MeterEnergy to grid and api.Battery to battery meters
+func (m *Victron) decorate(usage string) api.Meter {
+	switch usage {
+	case "grid":
+		return &VictronEnergy{m}
+	case "battery":
+		return &VictronBattery{m}
+	default:
+		return m
+	}
+}
+
+// CurrentPower implements the Meter.CurrentPower interface
+func (m *Victron) CurrentPower() (float64, error) {
+	return m.powerG()
+}
+
+// VictronEnergy decorates Victron with api.MeterEnergy interface
+type VictronEnergy struct {
+	*Victron
+}
+
+// TotalEnergy implements the api.MeterEnergy interface
+func (m *VictronEnergy) TotalEnergy() (float64, error) {
+	return m.energyG()
+}
+
+// VictronBattery decorates Victron with api.Battery interface
+type VictronBattery struct {
+	*Victron
+}
+
+// SoC implements the api.Battery interface
+func (m *VictronBattery) SoC() (float64, error) {
+	return m.socG()
+}
+
+// victronModbus reads the system and grid meter registers
+type victronModbus struct {
+	log     *util.Logger
+	system  gridx.Client
+	grid    gridx.Client
+	handler meters.Connection // alias for close method
+}
+
+// NewVictronModbus creates a Victron meter using Modbus TCP
+func NewVictronModbus(uri string, gridInstance uint8, usage string) (api.Meter, error) {
+	system, err := modbus.NewConnection(uri, "", "", 0, false)
+	if err != nil {
+		return nil, err
+	}
+
+	system.Slave(vicSystemID)
+
+	// devices share the physical connection
+	grid, _ := modbus.NewConnection(uri, "", "", 0, false)
+	grid.Slave(gridInstance)
+
+	r := &victronModbus{
+		log:     util.NewLogger("victron"),
+		system:  system.ModbusClient(),
+		grid:    grid.ModbusClient(),
+		handler: system,
+	}
+
+	m := &Victron{
+		energyG: r.gridEnergy,
+		socG:    r.batterySoC,
+	}
+
+	switch usage {
+	case "grid":
+		m.powerG = r.gridPower
+	case "pv":
+		m.powerG = r.pvPower
+	default:
+		m.powerG = r.batteryPower
+	}
+
+	return m.decorate(usage), nil
+}
+
+// read reads holding registers and closes the connection on error
+func (r *victronModbus) read(client gridx.Client, address, quantity uint16) ([]byte, error) {
+	return modbus.ReadRegisters(r.handler, r.log.TRACE, client.ReadHoldingRegisters, address, quantity)
+}
+
+// sum reads and adds up consecutive phase registers
+func (r *victronModbus) sum(address uint16, signed bool) (float64, error) {
+	b, err := r.read(r.system, address, 3)
+	if err != nil {
+		return 0, err
+	}
+
+	var power float64
+	for i := 0; i < 3; i++ {
+		u := binary.BigEndian.Uint16(b[2*i:])
+		if signed {
+			power += float64(int16(u))
+		} else {
+			power += float64(u)
+		}
+	}
+
+	return power, nil
+}
+
+func (r *victronModbus) gridPower() (float64, error) {
+	return r.sum(vicRegGridPower, true)
+}
+
+func (r *victronModbus) pvPower() (float64, error) {
+	power, err := r.sum(vicRegPVOutput, false)
+
+	if err == nil {
+		var f float64
+		if f, err = r.sum(vicRegPVInput, false); err == nil {
+			power += f
+		}
+	}
+
+	if err == nil {
+		var b []byte
+		if b, err = r.read(r.system, vicRegPVDC, 1); err == nil {
+			power += float64(binary.BigEndian.Uint16(b))
+		}
+	}
+
+	return power, err
+}
+
+func (r *victronModbus) batteryPower() (float64, error) {
+	b, err := r.read(r.system, vicRegBatteryPower, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	// charging is reported as positive value
+	return -float64(int16(binary.BigEndian.Uint16(b))), nil
+}
+
+func (r *victronModbus) batterySoC() (float64, error) {
+	b, err := r.read(r.system, vicRegBatterySoC, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	return float64(binary.BigEndian.Uint16(b)), nil
+}
+
+func (r *victronModbus) gridEnergy() (float64, error) {
+	b, err := r.read(r.grid, vicRegGridEnergy, 2)
+	if err != nil {
+		return 0, err
+	}
+
+	return float64(binary.BigEndian.Uint32(b)) / 100, nil
+}
+
+// victronMqtt stores the values published by VenusOS to N/<portal id>/
+type victronMqtt struct {
+	mux    sync.Mutex
+	prefix string
+	values map[string]*float64 // nil if published as null
+}
+
+// NewVictronMqtt creates a Victron meter subscribing to the portal's topics using listen.
+// VenusOS only publishes while receiving keepalive requests.
+func NewVictronMqtt(portalID string, gridInstance uint8, usage string, listen func(string, func(string)), publish func(string)) api.Meter {
+	r := &victronMqtt{
+		prefix: fmt.Sprintf("N/%s/", portalID),
+		values: make(map[string]*float64),
+	}
+
+	m := &Victron{
+		powerG: r.getter(listen, vicTopics[usage]...),
+	}
+
+	switch usage {
+	case "grid":
+		m.energyG = r.getter(listen, fmt.Sprintf(vicTopicGridEnergy, gridInstance))
+	case "battery":
+		m.socG = r.getter(listen, vicTopicBatterySoC)
+
+		// charging is reported as positive value
+		powerG := m.powerG
+		m.powerG = func() (float64, error) {
+			f, err := powerG()
+			return -f, err
+		}
+	}
+
+	victronKeepalive(portalID, publish)
+
+	return m.decorate(usage)
+}
+
+var (
+	vicKeepaliveMux sync.Mutex
+	vicKeepalives   = make(map[string]bool)
+)
+
+// victronKeepalive starts publishing keepalive requests unless already running for the portal.
+// Grid, pv and battery meters of the same portal share the keepalive.
+func victronKeepalive(portalID string, publish func(string)) {
+	vicKeepaliveMux.Lock()
+	defer vicKeepaliveMux.Unlock()
+
+	if vicKeepalives[portalID] {
+		return
+	}
+	vicKeepalives[portalID] = true
+
+	go func() {
+		ticker := time.NewTicker(vicKeepalive)
+		for {
+			publish(fmt.Sprintf("R/%s/keepalive", portalID))
+			<-ticker.C
+		}
+	}()
+}
+
+// receiver returns the listener storing the topic's value
+func (r *victronMqtt) receiver(topic string) func(string) {
+	return func(payload string) {
+		var res struct {
+			Value *float64 `json:"value"`
+		}
+
+		if err := json.Unmarshal([]byte(payload), &res); err != nil {
+			return
+		}
+
+		r.mux.Lock()
+		defer r.mux.Unlock()
+		r.values[topic] = res.Value
+	}
+}
+
+// getter subscribes to the topics and returns the sum of their values.
+// Topics published as null, e.g. phases not present, are ignored.
+func (r *victronMqtt) getter(listen func(string, func(string)), topics ...string) func() (float64, error) {
+	for _, topic := range topics {
+		listen(r.prefix+topic, r.receiver(topic))
+	}
+
+	return func() (float64, error) {
+		r.mux.Lock()
+		defer r.mux.Unlock()
+
+		var sum float64
+		var received bool
+
+		for _, topic := range topics {
+			if v, ok := r.values[topic]; ok {
+				received = true
+				if v != nil {
+					sum += *v
+				}
+			}
+		}
+
+		if !received {
+			return 0, fmt.Errorf("%s%s: no value received", r.prefix, topics[0])
+		}
+
+		return sum, nil
+	}
+}
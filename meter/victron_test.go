@@ -0,0 +1,122 @@
+package meter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/andig/evcc/api"
+	"github.com/andig/evcc/util"
+)
+
+func TestVictronModbus(t *testing.T) {
+	system := &registerClient{registers: map[uint16][]byte{
+		vicRegGridPower:    {0x03, 0xE8, 0xFE, 0x0C, 0x00, 0x00}, // 1000W, -500W, 0W
+		vicRegPVOutput:     {0x00, 0x64, 0x00, 0x00, 0x00, 0x00},
+		vicRegPVInput:      {0x00, 0x00, 0x00, 0xC8, 0x00, 0x00},
+		vicRegPVDC:         {0x0B, 0xB8},
+		vicRegBatteryPower: {0x07, 0xD0}, // 2000W charging
+		vicRegBatterySoC:   {0x00, 0x43},
+	}}
+
+	grid := &registerClient{registers: map[uint16][]byte{
+		vicRegGridEnergy: {0x00, 0x01, 0xE2, 0x40}, // 123456 x 0.01kWh
+	}}
+
+	r := &victronModbus{
+		log:     util.NewLogger("foo"),
+		system:  system,
+		grid:    grid,
+		handler: system,
+	}
+
+	tc := []struct {
+		name  string
+		get   func() (float64, error)
+		value float64
+	}{
+		{"grid", r.gridPower, 500},
+		{"pv", r.pvPower, 3300},
+		{"battery", r.batteryPower, -2000},
+		{"soc", r.batterySoC, 67},
+		{"energy", r.gridEnergy, 1234.56},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc.name)
+
+		if f, err := tc.get(); err != nil || f != tc.value {
+			t.Errorf("%s: expected %.2f, got %.2f (%v)", tc.name, tc.value, f, err)
+		}
+	}
+
+	// discharging
+	system.registers[vicRegBatteryPower] = []byte{0xFC, 0x18}
+	if f, err := r.batteryPower(); err != nil || f != 1000 {
+		t.Errorf("battery: expected 1000, got %.0f (%v)", f, err)
+	}
+}
+
+func TestVictronMqtt(t *testing.T) {
+	listeners := make(map[string]func(string))
+	listen := func(topic string, callback func(string)) {
+		listeners[topic] = callback
+	}
+
+	grid := NewVictronMqtt("abc", vicGridInstance, "grid", listen, func(string) {})
+	battery := NewVictronMqtt("abc", vicGridInstance, "battery", listen, func(string) {})
+
+	if _, err := grid.CurrentPower(); err == nil {
+		t.Error("expected error before receiving grid power")
+	}
+
+	listeners["N/abc/system/0/Ac/Grid/L1/Power"](`{"value": 1200}`)
+	listeners["N/abc/system/0/Ac/Grid/L2/Power"](`{"value": -200}`)
+	listeners["N/abc/system/0/Ac/Grid/L3/Power"](`{"value": null}`)
+	listeners["N/abc/grid/30/Ac/Energy/Forward"](`{"value": 1234.5}`)
+	listeners["N/abc/system/0/Dc/Battery/Power"](`{"value": 800}`)
+	listeners["N/abc/system/0/Dc/Battery/Soc"](`{"value": 55.5}`)
+
+	if f, err := grid.CurrentPower(); err != nil || f != 1000 {
+		t.Errorf("grid: expected 1000W, got %.0fW (%v)", f, err)
+	}
+
+	if f, err := grid.(api.MeterEnergy).TotalEnergy(); err != nil || f != 1234.5 {
+		t.Errorf("energy: expected 1234.5kWh, got %.1fkWh (%v)", f, err)
+	}
+
+	if f, err := battery.CurrentPower(); err != nil || f != -800 {
+		t.Errorf("battery: expected -800W, got %.0fW (%v)", f, err)
+	}
+
+	if f, err := battery.(api.Battery).SoC(); err != nil || f != 55.5 {
+		t.Errorf("soc: expected 55.5%%, got %.1f%% (%v)", f, err)
+	}
+}
+
+func TestVictronMqttKeepalive(t *testing.T) {
+	listen := func(string, func(string)) {}
+
+	keepalive := make(chan string, 3)
+	publish := func(topic string) {
+		keepalive <- topic
+	}
+
+	for _, usage := range []string{"grid", "pv", "battery"} {
+		_ = NewVictronMqtt("keepalive", vicGridInstance, usage, listen, publish)
+	}
+
+	select {
+	case topic := <-keepalive:
+		if topic != "R/keepalive/keepalive" {
+			t.Errorf("unexpected keepalive topic: %s", topic)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("missing keepalive")
+	}
+
+	select {
+	case <-keepalive:
+		t.Error("keepalive not shared")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
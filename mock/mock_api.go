@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/andig/evcc/api (interfaces: Charger,Meter,MeterEnergy,Vehicle,ChargeRater)
+// Source: github.com/andig/evcc/api (interfaces: Charger,Meter,MeterEnergy,MeterCurrent,Vehicle,ChargeRater,Battery,VehicleClimater,VehicleChargeController,VehicleCurrentController,VehicleRange,VehicleStatus,VehicleChargeLimit,VehicleWakeUp,CurrentLimiter,CurrentGetter,BatteryTemperature,ChargePhases)
 
 // Package mock is a generated GoMock package.
 package mock
@@ -167,6 +167,46 @@ func (mr *MockMeterEnergyMockRecorder) TotalEnergy() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TotalEnergy", reflect.TypeOf((*MockMeterEnergy)(nil).TotalEnergy))
 }
 
+// MockMeterCurrent is a mock of MeterCurrent interface
+type MockMeterCurrent struct {
+	ctrl     *gomock.Controller
+	recorder *MockMeterCurrentMockRecorder
+}
+
+// MockMeterCurrentMockRecorder is the mock recorder for MockMeterCurrent
+type MockMeterCurrentMockRecorder struct {
+	mock *MockMeterCurrent
+}
+
+// NewMockMeterCurrent creates a new mock instance
+func NewMockMeterCurrent(ctrl *gomock.Controller) *MockMeterCurrent {
+	mock := &MockMeterCurrent{ctrl: ctrl}
+	mock.recorder = &MockMeterCurrentMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockMeterCurrent) EXPECT() *MockMeterCurrentMockRecorder {
+	return m.recorder
+}
+
+// Currents mocks base method
+func (m *MockMeterCurrent) Currents() (float64, float64, float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Currents")
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(float64)
+	ret2, _ := ret[2].(float64)
+	ret3, _ := ret[3].(error)
+	return ret0, ret1, ret2, ret3
+}
+
+// Currents indicates an expected call of Currents
+func (mr *MockMeterCurrentMockRecorder) Currents() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Currents", reflect.TypeOf((*MockMeterCurrent)(nil).Currents))
+}
+
 // MockVehicle is a mock of Vehicle interface
 type MockVehicle struct {
 	ctrl     *gomock.Controller
@@ -270,3 +310,472 @@ func (mr *MockChargeRaterMockRecorder) ChargedEnergy() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChargedEnergy", reflect.TypeOf((*MockChargeRater)(nil).ChargedEnergy))
 }
+
+// MockBattery is a mock of Battery interface
+type MockBattery struct {
+	ctrl     *gomock.Controller
+	recorder *MockBatteryMockRecorder
+}
+
+// MockBatteryMockRecorder is the mock recorder for MockBattery
+type MockBatteryMockRecorder struct {
+	mock *MockBattery
+}
+
+// NewMockBattery creates a new mock instance
+func NewMockBattery(ctrl *gomock.Controller) *MockBattery {
+	mock := &MockBattery{ctrl: ctrl}
+	mock.recorder = &MockBatteryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockBattery) EXPECT() *MockBatteryMockRecorder {
+	return m.recorder
+}
+
+// SoC mocks base method
+func (m *MockBattery) SoC() (float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SoC")
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SoC indicates an expected call of SoC
+func (mr *MockBatteryMockRecorder) SoC() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SoC", reflect.TypeOf((*MockBattery)(nil).SoC))
+}
+
+// MockVehicleClimater is a mock of VehicleClimater interface
+type MockVehicleClimater struct {
+	ctrl     *gomock.Controller
+	recorder *MockVehicleClimaterMockRecorder
+}
+
+// MockVehicleClimaterMockRecorder is the mock recorder for MockVehicleClimater
+type MockVehicleClimaterMockRecorder struct {
+	mock *MockVehicleClimater
+}
+
+// NewMockVehicleClimater creates a new mock instance
+func NewMockVehicleClimater(ctrl *gomock.Controller) *MockVehicleClimater {
+	mock := &MockVehicleClimater{ctrl: ctrl}
+	mock.recorder = &MockVehicleClimaterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockVehicleClimater) EXPECT() *MockVehicleClimaterMockRecorder {
+	return m.recorder
+}
+
+// Climater mocks base method
+func (m *MockVehicleClimater) Climater() (bool, float64, float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Climater")
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(float64)
+	ret2, _ := ret[2].(float64)
+	ret3, _ := ret[3].(error)
+	return ret0, ret1, ret2, ret3
+}
+
+// Climater indicates an expected call of Climater
+func (mr *MockVehicleClimaterMockRecorder) Climater() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Climater", reflect.TypeOf((*MockVehicleClimater)(nil).Climater))
+}
+
+// MockVehicleChargeController is a mock of VehicleChargeController interface
+type MockVehicleChargeController struct {
+	ctrl     *gomock.Controller
+	recorder *MockVehicleChargeControllerMockRecorder
+}
+
+// MockVehicleChargeControllerMockRecorder is the mock recorder for MockVehicleChargeController
+type MockVehicleChargeControllerMockRecorder struct {
+	mock *MockVehicleChargeController
+}
+
+// NewMockVehicleChargeController creates a new mock instance
+func NewMockVehicleChargeController(ctrl *gomock.Controller) *MockVehicleChargeController {
+	mock := &MockVehicleChargeController{ctrl: ctrl}
+	mock.recorder = &MockVehicleChargeControllerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockVehicleChargeController) EXPECT() *MockVehicleChargeControllerMockRecorder {
+	return m.recorder
+}
+
+// StartCharge mocks base method
+func (m *MockVehicleChargeController) StartCharge() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartCharge")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StartCharge indicates an expected call of StartCharge
+func (mr *MockVehicleChargeControllerMockRecorder) StartCharge() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartCharge", reflect.TypeOf((*MockVehicleChargeController)(nil).StartCharge))
+}
+
+// StopCharge mocks base method
+func (m *MockVehicleChargeController) StopCharge() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StopCharge")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StopCharge indicates an expected call of StopCharge
+func (mr *MockVehicleChargeControllerMockRecorder) StopCharge() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StopCharge", reflect.TypeOf((*MockVehicleChargeController)(nil).StopCharge))
+}
+
+// MockVehicleCurrentController is a mock of VehicleCurrentController interface
+type MockVehicleCurrentController struct {
+	ctrl     *gomock.Controller
+	recorder *MockVehicleCurrentControllerMockRecorder
+}
+
+// MockVehicleCurrentControllerMockRecorder is the mock recorder for MockVehicleCurrentController
+type MockVehicleCurrentControllerMockRecorder struct {
+	mock *MockVehicleCurrentController
+}
+
+// NewMockVehicleCurrentController creates a new mock instance
+func NewMockVehicleCurrentController(ctrl *gomock.Controller) *MockVehicleCurrentController {
+	mock := &MockVehicleCurrentController{ctrl: ctrl}
+	mock.recorder = &MockVehicleCurrentControllerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockVehicleCurrentController) EXPECT() *MockVehicleCurrentControllerMockRecorder {
+	return m.recorder
+}
+
+// MaxCurrent mocks base method
+func (m *MockVehicleCurrentController) MaxCurrent(arg0 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MaxCurrent", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MaxCurrent indicates an expected call of MaxCurrent
+func (mr *MockVehicleCurrentControllerMockRecorder) MaxCurrent(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaxCurrent", reflect.TypeOf((*MockVehicleCurrentController)(nil).MaxCurrent), arg0)
+}
+
+// MockVehicleRange is a mock of VehicleRange interface
+type MockVehicleRange struct {
+	ctrl     *gomock.Controller
+	recorder *MockVehicleRangeMockRecorder
+}
+
+// MockVehicleRangeMockRecorder is the mock recorder for MockVehicleRange
+type MockVehicleRangeMockRecorder struct {
+	mock *MockVehicleRange
+}
+
+// NewMockVehicleRange creates a new mock instance
+func NewMockVehicleRange(ctrl *gomock.Controller) *MockVehicleRange {
+	mock := &MockVehicleRange{ctrl: ctrl}
+	mock.recorder = &MockVehicleRangeMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockVehicleRange) EXPECT() *MockVehicleRangeMockRecorder {
+	return m.recorder
+}
+
+// Range mocks base method
+func (m *MockVehicleRange) Range() (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Range")
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Range indicates an expected call of Range
+func (mr *MockVehicleRangeMockRecorder) Range() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Range", reflect.TypeOf((*MockVehicleRange)(nil).Range))
+}
+
+// MockVehicleStatus is a mock of VehicleStatus interface
+type MockVehicleStatus struct {
+	ctrl     *gomock.Controller
+	recorder *MockVehicleStatusMockRecorder
+}
+
+// MockVehicleStatusMockRecorder is the mock recorder for MockVehicleStatus
+type MockVehicleStatusMockRecorder struct {
+	mock *MockVehicleStatus
+}
+
+// NewMockVehicleStatus creates a new mock instance
+func NewMockVehicleStatus(ctrl *gomock.Controller) *MockVehicleStatus {
+	mock := &MockVehicleStatus{ctrl: ctrl}
+	mock.recorder = &MockVehicleStatusMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockVehicleStatus) EXPECT() *MockVehicleStatusMockRecorder {
+	return m.recorder
+}
+
+// Status mocks base method
+func (m *MockVehicleStatus) Status() (api.ChargeStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Status")
+	ret0, _ := ret[0].(api.ChargeStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Status indicates an expected call of Status
+func (mr *MockVehicleStatusMockRecorder) Status() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Status", reflect.TypeOf((*MockVehicleStatus)(nil).Status))
+}
+
+// MockVehicleChargeLimit is a mock of VehicleChargeLimit interface
+type MockVehicleChargeLimit struct {
+	ctrl     *gomock.Controller
+	recorder *MockVehicleChargeLimitMockRecorder
+}
+
+// MockVehicleChargeLimitMockRecorder is the mock recorder for MockVehicleChargeLimit
+type MockVehicleChargeLimitMockRecorder struct {
+	mock *MockVehicleChargeLimit
+}
+
+// NewMockVehicleChargeLimit creates a new mock instance
+func NewMockVehicleChargeLimit(ctrl *gomock.Controller) *MockVehicleChargeLimit {
+	mock := &MockVehicleChargeLimit{ctrl: ctrl}
+	mock.recorder = &MockVehicleChargeLimitMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockVehicleChargeLimit) EXPECT() *MockVehicleChargeLimitMockRecorder {
+	return m.recorder
+}
+
+// ChargeLimit mocks base method
+func (m *MockVehicleChargeLimit) ChargeLimit() (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChargeLimit")
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChargeLimit indicates an expected call of ChargeLimit
+func (mr *MockVehicleChargeLimitMockRecorder) ChargeLimit() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChargeLimit", reflect.TypeOf((*MockVehicleChargeLimit)(nil).ChargeLimit))
+}
+
+// MockVehicleWakeUp is a mock of VehicleWakeUp interface
+type MockVehicleWakeUp struct {
+	ctrl     *gomock.Controller
+	recorder *MockVehicleWakeUpMockRecorder
+}
+
+// MockVehicleWakeUpMockRecorder is the mock recorder for MockVehicleWakeUp
+type MockVehicleWakeUpMockRecorder struct {
+	mock *MockVehicleWakeUp
+}
+
+// NewMockVehicleWakeUp creates a new mock instance
+func NewMockVehicleWakeUp(ctrl *gomock.Controller) *MockVehicleWakeUp {
+	mock := &MockVehicleWakeUp{ctrl: ctrl}
+	mock.recorder = &MockVehicleWakeUpMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockVehicleWakeUp) EXPECT() *MockVehicleWakeUpMockRecorder {
+	return m.recorder
+}
+
+// WakeUp mocks base method
+func (m *MockVehicleWakeUp) WakeUp() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WakeUp")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WakeUp indicates an expected call of WakeUp
+func (mr *MockVehicleWakeUpMockRecorder) WakeUp() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WakeUp", reflect.TypeOf((*MockVehicleWakeUp)(nil).WakeUp))
+}
+
+// MockCurrentLimiter is a mock of CurrentLimiter interface
+type MockCurrentLimiter struct {
+	ctrl     *gomock.Controller
+	recorder *MockCurrentLimiterMockRecorder
+}
+
+// MockCurrentLimiterMockRecorder is the mock recorder for MockCurrentLimiter
+type MockCurrentLimiterMockRecorder struct {
+	mock *MockCurrentLimiter
+}
+
+// NewMockCurrentLimiter creates a new mock instance
+func NewMockCurrentLimiter(ctrl *gomock.Controller) *MockCurrentLimiter {
+	mock := &MockCurrentLimiter{ctrl: ctrl}
+	mock.recorder = &MockCurrentLimiterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockCurrentLimiter) EXPECT() *MockCurrentLimiterMockRecorder {
+	return m.recorder
+}
+
+// CurrentLimit mocks base method
+func (m *MockCurrentLimiter) CurrentLimit() (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CurrentLimit")
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CurrentLimit indicates an expected call of CurrentLimit
+func (mr *MockCurrentLimiterMockRecorder) CurrentLimit() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CurrentLimit", reflect.TypeOf((*MockCurrentLimiter)(nil).CurrentLimit))
+}
+
+// MockCurrentGetter is a mock of CurrentGetter interface
+type MockCurrentGetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockCurrentGetterMockRecorder
+}
+
+// MockCurrentGetterMockRecorder is the mock recorder for MockCurrentGetter
+type MockCurrentGetterMockRecorder struct {
+	mock *MockCurrentGetter
+}
+
+// NewMockCurrentGetter creates a new mock instance
+func NewMockCurrentGetter(ctrl *gomock.Controller) *MockCurrentGetter {
+	mock := &MockCurrentGetter{ctrl: ctrl}
+	mock.recorder = &MockCurrentGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockCurrentGetter) EXPECT() *MockCurrentGetterMockRecorder {
+	return m.recorder
+}
+
+// GetMaxCurrent mocks base method
+func (m *MockCurrentGetter) GetMaxCurrent() (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMaxCurrent")
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMaxCurrent indicates an expected call of GetMaxCurrent
+func (mr *MockCurrentGetterMockRecorder) GetMaxCurrent() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMaxCurrent", reflect.TypeOf((*MockCurrentGetter)(nil).GetMaxCurrent))
+}
+
+// MockBatteryTemperature is a mock of BatteryTemperature interface
+type MockBatteryTemperature struct {
+	ctrl     *gomock.Controller
+	recorder *MockBatteryTemperatureMockRecorder
+}
+
+// MockBatteryTemperatureMockRecorder is the mock recorder for MockBatteryTemperature
+type MockBatteryTemperatureMockRecorder struct {
+	mock *MockBatteryTemperature
+}
+
+// NewMockBatteryTemperature creates a new mock instance
+func NewMockBatteryTemperature(ctrl *gomock.Controller) *MockBatteryTemperature {
+	mock := &MockBatteryTemperature{ctrl: ctrl}
+	mock.recorder = &MockBatteryTemperatureMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockBatteryTemperature) EXPECT() *MockBatteryTemperatureMockRecorder {
+	return m.recorder
+}
+
+// BatteryTemperature mocks base method
+func (m *MockBatteryTemperature) BatteryTemperature() (float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatteryTemperature")
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BatteryTemperature indicates an expected call of BatteryTemperature
+func (mr *MockBatteryTemperatureMockRecorder) BatteryTemperature() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatteryTemperature", reflect.TypeOf((*MockBatteryTemperature)(nil).BatteryTemperature))
+}
+
+// MockChargePhases is a mock of ChargePhases interface
+type MockChargePhases struct {
+	ctrl     *gomock.Controller
+	recorder *MockChargePhasesMockRecorder
+}
+
+// MockChargePhasesMockRecorder is the mock recorder for MockChargePhases
+type MockChargePhasesMockRecorder struct {
+	mock *MockChargePhases
+}
+
+// NewMockChargePhases creates a new mock instance
+func NewMockChargePhases(ctrl *gomock.Controller) *MockChargePhases {
+	mock := &MockChargePhases{ctrl: ctrl}
+	mock.recorder = &MockChargePhasesMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockChargePhases) EXPECT() *MockChargePhasesMockRecorder {
+	return m.recorder
+}
+
+// ActivePhases mocks base method
+func (m *MockChargePhases) ActivePhases() (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ActivePhases")
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ActivePhases indicates an expected call of ActivePhases
+func (mr *MockChargePhasesMockRecorder) ActivePhases() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ActivePhases", reflect.TypeOf((*MockChargePhases)(nil).ActivePhases))
+}
@@ -118,3 +118,17 @@ func (mr *MockHandlerMockRecorder) TargetCurrent() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TargetCurrent", reflect.TypeOf((*MockHandler)(nil).TargetCurrent))
 }
+
+// WakeUp mocks base method
+func (m *MockHandler) WakeUp() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WakeUp")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WakeUp indicates an expected call of WakeUp
+func (mr *MockHandlerMockRecorder) WakeUp() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WakeUp", reflect.TypeOf((*MockHandler)(nil).WakeUp))
+}
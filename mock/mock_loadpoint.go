@@ -32,14 +32,26 @@ func (m *MockUpdater) EXPECT() *MockUpdaterMockRecorder {
 	return m.recorder
 }
 
+// SiteUnavailable mocks base method
+func (m *MockUpdater) SiteUnavailable() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SiteUnavailable")
+}
+
+// SiteUnavailable indicates an expected call of SiteUnavailable
+func (mr *MockUpdaterMockRecorder) SiteUnavailable() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SiteUnavailable", reflect.TypeOf((*MockUpdater)(nil).SiteUnavailable))
+}
+
 // Update mocks base method
-func (m *MockUpdater) Update(arg0 float64) {
+func (m *MockUpdater) Update(arg0, arg1 float64) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "Update", arg0)
+	m.ctrl.Call(m, "Update", arg0, arg1)
 }
 
 // Update indicates an expected call of Update
-func (mr *MockUpdaterMockRecorder) Update(arg0 interface{}) *gomock.Call {
+func (mr *MockUpdaterMockRecorder) Update(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockUpdater)(nil).Update), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockUpdater)(nil).Update), arg0, arg1)
 }
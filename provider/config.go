@@ -13,10 +13,14 @@ const (
 	execTimeout = 5 * time.Second
 )
 
-// Config is the general provider config
+// Config is the general provider config.
+// Scale and Offset are applied to numeric getters, Map translates raw string values.
 type Config struct {
-	Type  string
-	Other map[string]interface{} `mapstructure:",remain"`
+	Type   string
+	Scale  float64
+	Offset float64
+	Map    map[string]string
+	Other  map[string]interface{} `mapstructure:",remain"`
 }
 
 // mqttConfig is the specific mqtt getter/setter configuration
@@ -78,7 +82,8 @@ func NewFloatGetterFromConfig(config Config) (res func() (float64, error), err e
 			res = prov.FloatGetter
 		}
 	case "mqtt":
-		if pc, err := mqttFromConfig(config.Other); err == nil {
+		var pc mqttConfig
+		if pc, err = mqttFromConfig(config.Other); err == nil {
 			res = MQTT.FloatGetter(pc.Topic, pc.Scale, pc.Timeout)
 		}
 	case "script":
@@ -104,6 +109,10 @@ func NewFloatGetterFromConfig(config Config) (res func() (float64, error), err e
 		return nil, fmt.Errorf("invalid plugin type: %s", config.Type)
 	}
 
+	if err == nil {
+		res = config.floatTransform(res)
+	}
+
 	return
 }
 
@@ -148,6 +157,10 @@ func NewIntGetterFromConfig(config Config) (res func() (int64, error), err error
 		err = fmt.Errorf("invalid plugin type: %s", config.Type)
 	}
 
+	if err == nil {
+		res = config.intTransform(res)
+	}
+
 	return
 }
 
@@ -189,6 +202,10 @@ func NewStringGetterFromConfig(config Config) (res func() (string, error), err e
 		err = fmt.Errorf("invalid plugin type: %s", config.Type)
 	}
 
+	if err == nil {
+		res = config.stringTransform(res)
+	}
+
 	return
 }
 
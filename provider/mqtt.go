@@ -13,6 +13,12 @@ import (
 
 const publishTimeout = 2 * time.Second
 
+// availability payloads
+const (
+	MqttOnline  = "online"  // published retained on connect
+	MqttOffline = "offline" // last will published by the broker once the connection is lost
+)
+
 // MqttConfig is the public configuration
 type MqttConfig struct {
 	Broker   string
@@ -29,43 +35,59 @@ type MqttClient struct {
 	broker   string
 	Qos      byte
 	listener map[string]func(string)
+
+	availability string // availability topic, empty to disable
 }
 
-// NewMqttClient creates new publisher for paho
+// NewMqttClient creates new publisher for paho. If availability topic is not empty,
+// online is published on connect and offline is registered as last will.
 func NewMqttClient(
 	broker string,
 	user string,
 	password string,
 	clientID string,
 	qos byte,
+	availability string,
 ) *MqttClient {
 	log := util.NewLogger("mqtt")
 	log.INFO.Printf("connecting %s at %s", clientID, broker)
 
 	mc := &MqttClient{
-		log:      log,
-		broker:   broker,
-		Qos:      qos,
-		listener: make(map[string]func(string)),
+		log:          log,
+		broker:       broker,
+		Qos:          qos,
+		listener:     make(map[string]func(string)),
+		availability: availability,
+	}
+
+	options := mc.options(user, password, clientID)
+
+	client := mqtt.NewClient(options)
+	if token := client.Connect(); token.Wait() && token.Error() != nil {
+		log.FATAL.Fatalf("error connecting: %s", token.Error())
 	}
 
+	mc.Client = client
+	return mc
+}
+
+// options creates the client options including the availability last will
+func (m *MqttClient) options(user, password, clientID string) *mqtt.ClientOptions {
 	options := mqtt.NewClientOptions()
-	options.AddBroker(broker)
+	options.AddBroker(m.broker)
 	options.SetUsername(user)
 	options.SetPassword(password)
 	options.SetClientID(clientID)
 	options.SetCleanSession(true)
 	options.SetAutoReconnect(true)
-	options.SetOnConnectHandler(mc.ConnectionHandler)
-	options.SetConnectionLostHandler(mc.ConnectionLostHandler)
+	options.SetOnConnectHandler(m.ConnectionHandler)
+	options.SetConnectionLostHandler(m.ConnectionLostHandler)
 
-	client := mqtt.NewClient(options)
-	if token := client.Connect(); token.Wait() && token.Error() != nil {
-		log.FATAL.Fatalf("error connecting: %s", token.Error())
+	if m.availability != "" {
+		options.SetWill(m.availability, MqttOffline, m.Qos, true)
 	}
 
-	mc.Client = client
-	return mc
+	return options
 }
 
 // ConnectionLostHandler logs cause of connection loss as warning
@@ -73,10 +95,15 @@ func (m *MqttClient) ConnectionLostHandler(client mqtt.Client, reason error) {
 	m.log.ERROR.Printf("%s connection lost: %v", m.broker, reason.Error())
 }
 
-// ConnectionHandler restores listeners
+// ConnectionHandler publishes availability and restores listeners
 func (m *MqttClient) ConnectionHandler(client mqtt.Client) {
 	m.log.DEBUG.Printf("%s connected", m.broker)
 
+	if m.availability != "" {
+		token := client.Publish(m.availability, m.Qos, true, MqttOnline)
+		go m.WaitForToken(token)
+	}
+
 	m.mux.Lock()
 	defer m.mux.Unlock()
 
@@ -0,0 +1,37 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/andig/evcc/util"
+)
+
+func TestMqttLastWill(t *testing.T) {
+	mc := &MqttClient{
+		log:          util.NewLogger("foo"),
+		broker:       "localhost:1883",
+		Qos:          1,
+		availability: "evcc/status",
+	}
+
+	o := mc.options("user", "password", "evcc")
+
+	if !o.WillEnabled {
+		t.Fatal("last will not enabled")
+	}
+	if o.WillTopic != "evcc/status" {
+		t.Errorf("will topic: %s", o.WillTopic)
+	}
+	if string(o.WillPayload) != MqttOffline {
+		t.Errorf("will payload: %s", o.WillPayload)
+	}
+	if !o.WillRetained || o.WillQos != 1 {
+		t.Errorf("will retained: %v qos: %d", o.WillRetained, o.WillQos)
+	}
+
+	// no availability topic
+	mc.availability = ""
+	if o := mc.options("user", "password", "evcc"); o.WillEnabled {
+		t.Error("unexpected last will")
+	}
+}
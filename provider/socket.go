@@ -37,7 +37,10 @@ func NewSocketProviderFromConfig(other map[string]interface{}) (*Socket, error)
 		Insecure bool
 		Auth     Auth
 		Timeout  time.Duration
-	}{Headers: make(map[string]string)}
+	}{
+		Headers: make(map[string]string),
+		Scale:   1,
+	}
 	if err := util.DecodeOther(other, &cc); err != nil {
 		return nil, err
 	}
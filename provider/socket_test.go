@@ -0,0 +1,51 @@
+package provider
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andig/evcc/util"
+	"github.com/gorilla/websocket"
+)
+
+func TestSocketScale(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := new(websocket.Upgrader).Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+
+		_ = conn.WriteMessage(websocket.TextMessage, []byte("1500"))
+
+		// keep connection open until client disconnects
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	var cc Config
+	if err := util.DecodeOther(map[string]interface{}{
+		"type":    "websocket",
+		"uri":     "ws" + strings.TrimPrefix(srv.URL, "http"),
+		"scale":   0.001,
+		"timeout": "5s",
+	}, &cc); err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := NewFloatGetterFromConfig(cc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if f, err := g(); err != nil || f != 1.5 {
+		t.Errorf("expected 1.5, got %v %v", f, err)
+	}
+}
@@ -0,0 +1,67 @@
+package provider
+
+import (
+	"fmt"
+	"math"
+)
+
+// scale returns the configured scale factor or 1 if not configured
+func (c Config) scale() float64 {
+	if c.Scale == 0 {
+		return 1
+	}
+	return c.Scale
+}
+
+// floatTransform applies scale and offset to the getter's raw value
+func (c Config) floatTransform(g func() (float64, error)) func() (float64, error) {
+	if g == nil || c.scale() == 1 && c.Offset == 0 {
+		return g
+	}
+
+	return func() (float64, error) {
+		f, err := g()
+		if err != nil {
+			return 0, err
+		}
+
+		return f*c.scale() + c.Offset, nil
+	}
+}
+
+// intTransform applies scale and offset to the getter's raw value
+func (c Config) intTransform(g func() (int64, error)) func() (int64, error) {
+	if g == nil || c.scale() == 1 && c.Offset == 0 {
+		return g
+	}
+
+	return func() (int64, error) {
+		i, err := g()
+		if err != nil {
+			return 0, err
+		}
+
+		return int64(math.Round(float64(i)*c.scale() + c.Offset)), nil
+	}
+}
+
+// stringTransform maps the getter's raw value using the mapping table
+func (c Config) stringTransform(g func() (string, error)) func() (string, error) {
+	if g == nil || len(c.Map) == 0 {
+		return g
+	}
+
+	return func() (string, error) {
+		s, err := g()
+		if err != nil {
+			return "", err
+		}
+
+		res, ok := c.Map[s]
+		if !ok {
+			return "", fmt.Errorf("unmapped value: %s", s)
+		}
+
+		return res, nil
+	}
+}
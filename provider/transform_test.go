@@ -0,0 +1,82 @@
+package provider
+
+import (
+	"testing"
+)
+
+func TestFloatTransform(t *testing.T) {
+	cases := []struct {
+		scale, offset, in, out float64
+	}{
+		{0, 0, 10, 10},
+		{1, 0, 10, 10},
+		{0.001, 0, 1500, 1.5},
+		{-1, 0, 10, -10},
+		{0.1, -40, 650, 25},
+		{0, 5, 10, 15},
+	}
+
+	for _, tc := range cases {
+		t.Log(tc)
+
+		c := Config{Scale: tc.scale, Offset: tc.offset}
+		g := c.floatTransform(func() (float64, error) { return tc.in, nil })
+
+		if f, err := g(); err != nil || f != tc.out {
+			t.Errorf("expected %v, got %v %v", tc.out, f, err)
+		}
+	}
+}
+
+func TestIntTransform(t *testing.T) {
+	cases := []struct {
+		scale, offset float64
+		in, out       int64
+	}{
+		{0, 0, 16, 16},
+		{0.1, 0, 160, 16},
+		{10, 1, 16, 161},
+		{0.5, 0, 3, 2},
+	}
+
+	for _, tc := range cases {
+		t.Log(tc)
+
+		c := Config{Scale: tc.scale, Offset: tc.offset}
+		g := c.intTransform(func() (int64, error) { return tc.in, nil })
+
+		if i, err := g(); err != nil || i != tc.out {
+			t.Errorf("expected %v, got %v %v", tc.out, i, err)
+		}
+	}
+}
+
+func TestStringTransform(t *testing.T) {
+	c := Config{Map: map[string]string{"0": "A", "1": "B", "2": "C"}}
+
+	cases := []struct {
+		in, out string
+		err     bool
+	}{
+		{"0", "A", false},
+		{"2", "C", false},
+		{"7", "", true},
+	}
+
+	for _, tc := range cases {
+		t.Log(tc)
+
+		g := c.stringTransform(func() (string, error) { return tc.in, nil })
+
+		s, err := g()
+		if (err != nil) != tc.err || s != tc.out {
+			t.Errorf("expected %v, got %v %v", tc.out, s, err)
+		}
+	}
+
+	// no mapping configured
+	g := Config{}.stringTransform(func() (string, error) { return "7", nil })
+	if s, err := g(); err != nil || s != "7" {
+		t.Errorf("expected unmapped value, got %v %v", s, err)
+	}
+}
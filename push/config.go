@@ -32,6 +32,11 @@ func NewMessengerFromConfig(typ string, other map[string]interface{}) (res Sende
 		if err = util.DecodeOther(other, &cc); err == nil {
 			res, err = NewTelegramMessenger(cc.Token, cc.Chats)
 		}
+	case "webhook", "http":
+		var cc webhookConfig
+		if err = util.DecodeOther(other, &cc); err == nil {
+			res, err = NewWebhookMessenger(cc.URI, cc.Method, cc.Headers, cc.Body)
+		}
 	case "email", "shout":
 		var cc shoutrrrConfig
 		if err = util.DecodeOther(other, &cc); err == nil {
@@ -0,0 +1,52 @@
+package push
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/gregdel/pushover"
+)
+
+func TestPushOver(t *testing.T) {
+	srv, reqC := recorder(t, `{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`)
+	defer srv.Close()
+
+	endpoint := pushover.APIEndpoint
+	pushover.APIEndpoint = srv.URL
+	defer func() { pushover.APIEndpoint = endpoint }()
+
+	app := "azGDORePK8gMaC0QOYAMyEEuzJnyUi"
+	recipient := "uQiRzpo4DXghDmr9QzzfQu27cmVRsG"
+
+	m, err := NewPushOverMessenger(app, []string{recipient})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.Send("Charge started", "Started charging in pv mode")
+
+	req := <-reqC
+	if req.path != "/messages.json" {
+		t.Errorf("unexpected path: %s", req.path)
+	}
+
+	values, err := url.ParseQuery(req.body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for k, v := range map[string]string{
+		"token":   app,
+		"user":    recipient,
+		"title":   "Charge started",
+		"message": "Started charging in pv mode",
+	} {
+		if values.Get(k) != v {
+			t.Errorf("%s: wanted %s, got %s", k, v, values.Get(k))
+		}
+	}
+
+	if _, err := NewPushOverMessenger("", nil); err == nil {
+		t.Error("expected missing app error")
+	}
+}
@@ -2,6 +2,7 @@ package push
 
 import (
 	"errors"
+	"net/http"
 	"sync"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
@@ -25,9 +26,13 @@ func init() {
 	}
 }
 
-// NewTelegramMessenger creates new pushover messenger
+// NewTelegramMessenger creates new Telegram messenger
 func NewTelegramMessenger(token string, chats []int64) (*Telegram, error) {
-	bot, err := tgbotapi.NewBotAPI(token)
+	return newTelegramMessenger(token, chats, &http.Client{})
+}
+
+func newTelegramMessenger(token string, chats []int64, client *http.Client) (*Telegram, error) {
+	bot, err := tgbotapi.NewBotAPIWithClient(token, client)
 	if err != nil {
 		return nil, errors.New("telegram: invalid bot token")
 	}
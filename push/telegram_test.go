@@ -0,0 +1,69 @@
+package push
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestTelegram(t *testing.T) {
+	msgC := make(chan url.Values, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/getMe"):
+			_, _ = w.Write([]byte(`{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"evcc","username":"evcc_bot"}}`))
+
+		case strings.HasSuffix(r.URL.Path, "/getUpdates"):
+			time.Sleep(100 * time.Millisecond)
+			_, _ = w.Write([]byte(`{"ok":true,"result":[]}`))
+
+		case strings.HasSuffix(r.URL.Path, "/sendMessage"):
+			b, _ := ioutil.ReadAll(r.Body)
+			values, err := url.ParseQuery(string(b))
+			if err != nil {
+				t.Error(err)
+			}
+			msgC <- values
+
+			_, _ = w.Write([]byte(`{"ok":true,"result":{"message_id":1,"chat":{"id":123},"date":0}}`))
+
+		default:
+			t.Errorf("unexpected request: %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	// redirect api requests to test server
+	srvURL, _ := url.Parse(srv.URL)
+	client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		req.URL.Scheme = srvURL.Scheme
+		req.URL.Host = srvURL.Host
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+
+	m, err := newTelegramMessenger("token", []int64{123}, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.Send("Charge started", "Started charging in pv mode")
+
+	values := <-msgC
+	if chat := values.Get("chat_id"); chat != "123" {
+		t.Errorf("unexpected chat: %s", chat)
+	}
+	if text := values.Get("text"); text != "Started charging in pv mode" {
+		t.Errorf("unexpected text: %s", text)
+	}
+}
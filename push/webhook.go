@@ -0,0 +1,110 @@
+package push
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/andig/evcc/util"
+)
+
+// Webhook implements the generic webhook messenger
+type Webhook struct {
+	*util.HTTPHelper
+	uri     string
+	method  string
+	headers map[string]string
+	body    string
+}
+
+type webhookConfig struct {
+	URI     string
+	Method  string
+	Headers map[string]string
+	Body    string // optional body template, defaults to json title and message
+}
+
+// NewWebhookMessenger creates new webhook messenger
+func NewWebhookMessenger(uri, method string, headers map[string]string, body string) (*Webhook, error) {
+	if uri == "" {
+		return nil, errors.New("webhook: missing uri")
+	}
+
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	m := &Webhook{
+		HTTPHelper: util.NewHTTPHelper(log),
+		uri:        uri,
+		method:     strings.ToUpper(method),
+		headers:    headers,
+		body:       body,
+	}
+
+	return m, nil
+}
+
+// payload creates the request body
+func (m *Webhook) payload(title, msg string) ([]byte, error) {
+	if m.body == "" {
+		return json.Marshal(struct {
+			Title   string `json:"title"`
+			Message string `json:"message"`
+		}{
+			Title:   title,
+			Message: msg,
+		})
+	}
+
+	body, err := util.ReplaceFormatted(m.body, map[string]interface{}{
+		"title":   title,
+		"message": msg,
+	})
+
+	return []byte(body), err
+}
+
+// Send sends to the webhook
+func (m *Webhook) Send(title, msg string) {
+	body, err := m.payload(title, msg)
+	if err == nil {
+		err = m.send(body, m.body == "")
+	}
+
+	if err != nil {
+		log.ERROR.Printf("webhook: %v", err)
+	}
+}
+
+// SendJSON sends the json encoded payload to the webhook, ignoring the body template
+func (m *Webhook) SendJSON(payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return m.send(body, true)
+}
+
+// send sends the request body to the webhook
+func (m *Webhook) send(body []byte, isJSON bool) error {
+	req, err := http.NewRequest(m.method, m.uri, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	if isJSON {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range m.headers {
+		req.Header.Set(k, v)
+	}
+
+	log.TRACE.Printf("webhook: sending to %s", m.uri)
+
+	_, err = m.Request(req)
+	return err
+}
@@ -0,0 +1,85 @@
+package push
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type request struct {
+	method, path, contentType, body string
+	header                          http.Header
+}
+
+// recorder returns a test server recording all requests
+func recorder(t *testing.T, response string) (*httptest.Server, <-chan request) {
+	reqC := make(chan request, 10)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		reqC <- request{
+			method:      r.Method,
+			path:        r.URL.Path,
+			contentType: r.Header.Get("Content-Type"),
+			body:        string(b),
+			header:      r.Header,
+		}
+
+		_, _ = w.Write([]byte(response))
+	}))
+
+	return srv, reqC
+}
+
+func TestWebhook(t *testing.T) {
+	srv, reqC := recorder(t, "")
+	defer srv.Close()
+
+	tc := []struct {
+		method, body string
+		headers      map[string]string
+		expected     request
+	}{
+		{"", "", nil, request{
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        `{"title":"Charge started","message":"Started charging in \"pv\" mode"}`,
+		}},
+		{"put", "${title}: ${message}", map[string]string{"Content-Type": "text/plain"}, request{
+			method:      http.MethodPut,
+			contentType: "text/plain",
+			body:        `Charge started: Started charging in "pv" mode`,
+		}},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		m, err := NewWebhookMessenger(srv.URL+"/hook", tc.method, tc.headers, tc.body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		m.Send("Charge started", `Started charging in "pv" mode`)
+
+		req := <-reqC
+		if req.method != tc.expected.method || req.path != "/hook" {
+			t.Errorf("unexpected request: %s %s", req.method, req.path)
+		}
+		if req.contentType != tc.expected.contentType {
+			t.Errorf("unexpected content type: %s", req.contentType)
+		}
+		if req.body != tc.expected.body {
+			t.Errorf("unexpected body: %s", req.body)
+		}
+	}
+
+	if _, err := NewWebhookMessenger("", "", nil, ""); err == nil {
+		t.Error("expected missing uri error")
+	}
+}
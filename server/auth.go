@@ -0,0 +1,61 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// AuthConfig is the api key authentication configuration
+type AuthConfig struct {
+	Keys        []string // Valid api keys, authentication is disabled if empty
+	ProtectRead bool     // Require api key for read requests, too
+}
+
+// apiKey returns the api key from either bearer token or X-API-Key header
+func apiKey(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+
+	return r.Header.Get("X-API-Key")
+}
+
+// authorized returns true if the key matches any of the configured keys
+func (conf AuthConfig) authorized(key string) bool {
+	var ok bool
+	for _, k := range conf.Keys {
+		if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+			ok = true
+		}
+	}
+	return ok
+}
+
+// protected returns true if the request requires authentication
+func (conf AuthConfig) protected(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodOptions:
+		// cors preflight requests don't carry credentials
+		return false
+	case http.MethodGet, http.MethodHead:
+		return conf.ProtectRead
+	default:
+		return true
+	}
+}
+
+// authHandler rejects protected requests without valid api key
+func authHandler(conf AuthConfig) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(conf.Keys) > 0 && conf.protected(r) && !conf.authorized(apiKey(r)) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="evcc"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			h.ServeHTTP(w, r)
+		})
+	}
+}
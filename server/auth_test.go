@@ -0,0 +1,56 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthHandler(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tc := []struct {
+		conf          AuthConfig
+		method        string
+		header, value string
+		status        int
+	}{
+		// authentication disabled
+		{AuthConfig{}, http.MethodPost, "", "", http.StatusOK},
+		// public read
+		{AuthConfig{Keys: []string{"secret"}}, http.MethodGet, "", "", http.StatusOK},
+		// protected read
+		{AuthConfig{Keys: []string{"secret"}, ProtectRead: true}, http.MethodGet, "", "", http.StatusUnauthorized},
+		{AuthConfig{Keys: []string{"secret"}, ProtectRead: true}, http.MethodGet, "X-API-Key", "secret", http.StatusOK},
+		// protected write
+		{AuthConfig{Keys: []string{"secret"}}, http.MethodPost, "", "", http.StatusUnauthorized},
+		{AuthConfig{Keys: []string{"secret"}}, http.MethodPost, "Authorization", "Bearer foo", http.StatusUnauthorized},
+		{AuthConfig{Keys: []string{"secret"}}, http.MethodPost, "Authorization", "secret", http.StatusUnauthorized},
+		{AuthConfig{Keys: []string{"secret"}}, http.MethodPost, "Authorization", "Bearer secret", http.StatusOK},
+		{AuthConfig{Keys: []string{"foo", "secret"}}, http.MethodDelete, "X-API-Key", "secret", http.StatusOK},
+		// cors preflight
+		{AuthConfig{Keys: []string{"secret"}}, http.MethodOptions, "", "", http.StatusOK},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		req := httptest.NewRequest(tc.method, "/api/mode/pv", nil)
+		if tc.header != "" {
+			req.Header.Set(tc.header, tc.value)
+		}
+
+		w := httptest.NewRecorder()
+		authHandler(tc.conf)(ok).ServeHTTP(w, req)
+
+		if w.Code != tc.status {
+			t.Errorf("expected status %d, got %d", tc.status, w.Code)
+		}
+
+		if w.Code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+			t.Error("missing WWW-Authenticate header")
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gorilla/handlers"
+)
+
+// CorsConfig is the cross-origin resource sharing configuration.
+// Without origins, only same-origin requests are allowed.
+type CorsConfig struct {
+	Origins []string // Allowed origins, * allows any origin
+	Methods []string // Allowed methods, defaults to GET, HEAD, POST and DELETE
+}
+
+// corsHandler adds CORS headers for configured origins
+func corsHandler(conf CorsConfig) func(http.Handler) http.Handler {
+	methods := conf.Methods
+	if len(methods) == 0 {
+		methods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodDelete}
+	}
+
+	origins := handlers.AllowedOrigins(conf.Origins)
+	if len(conf.Origins) == 0 {
+		// empty origins would allow any origin
+		origins = handlers.AllowedOriginValidator(func(string) bool { return false })
+	}
+
+	return handlers.CORS(
+		origins,
+		handlers.AllowedMethods(methods),
+		handlers.AllowedHeaders([]string{
+			"Accept", "Accept-Language", "Content-Language", "Content-Type", "Origin",
+			"Authorization", "X-API-Key",
+		}),
+	)
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCorsHandler(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tc := []struct {
+		conf    CorsConfig
+		method  string
+		origin  string
+		allowed string
+	}{
+		// same-origin only by default
+		{CorsConfig{}, http.MethodGet, "http://dashboard", ""},
+		{CorsConfig{}, http.MethodOptions, "http://dashboard", ""},
+		// configured origins
+		{CorsConfig{Origins: []string{"http://dashboard"}}, http.MethodGet, "http://dashboard", "http://dashboard"},
+		{CorsConfig{Origins: []string{"http://dashboard"}}, http.MethodOptions, "http://dashboard", "http://dashboard"},
+		{CorsConfig{Origins: []string{"http://dashboard"}}, http.MethodGet, "http://other", ""},
+		{CorsConfig{Origins: []string{"*"}}, http.MethodGet, "http://other", "*"},
+		// configured methods
+		{CorsConfig{Origins: []string{"http://dashboard"}, Methods: []string{http.MethodGet}}, http.MethodOptions, "http://dashboard", ""},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		req := httptest.NewRequest(tc.method, "/api/mode/pv", nil)
+		req.Header.Set("Origin", tc.origin)
+		if tc.method == http.MethodOptions {
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		}
+
+		w := httptest.NewRecorder()
+		corsHandler(tc.conf)(ok).ServeHTTP(w, req)
+
+		if allowed := w.Header().Get("Access-Control-Allow-Origin"); allowed != tc.allowed {
+			t.Errorf("expected allowed origin %q, got %q", tc.allowed, allowed)
+		}
+	}
+}
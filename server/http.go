@@ -33,6 +33,26 @@ type targetSoCJSON struct {
 	TargetSoC int `json:"targetSoC"`
 }
 
+type startSoCJSON struct {
+	StartSoC int `json:"startSoC"`
+}
+
+type targetEnergyJSON struct {
+	TargetEnergy float64 `json:"targetEnergy"`
+}
+
+type targetTimeJSON struct {
+	TargetTime time.Time `json:"targetTime"`
+}
+
+type boostJSON struct {
+	BoostUntil time.Time `json:"boostUntil"`
+}
+
+type vehicleJSON struct {
+	Vehicle string `json:"vehicle"`
+}
+
 type route struct {
 	Methods     []string
 	Pattern     string
@@ -54,6 +74,48 @@ type loadpoint interface {
 	SetTargetSoC(targetSoC int)
 }
 
+// startSoCer is the interface for setting the offline soc estimation start soc
+type startSoCer interface {
+	GetStartSoC() int
+	SetStartSoC(startSoC int)
+}
+
+// energyTargeter is the interface for setting the session target energy
+type energyTargeter interface {
+	GetTargetEnergy() float64
+	SetTargetEnergy(targetEnergy float64)
+}
+
+// timeTargeter is the interface for planning the time at which the target soc should be reached
+type timeTargeter interface {
+	GetTargetTime() time.Time
+	SetTargetTime(targetTime time.Time)
+}
+
+// booster is the interface for temporarily charging at max current
+type booster interface {
+	GetBoost() time.Time
+	Boost(duration time.Duration)
+	CancelBoost()
+}
+
+// transitioner is the interface for accessing the loadpoint's recent state transitions
+type transitioner interface {
+	Transitions() []core.Transition
+}
+
+// counterResetter is the interface for accessing and resetting the loadpoint's energy and cost counters
+type counterResetter interface {
+	GetCounters() core.Counters
+	ResetCounters()
+}
+
+// vehicleSelector is the interface for selecting the loadpoint's active vehicle
+type vehicleSelector interface {
+	GetVehicle() string
+	SetVehicle(name string) error
+}
+
 // routeLogger traces matched routes including their executing time
 func routeLogger(inner http.Handler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -154,10 +216,17 @@ func TemplatesHandler() http.HandlerFunc {
 	}
 }
 
+// CapabilitiesHandler returns the implemented interfaces of the configured devices
+func CapabilitiesHandler(capabilities map[string]map[string][]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		jsonResponse(w, r, capabilities)
+	}
+}
+
 // StateHandler returns current charge mode
-func StateHandler(cache *util.Cache) http.HandlerFunc {
+func StateHandler(cache *util.Cache, units util.Units) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		res := cache.State()
+		res := cache.StateFunc(units.Convert)
 		jsonResponse(w, r, res)
 	}
 }
@@ -217,6 +286,164 @@ func TargetSoCHandler(loadpoint loadpoint) http.HandlerFunc {
 	}
 }
 
+// StartSoCHandler updates start soc for offline soc estimation
+func StartSoCHandler(loadpoint startSoCer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		socS, ok := vars["soc"]
+		soc, err := strconv.ParseInt(socS, 10, 32)
+
+		if !ok || err != nil || soc > 100 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		loadpoint.SetStartSoC(int(soc))
+
+		res := startSoCJSON{StartSoC: loadpoint.GetStartSoC()}
+		jsonResponse(w, r, res)
+	}
+}
+
+// CurrentTargetEnergyHandler returns the session target energy
+func CurrentTargetEnergyHandler(loadpoint energyTargeter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		res := targetEnergyJSON{TargetEnergy: loadpoint.GetTargetEnergy()}
+		jsonResponse(w, r, res)
+	}
+}
+
+// TargetEnergyHandler updates the session target energy
+func TargetEnergyHandler(loadpoint energyTargeter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		energyS, ok := vars["energy"]
+		energy, err := strconv.ParseFloat(energyS, 64)
+
+		if !ok || err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		loadpoint.SetTargetEnergy(energy)
+
+		res := targetEnergyJSON{TargetEnergy: loadpoint.GetTargetEnergy()}
+		jsonResponse(w, r, res)
+	}
+}
+
+// CurrentTargetTimeHandler returns the planned target time
+func CurrentTargetTimeHandler(loadpoint timeTargeter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		res := targetTimeJSON{TargetTime: loadpoint.GetTargetTime()}
+		jsonResponse(w, r, res)
+	}
+}
+
+// TargetTimeHandler updates or cancels the planned target time
+func TargetTimeHandler(loadpoint timeTargeter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var targetTime time.Time
+
+		if r.Method != http.MethodDelete {
+			vars := mux.Vars(r)
+
+			var err error
+			if targetTime, err = time.Parse(time.RFC3339, vars["time"]); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		}
+
+		loadpoint.SetTargetTime(targetTime)
+
+		res := targetTimeJSON{TargetTime: loadpoint.GetTargetTime()}
+		jsonResponse(w, r, res)
+	}
+}
+
+// CurrentBoostHandler returns the end of boost
+func CurrentBoostHandler(loadpoint booster) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		res := boostJSON{BoostUntil: loadpoint.GetBoost()}
+		jsonResponse(w, r, res)
+	}
+}
+
+// BoostHandler starts, extends or cancels boost
+func BoostHandler(loadpoint booster) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		if r.Method == http.MethodDelete {
+			loadpoint.CancelBoost()
+		} else {
+			var duration time.Duration
+
+			if durationS, ok := vars["duration"]; ok {
+				var err error
+				if duration, err = time.ParseDuration(durationS); err != nil || duration <= 0 {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+			}
+
+			loadpoint.Boost(duration)
+		}
+
+		res := boostJSON{BoostUntil: loadpoint.GetBoost()}
+		jsonResponse(w, r, res)
+	}
+}
+
+// TransitionsHandler returns the recent state transitions, oldest first
+func TransitionsHandler(loadpoint transitioner) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		jsonResponse(w, r, loadpoint.Transitions())
+	}
+}
+
+// CurrentVehicleHandler returns the active vehicle
+func CurrentVehicleHandler(loadpoint vehicleSelector) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		res := vehicleJSON{Vehicle: loadpoint.GetVehicle()}
+		jsonResponse(w, r, res)
+	}
+}
+
+// VehicleHandler selects the active vehicle, DELETE restores the configured vehicle
+func VehicleHandler(loadpoint vehicleSelector) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		name := vars["name"]
+		if r.Method == http.MethodDelete {
+			name = ""
+		}
+
+		if err := loadpoint.SetVehicle(name); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		res := vehicleJSON{Vehicle: loadpoint.GetVehicle()}
+		jsonResponse(w, r, res)
+	}
+}
+
+// CountersHandler returns the energy and cost counters, DELETE resets the resettable counters
+func CountersHandler(loadpoint counterResetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			loadpoint.ResetCounters()
+		}
+
+		jsonResponse(w, r, loadpoint.GetCounters())
+	}
+}
+
 // SocketHandler attaches websocket handler to uri
 func SocketHandler(hub *SocketHub) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -230,12 +457,13 @@ func applyRouteHandler(router *mux.Router, r route, handler http.HandlerFunc) {
 }
 
 // NewHTTPd creates HTTP server with configured routes for loadpoint
-func NewHTTPd(url string, links []MenuConfig, site site, hub *SocketHub, cache *util.Cache) *http.Server {
+func NewHTTPd(url string, links []MenuConfig, auth AuthConfig, cors CorsConfig, units util.Units, site site, capabilities map[string]map[string][]string, hub *SocketHub, cache *util.Cache) *http.Server {
 	var routes = map[string]route{
 		"health":       {[]string{"GET"}, "/health", HealthHandler()},
 		"config":       {[]string{"GET"}, "/config", ConfigHandler(site)},
 		"templates":    {[]string{"GET"}, "/config/templates/{class:[a-z]+}", TemplatesHandler()},
-		"state":        {[]string{"GET"}, "/state", StateHandler(cache)},
+		"capabilities": {[]string{"GET"}, "/capabilities", CapabilitiesHandler(capabilities)},
+		"state":        {[]string{"GET"}, "/state", StateHandler(cache, units)},
 		"getmode":      {[]string{"GET"}, "/mode", CurrentChargeModeHandler(site)},
 		"setmode":      {[]string{"POST", "OPTIONS"}, "/mode/{mode:[a-z]+}", ChargeModeHandler(site)},
 		"gettargetsoc": {[]string{"GET"}, "/targetsoc", CurrentTargetSoCHandler(site)},
@@ -261,11 +489,8 @@ func NewHTTPd(url string, links []MenuConfig, site site, hub *SocketHub, cache *
 	api := router.PathPrefix("/api").Subrouter()
 	api.Use(jsonHandler)
 	api.Use(handlers.CompressHandler)
-	api.Use(handlers.CORS(
-		handlers.AllowedHeaders([]string{
-			"Accept", "Accept-Language", "Content-Language", "Content-Type", "Origin",
-		}),
-	))
+	api.Use(corsHandler(cors))
+	api.Use(authHandler(auth))
 
 	// site api
 	for _, r := range routes {
@@ -279,6 +504,29 @@ func NewHTTPd(url string, links []MenuConfig, site site, hub *SocketHub, cache *
 		applyRouteHandler(subAPI, routes["setmode"], ChargeModeHandler(lp))
 		applyRouteHandler(subAPI, routes["gettargetsoc"], CurrentTargetSoCHandler(lp))
 		applyRouteHandler(subAPI, routes["settargetsoc"], TargetSoCHandler(lp))
+
+		// loadpoint-only routes
+		var lpRoutes = map[string]route{
+			"setstartsoc":      {[]string{"POST", "OPTIONS"}, "/startsoc/{soc:[0-9]+}", StartSoCHandler(lp)},
+			"gettargetenergy":  {[]string{"GET"}, "/targetenergy", CurrentTargetEnergyHandler(lp)},
+			"settargetenergy":  {[]string{"POST", "OPTIONS"}, "/targetenergy/{energy:[0-9.]+}", TargetEnergyHandler(lp)},
+			"gettargettime":    {[]string{"GET"}, "/targettime", CurrentTargetTimeHandler(lp)},
+			"settargettime":    {[]string{"POST", "OPTIONS"}, "/targettime/{time}", TargetTimeHandler(lp)},
+			"deletetargettime": {[]string{"DELETE"}, "/targettime", TargetTimeHandler(lp)},
+			"getboost":         {[]string{"GET"}, "/boost", CurrentBoostHandler(lp)},
+			"setboost":         {[]string{"POST", "OPTIONS"}, "/boost", BoostHandler(lp)},
+			"setboostduration": {[]string{"POST", "OPTIONS"}, "/boost/{duration}", BoostHandler(lp)},
+			"deleteboost":      {[]string{"DELETE"}, "/boost", BoostHandler(lp)},
+			"transitions":      {[]string{"GET"}, "/transitions", TransitionsHandler(lp)},
+			"getvehicle":       {[]string{"GET"}, "/vehicle", CurrentVehicleHandler(lp)},
+			"setvehicle":       {[]string{"POST", "OPTIONS"}, "/vehicle/{name}", VehicleHandler(lp)},
+			"deletevehicle":    {[]string{"DELETE"}, "/vehicle", VehicleHandler(lp)},
+			"counters":         {[]string{"GET", "DELETE"}, "/counters", CountersHandler(lp)},
+		}
+
+		for _, r := range lpRoutes {
+			subAPI.Methods(r.Methods...).Path(r.Pattern).Handler(r.HandlerFunc)
+		}
 	}
 
 	srv := &http.Server{
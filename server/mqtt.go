@@ -82,6 +82,35 @@ func (m *MQTT) listenSetters(topic string, apiHandler apiHandler) {
 	})
 }
 
+// listenVehicleSetter listens for vehicle selection, empty payload restores the configured vehicle
+func (m *MQTT) listenVehicleSetter(topic string, lp *core.LoadPoint) {
+	m.Handler.Listen(topic+"/vehicle/set", func(payload string) {
+		if err := lp.SetVehicle(payload); err != nil {
+			log.ERROR.Println(err)
+		}
+	})
+}
+
+// listenBoostSetter listens for boost requests. An empty payload boosts for the configured duration, 0 cancels the boost.
+func (m *MQTT) listenBoostSetter(topic string, lp *core.LoadPoint) {
+	m.Handler.Listen(topic+"/boost/set", func(payload string) {
+		if payload == "" {
+			lp.Boost(0)
+			return
+		}
+
+		duration, err := time.ParseDuration(payload)
+		switch {
+		case err != nil:
+			log.ERROR.Printf("invalid boost duration: %s", payload)
+		case duration <= 0:
+			lp.CancelBoost()
+		default:
+			lp.Boost(duration)
+		}
+	})
+}
+
 // Run starts the MQTT publisher for the MQTT API
 func (m *MQTT) Run(site *core.Site, in <-chan util.Param) {
 	topic := fmt.Sprintf("%s/site", m.root)
@@ -94,6 +123,8 @@ func (m *MQTT) Run(site *core.Site, in <-chan util.Param) {
 	for id, lp := range site.LoadPoints() {
 		topic := fmt.Sprintf("%s/loadpoints/%d", m.root, id)
 		m.listenSetters(topic, lp)
+		m.listenVehicleSetter(topic, lp)
+		m.listenBoostSetter(topic, lp)
 	}
 
 	// alive indicator
@@ -30,6 +30,11 @@ func (c *Cache) Run(in <-chan Param) {
 // State provides a structured copy of the cached values
 // Loadpoints are aggregated as loadpoints array
 func (c *Cache) State() map[string]interface{} {
+	return c.StateFunc(nil)
+}
+
+// StateFunc provides a structured copy of the cached values converted by fn
+func (c *Cache) StateFunc(fn func(Param) Param) map[string]interface{} {
 	c.Lock()
 	defer c.Unlock()
 
@@ -37,6 +42,10 @@ func (c *Cache) State() map[string]interface{} {
 	lps := make(map[int]map[string]interface{})
 
 	for _, param := range c.val {
+		if fn != nil {
+			param = fn(param)
+		}
+
 		if param.LoadPoint == nil {
 			res[param.Key] = param.Val
 		} else {
@@ -0,0 +1,120 @@
+package modbus
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	gridx "github.com/grid-x/modbus"
+)
+
+// Block is a contiguous register span read in a single transaction
+type Block struct {
+	Input           bool // input registers if true, holding registers otherwise
+	Start, Quantity uint16
+}
+
+// contains returns true if the block contains the given register span
+func (b Block) contains(input bool, address, quantity uint16) bool {
+	return b.Input == input && address >= b.Start && uint32(address)+uint32(quantity) <= uint32(b.Start)+uint32(b.Quantity)
+}
+
+type blockResult struct {
+	updated time.Time
+	bytes   []byte
+}
+
+// BatchClient reads adjacent registers in a single transaction. Reads of registers
+// contained in a configured block are served from the block's result until maxAge has
+// elapsed or a register has been written. All other requests are passed through.
+type BatchClient struct {
+	gridx.Client
+	mu     sync.Mutex
+	clock  clock.Clock
+	maxAge time.Duration
+	blocks []Block
+	cache  map[Block]blockResult
+}
+
+// NewBatchClient creates a client batching reads of the given register blocks
+func NewBatchClient(client gridx.Client, maxAge time.Duration, blocks ...Block) *BatchClient {
+	return &BatchClient{
+		Client: client,
+		clock:  clock.New(),
+		maxAge: maxAge,
+		blocks: blocks,
+		cache:  make(map[Block]blockResult),
+	}
+}
+
+// read returns the requested registers from the containing block if available
+func (c *BatchClient) read(input bool, address, quantity uint16, read func(address, quantity uint16) ([]byte, error)) ([]byte, error) {
+	for _, b := range c.blocks {
+		if !b.contains(input, address, quantity) {
+			continue
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		res, ok := c.cache[b]
+		if !ok || c.clock.Since(res.updated) >= c.maxAge {
+			bytes, err := read(b.Start, b.Quantity)
+			if err != nil {
+				delete(c.cache, b)
+				return nil, err
+			}
+
+			res = blockResult{updated: c.clock.Now(), bytes: bytes}
+			c.cache[b] = res
+		}
+
+		offset := 2 * int(address-b.Start)
+		if len(res.bytes) < offset+2*int(quantity) {
+			return nil, fmt.Errorf("invalid block length: %d", len(res.bytes))
+		}
+
+		return res.bytes[offset : offset+2*int(quantity)], nil
+	}
+
+	return read(address, quantity)
+}
+
+// invalidate discards all block results after writing
+func (c *BatchClient) invalidate(bytes []byte, err error) ([]byte, error) {
+	c.mu.Lock()
+	c.cache = make(map[Block]blockResult)
+	c.mu.Unlock()
+	return bytes, err
+}
+
+// ReadInputRegisters implements the modbus.Client interface
+func (c *BatchClient) ReadInputRegisters(address, quantity uint16) ([]byte, error) {
+	return c.read(true, address, quantity, c.Client.ReadInputRegisters)
+}
+
+// ReadHoldingRegisters implements the modbus.Client interface
+func (c *BatchClient) ReadHoldingRegisters(address, quantity uint16) ([]byte, error) {
+	return c.read(false, address, quantity, c.Client.ReadHoldingRegisters)
+}
+
+// WriteSingleCoil implements the modbus.Client interface
+func (c *BatchClient) WriteSingleCoil(address, value uint16) ([]byte, error) {
+	return c.invalidate(c.Client.WriteSingleCoil(address, value))
+}
+
+// WriteMultipleCoils implements the modbus.Client interface
+func (c *BatchClient) WriteMultipleCoils(address, quantity uint16, value []byte) ([]byte, error) {
+	return c.invalidate(c.Client.WriteMultipleCoils(address, quantity, value))
+}
+
+// WriteSingleRegister implements the modbus.Client interface
+func (c *BatchClient) WriteSingleRegister(address, value uint16) ([]byte, error) {
+	return c.invalidate(c.Client.WriteSingleRegister(address, value))
+}
+
+// WriteMultipleRegisters implements the modbus.Client interface
+func (c *BatchClient) WriteMultipleRegisters(address, quantity uint16, value []byte) ([]byte, error) {
+	return c.invalidate(c.Client.WriteMultipleRegisters(address, quantity, value))
+}
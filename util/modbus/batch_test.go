@@ -0,0 +1,96 @@
+package modbus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	gridx "github.com/grid-x/modbus"
+)
+
+// registerClient returns register addresses as values and records read requests
+type registerClient struct {
+	gridx.Client
+	reads [][2]uint16
+}
+
+func (c *registerClient) registers(address, quantity uint16) ([]byte, error) {
+	c.reads = append(c.reads, [2]uint16{address, quantity})
+
+	b := make([]byte, 0, 2*quantity)
+	for i := uint16(0); i < quantity; i++ {
+		b = append(b, byte((address+i)>>8), byte(address+i))
+	}
+
+	return b, nil
+}
+
+func (c *registerClient) ReadInputRegisters(address, quantity uint16) ([]byte, error) {
+	return c.registers(address, quantity)
+}
+
+func (c *registerClient) ReadHoldingRegisters(address, quantity uint16) ([]byte, error) {
+	return c.registers(address, quantity)
+}
+
+func (c *registerClient) WriteSingleRegister(address, value uint16) ([]byte, error) {
+	return nil, nil
+}
+
+func TestBatchClient(t *testing.T) {
+	rc := &registerClient{}
+	clck := clock.NewMock()
+
+	c := NewBatchClient(rc, time.Second, Block{Input: true, Start: 100, Quantity: 4})
+	c.clock = clck
+
+	// status, charge time and both registers of the block
+	for _, tc := range []struct {
+		address, quantity uint16
+	}{
+		{100, 1}, {102, 2}, {101, 1}, {100, 4},
+	} {
+		b, err := c.ReadInputRegisters(tc.address, tc.quantity)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(b) != 2*int(tc.quantity) || uint16(b[0])<<8|uint16(b[1]) != tc.address {
+			t.Errorf("unexpected result for %d: %0 X", tc.address, b)
+		}
+	}
+
+	if len(rc.reads) != 1 || rc.reads[0] != [2]uint16{100, 4} {
+		t.Errorf("expected single block read, got %v", rc.reads)
+	}
+
+	// not contained in block
+	if _, err := c.ReadInputRegisters(103, 2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ReadHoldingRegisters(100, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rc.reads) != 3 {
+		t.Errorf("expected pass-through reads, got %v", rc.reads)
+	}
+
+	// expired
+	clck.Add(time.Second)
+	if _, err := c.ReadInputRegisters(100, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// invalidated by write
+	if _, err := c.WriteSingleRegister(300, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ReadInputRegisters(102, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rc.reads) != 5 {
+		t.Errorf("expected block re-read, got %v", rc.reads)
+	}
+}
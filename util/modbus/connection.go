@@ -0,0 +1,151 @@
+package modbus
+
+import (
+	"sync"
+	"time"
+
+	gridx "github.com/grid-x/modbus"
+	"github.com/volkszaehler/mbmd/meters"
+)
+
+// physical is a physical modbus connection shared by all devices using the same uri or device
+type physical struct {
+	mu   sync.Mutex
+	conn meters.Connection
+}
+
+var (
+	mu          sync.Mutex
+	connections = make(map[string]*physical)
+)
+
+// registeredConnection returns the physical connection for the given key, creating it if necessary
+func registeredConnection(key string, newConn func() meters.Connection) *physical {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if conn, ok := connections[key]; ok {
+		return conn
+	}
+
+	conn := &physical{conn: newConn()}
+	connections[key] = conn
+
+	return conn
+}
+
+// sharedConnection is a device's view of a shared physical connection.
+// Bus operations are serialized and executed using the device's slave id.
+type sharedConnection struct {
+	*physical
+	slaveID uint8
+}
+
+var _ meters.Connection = (*sharedConnection)(nil)
+
+// String returns the bus device (RTU) or bus connection address (TCP)
+func (c *sharedConnection) String() string {
+	return c.conn.String()
+}
+
+// ModbusClient returns a modbus client serializing access to the physical connection
+func (c *sharedConnection) ModbusClient() gridx.Client {
+	return &client{c}
+}
+
+// Slave sets the modbus device id for the following operations
+func (c *sharedConnection) Slave(deviceID uint8) {
+	c.slaveID = deviceID
+}
+
+// Timeout sets the modbus timeout
+func (c *sharedConnection) Timeout(timeout time.Duration) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn.Timeout(timeout)
+}
+
+// Close closes the modbus connection.
+func (c *sharedConnection) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn.Close()
+}
+
+// Logger sets a logging instance for physical bus operations
+func (c *sharedConnection) Logger(l meters.Logger) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn.Logger(l)
+}
+
+// client implements gridx.Client by locking the physical connection for each operation
+type client struct {
+	*sharedConnection
+}
+
+// lock locks the physical connection and selects the device's slave id
+func (c *client) lock() gridx.Client {
+	c.mu.Lock()
+	c.conn.Slave(c.slaveID)
+	return c.conn.ModbusClient()
+}
+
+func (c *client) unlock() {
+	c.mu.Unlock()
+}
+
+func (c *client) ReadCoils(address, quantity uint16) ([]byte, error) {
+	defer c.unlock()
+	return c.lock().ReadCoils(address, quantity)
+}
+
+func (c *client) ReadDiscreteInputs(address, quantity uint16) ([]byte, error) {
+	defer c.unlock()
+	return c.lock().ReadDiscreteInputs(address, quantity)
+}
+
+func (c *client) WriteSingleCoil(address, value uint16) ([]byte, error) {
+	defer c.unlock()
+	return c.lock().WriteSingleCoil(address, value)
+}
+
+func (c *client) WriteMultipleCoils(address, quantity uint16, value []byte) ([]byte, error) {
+	defer c.unlock()
+	return c.lock().WriteMultipleCoils(address, quantity, value)
+}
+
+func (c *client) ReadInputRegisters(address, quantity uint16) ([]byte, error) {
+	defer c.unlock()
+	return c.lock().ReadInputRegisters(address, quantity)
+}
+
+func (c *client) ReadHoldingRegisters(address, quantity uint16) ([]byte, error) {
+	defer c.unlock()
+	return c.lock().ReadHoldingRegisters(address, quantity)
+}
+
+func (c *client) WriteSingleRegister(address, value uint16) ([]byte, error) {
+	defer c.unlock()
+	return c.lock().WriteSingleRegister(address, value)
+}
+
+func (c *client) WriteMultipleRegisters(address, quantity uint16, value []byte) ([]byte, error) {
+	defer c.unlock()
+	return c.lock().WriteMultipleRegisters(address, quantity, value)
+}
+
+func (c *client) ReadWriteMultipleRegisters(readAddress, readQuantity, writeAddress, writeQuantity uint16, value []byte) ([]byte, error) {
+	defer c.unlock()
+	return c.lock().ReadWriteMultipleRegisters(readAddress, readQuantity, writeAddress, writeQuantity, value)
+}
+
+func (c *client) MaskWriteRegister(address, andMask, orMask uint16) ([]byte, error) {
+	defer c.unlock()
+	return c.lock().MaskWriteRegister(address, andMask, orMask)
+}
+
+func (c *client) ReadFIFOQueue(address uint16) ([]byte, error) {
+	defer c.unlock()
+	return c.lock().ReadFIFOQueue(address)
+}
@@ -0,0 +1,89 @@
+package modbus
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	gridx "github.com/grid-x/modbus"
+	"github.com/volkszaehler/mbmd/meters"
+)
+
+func TestSharedConnection(t *testing.T) {
+	c1, err := NewConnection("192.0.2.1:502", "", "", 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c2, err := NewConnection("192.0.2.1:502", "", "", 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c3, err := NewConnection("192.0.2.2:502", "", "", 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c1 == c2 {
+		t.Error("devices must not share slave id")
+	}
+
+	if c1.(*sharedConnection).physical != c2.(*sharedConnection).physical {
+		t.Error("devices with same host must share physical connection")
+	}
+
+	if c1.(*sharedConnection).physical == c3.(*sharedConnection).physical {
+		t.Error("devices with different host must not share physical connection")
+	}
+}
+
+// recorder is a physical connection recording the slave id of each operation
+type recorder struct {
+	meters.Connection
+	gridx.Client
+	slave uint8
+	ops   map[uint8]int
+	wrong int
+}
+
+func (r *recorder) ModbusClient() gridx.Client { return r }
+func (r *recorder) Slave(id uint8)             { r.slave = id }
+
+func (r *recorder) ReadInputRegisters(address, quantity uint16) ([]byte, error) {
+	id := r.slave
+	time.Sleep(time.Millisecond)
+
+	// slave id must not change during operation
+	if r.slave != id || uint16(id) != address {
+		r.wrong++
+	}
+	r.ops[id]++
+
+	return nil, nil
+}
+
+func TestSharedConnectionSerialized(t *testing.T) {
+	rec := &recorder{ops: make(map[uint8]int)}
+	phys := registeredConnection("recorder", func() meters.Connection { return rec })
+
+	var wg sync.WaitGroup
+	for id := uint8(1); id <= 2; id++ {
+		conn := &sharedConnection{physical: phys}
+		conn.Slave(id)
+		client := conn.ModbusClient()
+
+		wg.Add(1)
+		go func(id uint8) {
+			for i := 0; i < 10; i++ {
+				_, _ = client.ReadInputRegisters(uint16(id), 1)
+			}
+			wg.Done()
+		}(id)
+	}
+	wg.Wait()
+
+	if rec.wrong > 0 || rec.ops[1] != 10 || rec.ops[2] != 10 {
+		t.Errorf("unserialized access: %d wrong, ops %v", rec.wrong, rec.ops)
+	}
+}
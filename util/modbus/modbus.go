@@ -26,33 +26,22 @@ type Connection struct {
 	RTU                 *bool // indicates RTU over TCP if true
 }
 
-var connections map[string]meters.Connection
-
-func registeredConnection(key string, newConn meters.Connection) meters.Connection {
-	if connections == nil {
-		connections = make(map[string]meters.Connection)
-	}
-
-	if conn, ok := connections[key]; ok {
-		return conn
-	}
-
-	connections[key] = newConn
-	return newConn
-}
-
-// NewConnection creates physical modbus device from config
+// NewConnection creates physical modbus device from config.
+// Devices using the same uri or device share the physical connection.
 func NewConnection(uri, device, comset string, baudrate int, rtu bool) (conn meters.Connection, err error) {
 	if device != "" {
-		conn = registeredConnection(device, meters.NewRTU(device, baudrate, comset))
+		conn = &sharedConnection{physical: registeredConnection(device, func() meters.Connection {
+			return meters.NewRTU(device, baudrate, comset)
+		})}
 	}
 
 	if uri != "" {
-		if rtu {
-			conn = registeredConnection(uri, meters.NewRTUOverTCP(uri))
-		} else {
-			conn = registeredConnection(uri, meters.NewTCP(uri))
-		}
+		conn = &sharedConnection{physical: registeredConnection(uri, func() meters.Connection {
+			if rtu {
+				return meters.NewRTUOverTCP(uri)
+			}
+			return meters.NewTCP(uri)
+		})}
 	}
 
 	if conn == nil {
@@ -207,3 +196,20 @@ func ParseOperation(dev meters.Device, measurement string, op *Operation) (err e
 
 	return err
 }
+
+// ReadRegisters executes the register read and verifies the response length.
+// The connection is closed in case of modbus error.
+func ReadRegisters(conn meters.Connection, log meters.Logger, read func(address, quantity uint16) ([]byte, error), address, quantity uint16) ([]byte, error) {
+	b, err := read(address, quantity)
+	log.Printf("read (%d): %0 X", address, b)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if len(b) != 2*int(quantity) {
+		return nil, fmt.Errorf("invalid response length: %d", len(b))
+	}
+
+	return b, nil
+}
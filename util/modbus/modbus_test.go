@@ -1,6 +1,13 @@
 package modbus
 
-import "testing"
+import (
+	"errors"
+	"io/ioutil"
+	stdlog "log"
+	"testing"
+
+	"github.com/volkszaehler/mbmd/meters"
+)
 
 func TestParsePoint(t *testing.T) {
 	tc := []struct {
@@ -28,3 +35,36 @@ func TestParsePoint(t *testing.T) {
 		}
 	}
 }
+
+// closeConnection records closing the connection
+type closeConnection struct {
+	meters.Connection
+	closed bool
+}
+
+func (c *closeConnection) Close() {
+	c.closed = true
+}
+
+func TestReadRegisters(t *testing.T) {
+	conn := &closeConnection{}
+	log := stdlog.New(ioutil.Discard, "", 0)
+
+	read := func(b []byte, err error) func(address, quantity uint16) ([]byte, error) {
+		return func(address, quantity uint16) ([]byte, error) {
+			return b, err
+		}
+	}
+
+	if b, err := ReadRegisters(conn, log, read([]byte{0, 1, 0, 2}, nil), 1, 2); err != nil || len(b) != 4 {
+		t.Errorf("unexpected result: % X %v", b, err)
+	}
+
+	if _, err := ReadRegisters(conn, log, read([]byte{0, 1}, nil), 1, 2); err == nil || conn.closed {
+		t.Error("expected invalid length error without closing connection")
+	}
+
+	if _, err := ReadRegisters(conn, log, read(nil, errors.New("timeout")), 1, 2); err == nil || !conn.closed {
+		t.Error("expected error closing connection")
+	}
+}
@@ -0,0 +1,28 @@
+package pipe
+
+import (
+	"github.com/andig/evcc/util"
+)
+
+// UnitConverter converts power and energy values to the configured units
+type UnitConverter struct {
+	units util.Units
+}
+
+// NewUnitConverter creates unit converter
+func NewUnitConverter(units util.Units) Piper {
+	return &UnitConverter{units: units}
+}
+
+func (l *UnitConverter) pipe(in <-chan util.Param, out chan<- util.Param) {
+	for p := range in {
+		out <- l.units.Convert(p)
+	}
+}
+
+// Pipe creates a new converted output channel for given input channel
+func (l *UnitConverter) Pipe(in <-chan util.Param) <-chan util.Param {
+	out := make(chan util.Param)
+	go l.pipe(in, out)
+	return out
+}
@@ -0,0 +1,25 @@
+package pipe
+
+import (
+	"testing"
+
+	"github.com/andig/evcc/util"
+)
+
+func TestUnitConverter(t *testing.T) {
+	in := make(chan util.Param)
+	out := NewUnitConverter(util.Units{Power: "kW", Energy: "kWh"}).Pipe(in)
+
+	lp := 0
+	for _, p := range []util.Param{
+		{Key: "gridPower", Val: 2000.0},
+		{LoadPoint: &lp, Key: "chargedEnergy", Val: 500.0},
+	} {
+		in <- p
+
+		o := <-out
+		if o.Key != p.Key || o.LoadPoint != p.LoadPoint || o.Val != p.Val.(float64)/1e3 {
+			t.Errorf("unexpected param %v", o)
+		}
+	}
+}
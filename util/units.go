@@ -0,0 +1,54 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Units configures the units of published power and energy values.
+// Internally, power is always handled in W and energy in Wh.
+type Units struct {
+	Power  string // W (default) or kW
+	Energy string // Wh (default) or kWh
+}
+
+// Validate validates the unit configuration
+func (u Units) Validate() error {
+	switch strings.ToLower(u.Power) {
+	case "", "w", "kw":
+	default:
+		return fmt.Errorf("invalid power unit: %s", u.Power)
+	}
+
+	switch strings.ToLower(u.Energy) {
+	case "", "wh", "kwh":
+	default:
+		return fmt.Errorf("invalid energy unit: %s", u.Energy)
+	}
+
+	return nil
+}
+
+// scale returns the divisor for values with given key
+func (u Units) scale(key string) float64 {
+	switch {
+	case strings.HasSuffix(key, "Power") && strings.EqualFold(u.Power, "kw"):
+		return 1e3
+	case strings.HasSuffix(key, "Energy") && strings.EqualFold(u.Energy, "kwh"):
+		return 1e3
+	default:
+		return 1
+	}
+}
+
+// Convert converts power (W) and energy (Wh) params to the configured units.
+// Power and energy params are identified by their key's Power or Energy suffix.
+func (u Units) Convert(p Param) Param {
+	if f, ok := p.Val.(float64); ok {
+		if scale := u.scale(p.Key); scale != 1 {
+			p.Val = f / scale
+		}
+	}
+
+	return p
+}
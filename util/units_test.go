@@ -0,0 +1,52 @@
+package util
+
+import (
+	"testing"
+)
+
+func TestUnitsValidate(t *testing.T) {
+	for _, u := range []Units{{}, {Power: "W", Energy: "Wh"}, {Power: "kW", Energy: "kWh"}} {
+		if err := u.Validate(); err != nil {
+			t.Errorf("%v: %v", u, err)
+		}
+	}
+
+	for _, u := range []Units{{Power: "MW"}, {Energy: "kW"}} {
+		if err := u.Validate(); err == nil {
+			t.Errorf("%v: expected error", u)
+		}
+	}
+}
+
+func TestUnitsConvert(t *testing.T) {
+	tc := []struct {
+		units Units
+		key   string
+		in    interface{}
+		out   interface{}
+	}{
+		// default units
+		{Units{}, "gridPower", 1500.0, 1500.0},
+		{Units{}, "chargedEnergy", 2500.0, 2500.0},
+		{Units{Power: "W", Energy: "Wh"}, "chargePower", 1500.0, 1500.0},
+		// kW/kWh
+		{Units{Power: "kW"}, "gridPower", 1500.0, 1.5},
+		{Units{Power: "kW"}, "chargedEnergy", 2500.0, 2500.0},
+		{Units{Energy: "kWh"}, "sessionEnergy", 2500.0, 2.5},
+		{Units{Energy: "kWh"}, "pvPower", 1500.0, 1500.0},
+		{Units{Power: "kw", Energy: "kwh"}, "chargePower", 11000.0, 11.0},
+		// other values are not converted
+		{Units{Power: "kW"}, "chargeCurrent", int64(16), int64(16)},
+		{Units{Power: "kW"}, "socCharge", 80.0, 80.0},
+		{Units{Power: "kW"}, "gridPower", "n/a", "n/a"},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		p := tc.units.Convert(Param{Key: tc.key, Val: tc.in})
+		if p.Key != tc.key || p.Val != tc.out {
+			t.Errorf("expected %v, got %v", tc.out, p.Val)
+		}
+	}
+}
@@ -21,9 +21,10 @@ const (
 )
 
 type audiTokenResponse struct {
-	AccessToken string `json:"access_token"`
-	TokenType   string `json:"token_type"`
-	ExpiresIn   int    `json:"expires_in"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
 }
 
 type audiErrorResponse struct {
@@ -39,6 +40,16 @@ type audiBatteryResponse struct {
 					Content int
 				}
 			}
+			ChargingStatusData struct {
+				ChargingState struct {
+					Content string // off, charging, completed, error
+				}
+			}
+			PlugStatusData struct {
+				PlugState struct {
+					Content string // connected, disconnected
+				}
+			}
 		}
 	}
 }
@@ -47,10 +58,13 @@ type audiBatteryResponse struct {
 type Audi struct {
 	*embed
 	*util.HTTPHelper
+	api                 string
 	user, password, vin string
-	token               string
+	token, refreshToken string
 	tokenValid          time.Time
+	tokens              *tokenStore
 	chargeStateG        func() (float64, error)
+	statusG             func() (string, error)
 }
 
 // NewAudiFromConfig creates a new vehicle
@@ -59,8 +73,10 @@ func NewAudiFromConfig(other map[string]interface{}) (api.Vehicle, error) {
 		Title               string
 		Capacity            int64
 		User, Password, VIN string
-		Cache               time.Duration
-	}{}
+		Cache, Timeout      time.Duration
+	}{
+		Timeout: requestTimeout,
+	}
 	if err := util.DecodeOther(other, &cc); err != nil {
 		return nil, err
 	}
@@ -68,18 +84,32 @@ func NewAudiFromConfig(other map[string]interface{}) (api.Vehicle, error) {
 	v := &Audi{
 		embed:      &embed{cc.Title, cc.Capacity},
 		HTTPHelper: util.NewHTTPHelper(util.NewLogger("audi")),
+		api:        audiURL,
 		user:       cc.User,
 		password:   cc.Password,
 		vin:        cc.VIN,
 	}
 
+	v.HTTPHelper.Client.Timeout = cc.Timeout
+
+	var err error
+	if v.tokens, err = sharedTokenStore(); err != nil {
+		return nil, err
+	}
+
+	// resume with persisted token, expired tokens are refreshed
+	if t, ok := v.tokens.LoadAny("audi:" + v.user); ok {
+		v.token, v.refreshToken, v.tokenValid = t.AccessToken, t.RefreshToken, t.Expiry
+	}
+
 	v.chargeStateG = provider.NewCached(v.chargeState, cc.Cache).FloatGetter()
+	v.statusG = provider.NewCached(v.status, cc.Cache).StringGetter()
 
 	return v, nil
 }
 
 func (v *Audi) apiURL(service, part string) string {
-	return fmt.Sprintf("%s/%s/%s/%s/%s", audiURL, service, "v1", audiDE, part)
+	return fmt.Sprintf("%s/%s/%s/%s/%s", v.api, service, "v1", audiDE, part)
 }
 
 func (v *Audi) headers(header *http.Header) {
@@ -101,14 +131,34 @@ func (v *Audi) headers(header *http.Header) {
 }
 
 func (v *Audi) login(user, password string) error {
-	uri := v.apiURL("core/auth", "token")
-
-	data := url.Values{
+	return v.authorize(url.Values{
 		"grant_type": []string{"password"},
 		"username":   []string{user},
 		"password":   []string{password},
+	})
+}
+
+// refresh renews the token using the refresh token, falling back to login
+func (v *Audi) refresh() error {
+	if v.refreshToken != "" {
+		err := v.authorize(url.Values{
+			"grant_type":    []string{"refresh_token"},
+			"refresh_token": []string{v.refreshToken},
+		})
+		if err == nil {
+			return nil
+		}
+
+		v.Log.WARN.Printf("token refresh failed: %v", err)
 	}
 
+	return v.login(v.user, v.password)
+}
+
+// authorize obtains and persists a token
+func (v *Audi) authorize(data url.Values) error {
+	uri := v.apiURL("core/auth", "token")
+
 	req, err := http.NewRequest(http.MethodPost, uri, strings.NewReader(data.Encode()))
 	if err != nil {
 		return err
@@ -132,12 +182,21 @@ func (v *Audi) login(user, password string) error {
 	v.token = tr.AccessToken
 	v.tokenValid = time.Now().Add(time.Duration(tr.ExpiresIn) * time.Second)
 
+	// keep refresh token if not rotated
+	if tr.RefreshToken != "" {
+		v.refreshToken = tr.RefreshToken
+	}
+
+	if err := v.tokens.Save("audi:"+v.user, token{AccessToken: v.token, RefreshToken: v.refreshToken, Expiry: v.tokenValid}); err != nil {
+		v.Log.WARN.Printf("cannot persist token: %v", err)
+	}
+
 	return nil
 }
 
 func (v *Audi) request(uri string) (*http.Request, error) {
 	if v.token == "" || time.Since(v.tokenValid) > 0 {
-		if err := v.login(v.user, v.password); err != nil {
+		if err := v.refresh(); err != nil {
 			return nil, err
 		}
 	}
@@ -153,21 +212,65 @@ func (v *Audi) request(uri string) (*http.Request, error) {
 	return req, nil
 }
 
-// chargeState implements the Vehicle.ChargeState interface
-func (v *Audi) chargeState() (float64, error) {
+// charger reads the charger status containing battery, charging and plug status
+func (v *Audi) charger() (audiBatteryResponse, error) {
+	var br audiBatteryResponse
+
 	uri := v.apiURL("bs/batterycharge", fmt.Sprintf("vehicles/%s/charger", v.vin))
 	req, err := v.request(uri)
-	if err != nil {
-		return 0, err
+	if err == nil {
+		_, err = v.RequestJSON(req, &br)
 	}
 
-	var br audiBatteryResponse
-	_, err = v.RequestJSON(req, &br)
+	return br, err
+}
 
+// chargeState implements the Vehicle.ChargeState interface
+func (v *Audi) chargeState() (float64, error) {
+	br, err := v.charger()
 	return float64(br.Charger.Status.BatteryStatusData.StateOfCharge.Content), err
 }
 
+// audiStatus maps the Audi charging and plug states to the charge status
+func audiStatus(chargingState, plugState string) (api.ChargeStatus, error) {
+	switch plugState {
+	case "disconnected":
+		return api.StatusA, nil
+	case "connected":
+	default:
+		return api.StatusNone, fmt.Errorf("invalid plug state: %s", plugState)
+	}
+
+	switch chargingState {
+	case "charging":
+		return api.StatusC, nil
+	case "error":
+		return api.StatusF, nil
+	default:
+		return api.StatusB, nil
+	}
+}
+
+// status implements the VehicleStatus.Status interface
+func (v *Audi) status() (string, error) {
+	br, err := v.charger()
+	if err != nil {
+		return string(api.StatusNone), err
+	}
+
+	status := br.Charger.Status
+	res, err := audiStatus(status.ChargingStatusData.ChargingState.Content, status.PlugStatusData.PlugState.Content)
+
+	return string(res), err
+}
+
 // ChargeState implements the Vehicle.ChargeState interface
 func (v *Audi) ChargeState() (float64, error) {
 	return v.chargeStateG()
 }
+
+// Status implements the VehicleStatus.Status interface
+func (v *Audi) Status() (api.ChargeStatus, error) {
+	status, err := v.statusG()
+	return api.ChargeStatus(status), err
+}
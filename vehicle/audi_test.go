@@ -0,0 +1,126 @@
+package vehicle
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/andig/evcc/api"
+	"github.com/andig/evcc/util"
+)
+
+func TestAudiStatus(t *testing.T) {
+	tc := []struct {
+		charging, plug string
+		status         api.ChargeStatus
+		err            bool
+	}{
+		{"off", "disconnected", api.StatusA, false},
+		{"off", "connected", api.StatusB, false},
+		{"completed", "connected", api.StatusB, false},
+		{"charging", "connected", api.StatusC, false},
+		{"error", "connected", api.StatusF, false},
+		{"off", "", api.StatusNone, true},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		status, err := audiStatus(tc.charging, tc.plug)
+		if (err != nil) != tc.err || status != tc.status {
+			t.Errorf("expected %s, got %s %v", tc.status, status, err)
+		}
+	}
+}
+
+func TestAudiCharger(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bs/batterycharge/v1/Audi/DE/vehicles/vin/charger" {
+			t.Errorf("unexpected request: %s", r.URL.Path)
+		}
+
+		if h := r.Header.Get("Authorization"); h != "AudiAuth 1 token" {
+			t.Errorf("invalid auth header: %s", h)
+		}
+
+		_, _ = w.Write([]byte(`{"charger":{"status":{
+			"chargingStatusData":{"chargingState":{"content":"charging"}},
+			"plugStatusData":{"plugState":{"content":"connected"}},
+			"batteryStatusData":{"stateOfCharge":{"content":63}}
+		}}}`))
+	}))
+	defer srv.Close()
+
+	v := &Audi{
+		HTTPHelper: util.NewHTTPHelper(util.NewLogger("foo")),
+		api:        srv.URL,
+		vin:        "vin",
+		token:      "token",
+		tokenValid: time.Now().Add(time.Hour),
+	}
+
+	if soc, err := v.chargeState(); err != nil || soc != 63 {
+		t.Errorf("expected 63%%, got %v %v", soc, err)
+	}
+
+	if status, err := v.status(); err != nil || status != string(api.StatusC) {
+		t.Errorf("expected %s, got %s %v", api.StatusC, status, err)
+	}
+}
+
+func TestAudiTokenRefresh(t *testing.T) {
+	var grants []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/core/auth/v1/Audi/DE/token" {
+			t.Errorf("unexpected request: %s", r.URL.Path)
+		}
+
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+
+		grant := r.Form.Get("grant_type")
+		grants = append(grants, grant)
+
+		switch {
+		case grant == "refresh_token" && r.Form.Get("refresh_token") == "refresh":
+			_, _ = w.Write([]byte(`{"access_token":"refreshed","refresh_token":"rotated","expires_in":3600}`))
+		case grant == "password":
+			_, _ = w.Write([]byte(`{"access_token":"fresh","refresh_token":"refresh","expires_in":3600}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant","error_description":"invalid refresh token"}`))
+		}
+	}))
+	defer srv.Close()
+
+	v := &Audi{
+		HTTPHelper:   util.NewHTTPHelper(util.NewLogger("foo")),
+		api:          srv.URL,
+		token:        "expired",
+		refreshToken: "refresh",
+		tokenValid:   time.Now().Add(-time.Hour),
+	}
+
+	// expired token is refreshed without login
+	if _, err := v.request(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	if v.token != "refreshed" || v.refreshToken != "rotated" || len(grants) != 1 {
+		t.Errorf("expected refresh, got %s %s %v", v.token, v.refreshToken, grants)
+	}
+
+	// invalid refresh token falls back to login
+	v.tokenValid = time.Now().Add(-time.Hour)
+
+	if _, err := v.request(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	if v.token != "fresh" || len(grants) != 3 || grants[2] != "password" {
+		t.Errorf("expected login, got %s %v", v.token, grants)
+	}
+}
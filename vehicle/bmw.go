@@ -17,11 +17,26 @@ import (
 const (
 	bmwAuth = "https://customer.bmwgroup.com/gcdm/oauth/authenticate"
 	bmwAPI  = "https://www.bmw-connecteddrive.com/api"
+
+	bmwCommandTimeout  = 2 * time.Minute
+	bmwCommandInterval = 5 * time.Second
+)
+
+// remote service execution states
+const (
+	bmwExecuted = "EXECUTED"
+	bmwError    = "CANCELLED_WITH_ERROR"
 )
 
+type bmwExecutionResponse struct {
+	RemoteServiceType   string `json:"remoteServiceType"`
+	RemoteServiceStatus string `json:"remoteServiceStatus"`
+}
+
 type bmwDynamicResponse struct {
 	AttributesMap struct {
-		ChargingLevelHv float64 `json:"chargingLevelHv,string"`
+		ChargingLevelHv            float64 `json:"chargingLevelHv,string"`
+		BeRemainingRangeElectricKm float64 `json:"beRemainingRangeElectricKm,string"`
 	}
 }
 
@@ -29,14 +44,20 @@ type bmwVehiclesResponse []struct {
 	Vin string `json:"vin"`
 }
 
-// BMW is an api.Vehicle implementation for BMW cars
+// BMW is an api.Vehicle implementation for BMW cars.
+// It does not implement api.VehicleChargeLimit as the dynamic attributes don't contain a charge limit.
 type BMW struct {
 	*embed
 	*util.HTTPHelper
+	api                 string
 	user, password, vin string
 	token               string
 	tokenValid          time.Time
+	tokens              *tokenStore
 	chargeStateG        func() (float64, error)
+	rangeG              func() (int64, error)
+	commandTimeout      time.Duration
+	commandInterval     time.Duration
 }
 
 // NewBMWFromConfig creates a new vehicle
@@ -45,8 +66,10 @@ func NewBMWFromConfig(other map[string]interface{}) (api.Vehicle, error) {
 		Title               string
 		Capacity            int64
 		User, Password, VIN string
-		Cache               time.Duration
-	}{}
+		Cache, Timeout      time.Duration
+	}{
+		Timeout: requestTimeout,
+	}
 
 	if err := util.DecodeOther(other, &cc); err != nil {
 		return nil, err
@@ -55,11 +78,26 @@ func NewBMWFromConfig(other map[string]interface{}) (api.Vehicle, error) {
 	log := util.NewLogger("bmw")
 
 	v := &BMW{
-		embed:      &embed{cc.Title, cc.Capacity},
-		HTTPHelper: util.NewHTTPHelper(log),
-		user:       cc.User,
-		password:   cc.Password,
-		vin:        cc.VIN,
+		embed:           &embed{cc.Title, cc.Capacity},
+		HTTPHelper:      util.NewHTTPHelper(log),
+		api:             bmwAPI,
+		user:            cc.User,
+		password:        cc.Password,
+		vin:             cc.VIN,
+		commandTimeout:  bmwCommandTimeout,
+		commandInterval: bmwCommandInterval,
+	}
+
+	v.HTTPHelper.Client.Timeout = cc.Timeout
+
+	var err error
+	if v.tokens, err = sharedTokenStore(); err != nil {
+		return nil, err
+	}
+
+	// resume with persisted token. The implicit login flow does not issue refresh tokens.
+	if t, ok := v.tokens.Load("bmw:" + v.user); ok {
+		v.token, v.tokenValid = t.AccessToken, t.Expiry
 	}
 
 	if cc.VIN == "" {
@@ -77,6 +115,7 @@ func NewBMWFromConfig(other map[string]interface{}) (api.Vehicle, error) {
 	}
 
 	v.chargeStateG = provider.NewCached(v.chargeState, cc.Cache).FloatGetter()
+	v.rangeG = provider.NewCached(v.rangeKm, cc.Cache).IntGetter()
 
 	return v, nil
 }
@@ -114,37 +153,45 @@ func (v *BMW) login(user, password string) error {
 		return err
 	}
 
-	token := query.Get("access_token")
+	accessToken := query.Get("access_token")
 	expires, err := strconv.Atoi(query.Get("expires_in"))
-	if err != nil || token == "" || expires == 0 {
+	if err != nil || accessToken == "" || expires == 0 {
 		return errors.New("could not obtain token")
 	}
 
-	v.token = token
+	v.token = accessToken
 	v.tokenValid = time.Now().Add(time.Duration(expires) * time.Second)
 
+	if err := v.tokens.Save("bmw:"+v.user, token{AccessToken: v.token, Expiry: v.tokenValid}); err != nil {
+		v.Log.WARN.Printf("cannot persist token: %v", err)
+	}
+
 	return nil
 }
 
 func (v *BMW) request(uri string) (*http.Request, error) {
+	return v.requestMethod(http.MethodGet, uri)
+}
+
+func (v *BMW) requestMethod(method, uri string) (*http.Request, error) {
 	if v.token == "" || time.Since(v.tokenValid) > 0 {
 		if err := v.login(v.user, v.password); err != nil {
 			return nil, err
 		}
 	}
 
-	req, err := http.NewRequest(http.MethodGet, uri, nil)
+	req, err := http.NewRequest(method, uri, nil)
 	if err == nil {
 		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", v.token))
 	}
 
-	return req, nil
+	return req, err
 }
 
 // vehicles implements returns the list of user vehicles
 func (v *BMW) vehicles() (bmwVehiclesResponse, error) {
 	var br bmwVehiclesResponse
-	uri := fmt.Sprintf("%s/me/vehicles/v2/", bmwAPI)
+	uri := fmt.Sprintf("%s/me/vehicles/v2/", v.api)
 
 	req, err := v.request(uri)
 	if err != nil {
@@ -155,21 +202,89 @@ func (v *BMW) vehicles() (bmwVehiclesResponse, error) {
 	return br, err
 }
 
-// chargeState implements the Vehicle.ChargeState interface
-func (v *BMW) chargeState() (float64, error) {
+// dynamic reads the vehicle's dynamic attributes
+func (v *BMW) dynamic() (bmwDynamicResponse, error) {
 	var br bmwDynamicResponse
-	uri := fmt.Sprintf("%s/vehicle/dynamic/v1/%s", bmwAPI, v.vin)
+	uri := fmt.Sprintf("%s/vehicle/dynamic/v1/%s", v.api, v.vin)
 
 	req, err := v.request(uri)
 	if err != nil {
-		return 0, err
+		return br, err
 	}
 
 	_, err = v.RequestJSON(req, &br)
+	return br, err
+}
+
+// chargeState implements the Vehicle.ChargeState interface
+func (v *BMW) chargeState() (float64, error) {
+	br, err := v.dynamic()
 	return br.AttributesMap.ChargingLevelHv, err
 }
 
+// rangeKm implements the VehicleRange.Range interface
+func (v *BMW) rangeKm() (int64, error) {
+	br, err := v.dynamic()
+	if err != nil {
+		return 0, err
+	}
+	return kilometers(br.AttributesMap.BeRemainingRangeElectricKm, unitKm)
+}
+
+// Range implements the VehicleRange.Range interface
+func (v *BMW) Range() (int64, error) {
+	return v.rangeG()
+}
+
 // ChargeState implements the Vehicle.ChargeState interface
 func (v *BMW) ChargeState() (float64, error) {
 	return v.chargeStateG()
 }
+
+// remoteService executes the remote service and waits for the asynchronous execution to complete
+func (v *BMW) remoteService(service string) error {
+	uri := fmt.Sprintf("%s/vehicle/remoteservices/v1/%s/%s", v.api, v.vin, service)
+
+	req, err := v.requestMethod(http.MethodPost, uri)
+	if err != nil {
+		return err
+	}
+
+	if _, err := v.Request(req); err != nil {
+		return err
+	}
+
+	uri = fmt.Sprintf("%s/vehicle/remoteservices/v1/%s/state/execution", v.api, v.vin)
+
+	for start := time.Now(); time.Since(start) < v.commandTimeout; time.Sleep(v.commandInterval) {
+		req, err := v.request(uri)
+		if err != nil {
+			return err
+		}
+
+		var res bmwExecutionResponse
+		if _, err := v.RequestJSON(req, &res); err != nil {
+			return err
+		}
+
+		switch res.RemoteServiceStatus {
+		case bmwExecuted:
+			return nil
+		case bmwError:
+			return fmt.Errorf("%s failed", service)
+		}
+	}
+
+	return fmt.Errorf("%s timeout", service)
+}
+
+// StartCharge implements the VehicleChargeController.StartCharge interface
+func (v *BMW) StartCharge() error {
+	return v.remoteService("CHARGE_NOW")
+}
+
+// StopCharge implements the VehicleChargeController.StopCharge interface.
+// BMW does not provide a remote service to stop charging, charging is stopped by the charger.
+func (v *BMW) StopCharge() error {
+	return nil
+}
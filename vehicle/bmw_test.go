@@ -0,0 +1,135 @@
+package vehicle
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/andig/evcc/util"
+)
+
+func TestBMWRange(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/vehicle/dynamic/v1/vin" {
+			t.Errorf("unexpected request: %s", r.URL.Path)
+		}
+
+		if h := r.Header.Get("Authorization"); h != "Bearer token" {
+			t.Errorf("invalid auth header: %s", h)
+		}
+
+		_, _ = w.Write([]byte(`{"attributesMap":{"chargingLevelHv":"75.0","beRemainingRangeElectricKm":"142.5","beRemainingRangeElectricMile":"88.5"}}`))
+	}))
+	defer srv.Close()
+
+	v := &BMW{
+		HTTPHelper: util.NewHTTPHelper(util.NewLogger("foo")),
+		api:        srv.URL,
+		vin:        "vin",
+		token:      "token",
+		tokenValid: time.Now().Add(time.Hour),
+	}
+
+	if soc, err := v.chargeState(); err != nil || soc != 75 {
+		t.Errorf("expected 75%%, got %v %v", soc, err)
+	}
+
+	if rng, err := v.rangeKm(); err != nil || rng != 143 {
+		t.Errorf("expected 143km, got %v %v", rng, err)
+	}
+}
+
+func TestBMWStartCharge(t *testing.T) {
+	tc := []struct {
+		states []string
+		err    bool
+	}{
+		{[]string{"PENDING", "DELIVERED_TO_VEHICLE", "EXECUTED"}, false},
+		{[]string{"PENDING", "CANCELLED_WITH_ERROR"}, true},
+		{[]string{"PENDING"}, true},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		var started bool
+		var polls int
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/vehicle/remoteservices/v1/vin/CHARGE_NOW":
+				if r.Method != http.MethodPost {
+					t.Errorf("unexpected method: %s", r.Method)
+				}
+				started = true
+				_, _ = w.Write([]byte(`{"remoteServiceType":"CHARGE_NOW","remoteServiceStatus":"PENDING"}`))
+
+			case "/vehicle/remoteservices/v1/vin/state/execution":
+				if !started {
+					t.Error("status polled before service execution")
+				}
+
+				state := tc.states[len(tc.states)-1]
+				if polls < len(tc.states) {
+					state = tc.states[polls]
+				}
+				polls++
+
+				_, _ = w.Write([]byte(`{"remoteServiceType":"CHARGE_NOW","remoteServiceStatus":"` + state + `"}`))
+
+			default:
+				t.Errorf("unexpected request: %s", r.URL.Path)
+			}
+		}))
+
+		v := &BMW{
+			HTTPHelper:      util.NewHTTPHelper(util.NewLogger("foo")),
+			api:             srv.URL,
+			vin:             "vin",
+			token:           "token",
+			tokenValid:      time.Now().Add(time.Hour),
+			commandTimeout:  50 * time.Millisecond,
+			commandInterval: time.Millisecond,
+		}
+
+		err := v.StartCharge()
+		if tc.err != (err != nil) {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if !started {
+			t.Error("charge not started")
+		}
+
+		srv.Close()
+	}
+}
+
+func TestBMWTimeout(t *testing.T) {
+	release := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	v := &BMW{
+		HTTPHelper: util.NewHTTPHelper(util.NewLogger("foo")),
+		api:        srv.URL,
+		vin:        "vin",
+		token:      "token",
+		tokenValid: time.Now().Add(time.Hour),
+	}
+	v.HTTPHelper.Client.Timeout = 50 * time.Millisecond
+
+	start := time.Now()
+	if _, err := v.chargeState(); err == nil {
+		t.Error("expected timeout error")
+	}
+
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("timeout not honored: %v", d)
+	}
+}
@@ -23,8 +23,18 @@ func NewFromConfig(typ string, other map[string]interface{}) (v api.Vehicle, err
 		v, err = NewNissanFromConfig(other)
 	case "renault", "zoe":
 		v, err = NewRenaultFromConfig(other)
+	case "dacia", "spring":
+		v, err = NewDaciaFromConfig(other)
 	case "porsche", "taycan":
 		v, err = NewPorscheFromConfig(other)
+	case "script", "exec":
+		v, err = NewScriptFromConfig(other)
+	case "teslamate":
+		v, err = NewTeslamateFromConfig(other)
+	case "http":
+		v, err = NewHTTPFromConfig(other)
+	case "modbus":
+		v, err = NewModbusFromConfig(other)
 	default:
 		err = fmt.Errorf("invalid vehicle type: %s", typ)
 	}
@@ -0,0 +1,130 @@
+package vehicle
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andig/evcc/api"
+	"github.com/andig/evcc/provider"
+	"github.com/andig/evcc/util"
+	"github.com/andig/evcc/util/jq"
+	"github.com/itchyny/gojq"
+)
+
+// HTTP is an api.Vehicle implementation reading the soc from a json api, e.g. of a third party bridge
+type HTTP struct {
+	*embed
+	scale float64
+	soc   *gojq.Query
+	bodyG func() (string, error)
+}
+
+// HTTPRange is a HTTP vehicle providing range
+type HTTPRange struct {
+	*HTTP
+	rng *gojq.Query
+}
+
+// NewHTTPFromConfig creates a new HTTP vehicle
+func NewHTTPFromConfig(other map[string]interface{}) (api.Vehicle, error) {
+	cc := struct {
+		Title      string
+		Capacity   int64
+		URI        string
+		SoC, Range string // jq queries
+		Scale      float64
+		Cache      time.Duration
+		Timeout    time.Duration
+		Other      map[string]interface{} `mapstructure:",remain"` // http request
+	}{
+		Timeout: requestTimeout,
+	}
+
+	if err := util.DecodeOther(other, &cc); err != nil {
+		return nil, err
+	}
+
+	for k, v := range map[string]string{"uri": cc.URI, "soc": cc.SoC} {
+		if v == "" {
+			return nil, fmt.Errorf("http vehicle config: %s required", k)
+		}
+	}
+
+	if cc.Scale == 0 {
+		cc.Scale = 1
+	}
+
+	soc, err := gojq.Parse(cc.SoC)
+	if err != nil {
+		return nil, fmt.Errorf("invalid soc query: %w", err)
+	}
+
+	if cc.Other == nil {
+		cc.Other = make(map[string]interface{})
+	}
+	cc.Other["uri"] = cc.URI
+
+	p, err := provider.NewHTTPProviderFromConfig(cc.Other)
+	if err != nil {
+		return nil, err
+	}
+
+	p.HTTPHelper.Client.Timeout = cc.Timeout
+
+	v := &HTTP{
+		embed: &embed{cc.Title, cc.Capacity},
+		scale: cc.Scale,
+		soc:   soc,
+		// soc and range are read from the same response
+		bodyG: provider.NewCached(p.StringGetter, cc.Cache).StringGetter(),
+	}
+
+	if cc.Range == "" {
+		return v, nil
+	}
+
+	rng, err := gojq.Parse(cc.Range)
+	if err != nil {
+		return nil, fmt.Errorf("invalid range query: %w", err)
+	}
+
+	return &HTTPRange{HTTP: v, rng: rng}, nil
+}
+
+// query applies the jq query to the api response. String results are parsed as numbers.
+func (v *HTTP) query(query *gojq.Query) (float64, error) {
+	b, err := v.bodyG()
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := jq.Query(query, []byte(b))
+	if err != nil {
+		return 0, err
+	}
+
+	switch res := res.(type) {
+	case nil:
+		return 0, errors.New("jq: null result")
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(res), 64)
+	default:
+		return jq.Float64(res)
+	}
+}
+
+// ChargeState implements the Vehicle.ChargeState interface
+func (v *HTTP) ChargeState() (float64, error) {
+	f, err := v.query(v.soc)
+	return v.scale * f, err
+}
+
+// Range implements the VehicleRange.Range interface
+func (v *HTTPRange) Range() (int64, error) {
+	f, err := v.query(v.rng)
+	return int64(math.Round(f)), err
+}
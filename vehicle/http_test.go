@@ -0,0 +1,79 @@
+package vehicle
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andig/evcc/api"
+)
+
+func TestHTTP(t *testing.T) {
+	var requests int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h := r.Header.Get("Authorization"); h != "Bearer secret" {
+			t.Errorf("invalid auth header: %s", h)
+		}
+
+		requests++
+		_, _ = w.Write([]byte(`{"entity_id":"sensor.soc","state":"715","attributes":{"range_km":312.4}}`))
+	}))
+	defer srv.Close()
+
+	v, err := NewHTTPFromConfig(map[string]interface{}{
+		"uri":     srv.URL,
+		"headers": map[string]string{"Authorization": "Bearer secret"},
+		"soc":     ".state",
+		"scale":   0.1,
+		"range":   ".attributes.range_km",
+		"cache":   "1m",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if soc, err := v.ChargeState(); err != nil || soc != 71.5 {
+		t.Errorf("expected soc 71.5, got %.1f %v", soc, err)
+	}
+
+	vr, ok := v.(api.VehicleRange)
+	if !ok {
+		t.Fatal("missing range")
+	}
+
+	if rng, err := vr.Range(); err != nil || rng != 312 {
+		t.Errorf("expected range 312, got %d %v", rng, err)
+	}
+
+	if requests != 1 {
+		t.Errorf("expected single request, got %d", requests)
+	}
+}
+
+func TestHTTPInvalid(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"battery_level":null}`))
+	}))
+	defer srv.Close()
+
+	v, err := NewHTTPFromConfig(map[string]interface{}{
+		"uri": srv.URL,
+		"soc": ".battery_level",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := v.(api.VehicleRange); ok {
+		t.Error("unexpected range")
+	}
+
+	if soc, err := v.ChargeState(); err == nil {
+		t.Errorf("expected error, got %.1f", soc)
+	}
+
+	if _, err := NewHTTPFromConfig(map[string]interface{}{"uri": srv.URL}); err == nil {
+		t.Error("expected missing soc error")
+	}
+}
@@ -0,0 +1,107 @@
+package vehicle
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/andig/evcc/api"
+	"github.com/andig/evcc/provider"
+	"github.com/andig/evcc/util"
+	"github.com/andig/evcc/util/modbus"
+)
+
+// modbusRegisterConfig is a register definition including scale
+type modbusRegisterConfig struct {
+	modbus.Register `mapstructure:",squash"`
+	Scale           float64
+}
+
+// Modbus is an api.Vehicle implementation reading soc from modbus registers, e.g. of a battery management system
+type Modbus struct {
+	*embed
+	chargeG func() (float64, error)
+}
+
+// ModbusRange is a Modbus vehicle providing range
+type ModbusRange struct {
+	*Modbus
+	rangeG func() (int64, error)
+}
+
+// NewModbusFromConfig creates a new Modbus vehicle
+func NewModbusFromConfig(other map[string]interface{}) (api.Vehicle, error) {
+	cc := struct {
+		Title    string
+		Capacity int64
+		SoC      modbusRegisterConfig
+		Range    *modbusRegisterConfig
+		Cache    time.Duration
+		Other    map[string]interface{} `mapstructure:",remain"` // modbus connection
+	}{}
+
+	if err := util.DecodeOther(other, &cc); err != nil {
+		return nil, err
+	}
+
+	if cc.SoC.Decode == "" {
+		return nil, errors.New("modbus vehicle config: soc required")
+	}
+
+	soc, err := modbusGetter(cc.Other, cc.SoC)
+	if err != nil {
+		return nil, fmt.Errorf("soc: %w", err)
+	}
+
+	v := &Modbus{
+		embed:   &embed{cc.Title, cc.Capacity},
+		chargeG: provider.NewCached(soc, cc.Cache).FloatGetter(),
+	}
+
+	if cc.Range == nil {
+		return v, nil
+	}
+
+	rng, err := modbusGetter(cc.Other, *cc.Range)
+	if err != nil {
+		return nil, fmt.Errorf("range: %w", err)
+	}
+
+	rangeKm := func() (int64, error) {
+		f, err := rng()
+		return int64(math.Round(f)), err
+	}
+
+	return &ModbusRange{
+		Modbus: v,
+		rangeG: provider.NewCached(rangeKm, cc.Cache).IntGetter(),
+	}, nil
+}
+
+// modbusGetter creates a modbus plugin reading the register from the vehicle's connection
+func modbusGetter(conn map[string]interface{}, reg modbusRegisterConfig) (func() (float64, error), error) {
+	other := map[string]interface{}{
+		"register": reg.Register,
+	}
+
+	for k, v := range conn {
+		other[k] = v
+	}
+
+	return provider.NewFloatGetterFromConfig(provider.Config{
+		Type:  "modbus",
+		Scale: reg.Scale,
+		Other: other,
+	})
+}
+
+// ChargeState implements the Vehicle.ChargeState interface
+func (v *Modbus) ChargeState() (float64, error) {
+	return v.chargeG()
+}
+
+// Range implements the VehicleRange.Range interface
+func (v *ModbusRange) Range() (int64, error) {
+	return v.rangeG()
+}
@@ -0,0 +1,50 @@
+package vehicle
+
+import (
+	"testing"
+
+	"github.com/andig/evcc/api"
+)
+
+func TestModbusConfig(t *testing.T) {
+	soc := map[string]interface{}{"address": 1, "type": "holding", "decode": "uint16", "scale": 0.1}
+
+	v, err := NewModbusFromConfig(map[string]interface{}{
+		"uri": "127.0.0.1:502",
+		"id":  1,
+		"soc": soc,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := v.(api.VehicleRange); ok {
+		t.Error("unexpected range")
+	}
+
+	v, err = NewModbusFromConfig(map[string]interface{}{
+		"uri":   "127.0.0.1:502",
+		"soc":   soc,
+		"range": map[string]interface{}{"address": 2, "type": "input", "decode": "float32"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := v.(api.VehicleRange); !ok {
+		t.Error("missing range")
+	}
+}
+
+func TestModbusConfigInvalid(t *testing.T) {
+	if _, err := NewModbusFromConfig(map[string]interface{}{"uri": "127.0.0.1:502"}); err == nil {
+		t.Error("expected missing soc error")
+	}
+
+	if _, err := NewModbusFromConfig(map[string]interface{}{
+		"uri": "127.0.0.1:502",
+		"soc": map[string]interface{}{"decode": "uint16", "foo": "bar"},
+	}); err == nil {
+		t.Error("expected invalid register error")
+	}
+}
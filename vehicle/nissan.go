@@ -14,6 +14,7 @@ import (
 type Nissan struct {
 	*embed
 	session      *carwings.Session
+	timeout      time.Duration
 	chargeStateG func() (float64, error)
 }
 
@@ -23,9 +24,10 @@ func NewNissanFromConfig(other map[string]interface{}) (api.Vehicle, error) {
 		Title                  string
 		Capacity               int64
 		User, Password, Region string
-		Cache                  time.Duration
+		Cache, Timeout         time.Duration
 	}{
-		Region: carwings.RegionEurope,
+		Region:  carwings.RegionEurope,
+		Timeout: requestTimeout,
 	}
 
 	if err := util.DecodeOther(other, &cc); err != nil {
@@ -36,13 +38,17 @@ func NewNissanFromConfig(other map[string]interface{}) (api.Vehicle, error) {
 		Region: cc.Region,
 	}
 
-	if err := session.Connect(cc.User, cc.Password); err != nil {
+	// carwings uses the default http client which can't be configured per vehicle
+	if err := withTimeout(cc.Timeout, func() error {
+		return session.Connect(cc.User, cc.Password)
+	}); err != nil {
 		return nil, err
 	}
 
 	v := &Nissan{
 		embed:   &embed{cc.Title, cc.Capacity},
 		session: session,
+		timeout: cc.Timeout,
 	}
 
 	v.chargeStateG = provider.NewCached(v.chargeState, cc.Cache).FloatGetter()
@@ -52,8 +58,18 @@ func NewNissanFromConfig(other map[string]interface{}) (api.Vehicle, error) {
 
 // chargeState implements the Vehicle.ChargeState interface
 func (v *Nissan) chargeState() (float64, error) {
-	bs, err := v.session.BatteryStatus()
-	return float64(bs.StateOfCharge), err
+	var soc float64
+	err := withTimeout(v.timeout, func() error {
+		bs, err := v.session.BatteryStatus()
+		soc = float64(bs.StateOfCharge)
+		return err
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return soc, nil
 }
 
 // ChargeState implements the Vehicle.ChargeState interface
@@ -29,10 +29,11 @@ const (
 )
 
 type porscheTokenResponse struct {
-	AccessToken string `json:"access_token"`
-	IDToken     string `json:"id_token"`
-	TokenType   string `json:"token_type"`
-	ExpiresIn   int    `json:"expires_in"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	IDToken      string `json:"id_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
 }
 
 type porscheVehicleResponse struct {
@@ -53,8 +54,9 @@ type Porsche struct {
 	*embed
 	*util.HTTPHelper
 	user, password, vin string
-	token               string
+	token, refreshToken string
 	tokenValid          time.Time
+	tokens              *tokenStore
 	chargeStateG        func() (float64, error)
 }
 
@@ -64,8 +66,10 @@ func NewPorscheFromConfig(other map[string]interface{}) (api.Vehicle, error) {
 		Title               string
 		Capacity            int64
 		User, Password, VIN string
-		Cache               time.Duration
-	}{}
+		Cache, Timeout      time.Duration
+	}{
+		Timeout: requestTimeout,
+	}
 	if err := util.DecodeOther(other, &cc); err != nil {
 		return nil, err
 	}
@@ -78,6 +82,18 @@ func NewPorscheFromConfig(other map[string]interface{}) (api.Vehicle, error) {
 		vin:        cc.VIN,
 	}
 
+	v.HTTPHelper.Client.Timeout = cc.Timeout
+
+	var err error
+	if v.tokens, err = sharedTokenStore(); err != nil {
+		return nil, err
+	}
+
+	// resume with persisted token, expired tokens are refreshed
+	if t, ok := v.tokens.LoadAny("porsche:" + v.user); ok {
+		v.token, v.refreshToken, v.tokenValid = t.AccessToken, t.RefreshToken, t.Expiry
+	}
+
 	v.chargeStateG = provider.NewCached(v.chargeState, cc.Cache).FloatGetter()
 
 	return v, nil
@@ -205,6 +221,41 @@ func (v *Porsche) login(user, password string) error {
 		return err
 	}
 
+	return v.updateToken(pr)
+}
+
+// refresh renews the token using the refresh token, falling back to login
+func (v *Porsche) refresh() error {
+	if v.refreshToken != "" {
+		data := url.Values{
+			"grant_type":    []string{"refresh_token"},
+			"client_id":     []string{porscheAPIClientID},
+			"refresh_token": []string{v.refreshToken},
+		}
+
+		req, err := http.NewRequest(http.MethodPost, porscheAPIToken, strings.NewReader(data.Encode()))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		var pr porscheTokenResponse
+		if _, err = v.RequestJSON(req, &pr); err == nil {
+			err = v.updateToken(pr)
+		}
+
+		if err == nil {
+			return nil
+		}
+
+		v.Log.WARN.Printf("token refresh failed: %v", err)
+	}
+
+	return v.login(v.user, v.password)
+}
+
+// updateToken applies and persists the token response
+func (v *Porsche) updateToken(pr porscheTokenResponse) error {
 	if pr.AccessToken == "" || pr.ExpiresIn == 0 {
 		return errors.New("could not obtain token")
 	}
@@ -212,12 +263,21 @@ func (v *Porsche) login(user, password string) error {
 	v.token = pr.AccessToken
 	v.tokenValid = time.Now().Add(time.Duration(pr.ExpiresIn) * time.Second)
 
+	// keep refresh token if not rotated
+	if pr.RefreshToken != "" {
+		v.refreshToken = pr.RefreshToken
+	}
+
+	if err := v.tokens.Save("porsche:"+v.user, token{AccessToken: v.token, RefreshToken: v.refreshToken, Expiry: v.tokenValid}); err != nil {
+		v.Log.WARN.Printf("cannot persist token: %v", err)
+	}
+
 	return nil
 }
 
 func (v *Porsche) request(uri string) (*http.Request, error) {
 	if v.token == "" || time.Since(v.tokenValid) > 0 {
-		if err := v.login(v.user, v.password); err != nil {
+		if err := v.refresh(); err != nil {
 			return nil, err
 		}
 	}
@@ -1,6 +1,8 @@
 package vehicle
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -22,6 +24,12 @@ const (
 	keyStore = "https://renault-wrd-prod-1-euw1-myrapp-one.s3-eu-west-1.amazonaws.com/configuration/android/config_%s.json"
 )
 
+// brands sharing the Kamereon backend. Accounts are separated by brand.
+const (
+	brandRenault = "renault"
+	brandDacia   = "dacia"
+)
+
 type configResponse struct {
 	Servers configServers
 }
@@ -57,8 +65,22 @@ type kamereonResponse struct {
 	Data         kamereonData      `json:"data"`         // /commerce/v1/accounts/%s/kamereon/kca/car-adapter/v1/cars/%s/battery-status
 }
 
+type kamereonAction struct {
+	Data kamereonActionData `json:"data"`
+}
+
+type kamereonActionData struct {
+	Type       string                   `json:"type"`
+	Attributes kamereonActionAttributes `json:"attributes"`
+}
+
+type kamereonActionAttributes struct {
+	Action string `json:"action"`
+}
+
 type kamereonAccount struct {
-	AccountID string `json:"accountId"`
+	AccountID   string `json:"accountId"`
+	AccountType string `json:"accountType"` // MYRENAULT or MYDACIA
 }
 
 type kamereonVehicle struct {
@@ -75,40 +97,63 @@ type batteryAttributes struct {
 	ChargeStatus       int    `json:"chargeStatus"`
 	InstantaneousPower int    `json:"instantaneousPower"`
 	RangeHvacOff       int    `json:"rangeHvacOff"`
+	BatteryAutonomy    int    `json:"batteryAutonomy"`
 	BatteryLevel       int    `json:"batteryLevel"`
-	BatteryTemperature int    `json:"batteryTemperature"`
+	BatteryTemperature *int   `json:"batteryTemperature"`
 	PlugStatus         int    `json:"plugStatus"`
 	LastUpdateTime     string `json:"lastUpdateTime"`
 	ChargePower        int    `json:"chargePower"`
 }
 
-// Renault is an api.Vehicle implementation for Renault cars
+// Renault is an api.Vehicle implementation for Renault cars.
+// It does not implement api.VehicleChargeLimit as the Kamereon battery status doesn't contain a charge limit.
 type Renault struct {
 	*embed
 	*util.HTTPHelper
 	user, password, vin string
+	brand               string
 	gigya, kamereon     configServer
 	gigyaJwtToken       string
 	accountID           string
 	chargeStateG        func() (float64, error)
+	rangeG              func() (int64, error)
+	temperatureG        func() (float64, error)
 }
 
 // NewRenaultFromConfig creates a new vehicle
 func NewRenaultFromConfig(other map[string]interface{}) (api.Vehicle, error) {
+	return newKamereonFromConfig(brandRenault, other)
+}
+
+// NewDaciaFromConfig creates a new Dacia vehicle, e.g. Spring, using the Renault backend
+func NewDaciaFromConfig(other map[string]interface{}) (api.Vehicle, error) {
+	return newKamereonFromConfig(brandDacia, other)
+}
+
+// newKamereonFromConfig creates a vehicle of the given default brand
+func newKamereonFromConfig(brand string, other map[string]interface{}) (api.Vehicle, error) {
 	cc := struct {
 		Title                       string
 		Capacity                    int64
 		User, Password, Region, VIN string
-		Cache                       time.Duration
+		Brand                       string
+		Cache, Timeout              time.Duration
 	}{
-		Region: "de_DE",
+		Region:  "de_DE",
+		Brand:   brand,
+		Timeout: requestTimeout,
 	}
 
 	if err := util.DecodeOther(other, &cc); err != nil {
 		return nil, err
 	}
 
-	log := util.NewLogger("renault")
+	cc.Brand = strings.ToLower(cc.Brand)
+	if cc.Brand != brandRenault && cc.Brand != brandDacia {
+		return nil, fmt.Errorf("invalid brand: %s", cc.Brand)
+	}
+
+	log := util.NewLogger(cc.Brand)
 
 	v := &Renault{
 		embed:      &embed{cc.Title, cc.Capacity},
@@ -116,8 +161,11 @@ func NewRenaultFromConfig(other map[string]interface{}) (api.Vehicle, error) {
 		user:       cc.User,
 		password:   cc.Password,
 		vin:        cc.VIN,
+		brand:      cc.Brand,
 	}
 
+	v.HTTPHelper.Client.Timeout = cc.Timeout
+
 	err := v.apiKeys(cc.Region)
 	if err == nil {
 		err = v.authFlow()
@@ -127,6 +175,8 @@ func NewRenaultFromConfig(other map[string]interface{}) (api.Vehicle, error) {
 	}
 
 	v.chargeStateG = provider.NewCached(v.chargeState, cc.Cache).FloatGetter()
+	v.rangeG = provider.NewCached(v.rangeKm, cc.Cache).IntGetter()
+	v.temperatureG = provider.NewCached(v.batteryTemperature, cc.Cache).FloatGetter()
 
 	return v, nil
 }
@@ -252,15 +302,18 @@ func (v *Renault) jwtToken(sessionCookie string) (string, error) {
 	return gr.IDToken, err
 }
 
-func (v *Renault) kamereonRequest(uri string) (kamereonResponse, error) {
-	data := url.Values{"country": []string{"DE"}}
-	headers := map[string]string{
+func (v *Renault) kamereonHeaders() map[string]string {
+	return map[string]string{
 		"x-gigya-id_token": v.gigyaJwtToken,
 		"apikey":           v.kamereon.APIKey,
 	}
+}
+
+func (v *Renault) kamereonRequest(uri string) (kamereonResponse, error) {
+	data := url.Values{"country": []string{"DE"}}
 
 	var kr kamereonResponse
-	req, err := v.request(uri, data, headers)
+	req, err := v.request(uri, data, v.kamereonHeaders())
 	if err == nil {
 		_, err = v.RequestJSON(req, &kr)
 	}
@@ -268,15 +321,50 @@ func (v *Renault) kamereonRequest(uri string) (kamereonResponse, error) {
 	return kr, err
 }
 
+func (v *Renault) kamereonPost(uri string, data interface{}) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, uri, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.URL.RawQuery = url.Values{"country": []string{"DE"}}.Encode()
+
+	req.Header.Set("Content-Type", "application/vnd.api+json")
+	for k, v := range v.kamereonHeaders() {
+		req.Header.Set(k, v)
+	}
+
+	_, err = v.Request(req)
+	return err
+}
+
+// kamereonPerson returns the person's account of the vehicle's brand.
+// Persons with vehicles of multiple brands have one account per brand.
 func (v *Renault) kamereonPerson(personID string) (string, error) {
 	uri := fmt.Sprintf("%s/commerce/v1/persons/%s", v.kamereon.Target, personID)
 	kr, err := v.kamereonRequest(uri)
 
-	if len(kr.Accounts) == 0 {
+	if err != nil || len(kr.Accounts) == 0 {
 		return "", err
 	}
 
-	return kr.Accounts[0].AccountID, err
+	accountType := "MY" + strings.ToUpper(v.brand)
+	for _, account := range kr.Accounts {
+		if strings.ToUpper(account.AccountType) == accountType {
+			return account.AccountID, nil
+		}
+	}
+
+	// legacy responses without account type
+	if kr.Accounts[0].AccountType == "" {
+		return kr.Accounts[0].AccountID, nil
+	}
+
+	return "", fmt.Errorf("missing %s account", accountType)
 }
 
 func (v *Renault) kamereonVehicles(accountID string) (string, error) {
@@ -284,9 +372,9 @@ func (v *Renault) kamereonVehicles(accountID string) (string, error) {
 	kr, err := v.kamereonRequest(uri)
 
 	if err == nil {
-		for _, v := range kr.VehicleLinks {
-			if strings.ToUpper(v.Status) == "ACTIVE" {
-				return v.VIN, nil
+		for _, vl := range kr.VehicleLinks {
+			if strings.ToUpper(vl.Status) == "ACTIVE" && (vl.Brand == "" || strings.EqualFold(vl.Brand, v.brand)) {
+				return vl.VIN, nil
 			}
 		}
 	}
@@ -294,8 +382,8 @@ func (v *Renault) kamereonVehicles(accountID string) (string, error) {
 	return "", err
 }
 
-// chargeState implements the Vehicle.ChargeState interface
-func (v *Renault) chargeState() (float64, error) {
+// batteryStatus reads the vehicle's battery status
+func (v *Renault) batteryStatus() (batteryAttributes, error) {
 	uri := fmt.Sprintf("%s/commerce/v1/accounts/%s/kamereon/kca/car-adapter/v1/cars/%s/battery-status", v.kamereon.Target, v.accountID, v.vin)
 	kr, err := v.kamereonRequest(uri)
 
@@ -306,10 +394,87 @@ func (v *Renault) chargeState() (float64, error) {
 		}
 	}
 
-	return float64(kr.Data.Attributes.BatteryLevel), err
+	return kr.Data.Attributes, err
+}
+
+// chargeState implements the Vehicle.ChargeState interface
+func (v *Renault) chargeState() (float64, error) {
+	attr, err := v.batteryStatus()
+	return float64(attr.BatteryLevel), err
+}
+
+// rangeKm implements the VehicleRange.Range interface
+func (v *Renault) rangeKm() (int64, error) {
+	attr, err := v.batteryStatus()
+	if err != nil {
+		return 0, err
+	}
+
+	distance := attr.BatteryAutonomy
+	if distance == 0 {
+		distance = attr.RangeHvacOff
+	}
+
+	return kilometers(float64(distance), unitKm)
+}
+
+// Range implements the VehicleRange.Range interface
+func (v *Renault) Range() (int64, error) {
+	return v.rangeG()
 }
 
 // ChargeState implements the Vehicle.ChargeState interface
 func (v *Renault) ChargeState() (float64, error) {
 	return v.chargeStateG()
 }
+
+// batteryTemperature implements the BatteryTemperature interface
+func (v *Renault) batteryTemperature() (float64, error) {
+	attr, err := v.batteryStatus()
+	if err == nil && attr.BatteryTemperature == nil {
+		err = errors.New("battery temperature not available")
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return float64(*attr.BatteryTemperature), nil
+}
+
+// BatteryTemperature implements the BatteryTemperature interface
+func (v *Renault) BatteryTemperature() (float64, error) {
+	return v.temperatureG()
+}
+
+// chargeAction executes the charging start/stop action
+func (v *Renault) chargeAction(action string) error {
+	uri := fmt.Sprintf("%s/commerce/v1/accounts/%s/kamereon/kca/car-adapter/v1/cars/%s/actions/charging-start", v.kamereon.Target, v.accountID, v.vin)
+
+	data := kamereonAction{
+		Data: kamereonActionData{
+			Type:       "ChargingStart",
+			Attributes: kamereonActionAttributes{Action: action},
+		},
+	}
+
+	err := v.kamereonPost(uri, data)
+
+	// repeat auth if error
+	if err != nil {
+		if err = v.authFlow(); err == nil {
+			err = v.kamereonPost(uri, data)
+		}
+	}
+
+	return err
+}
+
+// StartCharge implements the VehicleChargeController.StartCharge interface
+func (v *Renault) StartCharge() error {
+	return v.chargeAction("start")
+}
+
+// StopCharge implements the VehicleChargeController.StopCharge interface
+func (v *Renault) StopCharge() error {
+	return v.chargeAction("stop")
+}
@@ -0,0 +1,207 @@
+package vehicle
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andig/evcc/util"
+)
+
+func TestRenaultChargeControl(t *testing.T) {
+	var actions []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := "/commerce/v1/accounts/account/kamereon/kca/car-adapter/v1/cars/vin/actions/charging-start"
+		if r.Method != http.MethodPost || r.URL.Path != path {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if h := r.Header.Get("x-gigya-id_token"); h != "jwt" {
+			t.Errorf("invalid token header: %s", h)
+		}
+
+		var req kamereonAction
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+
+		if req.Data.Type != "ChargingStart" {
+			t.Errorf("invalid type: %s", req.Data.Type)
+		}
+
+		actions = append(actions, req.Data.Attributes.Action)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	v := &Renault{
+		HTTPHelper:    util.NewHTTPHelper(util.NewLogger("foo")),
+		kamereon:      configServer{Target: srv.URL, APIKey: "key"},
+		gigyaJwtToken: "jwt",
+		accountID:     "account",
+		vin:           "vin",
+	}
+
+	if err := v.StartCharge(); err != nil {
+		t.Error(err)
+	}
+
+	if err := v.StopCharge(); err != nil {
+		t.Error(err)
+	}
+
+	if len(actions) != 2 || actions[0] != "start" || actions[1] != "stop" {
+		t.Errorf("unexpected actions: %v", actions)
+	}
+}
+
+func TestRenaultRange(t *testing.T) {
+	tc := []struct {
+		body string
+		rng  int64
+	}{
+		{`{"data":{"attributes":{"batteryLevel":60,"batteryAutonomy":180,"rangeHvacOff":170}}}`, 180},
+		// older vehicles only report range without hvac
+		{`{"data":{"attributes":{"batteryLevel":60,"rangeHvacOff":170}}}`, 170},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path := "/commerce/v1/accounts/account/kamereon/kca/car-adapter/v1/cars/vin/battery-status"
+			if r.URL.Path != path {
+				t.Errorf("unexpected request: %s", r.URL.Path)
+			}
+
+			_, _ = w.Write([]byte(tc.body))
+		}))
+
+		v := &Renault{
+			HTTPHelper:    util.NewHTTPHelper(util.NewLogger("foo")),
+			kamereon:      configServer{Target: srv.URL, APIKey: "key"},
+			gigyaJwtToken: "jwt",
+			accountID:     "account",
+			vin:           "vin",
+		}
+
+		if rng, err := v.rangeKm(); err != nil || rng != tc.rng {
+			t.Errorf("expected %dkm, got %v %v", tc.rng, rng, err)
+		}
+
+		srv.Close()
+	}
+}
+
+func TestRenaultBatteryTemperature(t *testing.T) {
+	tc := []struct {
+		body string
+		temp float64
+		err  bool
+	}{
+		{`{"data":{"attributes":{"batteryLevel":60,"batteryTemperature":12}}}`, 12, false},
+		{`{"data":{"attributes":{"batteryLevel":60,"batteryTemperature":-3}}}`, -3, false},
+		// newer vehicles don't report the temperature
+		{`{"data":{"attributes":{"batteryLevel":60}}}`, 0, true},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(tc.body))
+		}))
+
+		v := &Renault{
+			HTTPHelper:    util.NewHTTPHelper(util.NewLogger("foo")),
+			kamereon:      configServer{Target: srv.URL, APIKey: "key"},
+			gigyaJwtToken: "jwt",
+			accountID:     "account",
+			vin:           "vin",
+		}
+
+		temp, err := v.batteryTemperature()
+		if (err != nil) != tc.err || temp != tc.temp {
+			t.Errorf("expected %.0f°C, got %v %v", tc.temp, temp, err)
+		}
+
+		srv.Close()
+	}
+}
+
+func TestRenaultBrandAccount(t *testing.T) {
+	tc := []struct {
+		brand, account, vin string
+	}{
+		{brandRenault, "renault-account", "renault-vin"},
+		{brandDacia, "dacia-account", "dacia-vin"},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/commerce/v1/persons/person":
+				_, _ = w.Write([]byte(`{"accounts":[
+					{"accountId":"renault-account","accountType":"MYRENAULT"},
+					{"accountId":"dacia-account","accountType":"MYDACIA"}
+				]}`))
+			case "/commerce/v1/accounts/" + tc.account + "/vehicles":
+				_, _ = w.Write([]byte(`{"vehicleLinks":[
+					{"brand":"RENAULT","vin":"renault-vin","status":"ACTIVE"},
+					{"brand":"DACIA","vin":"dacia-inactive","status":"INACTIVE"},
+					{"brand":"DACIA","vin":"dacia-vin","status":"ACTIVE"}
+				]}`))
+			default:
+				t.Errorf("unexpected request: %s", r.URL.Path)
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+
+		v := &Renault{
+			HTTPHelper:    util.NewHTTPHelper(util.NewLogger("foo")),
+			kamereon:      configServer{Target: srv.URL, APIKey: "key"},
+			gigyaJwtToken: "jwt",
+			brand:         tc.brand,
+		}
+
+		account, err := v.kamereonPerson("person")
+		if err != nil || account != tc.account {
+			t.Errorf("expected account %s, got %s %v", tc.account, account, err)
+		}
+
+		if vin, err := v.kamereonVehicles(account); err != nil || vin != tc.vin {
+			t.Errorf("expected vin %s, got %s %v", tc.vin, vin, err)
+		}
+
+		srv.Close()
+	}
+}
+
+func TestRenaultMissingBrandAccount(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"accounts":[{"accountId":"renault-account","accountType":"MYRENAULT"}]}`))
+	}))
+	defer srv.Close()
+
+	v := &Renault{
+		HTTPHelper: util.NewHTTPHelper(util.NewLogger("foo")),
+		kamereon:   configServer{Target: srv.URL, APIKey: "key"},
+		brand:      brandDacia,
+	}
+
+	if account, err := v.kamereonPerson("person"); err == nil {
+		t.Errorf("expected error, got account %s", account)
+	}
+}
+
+func TestRenaultInvalidBrand(t *testing.T) {
+	if _, err := NewRenaultFromConfig(map[string]interface{}{"brand": "alpine"}); err == nil {
+		t.Error("expected invalid brand error")
+	}
+}
@@ -0,0 +1,85 @@
+package vehicle
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andig/evcc/api"
+	"github.com/andig/evcc/provider"
+	"github.com/andig/evcc/util"
+	"github.com/kballard/go-shellquote"
+)
+
+// Script is an api.Vehicle implementation reading the soc from a user command's output
+type Script struct {
+	*embed
+	chargeG func() (float64, error)
+}
+
+// NewScriptFromConfig creates a new Script vehicle
+func NewScriptFromConfig(other map[string]interface{}) (api.Vehicle, error) {
+	cc := struct {
+		Title    string
+		Capacity int64
+		Cmd      string
+		Timeout  time.Duration
+		Cache    time.Duration
+	}{
+		Timeout: requestTimeout,
+	}
+
+	if err := util.DecodeOther(other, &cc); err != nil {
+		return nil, err
+	}
+
+	if args, err := shellquote.Split(cc.Cmd); err != nil {
+		return nil, fmt.Errorf("script vehicle config: %w", err)
+	} else if len(args) == 0 {
+		return nil, errors.New("script vehicle config: cmd required")
+	}
+
+	script, err := provider.NewScriptProvider(cc.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	exec := script.StringGetter(cc.Cmd)
+	chargeState := func() (float64, error) {
+		s, err := exec()
+		if err != nil {
+			return 0, err
+		}
+		return parseSoC(s)
+	}
+
+	v := &Script{
+		embed:   &embed{cc.Title, cc.Capacity},
+		chargeG: provider.NewCached(chargeState, cc.Cache).FloatGetter(),
+	}
+
+	return v, nil
+}
+
+// parseSoC parses a soc percentage, optionally followed by a % sign
+func parseSoC(s string) (float64, error) {
+	s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "%"))
+
+	soc, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid soc: %w", err)
+	}
+
+	if soc < 0 || soc > 100 {
+		return 0, fmt.Errorf("invalid soc: %.1f", soc)
+	}
+
+	return soc, nil
+}
+
+// ChargeState implements the Vehicle.ChargeState interface
+func (v *Script) ChargeState() (float64, error) {
+	return v.chargeG()
+}
@@ -0,0 +1,54 @@
+package vehicle
+
+import (
+	"testing"
+)
+
+func TestScript(t *testing.T) {
+	tc := []struct {
+		cmd string
+		soc float64
+		err bool
+	}{
+		{"echo 71.5", 71.5, false},
+		{`sh -c "echo ' 80% '"`, 80, false},
+		{"echo full", 0, true},
+		{"echo 120", 0, true},
+		{"false", 0, true},
+		{"sleep 1", 0, true},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		v, err := NewScriptFromConfig(map[string]interface{}{
+			"cmd":     tc.cmd,
+			"timeout": "100ms",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		soc, err := v.ChargeState()
+		if tc.err {
+			if err == nil {
+				t.Errorf("expected error, got %.1f", soc)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if soc != tc.soc {
+			t.Errorf("expected %.1f, got %.1f", tc.soc, soc)
+		}
+	}
+}
+
+func TestScriptMissingCmd(t *testing.T) {
+	if _, err := NewScriptFromConfig(map[string]interface{}{"title": "foo"}); err == nil {
+		t.Error("expected missing cmd error")
+	}
+}
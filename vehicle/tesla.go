@@ -1,7 +1,13 @@
 package vehicle
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/andig/evcc/api"
@@ -16,6 +22,8 @@ type Tesla struct {
 	vehicle        *tesla.Vehicle
 	chargeStateG   func() (float64, error)
 	chargedEnergyG func() (float64, error)
+	rangeG         func() (int64, error)
+	chargeLimitG   func() (int64, error)
 }
 
 // NewTeslaFromConfig creates a new Tesla vehicle
@@ -26,14 +34,31 @@ func NewTeslaFromConfig(other map[string]interface{}) (api.Vehicle, error) {
 		ClientID, ClientSecret string
 		Email, Password        string
 		VIN                    string
-		Cache                  time.Duration
-	}{}
+		API                    string // owner (default) or fleet
+		Region, RefreshToken   string // fleet api only
+		Cache, Timeout         time.Duration
+	}{
+		Timeout: requestTimeout,
+	}
 
 	if err := util.DecodeOther(other, &cc); err != nil {
 		return nil, err
 	}
 
-	client, err := tesla.NewClient(&tesla.Auth{
+	switch strings.ToLower(cc.API) {
+	case "", "owner":
+	case "fleet":
+		return newTeslaFleet(&embed{cc.Title, cc.Capacity}, cc.ClientID, cc.RefreshToken, cc.Region, cc.VIN, cc.Cache, cc.Timeout)
+	default:
+		return nil, fmt.Errorf("invalid api: %s", cc.API)
+	}
+
+	tokens, err := sharedTokenStore()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := teslaClient(tokens, &tesla.Auth{
 		ClientID:     cc.ClientID,
 		ClientSecret: cc.ClientSecret,
 		Email:        cc.Email,
@@ -43,6 +68,8 @@ func NewTeslaFromConfig(other map[string]interface{}) (api.Vehicle, error) {
 		return nil, err
 	}
 
+	client.HTTP.Timeout = cc.Timeout
+
 	vehicles, err := client.Vehicles()
 	if err != nil {
 		return nil, err
@@ -68,13 +95,117 @@ func NewTeslaFromConfig(other map[string]interface{}) (api.Vehicle, error) {
 
 	v.chargeStateG = provider.NewCached(v.chargeState, cc.Cache).FloatGetter()
 	v.chargedEnergyG = provider.NewCached(v.chargedEnergy, cc.Cache).FloatGetter()
+	v.rangeG = provider.NewCached(v.rangeKm, cc.Cache).IntGetter()
+	v.chargeLimitG = provider.NewCached(v.chargeLimit, cc.Cache).IntGetter()
 
 	return v, nil
 }
 
+// teslaClient creates an api client using the persisted token if still valid.
+// Expired tokens are renewed using the persisted refresh token, otherwise logs in.
+func teslaClient(tokens *tokenStore, auth *tesla.Auth) (*tesla.Client, error) {
+	key := "tesla:" + auth.Email
+
+	if t, ok := tokens.Load(key); ok {
+		// fails if token is about to expire
+		if client, err := tesla.NewClientWithToken(auth, &tesla.Token{
+			AccessToken: t.AccessToken,
+			Expires:     t.Expiry.Unix(),
+		}); err == nil {
+			return client, nil
+		}
+	}
+
+	log := util.NewLogger("tesla")
+
+	var t token
+	err := errors.New("missing refresh token")
+
+	if persisted, ok := tokens.LoadAny(key); ok && persisted.RefreshToken != "" {
+		if t, err = teslaToken(log, auth, persisted.RefreshToken); err != nil {
+			log.WARN.Printf("token refresh failed: %v", err)
+		}
+	}
+
+	if err != nil {
+		if t, err = teslaToken(log, auth, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tokens.Save(key, t); err != nil {
+		log.WARN.Printf("cannot persist token: %v", err)
+	}
+
+	return tesla.NewClientWithToken(auth, &tesla.Token{
+		AccessToken: t.AccessToken,
+		Expires:     t.Expiry.Unix(),
+	})
+}
+
+// teslaToken obtains a token using the refresh token if provided, otherwise using the account's password
+func teslaToken(log *util.Logger, auth *tesla.Auth, refreshToken string) (token, error) {
+	data := map[string]string{
+		"grant_type":    "password",
+		"client_id":     auth.ClientID,
+		"client_secret": auth.ClientSecret,
+		"email":         auth.Email,
+		"password":      auth.Password,
+	}
+
+	if refreshToken != "" {
+		data = map[string]string{
+			"grant_type":    "refresh_token",
+			"client_id":     auth.ClientID,
+			"client_secret": auth.ClientSecret,
+			"refresh_token": refreshToken,
+		}
+	}
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		return token{}, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, tesla.AuthURL, bytes.NewReader(body))
+	if err != nil {
+		return token{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var res teslaTokenResponse
+	if _, err := util.NewHTTPHelper(log).RequestJSON(req, &res); err != nil {
+		return token{}, err
+	}
+
+	if res.AccessToken == "" {
+		return token{}, errors.New("missing access token")
+	}
+
+	// keep refresh token if not rotated
+	if res.RefreshToken == "" {
+		res.RefreshToken = refreshToken
+	}
+
+	return token{
+		AccessToken:  res.AccessToken,
+		RefreshToken: res.RefreshToken,
+		Expiry:       time.Now().Add(time.Duration(res.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// chargeStateData reads the vehicle's charge state. Sleeping vehicles return api.ErrAsleep.
+func (v *Tesla) chargeStateData() (*tesla.ChargeState, error) {
+	state, err := v.vehicle.ChargeState()
+	if asleep(err) {
+		err = api.ErrAsleep
+	}
+	return state, err
+}
+
 // chargeState implements the Vehicle.ChargeState interface
 func (v *Tesla) chargeState() (float64, error) {
-	state, err := v.vehicle.ChargeState()
+	state, err := v.chargeStateData()
 	if err != nil {
 		return 0, err
 	}
@@ -88,7 +219,7 @@ func (v *Tesla) ChargeState() (float64, error) {
 
 // chargedEnergy implements the ChargeRater.ChargedEnergy interface
 func (v *Tesla) chargedEnergy() (float64, error) {
-	state, err := v.vehicle.ChargeState()
+	state, err := v.chargeStateData()
 	if err != nil {
 		return 0, err
 	}
@@ -100,6 +231,20 @@ func (v *Tesla) ChargedEnergy() (float64, error) {
 	return v.chargedEnergyG()
 }
 
+// rangeKm implements the VehicleRange.Range interface. Tesla reports range in miles.
+func (v *Tesla) rangeKm() (int64, error) {
+	state, err := v.chargeStateData()
+	if err != nil {
+		return 0, err
+	}
+	return kilometers(state.BatteryRange, unitMiles)
+}
+
+// Range implements the VehicleRange.Range interface
+func (v *Tesla) Range() (int64, error) {
+	return v.rangeG()
+}
+
 // depends on https://github.com/jsgoecke/tesla/issues/28
 //
 // CurrentPower implements the ChargeRater.CurrentPower interface
@@ -110,3 +255,125 @@ func (v *Tesla) ChargedEnergy() (float64, error) {
 // 	}
 // 	return state.ChargerPower, err
 // }
+
+// asleep returns true if the command failed due to the vehicle being asleep
+func asleep(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "408")
+}
+
+// wakeup sends a single wake request without waiting for the vehicle to come online.
+// It returns api.ErrAsleep unless the vehicle is already online.
+func (v *Tesla) wakeup() error {
+	vehicle, err := v.vehicle.Wakeup()
+	if err != nil {
+		return err
+	}
+
+	if vehicle.State != "online" {
+		return api.ErrAsleep
+	}
+
+	return nil
+}
+
+// WakeUp implements the VehicleWakeUp.WakeUp interface
+func (v *Tesla) WakeUp() error {
+	if err := v.wakeup(); err != api.ErrAsleep {
+		return err
+	}
+
+	return nil
+}
+
+// command executes the command, waking the vehicle if necessary. Commands sent while the
+// vehicle is still waking up fail with api.ErrAsleep and are retried by later control cycles.
+func (v *Tesla) command(cmd func() error) error {
+	err := cmd()
+	if asleep(err) {
+		if err = v.wakeup(); err == nil {
+			err = cmd()
+		}
+	}
+
+	return err
+}
+
+// setChargingAmps executes the set_charging_amps command which is not supported by the tesla client
+func (v *Tesla) setChargingAmps(current int64) error {
+	uri := fmt.Sprintf("%s/vehicles/%d/command/set_charging_amps", tesla.BaseURL, v.vehicle.ID)
+
+	body, err := json.Marshal(struct {
+		ChargingAmps int64 `json:"charging_amps"`
+	}{current})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, uri, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	client := tesla.ActiveClient
+	if client.Token != nil {
+		req.Header.Set("Authorization", "Bearer "+client.Token.AccessToken)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.New(resp.Status)
+	}
+
+	if body, err = ioutil.ReadAll(resp.Body); err != nil {
+		return err
+	}
+
+	var res tesla.CommandResponse
+	if err := json.Unmarshal(body, &res); err != nil {
+		return err
+	}
+
+	if !res.Response.Result && res.Response.Reason != "" {
+		return errors.New(res.Response.Reason)
+	}
+
+	return nil
+}
+
+// StartCharge implements the VehicleChargeController.StartCharge interface
+func (v *Tesla) StartCharge() error {
+	return v.command(v.vehicle.StartCharging)
+}
+
+// StopCharge implements the VehicleChargeController.StopCharge interface
+func (v *Tesla) StopCharge() error {
+	return v.command(v.vehicle.StopCharging)
+}
+
+// MaxCurrent implements the VehicleCurrentController.MaxCurrent interface
+func (v *Tesla) MaxCurrent(current int64) error {
+	return v.command(func() error {
+		return v.setChargingAmps(current)
+	})
+}
+
+// chargeLimit implements the VehicleChargeLimit.ChargeLimit interface
+func (v *Tesla) chargeLimit() (int64, error) {
+	state, err := v.chargeStateData()
+	if err != nil {
+		return 0, err
+	}
+	return int64(state.ChargeLimitSoc), nil
+}
+
+// ChargeLimit implements the VehicleChargeLimit.ChargeLimit interface
+func (v *Tesla) ChargeLimit() (int64, error) {
+	return v.chargeLimitG()
+}
@@ -0,0 +1,208 @@
+package vehicle
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andig/evcc/api"
+	"github.com/jsgoecke/tesla"
+)
+
+func TestTeslaCommands(t *testing.T) {
+	var requests []string
+	online := false
+	wakeups := 0
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/vehicles/1/")
+		requests = append(requests, path)
+
+		if h := r.Header.Get("Authorization"); h != "Bearer token" {
+			t.Errorf("invalid auth header: %s", h)
+		}
+
+		if path == "wake_up" {
+			// online after second wakeup
+			wakeups++
+			online = wakeups > 1
+
+			state := "asleep"
+			if online {
+				state = "online"
+			}
+
+			fmt.Fprintf(w, `{"response":{"id":1,"state":"%s"}}`, state)
+			return
+		}
+
+		if !online {
+			w.WriteHeader(http.StatusRequestTimeout)
+			return
+		}
+
+		if path == "command/set_charging_amps" {
+			var req struct {
+				ChargingAmps int64 `json:"charging_amps"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ChargingAmps != 12 {
+				t.Errorf("invalid charging amps: %v %v", req, err)
+			}
+		}
+
+		fmt.Fprint(w, `{"response":{"result":true,"reason":""}}`)
+	}))
+	defer srv.Close()
+
+	baseURL, client := tesla.BaseURL, tesla.ActiveClient
+	defer func() {
+		tesla.BaseURL, tesla.ActiveClient = baseURL, client
+	}()
+
+	tesla.BaseURL = srv.URL
+	tesla.ActiveClient = &tesla.Client{
+		HTTP:  srv.Client(),
+		Token: &tesla.Token{AccessToken: "token"},
+	}
+
+	v := &Tesla{
+		vehicle: &tesla.Vehicle{ID: 1},
+	}
+
+	// asleep: command, single wake request, retried by next cycle
+	if err := v.StartCharge(); err != api.ErrAsleep {
+		t.Errorf("expected asleep, got %v", err)
+	}
+
+	expected := []string{"command/charge_start", "wake_up"}
+	if strings.Join(requests, ",") != strings.Join(expected, ",") {
+		t.Errorf("unexpected requests: %v", requests)
+	}
+
+	// online after wake request: repeat command
+	requests = nil
+
+	if err := v.StartCharge(); err != nil {
+		t.Error(err)
+	}
+
+	expected = []string{"command/charge_start", "wake_up", "command/charge_start"}
+	if strings.Join(requests, ",") != strings.Join(expected, ",") {
+		t.Errorf("unexpected requests: %v", requests)
+	}
+
+	// online
+	requests = nil
+
+	if err := v.MaxCurrent(12); err != nil {
+		t.Error(err)
+	}
+
+	if err := v.StopCharge(); err != nil {
+		t.Error(err)
+	}
+
+	expected = []string{"command/set_charging_amps", "command/charge_stop"}
+	if strings.Join(requests, ",") != strings.Join(expected, ",") {
+		t.Errorf("unexpected requests: %v", requests)
+	}
+}
+
+func TestTeslaWakeUp(t *testing.T) {
+	var wakeups int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "wake_up") {
+			wakeups++
+			fmt.Fprint(w, `{"response":{"id":1,"state":"asleep"}}`)
+			return
+		}
+		w.WriteHeader(http.StatusRequestTimeout)
+	}))
+	defer srv.Close()
+
+	baseURL, client := tesla.BaseURL, tesla.ActiveClient
+	defer func() {
+		tesla.BaseURL, tesla.ActiveClient = baseURL, client
+	}()
+
+	tesla.BaseURL = srv.URL
+	tesla.ActiveClient = &tesla.Client{HTTP: srv.Client()}
+
+	v := &Tesla{
+		vehicle: &tesla.Vehicle{ID: 1},
+	}
+
+	// wake request doesn't wait for the vehicle to come online
+	if err := v.WakeUp(); err != nil {
+		t.Error(err)
+	}
+
+	if wakeups != 1 {
+		t.Errorf("expected single wake request, got %d", wakeups)
+	}
+}
+
+func TestTeslaRange(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/vehicles/1/data_request/charge_state" {
+			t.Errorf("unexpected request: %s", r.URL.Path)
+		}
+
+		fmt.Fprint(w, `{"response":{"battery_level":80,"battery_range":186.41}}`)
+	}))
+	defer srv.Close()
+
+	baseURL, client := tesla.BaseURL, tesla.ActiveClient
+	defer func() {
+		tesla.BaseURL, tesla.ActiveClient = baseURL, client
+	}()
+
+	tesla.BaseURL = srv.URL
+	tesla.ActiveClient = &tesla.Client{
+		HTTP:  srv.Client(),
+		Token: &tesla.Token{AccessToken: "token"},
+	}
+
+	v := &Tesla{
+		vehicle: &tesla.Vehicle{ID: 1},
+	}
+
+	// miles are converted to km
+	if rng, err := v.rangeKm(); err != nil || rng != 300 {
+		t.Errorf("expected 300km, got %v %v", rng, err)
+	}
+}
+
+func TestTeslaChargeLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/vehicles/1/data_request/charge_state" {
+			t.Errorf("unexpected request: %s", r.URL.Path)
+		}
+
+		fmt.Fprint(w, `{"response":{"battery_level":75,"charge_limit_soc":80}}`)
+	}))
+	defer srv.Close()
+
+	baseURL, client := tesla.BaseURL, tesla.ActiveClient
+	defer func() {
+		tesla.BaseURL, tesla.ActiveClient = baseURL, client
+	}()
+
+	tesla.BaseURL = srv.URL
+	tesla.ActiveClient = &tesla.Client{
+		HTTP:  srv.Client(),
+		Token: &tesla.Token{AccessToken: "token"},
+	}
+
+	v := &Tesla{
+		vehicle: &tesla.Vehicle{ID: 1},
+	}
+
+	if limit, err := v.chargeLimit(); err != nil || limit != 80 {
+		t.Errorf("expected 80%%, got %v %v", limit, err)
+	}
+}
@@ -0,0 +1,264 @@
+package vehicle
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andig/evcc/provider"
+	"github.com/andig/evcc/util"
+)
+
+// Fleet API requires a registered partner application. Vehicle commands must be signed
+// using the vehicle command protocol and are not supported, only vehicle data is read.
+// https://developer.tesla.com/docs/fleet-api
+
+const teslaFleetAuthURI = "https://auth.tesla.com/oauth2/v3/token"
+
+// teslaFleetRegions are the regional Fleet API base urls
+var teslaFleetRegions = map[string]string{
+	"na": "https://fleet-api.prd.na.vn.cloud.tesla.com",
+	"eu": "https://fleet-api.prd.eu.vn.cloud.tesla.com",
+	"cn": "https://fleet-api.prd.cn.vn.cloud.tesla.cn",
+}
+
+type teslaTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+type teslaFleetVehicles struct {
+	Response []struct {
+		ID  int64  `json:"id"`
+		VIN string `json:"vin"`
+	} `json:"response"`
+}
+
+type teslaFleetChargeState struct {
+	BatteryLevel      int     `json:"battery_level"`
+	BatteryRange      float64 `json:"battery_range"`
+	ChargeEnergyAdded float64 `json:"charge_energy_added"`
+	ChargeLimitSoc    int     `json:"charge_limit_soc"`
+}
+
+type teslaFleetVehicleData struct {
+	Response struct {
+		ChargeState teslaFleetChargeState `json:"charge_state"`
+	} `json:"response"`
+}
+
+type teslaFleetError struct {
+	Error string `json:"error"`
+}
+
+// TeslaFleet is an api.Vehicle implementation for Tesla cars using the Fleet API
+type TeslaFleet struct {
+	*embed
+	*util.HTTPHelper
+	mux           sync.Mutex
+	tokens        *tokenStore
+	authURI, uri  string
+	clientID, vin string
+	tokenKey      string
+	token         token
+	vehicleDataG  func() (interface{}, error)
+}
+
+// newTeslaFleet creates a Tesla vehicle using the Fleet API
+func newTeslaFleet(embed *embed, clientID, refreshToken, region, vin string, cache, timeout time.Duration) (*TeslaFleet, error) {
+	if clientID == "" || refreshToken == "" {
+		return nil, errors.New("fleet api requires clientid and refreshtoken")
+	}
+
+	if region == "" {
+		region = "na"
+	}
+
+	uri, ok := teslaFleetRegions[strings.ToLower(region)]
+	if !ok {
+		return nil, fmt.Errorf("invalid fleet api region: %s", region)
+	}
+
+	tokens, err := sharedTokenStore()
+	if err != nil {
+		return nil, err
+	}
+
+	v := &TeslaFleet{
+		embed:      embed,
+		HTTPHelper: util.NewHTTPHelper(util.NewLogger("tesla")),
+		tokens:     tokens,
+		authURI:    teslaFleetAuthURI,
+		uri:        uri,
+		clientID:   clientID,
+		vin:        vin,
+		tokenKey:   teslaFleetTokenKey(clientID, refreshToken),
+		token:      token{RefreshToken: refreshToken},
+	}
+
+	v.HTTPHelper.Client.Timeout = timeout
+
+	// refresh tokens are rotated, prefer the persisted token unless the configured token has changed
+	if t, ok := tokens.LoadAny(v.tokenKey); ok && t.RefreshToken != "" {
+		v.token = t
+	}
+
+	if v.vin == "" {
+		if v.vin, err = v.singleVehicle(); err != nil {
+			return nil, err
+		}
+	}
+
+	// all values are read from a single billed vehicle_data request
+	v.vehicleDataG = provider.NewCached(v.vehicleData, cache).InterfaceGetter()
+
+	return v, nil
+}
+
+// teslaFleetTokenKey identifies the persisted token by client and configured refresh token
+// such that a newly configured refresh token is not replaced by a stale persisted token
+func teslaFleetTokenKey(clientID, refreshToken string) string {
+	hash := sha256.Sum256([]byte(refreshToken))
+	return fmt.Sprintf("tesla-fleet:%s:%x", clientID, hash[:8])
+}
+
+// accessToken returns a valid access token, refreshing it if expired
+func (v *TeslaFleet) accessToken() (string, error) {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	if v.token.valid() {
+		return v.token.AccessToken, nil
+	}
+
+	data := url.Values{
+		"grant_type":    []string{"refresh_token"},
+		"client_id":     []string{v.clientID},
+		"refresh_token": []string{v.token.RefreshToken},
+	}
+
+	req, err := http.NewRequest(http.MethodPost, v.authURI, strings.NewReader(data.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var res teslaTokenResponse
+	if _, err := v.RequestJSON(req, &res); err != nil {
+		return "", err
+	}
+
+	if res.AccessToken == "" {
+		return "", errors.New("missing access token")
+	}
+
+	v.token = token{
+		AccessToken:  res.AccessToken,
+		RefreshToken: v.token.RefreshToken,
+		Expiry:       time.Now().Add(time.Duration(res.ExpiresIn) * time.Second),
+	}
+
+	if res.RefreshToken != "" {
+		v.token.RefreshToken = res.RefreshToken
+	}
+
+	if err := v.tokens.Save(v.tokenKey, v.token); err != nil {
+		v.Log.WARN.Printf("cannot persist token: %v", err)
+	}
+
+	return v.token.AccessToken, nil
+}
+
+// getJSON executes an authorized Fleet API request. Api errors like sleeping vehicles are returned as error.
+func (v *TeslaFleet) getJSON(uri string, res interface{}) error {
+	token, err := v.accessToken()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, uri, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	b, err := v.RequestJSON(req, res)
+	if err != nil {
+		var e teslaFleetError
+		if json.Unmarshal(b, &e) == nil && e.Error != "" {
+			err = errors.New(e.Error)
+		}
+	}
+
+	return err
+}
+
+// singleVehicle returns the vin of the only vehicle of the account
+func (v *TeslaFleet) singleVehicle() (string, error) {
+	var res teslaFleetVehicles
+	if err := v.getJSON(v.uri+"/api/1/vehicles", &res); err != nil {
+		return "", err
+	}
+
+	if len(res.Response) != 1 {
+		return "", errors.New("vin not found")
+	}
+
+	return res.Response[0].VIN, nil
+}
+
+// vehicleData reads the vehicle's charge state. Sleeping vehicles are not woken up.
+func (v *TeslaFleet) vehicleData() (interface{}, error) {
+	var res teslaFleetVehicleData
+
+	uri := fmt.Sprintf("%s/api/1/vehicles/%s/vehicle_data?endpoints=charge_state", v.uri, v.vin)
+	err := v.getJSON(uri, &res)
+
+	return res, err
+}
+
+// chargeState returns the cached vehicle charge state
+func (v *TeslaFleet) chargeState() (teslaFleetChargeState, error) {
+	res, err := v.vehicleDataG()
+	if err != nil {
+		return teslaFleetChargeState{}, err
+	}
+
+	return res.(teslaFleetVehicleData).Response.ChargeState, nil
+}
+
+// ChargeState implements the Vehicle.ChargeState interface
+func (v *TeslaFleet) ChargeState() (float64, error) {
+	res, err := v.chargeState()
+	return float64(res.BatteryLevel), err
+}
+
+// ChargedEnergy implements the ChargeRater.ChargedEnergy interface
+func (v *TeslaFleet) ChargedEnergy() (float64, error) {
+	res, err := v.chargeState()
+	return res.ChargeEnergyAdded, err
+}
+
+// Range implements the VehicleRange.Range interface. Tesla reports range in miles.
+func (v *TeslaFleet) Range() (int64, error) {
+	res, err := v.chargeState()
+	if err != nil {
+		return 0, err
+	}
+	return kilometers(res.BatteryRange, unitMiles)
+}
+
+// ChargeLimit implements the VehicleChargeLimit.ChargeLimit interface
+func (v *TeslaFleet) ChargeLimit() (int64, error) {
+	res, err := v.chargeState()
+	return int64(res.ChargeLimitSoc), err
+}
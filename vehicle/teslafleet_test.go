@@ -0,0 +1,127 @@
+package vehicle
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/andig/evcc/provider"
+	"github.com/andig/evcc/util"
+)
+
+func TestTeslaFleet(t *testing.T) {
+	var refreshed, requests int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth2/v3/token":
+			if err := r.ParseForm(); err != nil {
+				t.Error(err)
+			}
+
+			if r.Form.Get("grant_type") != "refresh_token" || r.Form.Get("client_id") != "client" || r.Form.Get("refresh_token") != "refresh" {
+				t.Errorf("invalid token request: %v", r.Form)
+			}
+
+			refreshed++
+			_, _ = w.Write([]byte(`{"access_token":"access","refresh_token":"rotated","expires_in":28800,"token_type":"Bearer"}`))
+
+		case "/api/1/vehicles":
+			_, _ = w.Write([]byte(`{"response":[{"id":100021,"vehicle_id":99999,"vin":"5YJ3E1EA1NF000000","state":"online"}],"count":1}`))
+
+		case "/api/1/vehicles/5YJ3E1EA1NF000000/vehicle_data":
+			if h := r.Header.Get("Authorization"); h != "Bearer access" {
+				t.Errorf("invalid auth header: %s", h)
+			}
+
+			if ep := r.URL.Query().Get("endpoints"); ep != "charge_state" {
+				t.Errorf("invalid endpoints: %s", ep)
+			}
+
+			requests++
+
+			_, _ = w.Write([]byte(`{"response":{"id":100021,"vin":"5YJ3E1EA1NF000000","state":"online","charge_state":{
+				"battery_level":42,"battery_range":155.34,"charge_energy_added":12.5,"charge_limit_soc":80,"charging_state":"Charging"}}}`))
+
+		default:
+			t.Errorf("unexpected request: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	v := &TeslaFleet{
+		HTTPHelper: util.NewHTTPHelper(util.NewLogger("foo")),
+		authURI:    srv.URL + "/oauth2/v3/token",
+		uri:        srv.URL,
+		clientID:   "client",
+		token:      token{RefreshToken: "refresh"},
+	}
+
+	vin, err := v.singleVehicle()
+	if err != nil || vin != "5YJ3E1EA1NF000000" {
+		t.Fatalf("unexpected vin: %s %v", vin, err)
+	}
+	v.vin = vin
+	v.vehicleDataG = provider.NewCached(v.vehicleData, time.Minute).InterfaceGetter()
+
+	if soc, err := v.ChargeState(); err != nil || soc != 42 {
+		t.Errorf("expected 42%%, got %v %v", soc, err)
+	}
+
+	if energy, err := v.ChargedEnergy(); err != nil || energy != 12.5 {
+		t.Errorf("expected 12.5kWh, got %v %v", energy, err)
+	}
+
+	if rng, err := v.Range(); err != nil || rng != 250 {
+		t.Errorf("expected 250km, got %v %v", rng, err)
+	}
+
+	if limit, err := v.ChargeLimit(); err != nil || limit != 80 {
+		t.Errorf("expected 80%%, got %v %v", limit, err)
+	}
+
+	// vehicle data is requested once for all values
+	if requests != 1 {
+		t.Errorf("expected single vehicle data request, got %d", requests)
+	}
+
+	// token is refreshed once and rotated
+	if refreshed != 1 || v.token.RefreshToken != "rotated" {
+		t.Errorf("unexpected token refresh: %d %+v", refreshed, v.token)
+	}
+}
+
+func TestTeslaFleetAsleep(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusRequestTimeout)
+		_, _ = w.Write([]byte(`{"response":null,"error":"vehicle unavailable: vehicle is offline or asleep","error_description":""}`))
+	}))
+	defer srv.Close()
+
+	v := &TeslaFleet{
+		HTTPHelper: util.NewHTTPHelper(util.NewLogger("foo")),
+		uri:        srv.URL,
+		vin:        "vin",
+		token:      token{AccessToken: "access", Expiry: time.Now().Add(time.Hour)},
+	}
+	v.vehicleDataG = v.vehicleData
+
+	if _, err := v.ChargeState(); err == nil || err.Error() != "vehicle unavailable: vehicle is offline or asleep" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestTeslaFleetTokenKey(t *testing.T) {
+	key := teslaFleetTokenKey("client", "refresh")
+
+	if key != teslaFleetTokenKey("client", "refresh") {
+		t.Error("expected stable key")
+	}
+
+	// newly configured refresh token must not load the persisted token
+	if key == teslaFleetTokenKey("client", "new") {
+		t.Error("expected key to change with configured refresh token")
+	}
+}
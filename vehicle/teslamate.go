@@ -0,0 +1,171 @@
+package vehicle
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andig/evcc/api"
+	"github.com/andig/evcc/provider"
+	"github.com/andig/evcc/util"
+)
+
+// Teslamate publishes the vehicle state retained to teslamate/cars/<car id>/<value>
+// https://docs.teslamate.org/docs/integrations/mqtt
+
+const teslamateTopic = "teslamate"
+
+// teslamate values
+const (
+	teslamateSoC      = "battery_level"
+	teslamateRange    = "rated_battery_range_km"
+	teslamatePlugged  = "plugged_in"
+	teslamateCharging = "charging_state"
+)
+
+// Teslamate is an api.Vehicle implementation reading the vehicle state from Teslamate via MQTT
+type Teslamate struct {
+	*embed
+	mux      sync.Mutex
+	prefix   string
+	payloads map[string]string
+}
+
+// NewTeslamateFromConfig creates a new Teslamate vehicle
+func NewTeslamateFromConfig(other map[string]interface{}) (api.Vehicle, error) {
+	cc := struct {
+		Title    string
+		Capacity int64
+		Topic    string
+		CarID    int
+	}{
+		Topic: teslamateTopic,
+		CarID: 1,
+	}
+
+	if err := util.DecodeOther(other, &cc); err != nil {
+		return nil, err
+	}
+
+	if provider.MQTT == nil {
+		return nil, errors.New("mqtt not configured")
+	}
+
+	return newTeslamate(&embed{cc.Title, cc.Capacity}, cc.Topic, cc.CarID, provider.MQTT.Listen), nil
+}
+
+// newTeslamate creates a Teslamate vehicle subscribing to the car's topics using listen
+func newTeslamate(embed *embed, topic string, carID int, listen func(string, func(string))) *Teslamate {
+	v := &Teslamate{
+		embed:    embed,
+		prefix:   teslamatePrefix(topic, carID),
+		payloads: make(map[string]string),
+	}
+
+	for _, name := range []string{teslamateSoC, teslamateRange, teslamatePlugged, teslamateCharging} {
+		listen(v.prefix+name, v.receiver(name))
+	}
+
+	return v
+}
+
+// teslamatePrefix returns the topic prefix of the car's values
+func teslamatePrefix(topic string, carID int) string {
+	topic = strings.Trim(topic, "/")
+	if topic == "" {
+		topic = teslamateTopic
+	}
+
+	// topic may already include the car
+	if !strings.Contains(topic, "/cars/") {
+		topic = fmt.Sprintf("%s/cars/%d", topic, carID)
+	}
+
+	return topic + "/"
+}
+
+// receiver returns the listener storing the payload of the named value
+func (v *Teslamate) receiver(name string) func(string) {
+	return func(payload string) {
+		v.mux.Lock()
+		defer v.mux.Unlock()
+		v.payloads[name] = strings.TrimSpace(payload)
+	}
+}
+
+// value returns the last payload of the named value
+func (v *Teslamate) value(name string) (string, error) {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	payload, ok := v.payloads[name]
+	if !ok {
+		return "", fmt.Errorf("%s%s: no value received", v.prefix, name)
+	}
+
+	return payload, nil
+}
+
+// float returns the last payload of the named value as float
+func (v *Teslamate) float(name string) (float64, error) {
+	payload, err := v.value(name)
+	if err != nil {
+		return 0, err
+	}
+
+	f, err := strconv.ParseFloat(payload, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s%s invalid: '%s'", v.prefix, name, payload)
+	}
+
+	return f, nil
+}
+
+// teslamateStatus maps the Teslamate plug and charging states to the charge status
+func teslamateStatus(plugged bool, chargingState string) (api.ChargeStatus, error) {
+	if !plugged {
+		return api.StatusA, nil
+	}
+
+	switch chargingState {
+	case "Charging", "Starting":
+		return api.StatusC, nil
+	case "Complete", "Stopped", "NoPower", "Disconnected":
+		return api.StatusB, nil
+	default:
+		return api.StatusNone, fmt.Errorf("invalid charging state: %s", chargingState)
+	}
+}
+
+// ChargeState implements the Vehicle.ChargeState interface
+func (v *Teslamate) ChargeState() (float64, error) {
+	return v.float(teslamateSoC)
+}
+
+// Range implements the VehicleRange.Range interface
+func (v *Teslamate) Range() (int64, error) {
+	f, err := v.float(teslamateRange)
+	return int64(math.Round(f)), err
+}
+
+// Status implements the VehicleStatus.Status interface
+func (v *Teslamate) Status() (api.ChargeStatus, error) {
+	plugged, err := v.value(teslamatePlugged)
+	if err != nil {
+		return api.StatusNone, err
+	}
+
+	if !util.Truish(plugged) {
+		return api.StatusA, nil
+	}
+
+	charging, err := v.value(teslamateCharging)
+	if err != nil {
+		return api.StatusNone, err
+	}
+
+	return teslamateStatus(true, charging)
+}
@@ -0,0 +1,95 @@
+package vehicle
+
+import (
+	"testing"
+
+	"github.com/andig/evcc/api"
+)
+
+func TestTeslamatePrefix(t *testing.T) {
+	tc := []struct {
+		topic  string
+		carID  int
+		prefix string
+	}{
+		{"", 1, "teslamate/cars/1/"},
+		{"teslamate", 2, "teslamate/cars/2/"},
+		{"home/teslamate/", 1, "home/teslamate/cars/1/"},
+		{"teslamate/cars/3", 1, "teslamate/cars/3/"},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		if prefix := teslamatePrefix(tc.topic, tc.carID); prefix != tc.prefix {
+			t.Errorf("expected %s, got %s", tc.prefix, prefix)
+		}
+	}
+}
+
+func TestTeslamate(t *testing.T) {
+	listeners := make(map[string]func(string))
+	listen := func(topic string, callback func(string)) {
+		listeners[topic] = callback
+	}
+
+	v := newTeslamate(&embed{}, "teslamate", 2, listen)
+
+	for _, topic := range []string{
+		"teslamate/cars/2/battery_level",
+		"teslamate/cars/2/rated_battery_range_km",
+		"teslamate/cars/2/plugged_in",
+		"teslamate/cars/2/charging_state",
+	} {
+		if _, ok := listeners[topic]; !ok {
+			t.Errorf("missing subscription: %s", topic)
+		}
+	}
+
+	if _, err := v.ChargeState(); err == nil {
+		t.Error("expected error before receiving soc")
+	}
+
+	listeners["teslamate/cars/2/battery_level"]("72")
+	listeners["teslamate/cars/2/rated_battery_range_km"]("301.66")
+	listeners["teslamate/cars/2/plugged_in"]("false")
+
+	if soc, err := v.ChargeState(); err != nil || soc != 72 {
+		t.Errorf("expected soc 72, got %.1f %v", soc, err)
+	}
+
+	if rng, err := v.Range(); err != nil || rng != 302 {
+		t.Errorf("expected range 302, got %d %v", rng, err)
+	}
+
+	if status, err := v.Status(); err != nil || status != api.StatusA {
+		t.Errorf("expected status A, got %s %v", status, err)
+	}
+
+	listeners["teslamate/cars/2/plugged_in"]("true")
+	if _, err := v.Status(); err == nil {
+		t.Error("expected error before receiving charging state")
+	}
+
+	for state, expected := range map[string]api.ChargeStatus{
+		"Charging": api.StatusC,
+		"Starting": api.StatusC,
+		"Complete": api.StatusB,
+		"Stopped":  api.StatusB,
+	} {
+		listeners["teslamate/cars/2/charging_state"](state)
+		if status, err := v.Status(); err != nil || status != expected {
+			t.Errorf("%s: expected status %s, got %s %v", state, expected, status, err)
+		}
+	}
+
+	listeners["teslamate/cars/2/charging_state"]("Unknown")
+	if _, err := v.Status(); err == nil {
+		t.Error("expected invalid charging state error")
+	}
+
+	listeners["teslamate/cars/2/battery_level"]("n/a")
+	if _, err := v.ChargeState(); err == nil {
+		t.Error("expected invalid soc error")
+	}
+}
@@ -0,0 +1,127 @@
+package vehicle
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// TokenFile is the path of the persistent token store. Tokens are not persisted if empty.
+var TokenFile string
+
+var (
+	tokenStoresMux sync.Mutex
+	tokenStores    = make(map[string]*tokenStore)
+)
+
+// token is a persisted api token
+type token struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Expiry       time.Time `json:"expiry"`
+}
+
+// valid returns true if the token has not expired
+func (t token) valid() bool {
+	return t.AccessToken != "" && time.Until(t.Expiry) > 0
+}
+
+// tokenStore persists api tokens across restarts. The file is only readable by the owner.
+// A nil tokenStore does not persist tokens.
+type tokenStore struct {
+	mux    sync.Mutex
+	file   string
+	tokens map[string]token
+}
+
+// sharedTokenStore returns the token store for TokenFile or nil if token persistence is disabled
+func sharedTokenStore() (*tokenStore, error) {
+	if TokenFile == "" {
+		return nil, nil
+	}
+
+	tokenStoresMux.Lock()
+	defer tokenStoresMux.Unlock()
+
+	if ts, ok := tokenStores[TokenFile]; ok {
+		return ts, nil
+	}
+
+	ts, err := newTokenStore(TokenFile)
+	if err == nil {
+		tokenStores[TokenFile] = ts
+	}
+
+	return ts, err
+}
+
+// newTokenStore creates a token store backed by the given file
+func newTokenStore(file string) (*tokenStore, error) {
+	ts := &tokenStore{
+		file:   file,
+		tokens: make(map[string]token),
+	}
+
+	b, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return ts, nil
+	}
+
+	if err == nil {
+		err = json.Unmarshal(b, &ts.tokens)
+	}
+
+	return ts, err
+}
+
+// Load returns the persisted token for key if it is still valid
+func (ts *tokenStore) Load(key string) (token, bool) {
+	if ts == nil {
+		return token{}, false
+	}
+
+	ts.mux.Lock()
+	defer ts.mux.Unlock()
+
+	t, ok := ts.tokens[key]
+	return t, ok && t.valid()
+}
+
+// LoadAny returns the persisted token for key even if expired, e.g. to use its refresh token
+func (ts *tokenStore) LoadAny(key string) (token, bool) {
+	if ts == nil {
+		return token{}, false
+	}
+
+	ts.mux.Lock()
+	defer ts.mux.Unlock()
+
+	t, ok := ts.tokens[key]
+	return t, ok
+}
+
+// Save persists the token for key
+func (ts *tokenStore) Save(key string, t token) error {
+	if ts == nil {
+		return nil
+	}
+
+	ts.mux.Lock()
+	defer ts.mux.Unlock()
+
+	ts.tokens[key] = t
+
+	b, err := json.MarshalIndent(ts.tokens, "", "  ")
+	if err == nil {
+		err = ioutil.WriteFile(ts.file, b, 0600)
+	}
+
+	// restrict permissions of pre-existing files
+	if err == nil {
+		err = os.Chmod(ts.file, 0600)
+	}
+
+	return err
+}
@@ -0,0 +1,152 @@
+package vehicle
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jsgoecke/tesla"
+)
+
+func TestTokenStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "evcc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "tokens.json")
+
+	ts, err := newTokenStore(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := ts.Load("foo"); ok {
+		t.Error("unexpected token")
+	}
+
+	valid := token{AccessToken: "valid", Expiry: time.Now().Add(time.Hour)}
+	if err := ts.Save("foo", valid); err != nil {
+		t.Fatal(err)
+	}
+
+	expired := token{AccessToken: "expired", Expiry: time.Now().Add(-time.Hour)}
+	if err := ts.Save("bar", expired); err != nil {
+		t.Fatal(err)
+	}
+
+	if fi, err := os.Stat(file); err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("invalid file permissions: %v %v", fi.Mode(), err)
+	}
+
+	// reload persisted store
+	if ts, err = newTokenStore(file); err != nil {
+		t.Fatal(err)
+	}
+
+	if tok, ok := ts.Load("foo"); !ok || tok.AccessToken != valid.AccessToken {
+		t.Errorf("missing persisted token: %v", tok)
+	}
+
+	if _, ok := ts.Load("bar"); ok {
+		t.Error("unexpected expired token")
+	}
+
+	// nil store does not persist
+	var nilStore *tokenStore
+	if err := nilStore.Save("foo", valid); err != nil {
+		t.Error(err)
+	}
+	if _, ok := nilStore.Load("foo"); ok {
+		t.Error("unexpected token")
+	}
+}
+
+func TestTeslaTokenRefresh(t *testing.T) {
+	dir, err := ioutil.TempDir("", "evcc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var logins, refreshs int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+
+		switch req["grant_type"] {
+		case "password":
+			logins++
+			_, _ = w.Write([]byte(`{"access_token":"fresh","refresh_token":"refresh","token_type":"bearer","expires_in":3888000}`))
+		case "refresh_token":
+			if req["refresh_token"] != "refresh" {
+				t.Errorf("invalid refresh token: %s", req["refresh_token"])
+			}
+			refreshs++
+			_, _ = w.Write([]byte(`{"access_token":"refreshed","refresh_token":"rotated","token_type":"bearer","expires_in":3888000}`))
+		default:
+			t.Errorf("invalid grant type: %v", req)
+		}
+	}))
+	defer srv.Close()
+
+	defer func(url string) { tesla.AuthURL = url }(tesla.AuthURL)
+	tesla.AuthURL = srv.URL
+
+	ts, err := newTokenStore(filepath.Join(dir, "tokens.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auth := &tesla.Auth{Email: "foo@bar.com"}
+
+	// expired token requires login
+	if err := ts.Save("tesla:foo@bar.com", token{AccessToken: "expired", Expiry: time.Now().Add(-time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := teslaClient(ts, auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if logins != 1 || client.Token.AccessToken != "fresh" {
+		t.Errorf("expected login, got %d logins with token %s", logins, client.Token.AccessToken)
+	}
+
+	// refreshed token is persisted and reused
+	if client, err = teslaClient(ts, auth); err != nil {
+		t.Fatal(err)
+	}
+
+	if logins != 1 || client.Token.AccessToken != "fresh" {
+		t.Errorf("expected persisted token, got %d logins with token %s", logins, client.Token.AccessToken)
+	}
+
+	// expired token is renewed using the persisted refresh token
+	tok, _ := ts.LoadAny("tesla:foo@bar.com")
+	tok.Expiry = time.Now().Add(-time.Hour)
+	if err := ts.Save("tesla:foo@bar.com", tok); err != nil {
+		t.Fatal(err)
+	}
+
+	if client, err = teslaClient(ts, auth); err != nil {
+		t.Fatal(err)
+	}
+
+	if logins != 1 || refreshs != 1 || client.Token.AccessToken != "refreshed" {
+		t.Errorf("expected refresh, got %d logins, %d refreshs with token %s", logins, refreshs, client.Token.AccessToken)
+	}
+
+	if tok, _ := ts.LoadAny("tesla:foo@bar.com"); tok.RefreshToken != "rotated" {
+		t.Errorf("expected rotated refresh token to be persisted, got %s", tok.RefreshToken)
+	}
+}
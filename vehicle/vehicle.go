@@ -2,6 +2,7 @@ package vehicle
 
 import (
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/andig/evcc/api"
@@ -9,6 +10,47 @@ import (
 	"github.com/andig/evcc/util"
 )
 
+// distance units
+const (
+	unitKm    = "km"
+	unitMiles = "mi"
+
+	kmPerMile = 1.609344
+)
+
+// requestTimeout is the default timeout for cloud api requests
+const requestTimeout = 10 * time.Second
+
+// withTimeout executes fn and returns an error if it does not complete within timeout.
+// It is used for api clients that don't allow configuring the http timeout.
+// The abandoned request continues in the background until its client gives up.
+func withTimeout(timeout time.Duration, fn func() error) error {
+	errC := make(chan error, 1)
+	go func() {
+		errC <- fn()
+	}()
+
+	select {
+	case err := <-errC:
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("timeout after %v", timeout)
+	}
+}
+
+// kilometers normalizes the distance in the given unit to km
+func kilometers(distance float64, unit string) (int64, error) {
+	switch unit {
+	case unitKm:
+	case unitMiles:
+		distance *= kmPerMile
+	default:
+		return 0, fmt.Errorf("invalid distance unit: %s", unit)
+	}
+
+	return int64(math.Round(distance)), nil
+}
+
 type embed struct {
 	title    string
 	capacity int64
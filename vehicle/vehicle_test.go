@@ -0,0 +1,53 @@
+package vehicle
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestKilometers(t *testing.T) {
+	tc := []struct {
+		distance float64
+		unit     string
+		km       int64
+		err      bool
+	}{
+		{100, unitKm, 100, false},
+		{99.6, unitKm, 100, false},
+		{100, unitMiles, 161, false},
+		{100, "parsec", 0, true},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		km, err := kilometers(tc.distance, tc.unit)
+		if (err != nil) != tc.err || km != tc.km {
+			t.Errorf("expected %dkm, got %v %v", tc.km, km, err)
+		}
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	errFoo := errors.New("foo")
+
+	if err := withTimeout(time.Second, func() error { return errFoo }); err != errFoo {
+		t.Errorf("expected %v, got %v", errFoo, err)
+	}
+
+	release := make(chan struct{})
+	defer close(release)
+
+	start := time.Now()
+	if err := withTimeout(10*time.Millisecond, func() error {
+		<-release
+		return nil
+	}); err == nil {
+		t.Error("expected timeout error")
+	}
+
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("timeout not honored: %v", d)
+	}
+}